@@ -0,0 +1,10 @@
+// Package api guarda a especificação OpenAPI do serviço, embutida no binário
+// e usada também na geração dos SDKs (sdk/generate.sh)
+package api
+
+import _ "embed"
+
+// Especificação OpenAPI 3 da API HTTP
+//
+//go:embed openapi.json
+var OpenAPI []byte
@@ -0,0 +1,19 @@
+// Binário do serviço: sobe o servidor HTTP ou executa os subcomandos do CLI
+// (lookup, bench, version). O serviço fica em internal/server
+package main
+
+import (
+	"os"
+
+	"github.com/weather-service/internal/server"
+)
+
+// Versão do binário, definida no build com -ldflags "-X main.version=1.2.3"
+var version = "dev"
+
+func main() {
+	service := server.New(server.DefaultClients())
+	if err := service.Execute(version); err != nil {
+		os.Exit(1)
+	}
+}
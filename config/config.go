@@ -0,0 +1,307 @@
+// Package config carrega o arquivo de configuração do serviço, valida as
+// configurações na inicialização e as entrega ao restante do serviço.
+//
+// O arquivo (YAML ou TOML, pela extensão) usa os mesmos nomes das variáveis
+// de ambiente, em maiúsculas ou minúsculas. Cada configuração é resolvida
+// em camadas, da maior para a menor precedência: flags, segredos do Secret
+// Manager, variáveis de ambiente, Vault e arquivo. O serviço lê os valores
+// efetivos pelos acessores tipados (String, Duration, Int...), nunca
+// direto do ambiente, de modo que valores vindos de flags, do arquivo ou de
+// um reload valem em qualquer ponto, mesmo depois da inicialização
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// Origens de um valor, na ordem de precedência
+const (
+	SourceFlag   = "flag"
+	SourceSecret = "secret"
+	SourceEnv    = "env"
+	SourceVault  = "vault"
+	SourceFile   = "file"
+)
+
+var precedence = []string{SourceFlag, SourceSecret, SourceEnv, SourceVault, SourceFile}
+
+// Arquivo carregado e os valores de cada camada, exceto o ambiente, que é
+// lido na hora
+var (
+	mu         sync.RWMutex
+	loadedPath string
+	layers     = map[string]map[string]string{
+		SourceFlag:   {},
+		SourceSecret: {},
+		SourceVault:  {},
+		SourceFile:   {},
+	}
+)
+
+// Define o valor de uma configuração em uma camada (flag, secret ou vault)
+func Set(source, name, value string) {
+	mu.Lock()
+	defer mu.Unlock()
+	layers[source][name] = value
+}
+
+// Remove o valor de uma configuração de uma camada
+func Unset(source, name string) {
+	mu.Lock()
+	defer mu.Unlock()
+	delete(layers[source], name)
+}
+
+// Valor efetivo de uma configuração, ou vazio quando não definida
+func String(name string) string {
+	value, _ := Get(name)
+	return value
+}
+
+// Valor efetivo de uma configuração e a camada de onde veio. Valores
+// vazios contam como não definidos, para que VAR= no docker-compose não
+// esconda o valor do arquivo
+func Get(name string) (value, source string) {
+	mu.RLock()
+	defer mu.RUnlock()
+	return get(name)
+}
+
+func get(name string) (string, string) {
+	for _, source := range precedence {
+		var value string
+		if source == SourceEnv {
+			value = os.Getenv(name)
+		} else {
+			value = layers[source][name]
+		}
+		if value != "" {
+			return value, source
+		}
+	}
+	return "", ""
+}
+
+// Carrega o arquivo (se path não for vazio) e valida a configuração efetiva
+func Load(path string) error {
+	mu.Lock()
+	defer mu.Unlock()
+
+	loadedPath = path
+	layers[SourceFile] = map[string]string{}
+	if path != "" {
+		values, err := readFile(path)
+		if err != nil {
+			return err
+		}
+		parsed, err := parseValues(values)
+		if err != nil {
+			return err
+		}
+		layers[SourceFile] = parsed
+	}
+	return validate()
+}
+
+// Relê o arquivo carregado por Load. Configurações definidas em camadas de
+// maior precedência (ambiente, flags...) continuam valendo. Se a nova
+// configuração for inválida, nada muda. Retorna os nomes cujo valor efetivo
+// mudou
+func Reload() ([]string, error) {
+	mu.Lock()
+	defer mu.Unlock()
+
+	if loadedPath == "" {
+		return nil, nil
+	}
+	values, err := readFile(loadedPath)
+	if err != nil {
+		return nil, err
+	}
+	next, err := parseValues(values)
+	if err != nil {
+		return nil, err
+	}
+
+	previous := layers[SourceFile]
+	before := map[string]string{}
+	for name := range union(previous, next) {
+		before[name], _ = get(name)
+	}
+	layers[SourceFile] = next
+	if err := validate(); err != nil {
+		layers[SourceFile] = previous
+		return nil, err
+	}
+
+	var changed []string
+	for name, value := range before {
+		if now, _ := get(name); now != value {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+func union(a, b map[string]string) map[string]struct{} {
+	names := map[string]struct{}{}
+	for name := range a {
+		names[name] = struct{}{}
+	}
+	for name := range b {
+		names[name] = struct{}{}
+	}
+	return names
+}
+
+func readFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	values := map[string]interface{}{}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &values)
+	case ".toml":
+		err = toml.Unmarshal(data, &values)
+	default:
+		return nil, fmt.Errorf("unsupported config file format %q (use .yaml, .yml or .toml)", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// Converte as chaves do arquivo para os nomes das variáveis, rejeitando
+// configurações desconhecidas
+func parseValues(values map[string]interface{}) (map[string]string, error) {
+	parsed := map[string]string{}
+	var errs []error
+	for _, key := range sortedKeys(values) {
+		name := strings.ToUpper(key)
+		if _, ok := Lookup(name); !ok {
+			errs = append(errs, fmt.Errorf("unknown setting %q in config file", key))
+			continue
+		}
+
+		value, err := stringify(values[key])
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", name, err))
+			continue
+		}
+		parsed[name] = value
+	}
+	return parsed, errors.Join(errs...)
+}
+
+// Listas viram valores separados por vírgula, como nas variáveis de ambiente
+func stringify(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool, int, int64, uint64, float64:
+		return fmt.Sprint(v), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := stringify(item)
+			if err != nil {
+				return "", err
+			}
+			items = append(items, s)
+		}
+		return strings.Join(items, ","), nil
+	default:
+		return "", fmt.Errorf("unsupported value of type %T", value)
+	}
+}
+
+// Verifica todas as configurações conhecidas com valor definido e retorna
+// todos os problemas encontrados de uma vez
+func Validate() error {
+	mu.RLock()
+	defer mu.RUnlock()
+	return validate()
+}
+
+func validate() error {
+	var errs []error
+	for _, setting := range Settings {
+		value, _ := get(setting.Name)
+		if value == "" || setting.check == nil {
+			continue
+		}
+		if err := setting.check(value); err != nil {
+			if setting.Secret {
+				errs = append(errs, fmt.Errorf("invalid %s: %w", setting.Name, err))
+			} else {
+				errs = append(errs, fmt.Errorf("invalid %s %q: %w", setting.Name, value, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Valor efetivo de uma configuração e sua origem (SourceFile, SourceEnv...)
+type Value struct {
+	Name   string
+	Value  string
+	Source string
+}
+
+// Texto exibido no lugar de valores secretos
+const Redacted = "[redacted]"
+
+// Configurações definidas no momento, na ordem do registro, com os valores
+// secretos ocultos
+func Effective() []Value {
+	mu.RLock()
+	defer mu.RUnlock()
+
+	var values []Value
+	for _, setting := range Settings {
+		value, source := get(setting.Name)
+		if value == "" {
+			continue
+		}
+		if setting.Secret {
+			value = Redacted
+		}
+		values = append(values, Value{Name: setting.Name, Value: value, Source: source})
+	}
+	return values
+}
+
+// Configuração registrada com o nome informado
+func Lookup(name string) (Setting, bool) {
+	for _, setting := range Settings {
+		if setting.Name == name {
+			return setting, true
+		}
+	}
+	return Setting{}, false
+}
+
+func sortedKeys(values map[string]interface{}) []string {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,257 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeConfig(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+// Garante que as variáveis definidas pelo arquivo não vazem entre testes
+func unsetenv(t *testing.T, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+}
+
+func TestLoad_YAML(t *testing.T) {
+	unsetenv(t, "PORT", "WEATHER_CACHE_TTL", "CORS_ALLOWED_ORIGINS", "SECURITY_HEADERS")
+	path := writeConfig(t, "config.yaml", `
+port: 9090
+WEATHER_CACHE_TTL: 5m
+cors_allowed_origins:
+  - https://a.example.com
+  - https://b.example.com
+security_headers: false
+`)
+
+	assert.NoError(t, Load(path))
+	assert.Equal(t, "9090", String("PORT"))
+	assert.Equal(t, "5m", String("WEATHER_CACHE_TTL"))
+	assert.Equal(t, "https://a.example.com,https://b.example.com", String("CORS_ALLOWED_ORIGINS"))
+	assert.Equal(t, "false", String("SECURITY_HEADERS"))
+}
+
+func TestLoad_TOML(t *testing.T) {
+	unsetenv(t, "PORT", "HTTP_WRITE_TIMEOUT", "RATE_LIMIT_RPS")
+	path := writeConfig(t, "config.toml", `
+port = 9090
+http_write_timeout = "2m"
+rate_limit_rps = 2.5
+`)
+
+	assert.NoError(t, Load(path))
+	assert.Equal(t, "9090", String("PORT"))
+	assert.Equal(t, "2m", String("HTTP_WRITE_TIMEOUT"))
+	assert.Equal(t, "2.5", String("RATE_LIMIT_RPS"))
+}
+
+func TestLoad_EnvironmentOverridesFile(t *testing.T) {
+	t.Setenv("PORT", "7070")
+	path := writeConfig(t, "config.yaml", "port: 9090\n")
+
+	assert.NoError(t, Load(path))
+	assert.Equal(t, "7070", String("PORT"))
+}
+
+// O arquivo não passa para o ambiente: os valores chegam ao serviço pelos
+// acessores do pacote
+func TestLoad_DoesNotExportToEnvironment(t *testing.T) {
+	unsetenv(t, "WEATHER_API_DAILY_BUDGET")
+	assert.NoError(t, Load(writeConfig(t, "config.yaml", "weather_api_daily_budget: 5\n")))
+	t.Cleanup(func() { Load("") })
+
+	_, set := os.LookupEnv("WEATHER_API_DAILY_BUDGET")
+	assert.False(t, set)
+	assert.Equal(t, 5, Int("WEATHER_API_DAILY_BUDGET", 0))
+}
+
+// Variável vazia (VAR= no docker-compose) não esconde o valor do arquivo
+func TestLoad_EmptyEnvironmentUsesFile(t *testing.T) {
+	t.Setenv("PORT", "")
+	assert.NoError(t, Load(writeConfig(t, "config.yaml", "port: 9090\n")))
+	t.Cleanup(func() { Load("") })
+
+	assert.Equal(t, "9090", String("PORT"))
+}
+
+func TestGet_Precedence(t *testing.T) {
+	unsetenv(t, "PORT")
+	assert.NoError(t, Load(writeConfig(t, "config.yaml", "port: 9090\n")))
+	t.Cleanup(func() { Load("") })
+	t.Cleanup(func() {
+		Unset(SourceFlag, "PORT")
+		Unset(SourceVault, "PORT")
+	})
+
+	steps := []struct {
+		set      func()
+		expected string
+		source   string
+	}{
+		{func() {}, "9090", SourceFile},
+		{func() { Set(SourceVault, "PORT", "8181") }, "8181", SourceVault},
+		{func() { t.Setenv("PORT", "7070") }, "7070", SourceEnv},
+		{func() { Set(SourceFlag, "PORT", "6060") }, "6060", SourceFlag},
+	}
+	for _, step := range steps {
+		step.set()
+		value, source := Get("PORT")
+		assert.Equal(t, step.expected, value)
+		assert.Equal(t, step.source, source)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		file     string
+		content  string
+		expected string
+	}{
+		{"unknown setting", "config.yaml", "prot: 8080\n", `unknown setting "prot" in config file`},
+		{"invalid value", "config.yaml", "gzip_min_size: big\n", `invalid GZIP_MIN_SIZE "big": must be an integer`},
+		{"unsupported format", "config.json", "{}", `unsupported config file format ".json" (use .yaml, .yml or .toml)`},
+		{"nested value", "config.yaml", "port:\n  http: 8080\n", "PORT: unsupported value of type map[string]interface {}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetenv(t, "PORT", "GZIP_MIN_SIZE")
+			err := Load(writeConfig(t, tt.file, tt.content))
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestLoad_MissingFile(t *testing.T) {
+	err := Load(filepath.Join(t.TempDir(), "missing.yaml"))
+	assert.ErrorContains(t, err, "failed to read config file")
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected string
+	}{
+		{"PORT", "8080", ""},
+		{"PORT", "70000", `invalid PORT "70000": must be a port between 1 and 65535`},
+		{"HTTP_READ_TIMEOUT", "0", ""},
+		{"HTTP_READ_TIMEOUT", "soon", `invalid HTTP_READ_TIMEOUT "soon": must be a duration such as 30s or 5m`},
+		{"LIVE_REFRESH_INTERVAL", "0s", `invalid LIVE_REFRESH_INTERVAL "0s": must be greater than zero`},
+		{"TRUSTED_PROXY_HOPS", "-1", `invalid TRUSTED_PROXY_HOPS "-1": must not be negative`},
+		{"RATE_LIMIT_RPS", "0.5", ""},
+		{"SECURITY_HEADERS", "maybe", `invalid SECURITY_HEADERS "maybe": must be true or false`},
+		{"STARTUP_CHECK", "warn", `invalid STARTUP_CHECK "warn": must be one of fail, degraded, off`},
+		{"LOG_LEVEL", "debug", `invalid LOG_LEVEL "debug": must be one of info, warning, error`},
+		{"CEP_PROVIDER_ORDER", "brasilapi, viacep", ""},
+		{"CEP_PROVIDER_ORDER", "correios", `invalid CEP_PROVIDER_ORDER "correios": "correios": must be one of viacep, brasilapi`},
+		{"CEP_PROVIDER_ORDER", "viacep,viacep", `invalid CEP_PROVIDER_ORDER "viacep,viacep": "viacep" listed twice`},
+		{"JWT_JWKS_URL", "example.com/jwks", `invalid JWT_JWKS_URL "example.com/jwks": must be an absolute URL`},
+		{"SCHEDULE_CRON", "*/10 6-22 * * 1-5", ""},
+		{"SCHEDULE_CRON", "*/10 * * *", `invalid SCHEDULE_CRON "*/10 * * *": must be a cron expression: expected 5 fields, got 4`},
+		{"KAFKA_BROKERS", "kafka-1:9092, kafka-2:9092", ""},
+		{"KAFKA_BROKERS", "kafka-1", `invalid KAFKA_BROKERS "kafka-1": must be a comma-separated list of host:port, got "kafka-1"`},
+		{"PUBSUB_TOPIC", "projects/fullcycle01/topics/weather", ""},
+		{"PUBSUB_TOPIC", "weather", `invalid PUBSUB_TOPIC "weather": must be a topic name such as projects/my-project/topics/my-topic`},
+		{"TLS_CERT_FILE", "/nonexistent/cert.pem", `invalid TLS_CERT_FILE "/nonexistent/cert.pem": file not found`},
+		// Valores secretos não aparecem na mensagem
+		{"API_KEYS_REDIS_URL", "senha", "invalid API_KEYS_REDIS_URL: must be an absolute URL"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+"="+tt.value, func(t *testing.T) {
+			t.Setenv(tt.name, tt.value)
+			err := Validate()
+			if tt.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expected)
+			}
+		})
+	}
+}
+
+func TestValidate_ReportsAllErrors(t *testing.T) {
+	t.Setenv("PORT", "http")
+	t.Setenv("GZIP_MIN_SIZE", "-1")
+
+	err := Validate()
+	assert.ErrorContains(t, err, "invalid PORT")
+	assert.ErrorContains(t, err, "invalid GZIP_MIN_SIZE")
+}
+
+func TestReload(t *testing.T) {
+	unsetenv(t, "WEATHER_CACHE_TTL", "RATE_LIMIT_RPS", "GZIP_MIN_SIZE")
+	t.Setenv("PORT", "7070")
+	path := writeConfig(t, "config.yaml", "port: 9090\nweather_cache_ttl: 1m\nrate_limit_rps: 5\n")
+	assert.NoError(t, Load(path))
+
+	assert.NoError(t, os.WriteFile(path, []byte("port: 9191\nweather_cache_ttl: 2m\ngzip_min_size: 512\n"), 0o600))
+	changed, err := Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"GZIP_MIN_SIZE", "RATE_LIMIT_RPS", "WEATHER_CACHE_TTL"}, changed)
+
+	assert.Equal(t, "2m", String("WEATHER_CACHE_TTL"))
+	assert.Equal(t, "512", String("GZIP_MIN_SIZE"))
+	// Removido do arquivo: volta ao padrão
+	assert.Empty(t, String("RATE_LIMIT_RPS"))
+	// Definido no ambiente: o arquivo não vale nem no reload
+	assert.Equal(t, "7070", String("PORT"))
+}
+
+func TestReload_InvalidKeepsPreviousConfig(t *testing.T) {
+	unsetenv(t, "WEATHER_CACHE_TTL", "GZIP_MIN_SIZE")
+	path := writeConfig(t, "config.yaml", "weather_cache_ttl: 1m\n")
+	assert.NoError(t, Load(path))
+
+	assert.NoError(t, os.WriteFile(path, []byte("weather_cache_ttl: 2m\ngzip_min_size: big\n"), 0o600))
+	_, err := Reload()
+	assert.EqualError(t, err, `invalid GZIP_MIN_SIZE "big": must be an integer`)
+
+	assert.Equal(t, "1m", String("WEATHER_CACHE_TTL"))
+	assert.Empty(t, String("GZIP_MIN_SIZE"))
+
+	// Sem mudanças, nada a recarregar
+	assert.NoError(t, os.WriteFile(path, []byte("weather_cache_ttl: 1m\n"), 0o600))
+	changed, err := Reload()
+	assert.NoError(t, err)
+	assert.Empty(t, changed)
+}
+
+func TestReload_WithoutConfigFile(t *testing.T) {
+	assert.NoError(t, Load(""))
+
+	changed, err := Reload()
+	assert.NoError(t, err)
+	assert.Nil(t, changed)
+}
+
+func TestEffective(t *testing.T) {
+	unsetenv(t, "WEATHER_CACHE_TTL", "GZIP_MIN_SIZE", "WEATHER_API_KEY")
+	t.Setenv("PORT", "7070")
+	t.Setenv("WEATHER_API_KEY", "super-secret")
+	path := writeConfig(t, "config.yaml", "weather_cache_ttl: 1m\nport: 9090\n")
+	assert.NoError(t, Load(path))
+	t.Cleanup(func() { Load("") })
+
+	values := map[string]Value{}
+	for _, value := range Effective() {
+		values[value.Name] = value
+	}
+	assert.Equal(t, Value{Name: "PORT", Value: "7070", Source: "env"}, values["PORT"])
+	assert.Equal(t, Value{Name: "WEATHER_CACHE_TTL", Value: "1m", Source: "file"}, values["WEATHER_CACHE_TTL"])
+	assert.Equal(t, Value{Name: "WEATHER_API_KEY", Value: Redacted, Source: "env"}, values["WEATHER_API_KEY"])
+	assert.NotContains(t, values, "GZIP_MIN_SIZE")
+}
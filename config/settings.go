@@ -0,0 +1,361 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weather-service/internal/schedule"
+)
+
+// Configuração reconhecida pelo serviço. Secret indica valores que não
+// podem aparecer em logs nem em mensagens de erro
+type Setting struct {
+	Name   string
+	Secret bool
+	check  func(string) error
+}
+
+// Todas as variáveis lidas pelo serviço. Novas configurações precisam ser
+// registradas aqui para serem aceitas no arquivo e validadas na inicialização
+var Settings = []Setting{
+	// Servidor
+	{Name: "PORT", check: port},
+	{Name: "LISTEN_SOCKET"},
+	{Name: "HTTP_REDIRECT_PORT", check: port},
+	{Name: "HTTP_READ_HEADER_TIMEOUT", check: duration},
+	{Name: "HTTP_READ_TIMEOUT", check: duration},
+	{Name: "HTTP_WRITE_TIMEOUT", check: duration},
+	{Name: "HTTP_IDLE_TIMEOUT", check: duration},
+	{Name: "TLS_CERT_FILE", check: existingFile},
+	{Name: "TLS_KEY_FILE", check: existingFile},
+	{Name: "TLS_CLIENT_CA_FILE", check: existingFile},
+	{Name: "ACME_DOMAINS"},
+	{Name: "ACME_EMAIL"},
+	{Name: "ACME_CACHE_DIR"},
+	{Name: "LOG_LEVEL", check: oneOf("info", "warning", "error")},
+
+	// WeatherAPI
+	{Name: "WEATHER_API_KEY", Secret: true},
+	{Name: "WEATHER_API_KEY_FILE", check: existingFile},
+	{Name: "WEATHER_API_KEY_COOLDOWN", check: duration},
+	{Name: "STARTUP_CHECK", check: oneOf("fail", "degraded", "off")},
+	{Name: "HEALTH_DEEP_TIMEOUT", check: positiveDuration},
+	{Name: "HEALTH_DEEP_CACHE_TTL", check: duration},
+	{Name: "CACHE_WARMUP_CEPS"},
+	{Name: "CACHE_WARMUP_FILE", check: existingFile},
+	{Name: "CIRCUIT_BREAKER_THRESHOLD", check: positiveInt},
+	{Name: "CIRCUIT_BREAKER_COOLDOWN", check: positiveDuration},
+	{Name: "PROVIDER_STATS_WINDOW", check: positiveDuration},
+	{Name: "WEATHER_CACHE_TTL", check: duration},
+	{Name: "WEATHER_CACHE_TTL_CURRENT", check: duration},
+	{Name: "WEATHER_CACHE_TTL_FORECAST", check: duration},
+	{Name: "WEATHER_CACHE_STALE_TIMEOUT", check: duration},
+	{Name: "WEATHER_CACHE_MAX_ENTRIES", check: positiveInt},
+	{Name: "WEATHER_CACHE_RETENTION", check: positiveDuration},
+	{Name: "WEATHER_API_DAILY_BUDGET", check: nonNegativeInt},
+	{Name: "WEATHER_API_MONTHLY_BUDGET", check: nonNegativeInt},
+
+	// HashiCorp Vault
+	{Name: "VAULT_ADDR", check: absoluteURL},
+	{Name: "VAULT_TOKEN", Secret: true},
+	{Name: "VAULT_ROLE"},
+	{Name: "VAULT_AUTH_PATH"},
+	{Name: "VAULT_K8S_TOKEN_FILE", check: existingFile},
+	{Name: "VAULT_SECRET_PATH"},
+	{Name: "VAULT_REFRESH_INTERVAL", check: positiveDuration},
+
+	// Google Cloud Secret Manager (valores gcp-sm://)
+	{Name: "GCP_ACCESS_TOKEN", Secret: true},
+
+	// Registro das consultas
+	{Name: "LOOKUPS_SQLITE_PATH"},
+	{Name: "LOOKUPS_POSTGRES_URL", Secret: true, check: absoluteURL},
+	{Name: "LOOKUPS_DB_MAX_CONNS", check: positiveInt},
+	{Name: "STATS_WINDOW", check: positiveDuration},
+
+	// Tabela de CEPs embutida
+	{Name: "OFFLINE_CEP", check: boolean},
+	{Name: "CEP_FALLBACK", check: boolean},
+	{Name: "CEP_STRICT", check: boolean},
+	{Name: "VIACEP_TIMEOUT", check: positiveDuration},
+	{Name: "CEP_HEDGE_DELAY", check: duration},
+	{Name: "CEP_PROVIDER_ORDER", check: listOf("viacep", "brasilapi")},
+
+	// Conexões com os provedores
+	{Name: "UPSTREAM_MAX_IDLE_CONNS_PER_HOST", check: positiveInt},
+	{Name: "UPSTREAM_MAX_CONNS_PER_HOST", check: nonNegativeInt},
+	{Name: "UPSTREAM_IDLE_CONN_TIMEOUT", check: duration},
+	{Name: "UPSTREAM_KEEP_ALIVE", check: duration},
+	{Name: "UPSTREAM_DISABLE_KEEP_ALIVES", check: boolean},
+
+	// Webhooks de limites de temperatura
+	{Name: "WEBHOOK_POLL_INTERVAL", check: positiveDuration},
+	{Name: "WEBHOOK_TIMEOUT", check: positiveDuration},
+	{Name: "WEBHOOK_SECRET", Secret: true},
+	{Name: "WEBHOOK_MAX_SUBSCRIPTIONS", check: positiveInt},
+	{Name: "WEBHOOK_ALLOW_PRIVATE", check: boolean},
+
+	// Atualização agendada
+	{Name: "SCHEDULE_CEPS"},
+	{Name: "SCHEDULE_CRON", check: cronExpression},
+
+	// E-mail das regras de alerta
+	{Name: "SMTP_HOST"},
+	{Name: "SMTP_PORT", check: port},
+	{Name: "SMTP_USERNAME"},
+	{Name: "SMTP_PASSWORD", Secret: true},
+	{Name: "SMTP_FROM", check: emailAddress},
+	{Name: "SMTP_ALLOWED_DOMAINS"},
+
+	// Publicação das leituras em MQTT
+	{Name: "MQTT_BROKER_URL", Secret: true, check: absoluteURL},
+	{Name: "MQTT_CLIENT_ID"},
+	{Name: "MQTT_USERNAME"},
+	{Name: "MQTT_PASSWORD", Secret: true},
+	{Name: "MQTT_TOPIC_PREFIX"},
+	{Name: "MQTT_QOS", check: oneOf("0", "1", "2")},
+	{Name: "MQTT_RETAIN", check: boolean},
+
+	// Eventos das consultas no Kafka
+	{Name: "KAFKA_BROKERS", check: hostPorts},
+	{Name: "KAFKA_TOPIC"},
+	{Name: "KAFKA_CLIENT_ID"},
+	{Name: "KAFKA_TIMEOUT", check: positiveDuration},
+	{Name: "KAFKA_TLS", check: boolean},
+	{Name: "KAFKA_TLS_CA_FILE", check: existingFile},
+	{Name: "KAFKA_SASL_MECHANISM", check: oneOf("plain", "scram-sha-256", "scram-sha-512")},
+	{Name: "KAFKA_SASL_USERNAME"},
+	{Name: "KAFKA_SASL_PASSWORD", Secret: true},
+
+	// Eventos das consultas e dos alertas no Pub/Sub
+	{Name: "PUBSUB_TOPIC", check: pubsubTopic},
+	{Name: "PUBSUB_ENDPOINT", check: absoluteURL},
+
+	// Consultas por NATS (request/reply)
+	{Name: "NATS_URL", Secret: true},
+	{Name: "NATS_SUBJECT"},
+	{Name: "NATS_QUEUE"},
+	{Name: "NATS_CREDS_FILE", check: existingFile},
+
+	// Bot do Telegram
+	{Name: "TELEGRAM_BOT_TOKEN", Secret: true},
+	{Name: "TELEGRAM_API_URL", check: absoluteURL},
+	{Name: "TELEGRAM_POLL_TIMEOUT", check: positiveDuration},
+
+	// Lotes
+	{Name: "BATCH_MAX_SIZE", check: positiveInt},
+	{Name: "BATCH_CONCURRENCY", check: positiveInt},
+	{Name: "BATCH_ITEM_TIMEOUT", check: positiveDuration},
+
+	// Cache de endereços
+	{Name: "CEP_CACHE_PATH"},
+	{Name: "CEP_CACHE_TTL", check: positiveDuration},
+	{Name: "CEP_NOT_FOUND_TTL", check: duration},
+
+	// Respostas
+	{Name: "TEMP_PRECISION", check: nonNegativeInt},
+	{Name: "LIVE_REFRESH_INTERVAL", check: positiveDuration},
+	{Name: "CACHE_MAX_AGE_WEATHER", check: duration},
+	{Name: "CACHE_MAX_AGE_ALERTS", check: duration},
+	{Name: "CACHE_MAX_AGE_ASTRONOMY", check: duration},
+	{Name: "CACHE_MAX_AGE_HISTORY", check: duration},
+	{Name: "GZIP_MIN_SIZE", check: nonNegativeInt},
+	{Name: "MAX_BODY_SIZE", check: positiveInt},
+	{Name: "CORS_ALLOWED_ORIGINS"},
+	{Name: "CORS_ALLOWED_METHODS"},
+	{Name: "CORS_ALLOWED_HEADERS"},
+
+	// Autenticação e proteção
+	{Name: "API_KEYS", Secret: true},
+	{Name: "API_KEYS_FILE", check: existingFile},
+	{Name: "API_KEYS_REDIS_URL", Secret: true, check: absoluteURL},
+	{Name: "API_KEYS_REDIS_SET"},
+	{Name: "TENANTS_FILE", check: existingFile},
+	{Name: "ADMIN_API_KEY", Secret: true},
+	{Name: "JWT_HS256_SECRET", Secret: true},
+	{Name: "JWT_JWKS_URL", check: absoluteURL},
+	{Name: "JWT_ISSUER"},
+	{Name: "JWT_AUDIENCE"},
+	{Name: "JWT_SUBJECT_DAILY_BUDGET", check: nonNegativeInt},
+	{Name: "JWT_SUBJECT_MONTHLY_BUDGET", check: nonNegativeInt},
+	{Name: "HMAC_SECRET", Secret: true},
+	{Name: "HMAC_MAX_SKEW", check: positiveDuration},
+	{Name: "RATE_LIMIT_RPS", check: positiveFloat},
+	{Name: "RATE_LIMIT_BURST", check: positiveInt},
+	{Name: "TRUSTED_PROXY_HOPS", check: nonNegativeInt},
+	{Name: "IP_ALLOWLIST"},
+	{Name: "IP_ALLOWLIST_FILE", check: existingFile},
+	{Name: "IP_DENYLIST"},
+	{Name: "IP_DENYLIST_FILE", check: existingFile},
+	{Name: "SECURITY_HEADERS", check: boolean},
+	{Name: "X_FRAME_OPTIONS"},
+	{Name: "CONTENT_SECURITY_POLICY"},
+	{Name: "HSTS_MAX_AGE", check: duration},
+}
+
+var (
+	errNegative    = errors.New("must not be negative")
+	errNotPositive = errors.New("must be greater than zero")
+)
+
+func nonNegativeInt(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return errors.New("must be an integer")
+	}
+	if n < 0 {
+		return errNegative
+	}
+	return nil
+}
+
+func positiveInt(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return errors.New("must be an integer")
+	}
+	if n <= 0 {
+		return errNotPositive
+	}
+	return nil
+}
+
+func positiveFloat(value string) error {
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return errors.New("must be a number")
+	}
+	if n <= 0 {
+		return errNotPositive
+	}
+	return nil
+}
+
+// Durações no formato do Go (ex.: 30s, 5m, 1h)
+func duration(value string) error {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		return errors.New("must be a duration such as 30s or 5m")
+	}
+	if d < 0 {
+		return errNegative
+	}
+	return nil
+}
+
+func positiveDuration(value string) error {
+	if err := duration(value); err != nil {
+		return err
+	}
+	if d, _ := time.ParseDuration(value); d == 0 {
+		return errNotPositive
+	}
+	return nil
+}
+
+func boolean(value string) error {
+	if _, err := strconv.ParseBool(value); err != nil {
+		return errors.New("must be true or false")
+	}
+	return nil
+}
+
+func oneOf(options ...string) func(string) error {
+	return func(value string) error {
+		for _, option := range options {
+			if value == option {
+				return nil
+			}
+		}
+		return fmt.Errorf("must be one of %s", strings.Join(options, ", "))
+	}
+}
+
+// Lista separada por vírgulas, só com as opções dadas e sem repetições
+func listOf(options ...string) func(string) error {
+	valid := oneOf(options...)
+	return func(value string) error {
+		seen := make(map[string]bool)
+		for _, item := range strings.Split(value, ",") {
+			item = strings.TrimSpace(item)
+			if err := valid(item); err != nil {
+				return fmt.Errorf("%q: %w", item, err)
+			}
+			if seen[item] {
+				return fmt.Errorf("%q listed twice", item)
+			}
+			seen[item] = true
+		}
+		return nil
+	}
+}
+
+func cronExpression(value string) error {
+	if _, err := schedule.Parse(value); err != nil {
+		return fmt.Errorf("must be a cron expression: %v", err)
+	}
+	return nil
+}
+
+func emailAddress(value string) error {
+	if _, err := mail.ParseAddress(value); err != nil {
+		return errors.New("must be an email address")
+	}
+	return nil
+}
+
+// Lista de endereços host:port separados por vírgula
+func hostPorts(value string) error {
+	for _, addr := range strings.Split(value, ",") {
+		if addr = strings.TrimSpace(addr); addr == "" {
+			continue
+		}
+		if _, p, err := net.SplitHostPort(addr); err != nil || port(p) != nil {
+			return fmt.Errorf("must be a comma-separated list of host:port, got %q", addr)
+		}
+	}
+	return nil
+}
+
+// Nome completo do tópico: projects/P/topics/T
+func pubsubTopic(value string) error {
+	parts := strings.Split(value, "/")
+	if len(parts) != 4 || parts[0] != "projects" || parts[1] == "" || parts[2] != "topics" || parts[3] == "" {
+		return errors.New("must be a topic name such as projects/my-project/topics/my-topic")
+	}
+	return nil
+}
+
+func port(value string) error {
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 || n > 65535 {
+		return errors.New("must be a port between 1 and 65535")
+	}
+	return nil
+}
+
+func absoluteURL(value string) error {
+	u, err := url.Parse(value)
+	if err != nil || u.Scheme == "" || u.Host == "" {
+		return errors.New("must be an absolute URL")
+	}
+	return nil
+}
+
+func existingFile(value string) error {
+	info, err := os.Stat(value)
+	if err != nil {
+		return errors.New("file not found")
+	}
+	if info.IsDir() {
+		return errors.New("is a directory")
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"log"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lista separada por vírgulas; valor vazio usa o padrão
+func List(name string, fallback []string) []string {
+	var values []string
+	for _, value := range strings.Split(String(name), ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return fallback
+	}
+	return values
+}
+
+// Booleano (true, false, 1, 0...); vazio ou inválido usa o padrão
+func Bool(name string, fallback bool) bool {
+	value := String(name)
+	if value == "" {
+		return fallback
+	}
+
+	enabled, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Printf("WARNING: ignoring invalid %s %q", name, value)
+		return fallback
+	}
+	return enabled
+}
+
+// Duração no formato do Go (ex.: 5m, 1h); vazio, negativo ou inválido usa
+// o padrão
+func Duration(name string, fallback time.Duration) time.Duration {
+	value := String(name)
+	if value == "" {
+		return fallback
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		log.Printf("WARNING: ignoring invalid %s %q", name, value)
+		return fallback
+	}
+	return duration
+}
+
+// Inteiro não negativo; vazio, negativo ou inválido usa o padrão
+func Int(name string, fallback int) int {
+	value := String(name)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		log.Printf("WARNING: ignoring invalid %s %q", name, value)
+		return fallback
+	}
+	return n
+}
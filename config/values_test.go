@@ -0,0 +1,70 @@
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestList(t *testing.T) {
+	t.Setenv("ACME_DOMAINS", " a.example.com, ,b.example.com ")
+	assert.Equal(t, []string{"a.example.com", "b.example.com"}, List("ACME_DOMAINS", nil))
+
+	t.Setenv("ACME_DOMAINS", "")
+	assert.Equal(t, []string{"fallback"}, List("ACME_DOMAINS", []string{"fallback"}))
+}
+
+func TestDuration(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", time.Hour},
+		{"10m", 10 * time.Minute},
+		{"0", 0},
+		{"-1m", time.Hour},
+		{"soon", time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("CACHE_MAX_AGE_ASTRONOMY", tt.value)
+		assert.Equal(t, tt.expected, Duration("CACHE_MAX_AGE_ASTRONOMY", time.Hour))
+	}
+}
+
+func TestBool(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected bool
+	}{
+		{"", true},
+		{"false", false},
+		{"0", false},
+		{"TRUE", true},
+		{"sim", true},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("OFFLINE_CEP", tt.value)
+		assert.Equal(t, tt.expected, Bool("OFFLINE_CEP", true))
+	}
+}
+
+func TestInt(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+	}{
+		{"", 7},
+		{"0", 0},
+		{"1000", 1000},
+		{"-1", 7},
+		{"many", 7},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("WEATHER_API_DAILY_BUDGET", tt.value)
+		assert.Equal(t, tt.expected, Int("WEATHER_API_DAILY_BUDGET", 7))
+	}
+}
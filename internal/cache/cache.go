@@ -0,0 +1,196 @@
+// Package cache guarda em memória respostas de provedores externos,
+// indexadas por uma chave escolhida por quem chama (endpoint e parâmetros,
+// por exemplo). O TTL é decidido na leitura, para que uma entrada vencida
+// ainda possa servir de reserva quando o provedor está fora do ar
+package cache
+
+import (
+	"container/list"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Resposta guardada e o momento em que foi guardada
+type Entry struct {
+	Body     []byte
+	StoredAt time.Time
+}
+
+type Store struct {
+	Counters
+
+	mu sync.Mutex
+	// Elementos de order, indexados pela chave
+	entries map[string]*list.Element
+	// Da entrada guardada há mais tempo para a mais recente
+	order      *list.List
+	maxEntries int
+	now        func() time.Time
+}
+
+type item struct {
+	key   string
+	entry Entry
+}
+
+func New() *Store {
+	return NewWithClock(time.Now)
+}
+
+// Cache com um relógio próprio, para testes que simulam a passagem do tempo
+func NewWithClock(now func() time.Time) *Store {
+	return &Store{entries: make(map[string]*list.Element), order: list.New(), now: now}
+}
+
+// Limita a quantidade de entradas; 0 deixa sem limite. Quando o cache está
+// cheio, Set descarta as entradas guardadas há mais tempo. Um limite menor
+// que a quantidade atual vale a partir do próximo Set
+func (s *Store) SetLimit(maxEntries int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.maxEntries = maxEntries
+}
+
+func (s *Store) Get(key string) (Entry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if element, ok := s.entries[key]; ok {
+		return element.Value.(*item).entry, true
+	}
+	return Entry{}, false
+}
+
+func (s *Store) Set(key string, body []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := Entry{Body: body, StoredAt: s.now()}
+	if element, ok := s.entries[key]; ok {
+		element.Value.(*item).entry = entry
+		s.order.MoveToBack(element)
+		return
+	}
+
+	evicted := 0
+	for s.maxEntries > 0 && s.order.Len() >= s.maxEntries {
+		s.remove(s.order.Front())
+		evicted++
+	}
+	s.Evicted(evicted)
+	s.entries[key] = s.order.PushBack(&item{key: key, entry: entry})
+}
+
+func (s *Store) remove(element *list.Element) {
+	s.order.Remove(element)
+	delete(s.entries, element.Value.(*item).key)
+}
+
+// Descarta todas as entradas e retorna quantas havia
+func (s *Store) Clear() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := len(s.entries)
+	s.entries = make(map[string]*list.Element)
+	s.order.Init()
+	s.Evicted(count)
+	return count
+}
+
+// Descarta as entradas mais antigas que ttl e retorna quantas eram
+func (s *Store) Prune(ttl time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	count := 0
+	for element := s.order.Front(); element != nil; {
+		next := element.Next()
+		if s.now().Sub(element.Value.(*item).entry.StoredAt) >= ttl {
+			s.remove(element)
+			count++
+		}
+		element = next
+	}
+	s.Evicted(count)
+	return count
+}
+
+func (s *Store) Len() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.entries)
+}
+
+// Chave de uma entrada, com o momento em que foi guardada e o tamanho da resposta
+type Key struct {
+	Key      string
+	StoredAt time.Time
+	Size     int
+}
+
+// Até limit chaves (todas com limit 0), em ordem alfabética
+func (s *Store) Keys(limit int) []Key {
+	s.mu.Lock()
+	keys := make([]Key, 0, len(s.entries))
+	for element := s.order.Front(); element != nil; element = element.Next() {
+		item := element.Value.(*item)
+		keys = append(keys, Key{Key: item.key, StoredAt: item.entry.StoredAt, Size: len(item.entry.Body)})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Key < keys[j].Key })
+	if limit > 0 && len(keys) > limit {
+		keys = keys[:limit]
+	}
+	return keys
+}
+
+// Verifica se a entrada ainda está dentro do TTL
+func (s *Store) Fresh(entry Entry, ttl time.Duration) bool {
+	return s.now().Sub(entry.StoredAt) < ttl
+}
+
+// Contadores de uso de um cache, para acompanhar a eficácia do TTL. Quem
+// consulta o cache decide o que conta como hit: o Store não conhece o TTL
+type Counters struct {
+	hits      atomic.Int64
+	misses    atomic.Int64
+	stale     atomic.Int64
+	evictions atomic.Int64
+}
+
+// Consulta atendida por uma entrada dentro do TTL
+func (c *Counters) Hit() { c.hits.Add(1) }
+
+// Consulta que precisou ir ao provedor
+func (c *Counters) Miss() { c.misses.Add(1) }
+
+// Entrada vencida servida porque o provedor falhou
+func (c *Counters) Stale() { c.stale.Add(1) }
+
+// Entradas descartadas antes de serem substituídas
+func (c *Counters) Evicted(n int) { c.evictions.Add(int64(n)) }
+
+type Stats struct {
+	Hits      int64
+	Misses    int64
+	Stale     int64
+	Evictions int64
+}
+
+func (c *Counters) Stats() Stats {
+	return Stats{Hits: c.hits.Load(), Misses: c.misses.Load(), Stale: c.stale.Load(), Evictions: c.evictions.Load()}
+}
+
+// Fração das consultas atendidas dentro do TTL; 0 sem nenhuma consulta
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
@@ -0,0 +1,96 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStore(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	store := NewWithClock(func() time.Time { return now })
+
+	_, ok := store.Get("current.json?q=a")
+	assert.False(t, ok)
+
+	store.Set("current.json?q=a", []byte(`{"a":1}`))
+	entry, ok := store.Get("current.json?q=a")
+	assert.True(t, ok)
+	assert.Equal(t, `{"a":1}`, string(entry.Body))
+	assert.True(t, store.Fresh(entry, time.Minute))
+
+	// Vencida, a entrada continua disponível para quem quiser usá-la como reserva
+	now = now.Add(2 * time.Minute)
+	entry, ok = store.Get("current.json?q=a")
+	assert.True(t, ok)
+	assert.False(t, store.Fresh(entry, time.Minute))
+	assert.False(t, store.Fresh(entry, 0))
+
+	store.Set("current.json?q=b", []byte(`{}`))
+	assert.Equal(t, 2, store.Len())
+	assert.Equal(t, []Key{{Key: "current.json?q=a", StoredAt: now.Add(-2 * time.Minute), Size: 7}}, store.Keys(1))
+	assert.Equal(t, 2, store.Clear())
+	assert.Equal(t, int64(2), store.Stats().Evictions)
+	_, ok = store.Get("current.json?q=a")
+	assert.False(t, ok)
+}
+
+func TestCounters(t *testing.T) {
+	var counters Counters
+	assert.Equal(t, 0.0, counters.Stats().HitRatio())
+
+	counters.Hit()
+	counters.Hit()
+	counters.Hit()
+	counters.Miss()
+	counters.Stale()
+	counters.Evicted(4)
+
+	stats := counters.Stats()
+	assert.Equal(t, Stats{Hits: 3, Misses: 1, Stale: 1, Evictions: 4}, stats)
+	assert.Equal(t, 0.75, stats.HitRatio())
+}
+
+func TestStore_Prune(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	store := NewWithClock(func() time.Time { return now })
+	store.Set("old", nil)
+	now = now.Add(2 * time.Minute)
+	store.Set("new", nil)
+
+	assert.Equal(t, 1, store.Prune(time.Minute))
+	_, ok := store.Get("old")
+	assert.False(t, ok)
+	_, ok = store.Get("new")
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), store.Stats().Evictions)
+}
+
+// Cheio, o cache descarta as entradas guardadas há mais tempo; regravar uma
+// chave a torna a mais recente
+func TestStore_Limit(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	store := NewWithClock(func() time.Time { return now })
+	store.SetLimit(2)
+
+	store.Set("a", nil)
+	now = now.Add(time.Second)
+	store.Set("b", nil)
+	now = now.Add(time.Second)
+	store.Set("a", []byte("new"))
+	now = now.Add(time.Second)
+	store.Set("c", nil)
+
+	assert.Equal(t, 2, store.Len())
+	_, ok := store.Get("b")
+	assert.False(t, ok)
+	entry, ok := store.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "new", string(entry.Body))
+	assert.Equal(t, int64(1), store.Stats().Evictions)
+
+	store.SetLimit(0)
+	store.Set("d", nil)
+	assert.Equal(t, 3, store.Len())
+}
@@ -0,0 +1,69 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const DefaultBrasilAPIURL = "https://brasilapi.com.br/api"
+
+// Cliente da consulta de CEP da BrasilAPI
+type BrasilAPI struct {
+	baseURL string
+	client  Doer
+}
+
+// Cliente da BrasilAPI em baseURL (DefaultBrasilAPIURL em produção). Sem
+// client, usa o http.DefaultClient
+func NewBrasilAPI(baseURL string, client Doer) *BrasilAPI {
+	return &BrasilAPI{baseURL: baseURL, client: doerOrDefault(client)}
+}
+
+// Resposta de /cep/v2/{cep} da BrasilAPI
+type brasilAPIBody struct {
+	CEP          string `json:"cep"`
+	State        string `json:"state"`
+	City         string `json:"city"`
+	Neighborhood string `json:"neighborhood"`
+	Street       string `json:"street"`
+}
+
+// Consulta um CEP só com dígitos, com os mesmos erros do ViaCEP.Lookup
+func (b *BrasilAPI) Lookup(ctx context.Context, cep string) (*Address, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/cep/v2/%s", b.baseURL, cep), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("brasilapi returned status %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, ErrNotFound
+	}
+
+	var body brasilAPIBody
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if body.City == "" {
+		return nil, ErrNotFound
+	}
+
+	// A BrasilAPI devolve o CEP sem hífen; o ViaCEP, com
+	return &Address{
+		CEP:          format(cep),
+		Street:       body.Street,
+		Neighborhood: body.Neighborhood,
+		City:         body.City,
+		UF:           body.State,
+	}, nil
+}
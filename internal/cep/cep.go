@@ -0,0 +1,87 @@
+// Package cep valida CEPs e os resolve em endereços, consultando o ViaCEP e
+// a BrasilAPI ou, sem rede, a tabela de faixas de CEP embutida.
+//
+// Os clientes só fazem a chamada HTTP e traduzem a resposta; circuit
+// breaker, estatísticas, cache e a escolha entre os provedores ficam com
+// quem os usa
+package cep
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode"
+)
+
+// O provedor respondeu que o CEP não existe
+var ErrNotFound = errors.New("CEP not found")
+
+// Endereço no formato comum aos provedores
+type Address struct {
+	CEP          string
+	Street       string
+	Complement   string
+	Neighborhood string
+	City         string
+	UF           string
+	// Código IBGE do município, quando o provedor informa (só o ViaCEP)
+	IBGE string
+}
+
+// Executa as requisições dos clientes. *http.Client atende a interface; nos
+// testes, qualquer implementação que não acesse a rede
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+func doerOrDefault(client Doer) Doer {
+	if client == nil {
+		return http.DefaultClient
+	}
+	return client
+}
+
+// Remove do CEP os pontos e espaços com que ele costuma ser digitado e
+// desfaz escapes de URL ("01.310 100" e "01310%2E100" viram "01310100").
+// O hífen fica com Normalize
+func Clean(cep string) string {
+	if unescaped, err := url.PathUnescape(cep); err == nil {
+		cep = unescaped
+	}
+	return strings.Map(func(r rune) rune {
+		if r == '.' || unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, cep)
+}
+
+// Remove o hífen do CEP (01310-100 vira 01310100)
+func Normalize(cep string) string {
+	return strings.ReplaceAll(cep, "-", "")
+}
+
+// O CEP tem exatamente 8 dígitos ASCII, com ou sem hífen. Dígitos de
+// outros alfabetos não são aceitos
+func Valid(cep string) bool {
+	return digits(Normalize(cep))
+}
+
+// Exatamente 8 dígitos ASCII, sem hífen nem sinal
+func digits(cep string) bool {
+	if len(cep) != 8 {
+		return false
+	}
+	for i := 0; i < len(cep); i++ {
+		if cep[i] < '0' || cep[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// CEP só com dígitos no formato 01310-100
+func format(cep string) string {
+	return cep[:5] + "-" + cep[5:]
+}
@@ -0,0 +1,139 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name     string
+		cep      string
+		expected bool
+	}{
+		{"Valid CEP with 8 digits", "01310100", true},
+		{"Valid CEP with hyphen", "01310-100", true},
+		{"Invalid CEP with 7 digits", "0131010", false},
+		{"Invalid CEP with 9 digits", "013101000", false},
+		{"Invalid CEP with letters", "0131010a", false},
+		{"Empty CEP", "", false},
+		{"CEP with spaces", "01310 100", false},
+		{"Non-ASCII digits", "０１３１０１００", false},
+		{"Hyphen out of place", "0131-0100", true},
+		{"Several hyphens", "0-1-3-1-0-1-0-0", true},
+		{"Hyphen only", "--------", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Valid(tt.cep))
+		})
+	}
+}
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		cep      string
+		expected string
+	}{
+		{"01310100", "01310100"},
+		{"01310-100", "01310-100"},
+		{"01310.100", "01310100"},
+		{"01.310-100", "01310-100"},
+		{"01310 100", "01310100"},
+		{" 01310100\t", "01310100"},
+		{"01310%20100", "01310100"},
+		{"01310%2E100", "01310100"},
+		{"%30%31%33%31%30%31%30%30", "01310100"},
+		{"01310%2F100", "01310/100"},
+		{"01310%zz100", "01310%zz100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cep, func(t *testing.T) {
+			assert.Equal(t, tt.expected, Clean(tt.cep))
+		})
+	}
+}
+
+// Servidor com as respostas do ViaCEP e da BrasilAPI por caminho
+func fakeProvider(t *testing.T, responses map[string]string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if body == "" {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestViaCEP_Lookup(t *testing.T) {
+	server := fakeProvider(t, map[string]string{
+		"/ws/01310100/json/": `{"cep":"01310-100","logradouro":"Avenida Paulista","bairro":"Bela Vista","localidade":"São Paulo","uf":"SP"}`,
+		"/ws/99999999/json/": `{"erro": true}`,
+		"/ws/99999998/json/": `{"erro": "true"}`,
+		"/ws/50000000/json/": "",
+	})
+	client := NewViaCEP(server.URL+"/ws", server.Client())
+
+	address, err := client.Lookup(context.Background(), "01310100")
+	assert.NoError(t, err)
+	assert.Equal(t, &Address{CEP: "01310-100", Street: "Avenida Paulista", Neighborhood: "Bela Vista", City: "São Paulo", UF: "SP"}, address)
+
+	for _, cep := range []string{"99999999", "99999998", "11111111"} {
+		_, err = client.Lookup(context.Background(), cep)
+		assert.True(t, errors.Is(err, ErrNotFound), cep)
+	}
+
+	_, err = client.Lookup(context.Background(), "50000000")
+	assert.EqualError(t, err, "viacep returned status 502")
+}
+
+func TestViaCEP_Search(t *testing.T) {
+	server := fakeProvider(t, map[string]string{
+		"/ws/SP/São Paulo/Paulista/json/":    `[{"cep":"01310-100","logradouro":"Avenida Paulista","bairro":"Bela Vista","localidade":"São Paulo","uf":"SP"}]`,
+		"/ws/SP/São Paulo/Inexistente/json/": `[]`,
+		"/ws/RJ/Rio de Janeiro/Rua/json/":    "",
+	})
+	client := NewViaCEP(server.URL+"/ws", server.Client())
+
+	addresses, err := client.Search(context.Background(), "SP", "São Paulo", "Paulista")
+	assert.NoError(t, err)
+	assert.Equal(t, []*Address{{CEP: "01310-100", Street: "Avenida Paulista", Neighborhood: "Bela Vista", City: "São Paulo", UF: "SP"}}, addresses)
+
+	_, err = client.Search(context.Background(), "SP", "São Paulo", "Inexistente")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	_, err = client.Search(context.Background(), "RJ", "Rio de Janeiro", "Rua")
+	assert.EqualError(t, err, "viacep returned status 502")
+}
+
+func TestBrasilAPI_Lookup(t *testing.T) {
+	server := fakeProvider(t, map[string]string{
+		"/api/cep/v2/01310100": `{"cep":"01310100","state":"SP","city":"São Paulo","neighborhood":"Bela Vista","street":"Avenida Paulista"}`,
+		"/api/cep/v2/50000000": "",
+	})
+	client := NewBrasilAPI(server.URL+"/api", server.Client())
+
+	address, err := client.Lookup(context.Background(), "01310100")
+	assert.NoError(t, err)
+	assert.Equal(t, &Address{CEP: "01310-100", Street: "Avenida Paulista", Neighborhood: "Bela Vista", City: "São Paulo", UF: "SP"}, address)
+
+	_, err = client.Lookup(context.Background(), "99999999")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	_, err = client.Lookup(context.Background(), "50000000")
+	assert.EqualError(t, err, "brasilapi returned status 502")
+}
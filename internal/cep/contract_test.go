@@ -0,0 +1,61 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/internal/recorder"
+)
+
+// Cassete de testdata/cassettes. Com RECORD_CASSETTES=true, os cassetes
+// regraváveis são refeitos contra o provedor real ao fim do teste
+func cassette(t *testing.T, name string, recordable bool) *recorder.Recorder {
+	mode := recorder.ModeFromEnv()
+	if !recordable {
+		mode = recorder.Replay
+	}
+	rec, err := recorder.New(filepath.Join("testdata", "cassettes", name+".yaml"), mode, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := rec.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	return rec
+}
+
+// Respostas reais do ViaCEP, incluindo as variações de "erro" que ele já
+// devolveu para CEPs inexistentes
+func TestViaCEP_Contract(t *testing.T) {
+	tests := []struct {
+		cassette   string
+		recordable bool
+		cep        string
+		expected   *Address
+		err        error
+	}{
+		{
+			cassette: "viacep_found", recordable: true, cep: "01001000",
+			expected: &Address{CEP: "01001-000", Street: "Praça da Sé", Complement: "lado ímpar", Neighborhood: "Sé", City: "São Paulo", UF: "SP", IBGE: "3550308"},
+		},
+		{cassette: "viacep_erro_string", recordable: true, cep: "99999999", err: ErrNotFound},
+		{cassette: "viacep_erro_bool", cep: "99999999", err: ErrNotFound},
+		// CEP malformado: 400 com uma página HTML em vez de JSON
+		{cassette: "viacep_bad_request", recordable: true, cep: "0100100", err: ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cassette, func(t *testing.T) {
+			client := NewViaCEP(DefaultViaCEPURL, cassette(t, tt.cassette, tt.recordable))
+
+			address, err := client.Lookup(context.Background(), tt.cep)
+			assert.True(t, errors.Is(err, tt.err), "unexpected error: %v", err)
+			assert.Equal(t, tt.expected, address)
+		})
+	}
+}
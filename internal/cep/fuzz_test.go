@@ -0,0 +1,65 @@
+package cep
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+)
+
+// Formato aceito depois de remover os hífens, escrito de outro jeito para
+// comparar com Valid
+var validCEP = regexp.MustCompile(`^[0-9]{8}$`)
+
+func FuzzValid(f *testing.F) {
+	for _, seed := range []string{
+		"01310100", "01310-100", "0131010", "013101000", "0131010a", "",
+		"01310 100", "0131-0100", "-01310100", "01310100-", "0-1-3-1-0-1-0-0",
+		"０１３１０１００", "٠١٣١٠١٠٠", "01310%2F100", "01310/100", "\x0001310100",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, cep string) {
+		valid := Valid(cep)
+		if valid != validCEP.MatchString(strings.ReplaceAll(cep, "-", "")) {
+			t.Fatalf("Valid(%q) = %v", cep, valid)
+		}
+		if !valid {
+			return
+		}
+
+		normalized := Normalize(cep)
+		if len(normalized) != 8 || !Valid(normalized) {
+			t.Fatalf("Normalize(%q) = %q", cep, normalized)
+		}
+	})
+}
+
+// O que sai de Clean não tem mais pontos nem espaços, de onde quer que eles
+// tenham vindo (digitados ou escapados)
+func FuzzClean(f *testing.F) {
+	for _, seed := range []string{"01310100", "01.310-100", "01310 100", "01310%2E100", "%30%31%33%31%30%31%30%30", "%", "0131%2D0100"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, cep string) {
+		cleaned := Clean(cep)
+		if strings.ContainsAny(cleaned, ". \t\n\r") {
+			t.Fatalf("Clean(%q) = %q", cep, cleaned)
+		}
+	})
+}
+
+// A tabela não pode entrar em pânico com nenhuma entrada, válida ou não
+func FuzzTableLookup(f *testing.F) {
+	for _, seed := range []string{"01310100", "99999999", "00000000", "", "-1", "+1310100", "1e7", "013101000000"} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, cep string) {
+		address, ok := Embedded().Lookup(cep)
+		if ok && (!Valid(cep) || address.City == "") {
+			t.Fatalf("Lookup(%q) = %+v", cep, address)
+		}
+	})
+}
@@ -0,0 +1,94 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const DefaultIBGEURL = "https://servicodados.ibge.gov.br/api/v1/localidades"
+
+// Códigos IBGE das UFs, os dois primeiros dígitos do código de um município
+var ibgeStateCodes = map[string]bool{
+	"11": true, "12": true, "13": true, "14": true, "15": true, "16": true, "17": true,
+	"21": true, "22": true, "23": true, "24": true, "25": true, "26": true, "27": true, "28": true, "29": true,
+	"31": true, "32": true, "33": true, "35": true,
+	"41": true, "42": true, "43": true,
+	"50": true, "51": true, "52": true, "53": true,
+}
+
+// O código IBGE do município tem 7 dígitos, começando pelo código de uma UF
+func ValidIBGE(code string) bool {
+	if len(code) != 7 {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		if code[i] < '0' || code[i] > '9' {
+			return false
+		}
+	}
+	return ibgeStateCodes[code[:2]]
+}
+
+// Cliente da API de localidades do IBGE
+type IBGE struct {
+	baseURL string
+	client  Doer
+}
+
+// Cliente do IBGE em baseURL (DefaultIBGEURL em produção). Sem client, usa o
+// http.DefaultClient
+func NewIBGE(baseURL string, client Doer) *IBGE {
+	return &IBGE{baseURL: baseURL, client: doerOrDefault(client)}
+}
+
+// Resposta de /municipios/{id}. Municípios criados depois da divisão em
+// microrregiões não têm microrregiao, então a UF vem da região imediata
+type ibgeBody struct {
+	ID             int    `json:"id"`
+	Nome           string `json:"nome"`
+	RegiaoImediata struct {
+		RegiaoIntermediaria struct {
+			UF struct {
+				Sigla string `json:"sigla"`
+			} `json:"UF"`
+		} `json:"regiao-intermediaria"`
+	} `json:"regiao-imediata"`
+}
+
+// Município de um código IBGE, só com cidade, UF e o próprio código.
+// Retorna ErrNotFound quando o código não existe (o IBGE responde 200 com uma
+// lista vazia) e um erro com o status quando o IBGE falha
+func (g *IBGE) Municipality(ctx context.Context, code string) (*Address, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/municipios/%s", g.baseURL, code), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ibge returned status %d", resp.StatusCode)
+	}
+
+	var raw json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	if len(raw) > 0 && raw[0] == '[' {
+		return nil, ErrNotFound
+	}
+	var body ibgeBody
+	if err := json.Unmarshal(raw, &body); err != nil {
+		return nil, err
+	}
+	if body.Nome == "" {
+		return nil, ErrNotFound
+	}
+	return &Address{City: body.Nome, UF: body.RegiaoImediata.RegiaoIntermediaria.UF.Sigla, IBGE: code}, nil
+}
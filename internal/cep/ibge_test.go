@@ -0,0 +1,37 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidIBGE(t *testing.T) {
+	for _, code := range []string{"3550308", "5300108", "1100015"} {
+		assert.True(t, ValidIBGE(code), code)
+	}
+	for _, code := range []string{"", "355030", "35503080", "355030a", "9950308", "3450308"} {
+		assert.False(t, ValidIBGE(code), code)
+	}
+}
+
+func TestIBGE_Municipality(t *testing.T) {
+	server := fakeProvider(t, map[string]string{
+		"/municipios/3550308": `{"id":3550308,"nome":"São Paulo","microrregiao":{"id":35061},"regiao-imediata":{"id":350001,"regiao-intermediaria":{"id":3501,"UF":{"id":35,"sigla":"SP","nome":"São Paulo"}}}}`,
+		"/municipios/3599999": `[]`,
+		"/municipios/5300108": "",
+	})
+	client := NewIBGE(server.URL, server.Client())
+
+	address, err := client.Municipality(context.Background(), "3550308")
+	assert.NoError(t, err)
+	assert.Equal(t, &Address{City: "São Paulo", UF: "SP", IBGE: "3550308"}, address)
+
+	_, err = client.Municipality(context.Background(), "3599999")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	_, err = client.Municipality(context.Background(), "5300108")
+	assert.EqualError(t, err, "ibge returned status 502")
+}
@@ -0,0 +1,108 @@
+package cep
+
+import (
+	_ "embed"
+	"encoding/csv"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Faixas de CEP dos municípios mais populosos, com cidade e UF. Cobre as
+// capitais e as maiores cidades; não tem bairro nem logradouro
+//
+//go:embed data/cep_ranges.csv
+var rangesCSV string
+
+// Faixa contínua de CEPs de um município
+type cepRange struct {
+	start, end int
+	city, uf   string
+}
+
+// Tabela de faixas de CEP por município, ordenada pelo início das faixas
+type Table struct {
+	ranges []cepRange
+}
+
+var (
+	embeddedOnce  sync.Once
+	embeddedTable *Table
+)
+
+// Tabela embutida no binário. O arquivo é validado pelos testes, então um
+// erro aqui é um defeito de build
+func Embedded() *Table {
+	embeddedOnce.Do(func() {
+		table, err := ParseTable(rangesCSV)
+		if err != nil {
+			panic(fmt.Sprintf("invalid embedded CEP dataset: %v", err))
+		}
+		embeddedTable = table
+	})
+	return embeddedTable
+}
+
+// Lê uma tabela em CSV com o cabeçalho cep_start,cep_end,city,uf. Faixas
+// invertidas, sem cidade ou sobrepostas são rejeitadas
+func ParseTable(data string) (*Table, error) {
+	records, err := csv.NewReader(strings.NewReader(data)).ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("missing header")
+	}
+
+	ranges := make([]cepRange, 0, len(records)-1)
+	for i, record := range records[1:] {
+		start, startErr := strconv.Atoi(record[0])
+		end, endErr := strconv.Atoi(record[1])
+		if startErr != nil || endErr != nil || start > end || record[2] == "" || record[3] == "" {
+			return nil, fmt.Errorf("line %d: invalid range %v", i+2, record)
+		}
+		ranges = append(ranges, cepRange{start: start, end: end, city: record[2], uf: record[3]})
+	}
+
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i].start < ranges[j].start })
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].start <= ranges[i-1].end {
+			return nil, fmt.Errorf("range %08d-%08d overlaps %08d-%08d", ranges[i].start, ranges[i].end, ranges[i-1].start, ranges[i-1].end)
+		}
+	}
+	return &Table{ranges: ranges}, nil
+}
+
+// Quantidade de faixas da tabela
+func (t *Table) Len() int {
+	return len(t.ranges)
+}
+
+// Município de um CEP só com dígitos, sem bairro nem logradouro
+func (t *Table) Lookup(cep string) (*Address, bool) {
+	// Atoi aceitaria sinal (+1310100)
+	if !digits(cep) {
+		return nil, false
+	}
+	n, _ := strconv.Atoi(cep)
+
+	i := sort.Search(len(t.ranges), func(i int) bool { return t.ranges[i].end >= n })
+	if i == len(t.ranges) || t.ranges[i].start > n {
+		return nil, false
+	}
+	return &Address{CEP: format(cep), City: t.ranges[i].city, UF: t.ranges[i].uf}, true
+}
+
+// Município pelo nome, com ou sem acentos. O CEP é o início da primeira
+// faixa do município, um CEP aproximado para quem só conhece a cidade
+func (t *Table) FindCity(city, uf string) (*Address, bool) {
+	city = Fold(city)
+	for _, r := range t.ranges {
+		if r.uf == uf && Fold(r.city) == city {
+			return &Address{CEP: format(fmt.Sprintf("%08d", r.start)), City: r.city, UF: r.uf}, true
+		}
+	}
+	return nil, false
+}
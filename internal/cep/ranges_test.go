@@ -0,0 +1,75 @@
+package cep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A tabela embutida precisa estar sempre válida: um erro nela derruba o modo offline
+func TestEmbeddedTable(t *testing.T) {
+	table, err := ParseTable(rangesCSV)
+	assert.NoError(t, err)
+	assert.NotZero(t, table.Len())
+}
+
+func TestParseTable_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		expected string
+	}{
+		{"Reversed range", "cep_start,cep_end,city,uf\n02000000,01000000,São Paulo,SP\n", "line 2: invalid range"},
+		{"Missing city", "cep_start,cep_end,city,uf\n01000000,02000000,,SP\n", "line 2: invalid range"},
+		{"Overlap", "cep_start,cep_end,city,uf\n01000000,02000000,A,SP\n01500000,03000000,B,SP\n", "overlaps"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseTable(tt.data)
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+func TestTable_Lookup(t *testing.T) {
+	tests := []struct {
+		cep  string
+		city string
+		uf   string
+	}{
+		{"01310100", "São Paulo", "SP"},
+		{"01000000", "São Paulo", "SP"},
+		{"05999999", "São Paulo", "SP"},
+		{"20040002", "Rio de Janeiro", "RJ"},
+		{"73010000", "Brasília", "DF"},
+		{"90010000", "Porto Alegre", "RS"},
+		{"00000000", "", ""},
+		{"99999999", "", ""},
+		{"06500000", "", ""},
+		{"0131010", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cep, func(t *testing.T) {
+			address, ok := Embedded().Lookup(tt.cep)
+			assert.Equal(t, tt.city != "", ok)
+			if ok {
+				assert.Equal(t, &Address{CEP: tt.cep[:5] + "-" + tt.cep[5:], City: tt.city, UF: tt.uf}, address)
+			}
+		})
+	}
+}
+
+func TestTable_FindCity(t *testing.T) {
+	address, ok := Embedded().FindCity("Sao Paulo", "SP")
+	assert.True(t, ok)
+	assert.Equal(t, &Address{CEP: "01000-000", City: "São Paulo", UF: "SP"}, address)
+
+	address, ok = Embedded().FindCity("brasília", "DF")
+	assert.True(t, ok)
+	assert.Equal(t, &Address{CEP: "70000-000", City: "Brasília", UF: "DF"}, address)
+
+	_, ok = Embedded().FindCity("São Paulo", "RJ")
+	assert.False(t, ok)
+}
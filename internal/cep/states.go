@@ -0,0 +1,124 @@
+package cep
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Unidades federativas por sigla
+var states = map[string]string{
+	"AC": "Acre",
+	"AL": "Alagoas",
+	"AP": "Amapá",
+	"AM": "Amazonas",
+	"BA": "Bahia",
+	"CE": "Ceará",
+	"DF": "Distrito Federal",
+	"ES": "Espírito Santo",
+	"GO": "Goiás",
+	"MA": "Maranhão",
+	"MT": "Mato Grosso",
+	"MS": "Mato Grosso do Sul",
+	"MG": "Minas Gerais",
+	"PA": "Pará",
+	"PB": "Paraíba",
+	"PR": "Paraná",
+	"PE": "Pernambuco",
+	"PI": "Piauí",
+	"RJ": "Rio de Janeiro",
+	"RN": "Rio Grande do Norte",
+	"RS": "Rio Grande do Sul",
+	"RO": "Rondônia",
+	"RR": "Roraima",
+	"SC": "Santa Catarina",
+	"SP": "São Paulo",
+	"SE": "Sergipe",
+	"TO": "Tocantins",
+}
+
+// Faixas de CEP de cada UF pelos cinco primeiros dígitos, em ordem. O que
+// fica fora delas (00000 a 00999) não foi distribuído pelos Correios
+var stateRanges = []struct {
+	start, end int
+	uf         string
+}{
+	{1000, 19999, "SP"},
+	{20000, 28999, "RJ"},
+	{29000, 29999, "ES"},
+	{30000, 39999, "MG"},
+	{40000, 48999, "BA"},
+	{49000, 49999, "SE"},
+	{50000, 56999, "PE"},
+	{57000, 57999, "AL"},
+	{58000, 58999, "PB"},
+	{59000, 59999, "RN"},
+	{60000, 63999, "CE"},
+	{64000, 64999, "PI"},
+	{65000, 65999, "MA"},
+	{66000, 68899, "PA"},
+	{68900, 68999, "AP"},
+	{69000, 69299, "AM"},
+	{69300, 69399, "RR"},
+	{69400, 69899, "AM"},
+	{69900, 69999, "AC"},
+	{70000, 72799, "DF"},
+	{72800, 72999, "GO"},
+	{73000, 73699, "DF"},
+	{73700, 76799, "GO"},
+	{76800, 76999, "RO"},
+	{77000, 77999, "TO"},
+	{78000, 78899, "MT"},
+	{78900, 78999, "RO"},
+	{79000, 79999, "MS"},
+	{80000, 87999, "PR"},
+	{88000, 89999, "SC"},
+	{90000, 99999, "RS"},
+}
+
+// UF da faixa em que o CEP (só dígitos, ou com hífen) cai. Um CEP fora de
+// todas as faixas nem chega a existir
+func StateOf(cep string) (string, bool) {
+	cep = Normalize(cep)
+	if !digits(cep) {
+		return "", false
+	}
+	prefix, _ := strconv.Atoi(cep[:5])
+	i := sort.Search(len(stateRanges), func(i int) bool { return stateRanges[i].end >= prefix })
+	if i == len(stateRanges) || stateRanges[i].start > prefix {
+		return "", false
+	}
+	return stateRanges[i].uf, true
+}
+
+// Sigla de uma das 27 UFs, em maiúsculas ou minúsculas
+func ValidUF(uf string) bool {
+	_, ok := states[strings.ToUpper(uf)]
+	return ok
+}
+
+// Sigla da UF pelo nome do estado, com ou sem acentos ("Sao Paulo" vira SP)
+func StateUF(name string) (string, bool) {
+	name = Fold(name)
+	for uf, state := range states {
+		if Fold(state) == name {
+			return uf, true
+		}
+	}
+	return "", false
+}
+
+var accents = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ã", "a", "ä", "a",
+	"é", "e", "ê", "e", "è", "e", "ë", "e",
+	"í", "i", "î", "i", "ì", "i", "ï", "i",
+	"ó", "o", "ô", "o", "õ", "o", "ò", "o", "ö", "o",
+	"ú", "u", "û", "u", "ù", "u", "ü", "u",
+	"ç", "c", "ñ", "n",
+)
+
+// Nome sem acentos, em minúsculas e sem espaços nas pontas, para comparar
+// nomes de cidades e estados vindos de provedores diferentes
+func Fold(name string) string {
+	return accents.Replace(strings.ToLower(strings.TrimSpace(name)))
+}
@@ -0,0 +1,84 @@
+package cep
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateUF(t *testing.T) {
+	tests := []struct {
+		name string
+		uf   string
+	}{
+		{"São Paulo", "SP"},
+		{"Sao Paulo", "SP"},
+		{" rio grande do sul ", "RS"},
+		{"Distrito Federal", "DF"},
+		{"Espirito Santo", "ES"},
+		{"Buenos Aires", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			uf, ok := StateUF(tt.name)
+			assert.Equal(t, tt.uf != "", ok)
+			assert.Equal(t, tt.uf, uf)
+		})
+	}
+}
+
+func TestValidUF(t *testing.T) {
+	for _, uf := range []string{"SP", "rj", "Df", "TO"} {
+		assert.True(t, ValidUF(uf), uf)
+	}
+	for _, uf := range []string{"", "XX", "S", "SPP", "São Paulo"} {
+		assert.False(t, ValidUF(uf), uf)
+	}
+}
+
+func TestStateOf(t *testing.T) {
+	tests := []struct {
+		cep string
+		uf  string
+	}{
+		{"01310100", "SP"},
+		{"01000-000", "SP"},
+		{"20040020", "RJ"},
+		{"69301000", "RR"},
+		{"69900000", "AC"},
+		{"70040010", "DF"},
+		{"72800000", "GO"},
+		{"73000000", "DF"},
+		{"99999999", "RS"},
+		{"00000000", ""},
+		{"00999999", ""},
+		{"0131010", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.cep, func(t *testing.T) {
+			uf, ok := StateOf(tt.cep)
+			assert.Equal(t, tt.uf != "", ok)
+			assert.Equal(t, tt.uf, uf)
+		})
+	}
+}
+
+// As faixas das UFs estão em ordem, não se sobrepõem e são de UFs conhecidas
+func TestStateRanges(t *testing.T) {
+	for i, r := range stateRanges {
+		assert.True(t, ValidUF(r.uf), r.uf)
+		assert.LessOrEqual(t, r.start, r.end, r.uf)
+		if i > 0 {
+			assert.Equal(t, stateRanges[i-1].end+1, r.start, "gap or overlap before %s", r.uf)
+		}
+	}
+	// A tabela embutida só tem CEPs dentro das faixas da própria UF
+	for _, r := range Embedded().ranges {
+		uf, ok := StateOf(fmt.Sprintf("%08d", r.start))
+		assert.True(t, ok && uf == r.uf, "%s,%s starts at %08d", r.city, r.uf, r.start)
+	}
+}
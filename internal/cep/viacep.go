@@ -0,0 +1,119 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const DefaultViaCEPURL = "https://viacep.com.br/ws"
+
+// Cliente do ViaCEP
+type ViaCEP struct {
+	baseURL string
+	client  Doer
+}
+
+// Cliente do ViaCEP em baseURL (DefaultViaCEPURL em produção). Sem client,
+// usa o http.DefaultClient
+func NewViaCEP(baseURL string, client Doer) *ViaCEP {
+	return &ViaCEP{baseURL: baseURL, client: doerOrDefault(client)}
+}
+
+// URL da consulta de um CEP, usada também pela verificação de saúde
+func (v *ViaCEP) URL(cep string) string {
+	return fmt.Sprintf("%s/%s/json/", v.baseURL, cep)
+}
+
+type viaCEPBody struct {
+	Cep         string      `json:"cep"`
+	Logradouro  string      `json:"logradouro"`
+	Complemento string      `json:"complemento"`
+	Bairro      string      `json:"bairro"`
+	Localidade  string      `json:"localidade"`
+	UF          string      `json:"uf"`
+	IBGE        string      `json:"ibge"`
+	Erro        interface{} `json:"erro,omitempty"`
+}
+
+func (b viaCEPBody) address() *Address {
+	return &Address{
+		CEP:          b.Cep,
+		Street:       b.Logradouro,
+		Complement:   b.Complemento,
+		Neighborhood: b.Bairro,
+		City:         b.Localidade,
+		UF:           b.UF,
+		IBGE:         b.IBGE,
+	}
+}
+
+// Consulta um CEP só com dígitos. Retorna ErrNotFound quando o CEP não
+// existe e um erro com o status quando o ViaCEP falha (5xx)
+func (v *ViaCEP) Lookup(ctx context.Context, cep string) (*Address, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", v.URL(cep), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("viacep returned status %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, ErrNotFound
+	}
+
+	var body viaCEPBody
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+
+	// ViaCEP retorna um campo "erro": true quando o CEP não existe
+	// O campo pode ser bool ou string, então verificamos também se a localidade está vazia
+	if body.Erro != nil || body.Localidade == "" {
+		return nil, ErrNotFound
+	}
+	return body.address(), nil
+}
+
+// Busca CEPs pelo endereço (UF, cidade e parte do logradouro, com pelo menos
+// 3 letras). Retorna ErrNotFound quando nenhum endereço confere
+func (v *ViaCEP) Search(ctx context.Context, uf, city, street string) ([]*Address, error) {
+	endpoint := fmt.Sprintf("%s/%s/%s/%s/json/", v.baseURL, url.PathEscape(uf), url.PathEscape(city), url.PathEscape(street))
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("viacep returned status %d", resp.StatusCode)
+	}
+
+	var bodies []viaCEPBody
+	if err := json.NewDecoder(resp.Body).Decode(&bodies); err != nil {
+		return nil, err
+	}
+	if len(bodies) == 0 {
+		return nil, ErrNotFound
+	}
+
+	addresses := make([]*Address, len(bodies))
+	for i, body := range bodies {
+		addresses[i] = body.address()
+	}
+	return addresses, nil
+}
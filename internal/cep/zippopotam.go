@@ -0,0 +1,123 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const DefaultZippopotamURL = "https://api.zippopotam.us"
+
+// Lugar de um código postal de fora do Brasil
+type Place struct {
+	PostalCode string
+	Country    string
+	City       string
+	State      string
+	Lat        float64
+	Lon        float64
+}
+
+// Código de país ISO 3166-1 alfa-2 (duas letras ASCII), em maiúsculas ou minúsculas
+func ValidCountry(country string) bool {
+	if len(country) != 2 {
+		return false
+	}
+	for i := 0; i < len(country); i++ {
+		c := country[i] | 0x20
+		if c < 'a' || c > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// Código postal com até 10 letras, dígitos, espaços ou hífens, o suficiente
+// para os formatos dos países atendidos pelo Zippopotam.us
+func ValidPostalCode(code string) bool {
+	if code == "" || len(code) > 10 {
+		return false
+	}
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		if !(c >= '0' && c <= '9' || c|0x20 >= 'a' && c|0x20 <= 'z' || c == ' ' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// Cliente do Zippopotam.us, que resolve códigos postais de dezenas de países
+type Zippopotam struct {
+	baseURL string
+	client  Doer
+}
+
+// Cliente do Zippopotam.us em baseURL (DefaultZippopotamURL em produção). Sem
+// client, usa o http.DefaultClient
+func NewZippopotam(baseURL string, client Doer) *Zippopotam {
+	return &Zippopotam{baseURL: baseURL, client: doerOrDefault(client)}
+}
+
+// Resposta de /{country}/{code}. As coordenadas vêm como texto
+type zippopotamBody struct {
+	PostCode string `json:"post code"`
+	Country  string `json:"country abbreviation"`
+	Places   []struct {
+		Name      string `json:"place name"`
+		State     string `json:"state abbreviation"`
+		Latitude  string `json:"latitude"`
+		Longitude string `json:"longitude"`
+	} `json:"places"`
+}
+
+// Primeiro lugar do código postal no país. Retorna ErrNotFound quando o
+// código (ou o país) não é conhecido e um erro com o status quando o
+// Zippopotam.us falha
+func (z *Zippopotam) Lookup(ctx context.Context, country, code string) (*Place, error) {
+	endpoint := fmt.Sprintf("%s/%s/%s", z.baseURL, url.PathEscape(strings.ToLower(country)), url.PathEscape(code))
+	req, err := http.NewRequestWithContext(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := z.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return nil, fmt.Errorf("zippopotam returned status %d", resp.StatusCode)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, ErrNotFound
+	}
+
+	var body zippopotamBody
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, err
+	}
+	if len(body.Places) == 0 {
+		return nil, ErrNotFound
+	}
+
+	first := body.Places[0]
+	lat, latErr := strconv.ParseFloat(first.Latitude, 64)
+	lon, lonErr := strconv.ParseFloat(first.Longitude, 64)
+	if latErr != nil || lonErr != nil {
+		return nil, fmt.Errorf("zippopotam returned invalid coordinates %q,%q", first.Latitude, first.Longitude)
+	}
+	return &Place{
+		PostalCode: body.PostCode,
+		Country:    body.Country,
+		City:       first.Name,
+		State:      first.State,
+		Lat:        lat,
+		Lon:        lon,
+	}, nil
+}
@@ -0,0 +1,48 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidCountry(t *testing.T) {
+	for _, country := range []string{"US", "de", "Gb"} {
+		assert.True(t, ValidCountry(country), country)
+	}
+	for _, country := range []string{"", "U", "USA", "1A", "É"} {
+		assert.False(t, ValidCountry(country), country)
+	}
+}
+
+func TestValidPostalCode(t *testing.T) {
+	for _, code := range []string{"90210", "10115", "SW1A", "1010-001", "K1A 0B1"} {
+		assert.True(t, ValidPostalCode(code), code)
+	}
+	for _, code := range []string{"", "12345678901", "90210/x", "902.10"} {
+		assert.False(t, ValidPostalCode(code), code)
+	}
+}
+
+func TestZippopotam_Lookup(t *testing.T) {
+	server := fakeProvider(t, map[string]string{
+		"/us/90210": `{"post code":"90210","country":"United States","country abbreviation":"US","places":[{"place name":"Beverly Hills","longitude":"-118.4065","state":"California","state abbreviation":"CA","latitude":"34.0901"}]}`,
+		"/us/00000": `{"post code":"00000","places":[]}`,
+		"/de/10115": "",
+	})
+	client := NewZippopotam(server.URL, server.Client())
+
+	place, err := client.Lookup(context.Background(), "US", "90210")
+	assert.NoError(t, err)
+	assert.Equal(t, &Place{PostalCode: "90210", Country: "US", City: "Beverly Hills", State: "CA", Lat: 34.0901, Lon: -118.4065}, place)
+
+	for _, code := range []string{"00000", "99999"} {
+		_, err = client.Lookup(context.Background(), "us", code)
+		assert.True(t, errors.Is(err, ErrNotFound), code)
+	}
+
+	_, err = client.Lookup(context.Background(), "DE", "10115")
+	assert.EqualError(t, err, "zippopotam returned status 502")
+}
@@ -0,0 +1,200 @@
+// Package recorder grava respostas reais dos provedores em arquivos YAML
+// (cassetes) e as reproduz nos testes, no estilo do go-vcr.
+//
+// No modo Replay nenhuma requisição sai para a rede: cada uma é respondida
+// pela interação gravada com o mesmo método e URL. No modo Record as
+// requisições vão ao provedor e as respostas são gravadas no cassete ao
+// chamar Stop. Parâmetros sensíveis, como a chave da WeatherAPI, são
+// trocados por RedactedValue antes de gravar e de comparar
+package recorder
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+type Mode int
+
+const (
+	// Responde só com o que está no cassete
+	Replay Mode = iota
+	// Chama o provedor e grava as respostas no cassete
+	Record
+)
+
+// Variável de ambiente que liga o modo Record nos testes de contrato
+const RecordEnv = "RECORD_CASSETTES"
+
+// Record quando RECORD_CASSETTES=true, para regravar os cassetes contra os
+// provedores reais; Replay em qualquer outro caso
+func ModeFromEnv() Mode {
+	if record, _ := strconv.ParseBool(os.Getenv(RecordEnv)); record {
+		return Record
+	}
+	return Replay
+}
+
+// Valor gravado no lugar dos parâmetros sensíveis
+const RedactedValue = "REDACTED"
+
+// Parâmetros de query que nunca vão para o cassete
+var redactedParams = []string{"key"}
+
+// Nenhuma interação do cassete corresponde à requisição
+var ErrInteractionNotFound = errors.New("recorder: interaction not found")
+
+// Executa as requisições no modo Record. *http.Client atende a interface
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type Request struct {
+	Method string `yaml:"method"`
+	URL    string `yaml:"url"`
+}
+
+type Response struct {
+	Status      int    `yaml:"status"`
+	ContentType string `yaml:"content_type,omitempty"`
+	Body        string `yaml:"body"`
+}
+
+// Requisição e a resposta que o provedor deu a ela
+type Interaction struct {
+	Request  Request  `yaml:"request"`
+	Response Response `yaml:"response"`
+}
+
+type cassette struct {
+	Interactions []Interaction `yaml:"interactions"`
+}
+
+type Recorder struct {
+	path   string
+	mode   Mode
+	client Doer
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// Recorder do cassete em path. No modo Replay o arquivo precisa existir; no
+// modo Record ele é (re)escrito por Stop com as requisições feitas por
+// client (http.DefaultClient quando nil)
+func New(path string, mode Mode, client Doer) (*Recorder, error) {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	r := &Recorder{path: path, mode: mode, client: client}
+	if mode == Record {
+		return r, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("recorder: reading cassette: %w", err)
+	}
+	var c cassette
+	if err := yaml.Unmarshal(data, &c); err != nil {
+		return nil, fmt.Errorf("recorder: parsing cassette %s: %w", path, err)
+	}
+	r.interactions = c.Interactions
+	return r, nil
+}
+
+func (r *Recorder) Do(req *http.Request) (*http.Response, error) {
+	request := Request{Method: req.Method, URL: redact(req.URL)}
+	if r.mode == Replay {
+		return r.replay(req, request)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{
+		Request:  request,
+		Response: Response{Status: resp.StatusCode, ContentType: resp.Header.Get("Content-Type"), Body: string(body)},
+	})
+	r.mu.Unlock()
+
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// A mesma interação responde quantas vezes a requisição se repetir
+func (r *Recorder) replay(req *http.Request, request Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for _, interaction := range r.interactions {
+		if interaction.Request != request {
+			continue
+		}
+		header := http.Header{}
+		if interaction.Response.ContentType != "" {
+			header.Set("Content-Type", interaction.Response.ContentType)
+		}
+		return &http.Response{
+			Status:        fmt.Sprintf("%d %s", interaction.Response.Status, http.StatusText(interaction.Response.Status)),
+			StatusCode:    interaction.Response.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        header,
+			Body:          io.NopCloser(bytes.NewBufferString(interaction.Response.Body)),
+			ContentLength: int64(len(interaction.Response.Body)),
+			Request:       req,
+		}, nil
+	}
+	return nil, fmt.Errorf("%w: %s %s in %s", ErrInteractionNotFound, request.Method, request.URL, r.path)
+}
+
+// Grava o cassete no modo Record. No modo Replay não faz nada
+func (r *Recorder) Stop() error {
+	if r.mode != Record {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := yaml.Marshal(cassette{Interactions: r.interactions})
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, data, 0o644)
+}
+
+// URL com os parâmetros sensíveis trocados por RedactedValue e a query em
+// ordem alfabética, para que a comparação não dependa da ordem
+func redact(u *url.URL) string {
+	redacted := *u
+	query := redacted.Query()
+	for _, name := range redactedParams {
+		if query.Has(name) {
+			query.Set(name, RedactedValue)
+		}
+	}
+	redacted.RawQuery = query.Encode()
+	return redacted.String()
+}
@@ -0,0 +1,89 @@
+package recorder
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func get(t *testing.T, client Doer, url string) (*http.Response, string) {
+	req, err := http.NewRequestWithContext(context.Background(), "GET", url, nil)
+	assert.NoError(t, err)
+	resp, err := client.Do(req)
+	if !assert.NoError(t, err) {
+		t.FailNow()
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	return resp, string(body)
+}
+
+func TestRecorder_RecordAndReplay(t *testing.T) {
+	var calls int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"q":"` + r.URL.Query().Get("q") + `"}`))
+	}))
+	defer server.Close()
+	path := filepath.Join(t.TempDir(), "cassettes", "upstream.yaml")
+
+	rec, err := New(path, Record, nil)
+	assert.NoError(t, err)
+	resp, body := get(t, rec, server.URL+"/current.json?q=Recife&key=secret")
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, `{"q":"Recife"}`, body, "the caller still reads the recorded body")
+	assert.NoError(t, rec.Stop())
+
+	data, err := os.ReadFile(path)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "secret")
+	assert.Contains(t, string(data), "key="+RedactedValue)
+
+	// Sem o servidor, a resposta vem do cassete, com qualquer chave e em
+	// qualquer ordem de parâmetros
+	server.Close()
+	rec, err = New(path, Replay, nil)
+	assert.NoError(t, err)
+	for i := 0; i < 2; i++ {
+		resp, body = get(t, rec, server.URL+"/current.json?key=other&q=Recife")
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+		assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+		assert.Equal(t, `{"q":"Recife"}`, body)
+	}
+	assert.Equal(t, 1, calls)
+}
+
+func TestRecorder_ReplayUnknownRequest(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "empty.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte("interactions: []\n"), 0o644))
+
+	rec, err := New(path, Replay, nil)
+	assert.NoError(t, err)
+	req, _ := http.NewRequest("GET", "https://viacep.com.br/ws/01001000/json/", nil)
+	_, err = rec.Do(req)
+	assert.True(t, errors.Is(err, ErrInteractionNotFound))
+	assert.True(t, strings.Contains(err.Error(), "01001000"))
+}
+
+func TestRecorder_MissingCassette(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "missing.yaml"), Replay, nil)
+	assert.Error(t, err)
+}
+
+func TestModeFromEnv(t *testing.T) {
+	t.Setenv(RecordEnv, "")
+	assert.Equal(t, Replay, ModeFromEnv())
+	t.Setenv(RecordEnv, "true")
+	assert.Equal(t, Record, ModeFromEnv())
+}
@@ -0,0 +1,158 @@
+// Package schedule interpreta expressões no formato do cron e calcula o
+// próximo horário em que elas disparam.
+//
+// São aceitos os cinco campos do cron (minuto, hora, dia do mês, mês e dia
+// da semana, 0 sendo domingo), com *, listas (1,15), faixas (1-5) e passos
+// (*/10, 8-18/2), e os atalhos @hourly, @daily, @weekly, @monthly e
+// @every <duração> (ex.: @every 90s). Como no cron, quando o dia do mês e o
+// dia da semana são restritos, basta um dos dois coincidir
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Schedule interface {
+	// Primeiro horário estritamente depois de t
+	Next(t time.Time) time.Time
+}
+
+var descriptors = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+}
+
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 6},
+}
+
+func Parse(expr string) (Schedule, error) {
+	expr = strings.TrimSpace(expr)
+	if rest, ok := strings.CutPrefix(expr, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(rest))
+		if err != nil || every < time.Second {
+			return nil, fmt.Errorf("invalid @every duration %q", rest)
+		}
+		return interval(every), nil
+	}
+	if spec, ok := descriptors[expr]; ok {
+		expr = spec
+	}
+
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("expected 5 fields, got %d", len(parts))
+	}
+	var c cron
+	sets := []*uint64{&c.minutes, &c.hours, &c.days, &c.months, &c.weekdays}
+	for i, part := range parts {
+		set, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, err
+		}
+		*sets[i] = set
+	}
+	c.anyDay, c.anyWeekday = parts[2] == "*", parts[4] == "*"
+	return c, nil
+}
+
+// Conjunto de valores do campo como bits (bit n = valor n)
+func parseField(part string, f field) (uint64, error) {
+	var set uint64
+	for _, item := range strings.Split(part, ",") {
+		rangePart, step := item, 1
+		if before, after, ok := strings.Cut(item, "/"); ok {
+			n, err := strconv.Atoi(after)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step %q in %s", after, f.name)
+			}
+			rangePart, step = before, n
+		}
+
+		low, high := f.min, f.max
+		if rangePart != "*" {
+			before, after, isRange := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = strconv.Atoi(before); err != nil {
+				return 0, fmt.Errorf("invalid value %q in %s", before, f.name)
+			}
+			high = low
+			if isRange {
+				if high, err = strconv.Atoi(after); err != nil {
+					return 0, fmt.Errorf("invalid value %q in %s", after, f.name)
+				}
+			} else if step > 1 {
+				// 5/15 é o mesmo que 5-max/15
+				high = f.max
+			}
+		}
+		if low < f.min || high > f.max || low > high {
+			return 0, fmt.Errorf("%s must be between %d and %d, got %q", f.name, f.min, f.max, item)
+		}
+		for v := low; v <= high; v += step {
+			set |= 1 << uint(v)
+		}
+	}
+	return set, nil
+}
+
+type interval time.Duration
+
+// Múltiplos de d contados a partir do horário zero, para que instâncias
+// diferentes disparem juntas
+func (d interval) Next(t time.Time) time.Time {
+	return t.Truncate(time.Duration(d)).Add(time.Duration(d))
+}
+
+type cron struct {
+	minutes, hours, days, months, weekdays uint64
+	anyDay, anyWeekday                     bool
+}
+
+func (c cron) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// Uma expressão válida dispara ao menos uma vez a cada 4 anos (29/02)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case !has(c.months, int(t.Month())):
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !c.dayMatches(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case !has(c.hours, t.Hour()):
+			t = t.Truncate(time.Hour).Add(time.Hour)
+		case !has(c.minutes, t.Minute()):
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (c cron) dayMatches(t time.Time) bool {
+	day, weekday := has(c.days, t.Day()), has(c.weekdays, int(t.Weekday()))
+	if c.anyDay || c.anyWeekday {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+func has(set uint64, v int) bool {
+	return set&(1<<uint(v)) != 0
+}
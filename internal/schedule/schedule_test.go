@@ -0,0 +1,73 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func at(value string) time.Time {
+	t, err := time.Parse("2006-01-02 15:04:05", value)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+func TestNext(t *testing.T) {
+	tests := []struct {
+		expr     string
+		from     string
+		expected string
+	}{
+		{"* * * * *", "2024-03-10 12:00:30", "2024-03-10 12:01:00"},
+		{"*/15 * * * *", "2024-03-10 12:00:00", "2024-03-10 12:15:00"},
+		{"*/15 * * * *", "2024-03-10 12:50:00", "2024-03-10 13:00:00"},
+		{"5/20 * * * *", "2024-03-10 12:30:00", "2024-03-10 12:45:00"},
+		{"0 6-18/6 * * *", "2024-03-10 12:00:00", "2024-03-10 18:00:00"},
+		{"0 6-18/6 * * *", "2024-03-10 18:00:00", "2024-03-11 06:00:00"},
+		{"30 8 * * 1-5", "2024-03-08 09:00:00", "2024-03-11 08:30:00"}, // sexta -> segunda
+		{"0 0 1,15 * *", "2024-03-02 00:00:00", "2024-03-15 00:00:00"},
+		{"0 0 31 * *", "2024-04-01 00:00:00", "2024-05-31 00:00:00"},
+		{"0 0 29 2 *", "2024-03-01 00:00:00", "2028-02-29 00:00:00"},
+		// Dia do mês e dia da semana restritos: basta um coincidir
+		{"0 0 13 * 5", "2024-03-10 00:00:00", "2024-03-13 00:00:00"},
+		{"0 0 13 * 5", "2024-03-13 00:00:00", "2024-03-15 00:00:00"},
+		{"@hourly", "2024-03-10 12:34:00", "2024-03-10 13:00:00"},
+		{"@daily", "2024-03-10 12:34:00", "2024-03-11 00:00:00"},
+		{"@every 90s", "2024-03-10 12:00:00", "2024-03-10 12:01:30"},
+		{"@every 90s", "2024-03-10 12:01:31", "2024-03-10 12:03:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr+" from "+tt.from, func(t *testing.T) {
+			schedule, err := Parse(tt.expr)
+			assert.NoError(t, err)
+			assert.Equal(t, at(tt.expected), schedule.Next(at(tt.from)))
+		})
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := []struct {
+		expr     string
+		expected string
+	}{
+		{"* * * *", "expected 5 fields, got 4"},
+		{"60 * * * *", `minute must be between 0 and 59, got "60"`},
+		{"* 5-2 * * *", `hour must be between 0 and 23, got "5-2"`},
+		{"* * 0 * *", `day of month must be between 1 and 31, got "0"`},
+		{"*/0 * * * *", `invalid step "0" in minute`},
+		{"* * * jan *", `invalid value "jan" in month`},
+		{"@every 10ms", `invalid @every duration "10ms"`},
+		{"@yearly", "expected 5 fields, got 1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := Parse(tt.expr)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	bolt "go.etcd.io/bbolt"
+
+	"github.com/weather-service/config"
+	"github.com/weather-service/internal/cache"
+)
+
+const defaultCEPCacheTTL = 30 * 24 * time.Hour
+
+var addressBucket = []byte("addresses")
+
+// Endereços do ViaCEP já resolvidos, indexados pelo CEP (só dígitos). Um CEP
+// raramente muda de cidade, então as entradas valem por CEP_CACHE_TTL
+type addressCache interface {
+	get(cep string) (cachedAddress, bool)
+	set(cep string, address *ViaCEPResponse) error
+	// Descarta todas as entradas e retorna quantas havia
+	clear() (int, error)
+	count() (int, error)
+	// Até limit CEPs em cache, em ordem
+	keys(limit int) ([]cache.Key, error)
+	Close() error
+}
+
+type cachedAddress struct {
+	Address  ViaCEPResponse `json:"address"`
+	StoredAt time.Time      `json:"stored_at"`
+}
+
+// Uso do cache de endereços, para /metrics e /admin/cache
+var addressCacheCounters cache.Counters
+
+// Abre o cache em CEP_CACHE_PATH, ou retorna nil quando não está configurado
+func addressCacheFromEnv() (addressCache, error) {
+	path := config.String("CEP_CACHE_PATH")
+	if path == "" {
+		return nil, nil
+	}
+	return openBoltAddressCache(path)
+}
+
+// Tempo em que um endereço em cache dispensa a consulta ao ViaCEP (CEP_CACHE_TTL)
+func cepCacheTTL() time.Duration {
+	return config.Duration("CEP_CACHE_TTL", defaultCEPCacheTTL)
+}
+
+func (c cachedAddress) fresh(now time.Time) bool {
+	return now.Sub(c.StoredAt) < cepCacheTTL()
+}
+
+// Arquivo Bolt local, que sobrevive a reinícios sem exigir um Redis. O
+// arquivo fica travado enquanto aberto, então serve a uma instância só
+type boltAddressCache struct {
+	db  *bolt.DB
+	now func() time.Time
+}
+
+func openBoltAddressCache(path string) (*boltAddressCache, error) {
+	// Sem o timeout, uma segunda instância apontando para o mesmo arquivo
+	// ficaria parada esperando o lock
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open CEP cache %s: %w", path, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		_, err := tx.CreateBucketIfNotExists(addressBucket)
+		return err
+	})
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to initialize CEP cache %s: %w", path, err)
+	}
+	return &boltAddressCache{db: db, now: time.Now}, nil
+}
+
+func (c *boltAddressCache) get(cep string) (cachedAddress, bool) {
+	var entry cachedAddress
+	var found bool
+	err := c.db.View(func(tx *bolt.Tx) error {
+		value := tx.Bucket(addressBucket).Get([]byte(cep))
+		if value == nil {
+			return nil
+		}
+		found = true
+		return json.Unmarshal(value, &entry)
+	})
+	if err != nil {
+		log.Printf("WARNING: Ignoring unreadable CEP cache entry for %s: %v", cep, err)
+		return cachedAddress{}, false
+	}
+	return entry, found
+}
+
+func (c *boltAddressCache) set(cep string, address *ViaCEPResponse) error {
+	value, err := json.Marshal(cachedAddress{Address: *address, StoredAt: c.now()})
+	if err != nil {
+		return err
+	}
+	return c.db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(addressBucket).Put([]byte(cep), value)
+	})
+}
+
+func (c *boltAddressCache) clear() (int, error) {
+	var count int
+	err := c.db.Update(func(tx *bolt.Tx) error {
+		count = tx.Bucket(addressBucket).Stats().KeyN
+		if err := tx.DeleteBucket(addressBucket); err != nil {
+			return err
+		}
+		_, err := tx.CreateBucket(addressBucket)
+		return err
+	})
+	return count, err
+}
+
+func (c *boltAddressCache) count() (int, error) {
+	var count int
+	err := c.db.View(func(tx *bolt.Tx) error {
+		count = tx.Bucket(addressBucket).Stats().KeyN
+		return nil
+	})
+	return count, err
+}
+
+func (c *boltAddressCache) keys(limit int) ([]cache.Key, error) {
+	var keys []cache.Key
+	err := c.db.View(func(tx *bolt.Tx) error {
+		cursor := tx.Bucket(addressBucket).Cursor()
+		for key, value := cursor.First(); key != nil && (limit <= 0 || len(keys) < limit); key, value = cursor.Next() {
+			var entry cachedAddress
+			if err := json.Unmarshal(value, &entry); err != nil {
+				continue
+			}
+			keys = append(keys, cache.Key{Key: string(key), StoredAt: entry.StoredAt, Size: len(value)})
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (c *boltAddressCache) Close() error {
+	return c.db.Close()
+}
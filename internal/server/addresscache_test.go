@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/internal/cep"
+)
+
+// Usa um cache de endereços Bolt temporário em s
+func withAddressCache(t *testing.T, s *Server) *boltAddressCache {
+	t.Helper()
+	cache, err := openBoltAddressCache(filepath.Join(t.TempDir(), "cep-cache.db"))
+	assert.NoError(t, err)
+	t.Cleanup(func() { cache.Close() })
+
+	s.addresses = cache
+	return cache
+}
+
+func TestBoltAddressCache_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cep-cache.db")
+	cache, err := openBoltAddressCache(path)
+	assert.NoError(t, err)
+	assert.NoError(t, cache.set("01310100", &ViaCEPResponse{Cep: "01310-100", Localidade: "São Paulo", UF: "SP"}))
+	assert.NoError(t, cache.Close())
+
+	cache, err = openBoltAddressCache(path)
+	assert.NoError(t, err)
+	defer cache.Close()
+
+	entry, ok := cache.get("01310100")
+	assert.True(t, ok)
+	assert.Equal(t, "São Paulo", entry.Address.Localidade)
+	_, ok = cache.get("20040002")
+	assert.False(t, ok)
+
+	count, err := cache.clear()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	_, ok = cache.get("01310100")
+	assert.False(t, ok)
+}
+
+// O arquivo fica travado pela instância que o abriu
+func TestBoltAddressCache_Locked(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cep-cache.db")
+	cache, err := openBoltAddressCache(path)
+	assert.NoError(t, err)
+	defer cache.Close()
+
+	_, err = openBoltAddressCache(path)
+	assert.ErrorContains(t, err, "failed to open CEP cache")
+}
+
+func TestGetAddressByCEP_Cached(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{"/ws/01310100/json/": viaCEPSaoPaulo})
+	cache := withAddressCache(t, s)
+
+	address, err := s.getAddressByCEP(context.Background(), "01310-100")
+	assert.NoError(t, err)
+	assert.Equal(t, "São Paulo", address.Localidade)
+
+	// Com o endereço em cache, o ViaCEP não é mais consultado
+	s.viaCEP = cep.NewViaCEP("http://127.0.0.1:1", nil)
+	address, err = s.getAddressByCEP(context.Background(), "01310100")
+	assert.NoError(t, err)
+	assert.Equal(t, "São Paulo,SP", address.location())
+
+	// Vencido o TTL, a entrada só é usada com o circuit breaker aberto
+	t.Setenv("CIRCUIT_BREAKER_THRESHOLD", "1")
+	t.Setenv("CEP_FALLBACK", "false")
+	cache.now = func() time.Time { return time.Now().Add(-31 * 24 * time.Hour) }
+	assert.NoError(t, cache.set("01310100", address))
+	_, err = s.getAddressByCEP(context.Background(), "01310100")
+	assert.Error(t, err)
+
+	address, err = s.getAddressByCEP(context.Background(), "01310100")
+	assert.NoError(t, err)
+	assert.Equal(t, "São Paulo", address.Localidade)
+}
+
+func TestCacheFlushHandler_Addresses(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+	cache := withAddressCache(t, s)
+	assert.NoError(t, cache.set("01310100", &ViaCEPResponse{Localidade: "São Paulo", UF: "SP"}))
+	s.weatherCache.Set("current.json?q=a", []byte(`{}`))
+
+	rr := adminRequest(t, s.router(), "POST", "/admin/cache/flush")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"flushed":2}`, rr.Body.String())
+	_, ok := cache.get("01310100")
+	assert.False(t, ok)
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/weather-service/config"
+)
+
+type CacheFlushResponse struct {
+	Flushed int `json:"flushed" xml:"flushed"`
+}
+
+type ConfigResponse struct {
+	Settings []ConfigSetting `json:"settings" xml:"setting"`
+}
+
+// Valores secretos aparecem como [redacted]
+type ConfigSetting struct {
+	Name   string `json:"name" xml:"name"`
+	Value  string `json:"value" xml:"value"`
+	Source string `json:"source" xml:"source"`
+}
+
+type ProvidersResponse struct {
+	Providers []ProviderState `json:"providers" xml:"provider"`
+}
+
+type ProviderState struct {
+	Name                string `json:"name" xml:"name"`
+	CircuitBreaker      string `json:"circuit_breaker" xml:"circuit_breaker"`
+	ConsecutiveFailures int    `json:"consecutive_failures" xml:"consecutive_failures"`
+}
+
+// POST /admin/cache/flush: descarta as respostas da WeatherAPI, os endereços
+// e os CEPs inexistentes em cache e o resultado do último health check profundo
+func (s *Server) cacheFlushHandler(w http.ResponseWriter, r *http.Request) {
+	flushed := s.weatherCache.Clear() + s.notFoundCEPs.Clear()
+	if s.addresses != nil {
+		count, err := s.addresses.clear()
+		if err != nil {
+			log.Printf("ERROR: Failed to flush CEP cache: %v", err)
+			writeResponse(w, r, http.StatusInternalServerError, ErrorResponse{Message: "internal server error"})
+			return
+		}
+		addressCacheCounters.Evicted(count)
+		flushed += count
+	}
+	s.deepHealth.clear()
+	writeResponse(w, r, http.StatusOK, CacheFlushResponse{Flushed: flushed})
+}
+
+// GET /admin/config: configuração efetiva, vinda do ambiente ou do arquivo
+func configHandler(w http.ResponseWriter, r *http.Request) {
+	response := ConfigResponse{Settings: []ConfigSetting{}}
+	for _, value := range config.Effective() {
+		response.Settings = append(response.Settings, ConfigSetting{Name: value.Name, Value: value.Value, Source: value.Source})
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// GET /admin/providers: estado do circuit breaker de cada provedor
+func (s *Server) providersHandler(w http.ResponseWriter, r *http.Request) {
+	var response ProvidersResponse
+	for _, p := range s.activeProviders() {
+		state, failures := p.breaker.state()
+		response.Providers = append(response.Providers, ProviderState{Name: p.name, CircuitBreaker: state, ConsecutiveFailures: failures})
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
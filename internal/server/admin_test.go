@@ -0,0 +1,88 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func adminRequest(t *testing.T, router http.Handler, method, target string) *httptest.ResponseRecorder {
+	req, err := http.NewRequest(method, target, nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-API-Key", "admin-secret")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	return rr
+}
+
+func TestCacheFlushHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+	s.weatherCache.Set("current.json?q=a", []byte(`{}`))
+	s.weatherCache.Set("current.json?q=b", []byte(`{}`))
+
+	router := s.router()
+	rr := adminRequest(t, router, "POST", "/admin/cache/flush")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"flushed":2}`, rr.Body.String())
+
+	_, cached := s.weatherCache.Get("current.json?q=a")
+	assert.False(t, cached)
+
+	rr = adminRequest(t, router, "POST", "/admin/cache/flush")
+	assert.JSONEq(t, `{"flushed":0}`, rr.Body.String())
+}
+
+func TestConfigHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+	t.Setenv("WEATHER_API_KEY", "upstream-secret")
+	withConfigFile(t, "weather_cache_ttl: 5m\n", "WEATHER_CACHE_TTL")
+
+	rr := adminRequest(t, s.router(), "GET", "/admin/config")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "upstream-secret")
+	assert.NotContains(t, rr.Body.String(), "admin-secret")
+
+	var response ConfigResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	settings := map[string]ConfigSetting{}
+	for _, setting := range response.Settings {
+		settings[setting.Name] = setting
+	}
+	assert.Equal(t, ConfigSetting{Name: "WEATHER_CACHE_TTL", Value: "5m", Source: "file"}, settings["WEATHER_CACHE_TTL"])
+	assert.Equal(t, ConfigSetting{Name: "WEATHER_API_KEY", Value: "[redacted]", Source: "env"}, settings["WEATHER_API_KEY"])
+	assert.Equal(t, "[redacted]", settings["ADMIN_API_KEY"].Value)
+}
+
+func TestProvidersHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+	t.Setenv("CIRCUIT_BREAKER_THRESHOLD", "1")
+	weatherAPIProvider.breaker.record(false)
+
+	rr := adminRequest(t, s.router(), "GET", "/admin/providers")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"providers":[
+		{"name":"viacep","circuit_breaker":"closed","consecutive_failures":0},
+		{"name":"weather_api","circuit_breaker":"open","consecutive_failures":1}
+	]}`, rr.Body.String())
+}
+
+func TestAdminHandlers_XML(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+
+	req, err := http.NewRequest("GET", "/admin/providers", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-API-Key", "admin-secret")
+	req.Header.Set("Accept", "application/xml")
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), "<providers><provider><name>viacep</name>")
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// Regras de alerta (inscrições dos webhooks) gravadas no banco do registro
+// das consultas, para sobreviver a restarts e, no PostgreSQL, serem vistas
+// por todas as réplicas
+type sqlSubscriptionStore struct {
+	db      *sql.DB
+	dialect *sqlDialect
+}
+
+func newSQLSubscriptionStore(store *sqlLookupStore) *sqlSubscriptionStore {
+	return &sqlSubscriptionStore{db: store.db, dialect: store.dialect}
+}
+
+const subscriptionColumns = "id, cep, metric, operator, threshold, channel, callback_url, email, status, created_at, last_fired_at, owner"
+
+// O limite é conferido antes do INSERT, sem trava: réplicas criando regras ao
+// mesmo tempo podem passar dele por poucas unidades
+func (s *sqlSubscriptionStore) create(ctx context.Context, subscription Subscription) error {
+	var count int
+	if err := s.db.QueryRowContext(ctx, "SELECT COUNT(*) FROM alert_rules").Scan(&count); err != nil {
+		return err
+	}
+	if count >= webhookMaxSubscriptions() {
+		return errSubscriptionLimit
+	}
+
+	_, err := s.db.ExecContext(ctx,
+		s.dialect.bind("INSERT INTO alert_rules ("+subscriptionColumns+") VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, NULL, ?)"),
+		subscription.ID, subscription.CEP, subscription.Metric, subscription.Operator, subscription.Threshold,
+		subscription.Channel, subscription.CallbackURL, subscription.Email, subscription.Status, s.dialect.timestamp(subscription.CreatedAt), subscription.Owner)
+	return err
+}
+
+func (s *sqlSubscriptionStore) list(ctx context.Context) ([]Subscription, error) {
+	subscriptions := []Subscription{}
+	rows, err := s.db.QueryContext(ctx, "SELECT "+subscriptionColumns+" FROM alert_rules ORDER BY created_at, id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		subscription, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subscriptions = append(subscriptions, subscription)
+	}
+	return subscriptions, rows.Err()
+}
+
+func (s *sqlSubscriptionStore) get(ctx context.Context, id string) (Subscription, bool, error) {
+	row := s.db.QueryRowContext(ctx, s.dialect.bind("SELECT "+subscriptionColumns+" FROM alert_rules WHERE id = ?"), id)
+	subscription, err := scanSubscription(row)
+	if err == sql.ErrNoRows {
+		return Subscription{}, false, nil
+	}
+	return subscription, err == nil, err
+}
+
+func (s *sqlSubscriptionStore) delete(ctx context.Context, id string) (bool, error) {
+	result, err := s.db.ExecContext(ctx, s.dialect.bind("DELETE FROM alert_rules WHERE id = ?"), id)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+func (s *sqlSubscriptionStore) setStatus(ctx context.Context, id, status string, firedAt time.Time) error {
+	if firedAt.IsZero() {
+		_, err := s.db.ExecContext(ctx, s.dialect.bind("UPDATE alert_rules SET status = ? WHERE id = ?"), status, id)
+		return err
+	}
+	_, err := s.db.ExecContext(ctx, s.dialect.bind("UPDATE alert_rules SET status = ?, last_fired_at = ? WHERE id = ?"),
+		status, s.dialect.timestamp(firedAt), id)
+	return err
+}
+
+// *sql.Row ou *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanSubscription(row rowScanner) (Subscription, error) {
+	var subscription Subscription
+	var createdAt string
+	var lastFiredAt sql.NullString
+	err := row.Scan(&subscription.ID, &subscription.CEP, &subscription.Metric, &subscription.Operator, &subscription.Threshold,
+		&subscription.Channel, &subscription.CallbackURL, &subscription.Email, &subscription.Status, &createdAt, &lastFiredAt, &subscription.Owner)
+	if err != nil {
+		return subscription, err
+	}
+
+	if subscription.CreatedAt, err = time.Parse(time.RFC3339Nano, createdAt); err != nil {
+		return subscription, fmt.Errorf("invalid created_at %q: %w", createdAt, err)
+	}
+	if lastFiredAt.Valid {
+		firedAt, err := time.Parse(time.RFC3339Nano, lastFiredAt.String)
+		if err != nil {
+			return subscription, fmt.Errorf("invalid last_fired_at %q: %w", lastFiredAt.String, err)
+		}
+		subscription.LastFiredAt = &firedAt
+	}
+	return subscription, nil
+}
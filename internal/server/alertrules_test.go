@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Guarda as regras de s em um SQLite temporário
+func withSQLiteSubscriptions(t *testing.T, s *Server) *sqlSubscriptionStore {
+	t.Helper()
+	lookupStore, err := openSQLiteLookupStore(filepath.Join(t.TempDir(), "lookups.db"))
+	assert.NoError(t, err)
+	t.Cleanup(func() { lookupStore.Close() })
+
+	store := newSQLSubscriptionStore(lookupStore)
+	s.subscriptions = store
+	return store
+}
+
+func TestSQLSubscriptionStore(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	store := withSQLiteSubscriptions(t, s)
+	ctx := context.Background()
+	createdAt := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+
+	first := Subscription{ID: "first", CEP: "01310100", Metric: "temp_C", Operator: ">", Threshold: 35.5, Channel: alertChannelWebhook, CallbackURL: "https://example.com/hook", Status: alertStatusPending, CreatedAt: createdAt, Owner: "sub:user-1"}
+	second := Subscription{ID: "second", CEP: "20040002", Metric: "temp_F", Operator: "<=", Threshold: 50, Channel: alertChannelSlack, CallbackURL: "https://hooks.slack.com/services/T0/B0/x", Status: alertStatusPending, CreatedAt: createdAt.Add(time.Minute)}
+	assert.NoError(t, store.create(ctx, second))
+	assert.NoError(t, store.create(ctx, first))
+
+	list, err := store.list(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []Subscription{first, second}, list)
+
+	firedAt := createdAt.Add(time.Hour)
+	assert.NoError(t, store.setStatus(ctx, "first", alertStatusFiring, firedAt))
+	assert.NoError(t, store.setStatus(ctx, "first", alertStatusOK, time.Time{}))
+	got, ok, err := store.get(ctx, "first")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, alertStatusOK, got.Status)
+	if assert.NotNil(t, got.LastFiredAt, "the last firing is kept") {
+		assert.True(t, firedAt.Equal(*got.LastFiredAt))
+	}
+
+	deleted, err := store.delete(ctx, "first")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+	deleted, err = store.delete(ctx, "first")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+	_, ok, err = store.get(ctx, "first")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	t.Setenv("WEBHOOK_MAX_SUBSCRIPTIONS", "1")
+	assert.ErrorIs(t, store.create(ctx, first), errSubscriptionLimit)
+}
+
+// As regras criadas pela API ficam no banco, com a situação e o último
+// disparo atualizados pelo verificador
+func TestAlertRules_Persisted(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, fakeWeatherClient{"São Paulo,SP": 36})
+	store := withSQLiteSubscriptions(t, s)
+	receiver := newWebhookReceiver(t)
+
+	rr := requestSubscriptions(t, s, "POST", "/alert-rules", `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"callback_url":"`+receiver.URL+`"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var created Subscription
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&created))
+	assert.Equal(t, alertStatusPending, created.Status)
+	assert.Nil(t, created.LastFiredAt)
+
+	s.pollSubscriptions(context.Background())
+	assert.Len(t, receiver.received(), 1)
+
+	// Uma nova instância do store lê o que foi gravado
+	reopened := &sqlSubscriptionStore{db: store.db, dialect: store.dialect}
+	stored, ok, err := reopened.get(context.Background(), created.ID)
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, alertStatusFiring, stored.Status)
+	if assert.NotNil(t, stored.LastFiredAt) {
+		assert.True(t, receiver.received()[0].FiredAt.Equal(*stored.LastFiredAt))
+	}
+
+	rr = requestSubscriptions(t, s, "GET", "/alert-rules/"+created.ID, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"status":"firing"`)
+	assert.Contains(t, rr.Body.String(), `"last_fired_at":`)
+}
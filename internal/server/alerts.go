@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+)
+
+type AlertsResponse struct {
+	Alerts []Alert `json:"alerts" xml:"alert"`
+}
+
+type Alert struct {
+	Headline    string `json:"headline" xml:"headline"`
+	Event       string `json:"event" xml:"event"`
+	Severity    string `json:"severity" xml:"severity"`
+	Urgency     string `json:"urgency" xml:"urgency"`
+	Areas       string `json:"areas" xml:"areas"`
+	Effective   string `json:"effective" xml:"effective"`
+	Expires     string `json:"expires" xml:"expires"`
+	Description string `json:"description" xml:"description"`
+	Instruction string `json:"instruction" xml:"instruction"`
+}
+
+type WeatherAPIAlertsResponse struct {
+	Alerts struct {
+		Alert []struct {
+			Headline    string `json:"headline"`
+			Event       string `json:"event"`
+			Severity    string `json:"severity"`
+			Urgency     string `json:"urgency"`
+			Areas       string `json:"areas"`
+			Effective   string `json:"effective"`
+			Expires     string `json:"expires"`
+			Desc        string `json:"desc"`
+			Instruction string `json:"instruction"`
+		} `json:"alert"`
+	} `json:"alerts"`
+}
+
+func (s *Server) alertsHandler(w http.ResponseWriter, r *http.Request) {
+	cep := cepParam(r)
+	location, ok := s.resolveLocation(w, r, cep)
+	if !ok {
+		return
+	}
+
+	alerts, err := s.getAlerts(r.Context(), location)
+	if err != nil {
+		log.Printf("ERROR: Failed to get alerts for location '%s': %v", location, err)
+		status, body := httpError(err)
+		writeResponse(w, r, status, body)
+		return
+	}
+
+	log.Printf("Successfully processed alerts for CEP %s: %d active", cep, len(alerts.Alerts))
+
+	writeResponse(w, r, http.StatusOK, alerts)
+}
+
+func (s *Server) getAlerts(ctx context.Context, location string) (*AlertsResponse, error) {
+	log.Printf("Fetching alerts for location: %s", location)
+
+	var apiAlerts WeatherAPIAlertsResponse
+	params := url.Values{"q": {location}}
+	if err := s.callWeatherAPI(ctx, "alerts.json", params, &apiAlerts); err != nil {
+		return nil, err
+	}
+
+	// Lista vazia (e não null) quando não há alertas ativos
+	alerts := &AlertsResponse{Alerts: []Alert{}}
+	for _, a := range apiAlerts.Alerts.Alert {
+		alerts.Alerts = append(alerts.Alerts, Alert{
+			Headline:    a.Headline,
+			Event:       a.Event,
+			Severity:    a.Severity,
+			Urgency:     a.Urgency,
+			Areas:       a.Areas,
+			Effective:   a.Effective,
+			Expires:     a.Expires,
+			Description: a.Desc,
+			Instruction: a.Instruction,
+		})
+	}
+
+	return alerts, nil
+}
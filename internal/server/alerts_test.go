@@ -0,0 +1,46 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAlertsHandler(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			"No active alerts",
+			`{"alerts":{"alert":[]}}`,
+			`{"alerts":[]}`,
+		},
+		{
+			"Storm warning",
+			`{"alerts":{"alert":[{"headline":"Tempestade","event":"Tempestade","severity":"Severe","urgency":"Immediate","areas":"São Paulo","effective":"2025-11-30T12:00:00-03:00","expires":"2025-11-30T23:00:00-03:00","desc":"Chuva intensa","instruction":"Evite áreas alagadas"}]}}`,
+			`{"alerts":[{"headline":"Tempestade","event":"Tempestade","severity":"Severe","urgency":"Immediate","areas":"São Paulo","effective":"2025-11-30T12:00:00-03:00","expires":"2025-11-30T23:00:00-03:00","description":"Chuva intensa","instruction":"Evite áreas alagadas"}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeUpstreamServer(t, map[string]string{
+				"/ws/01310100/json/": viaCEPSaoPaulo,
+				"/v1/alerts.json":    tt.body,
+			})
+
+			req, err := http.NewRequest("GET", "/alerts/01310100", nil)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.JSONEq(t, tt.expected, rr.Body.String())
+		})
+	}
+}
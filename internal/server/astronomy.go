@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+type AstronomyResponse struct {
+	Sunrise   string `json:"sunrise" xml:"sunrise"`
+	Sunset    string `json:"sunset" xml:"sunset"`
+	Moonrise  string `json:"moonrise" xml:"moonrise"`
+	Moonset   string `json:"moonset" xml:"moonset"`
+	MoonPhase string `json:"moon_phase" xml:"moon_phase"`
+}
+
+type WeatherAPIAstronomyResponse struct {
+	Astronomy struct {
+		Astro struct {
+			Sunrise   string `json:"sunrise"`
+			Sunset    string `json:"sunset"`
+			Moonrise  string `json:"moonrise"`
+			Moonset   string `json:"moonset"`
+			MoonPhase string `json:"moon_phase"`
+		} `json:"astro"`
+	} `json:"astronomy"`
+}
+
+func (s *Server) astronomyHandler(w http.ResponseWriter, r *http.Request) {
+	cep := cepParam(r)
+	location, ok := s.resolveLocation(w, r, cep)
+	if !ok {
+		return
+	}
+
+	astronomy, err := s.getAstronomy(r.Context(), location, time.Now().Format("2006-01-02"))
+	if err != nil {
+		log.Printf("ERROR: Failed to get astronomy for location '%s': %v", location, err)
+		status, body := httpError(err)
+		writeResponse(w, r, status, body)
+		return
+	}
+
+	log.Printf("Successfully processed astronomy for CEP %s: sunrise %s, sunset %s", cep, astronomy.Sunrise, astronomy.Sunset)
+
+	writeResponse(w, r, http.StatusOK, astronomy)
+}
+
+func (s *Server) getAstronomy(ctx context.Context, location, date string) (*AstronomyResponse, error) {
+	log.Printf("Fetching astronomy for location: %s on %s", location, date)
+
+	var astronomy WeatherAPIAstronomyResponse
+	params := url.Values{"q": {location}, "dt": {date}}
+	if err := s.callWeatherAPI(ctx, "astronomy.json", params, &astronomy); err != nil {
+		return nil, err
+	}
+
+	astro := astronomy.Astronomy.Astro
+	return &AstronomyResponse{
+		Sunrise:   astro.Sunrise,
+		Sunset:    astro.Sunset,
+		Moonrise:  astro.Moonrise,
+		Moonset:   astro.Moonset,
+		MoonPhase: astro.MoonPhase,
+	}, nil
+}
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAstronomyHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/astronomy.json": `{"astronomy":{"astro":{"sunrise":"05:12 AM","sunset":"06:31 PM","moonrise":"09:40 PM","moonset":"08:55 AM","moon_phase":"Waning Gibbous"}}}`,
+	})
+
+	req, err := http.NewRequest("GET", "/astronomy/01310100", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response AstronomyResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, AstronomyResponse{
+		Sunrise:   "05:12 AM",
+		Sunset:    "06:31 PM",
+		Moonrise:  "09:40 PM",
+		Moonset:   "08:55 AM",
+		MoonPhase: "Waning Gibbous",
+	}, response)
+}
+
+func TestAstronomyHandler_InvalidCEP(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	req, err := http.NewRequest("GET", "/astronomy/123", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+}
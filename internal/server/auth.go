@@ -0,0 +1,149 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/weather-service/config"
+)
+
+const (
+	apiKeyHeader           = "X-API-Key"
+	defaultAPIKeysRedisSet = "weather:api-keys"
+)
+
+// Chave de API conferida por requireAPIKey, guardada no contexto da requisição
+type apiKeyContextKey struct{}
+
+// Origem das chaves de API aceitas pelo serviço
+type apiKeyStore interface {
+	validAPIKey(ctx context.Context, key string) (bool, error)
+}
+
+// Chaves fixas, vindas de API_KEYS e/ou API_KEYS_FILE
+type staticAPIKeys map[string]struct{}
+
+func (s staticAPIKeys) validAPIKey(_ context.Context, key string) (bool, error) {
+	_, ok := s[key]
+	return ok, nil
+}
+
+// Chaves mantidas em um set do Redis, permitindo revogar sem reiniciar o serviço
+type redisAPIKeys struct {
+	client *redis.Client
+	set    string
+}
+
+func (s redisAPIKeys) validAPIKey(ctx context.Context, key string) (bool, error) {
+	return s.client.SIsMember(ctx, s.set, key).Result()
+}
+
+// Consulta as origens em ordem, aceitando a chave encontrada em qualquer uma
+type apiKeyStores []apiKeyStore
+
+func (s apiKeyStores) validAPIKey(ctx context.Context, key string) (bool, error) {
+	for _, store := range s {
+		ok, err := store.validAPIKey(ctx, key)
+		if err != nil || ok {
+			return ok, err
+		}
+	}
+	return false, nil
+}
+
+// Monta as origens de chaves configuradas no ambiente. Retorna nil quando
+// nenhuma está configurada, deixando a autenticação desligada
+func apiKeyStoreFromEnv() (apiKeyStore, error) {
+	var stores apiKeyStores
+
+	static := staticAPIKeys{}
+	for _, key := range config.List("API_KEYS", nil) {
+		static[key] = struct{}{}
+	}
+	if path := config.String("API_KEYS_FILE"); path != "" {
+		if err := loadAPIKeysFile(path, static); err != nil {
+			return nil, err
+		}
+	}
+	if len(static) > 0 {
+		stores = append(stores, static)
+	}
+
+	if redisURL := config.String("API_KEYS_REDIS_URL"); redisURL != "" {
+		options, err := redis.ParseURL(redisURL)
+		if err != nil {
+			return nil, fmt.Errorf("invalid API_KEYS_REDIS_URL: %w", err)
+		}
+		set := config.String("API_KEYS_REDIS_SET")
+		if set == "" {
+			set = defaultAPIKeysRedisSet
+		}
+		stores = append(stores, redisAPIKeys{client: redis.NewClient(options), set: set})
+	}
+
+	if len(stores) == 0 {
+		return nil, nil
+	}
+	return stores, nil
+}
+
+// Uma chave por linha; linhas vazias e comentários (#) são ignorados
+func loadAPIKeysFile(path string, keys staticAPIKeys) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open API_KEYS_FILE: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		keys[line] = struct{}{}
+	}
+	return scanner.Err()
+}
+
+func requireAPIKey(store apiKeyStore) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if store == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(apiKeyHeader)
+			if key == "" {
+				writeResponse(w, r, http.StatusUnauthorized, ErrorResponse{Message: "missing api key"})
+				return
+			}
+
+			ok, err := store.validAPIKey(r.Context(), key)
+			if err != nil {
+				log.Printf("ERROR: Failed to validate API key: %v", err)
+				writeResponse(w, r, http.StatusInternalServerError, ErrorResponse{Message: "internal server error"})
+				return
+			}
+			if !ok {
+				writeResponse(w, r, http.StatusUnauthorized, ErrorResponse{Message: "invalid api key"})
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), apiKeyContextKey{}, key)))
+		})
+	}
+}
+
+// Chave de API da requisição, só quando requireAPIKey a conferiu; com a
+// autenticação por chave desligada, X-API-Key não identifica ninguém
+func authenticatedAPIKey(ctx context.Context) string {
+	key, _ := ctx.Value(apiKeyContextKey{}).(string)
+	return key
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireAPIKey(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	keysFile := filepath.Join(t.TempDir(), "keys.txt")
+	assert.NoError(t, os.WriteFile(keysFile, []byte("# clientes\nfile-key\n\n"), 0o600))
+	t.Setenv("API_KEYS", "env-key, other-key")
+	t.Setenv("API_KEYS_FILE", keysFile)
+
+	tests := []struct {
+		name            string
+		path            string
+		key             string
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{"Missing key", "/v1/weather/123", "", http.StatusUnauthorized, "missing api key"},
+		{"Invalid key", "/v1/weather/123", "wrong", http.StatusUnauthorized, "invalid api key"},
+		{"Key from env", "/v1/weather/123", "other-key", http.StatusUnprocessableEntity, "invalid zipcode"},
+		{"Key from file", "/weather/123", "file-key", http.StatusUnprocessableEntity, "invalid zipcode"},
+		{"GraphQL is protected", "/graphql", "", http.StatusUnauthorized, "missing api key"},
+	}
+
+	router := s.router()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.path, nil)
+			assert.NoError(t, err)
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Contains(t, rr.Body.String(), tt.expectedMessage)
+		})
+	}
+}
+
+func TestRequireAPIKey_PublicEndpoints(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("API_KEYS", "env-key")
+
+	router := s.router()
+	for _, path := range []string{"/", "/openapi.json", "/docs"} {
+		req, err := http.NewRequest("GET", path, nil)
+		assert.NoError(t, err)
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		assert.Equal(t, http.StatusOK, rr.Code, path)
+	}
+}
+
+func TestAPIKeyStoreFromEnv(t *testing.T) {
+	t.Run("Disabled without keys", func(t *testing.T) {
+		store, err := apiKeyStoreFromEnv()
+		assert.NoError(t, err)
+		assert.Nil(t, store)
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		t.Setenv("API_KEYS_FILE", filepath.Join(t.TempDir(), "missing.txt"))
+		_, err := apiKeyStoreFromEnv()
+		assert.Error(t, err)
+	})
+
+	t.Run("Invalid Redis URL", func(t *testing.T) {
+		t.Setenv("API_KEYS_REDIS_URL", "http://localhost")
+		_, err := apiKeyStoreFromEnv()
+		assert.Error(t, err)
+	})
+}
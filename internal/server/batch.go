@@ -0,0 +1,149 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+const (
+	defaultBatchMaxSize     = 500
+	defaultBatchConcurrency = 10
+	defaultBatchItemTimeout = 10 * time.Second
+)
+
+type BatchRequest struct {
+	CEPs []string `json:"ceps"`
+}
+
+// Resultados na mesma ordem dos CEPs enviados
+type BatchResponse struct {
+	Results []BatchResult `json:"results" xml:"result"`
+}
+
+// Cada CEP traz a temperatura ou o erro que o endpoint /weather/{cep} daria
+type BatchResult struct {
+	CEP     string           `json:"cep" xml:"cep"`
+	Weather *WeatherResponse `json:"weather,omitempty" xml:"weather,omitempty"`
+	Error   string           `json:"error,omitempty" xml:"error,omitempty"`
+}
+
+func batchMaxSize() int {
+	if size := config.Int("BATCH_MAX_SIZE", 0); size > 0 {
+		return size
+	}
+	return defaultBatchMaxSize
+}
+
+func batchConcurrency() int {
+	if concurrency := config.Int("BATCH_CONCURRENCY", 0); concurrency > 0 {
+		return concurrency
+	}
+	return defaultBatchConcurrency
+}
+
+// POST /weather/batch: temperatura de vários CEPs em uma requisição. Os CEPs
+// são consultados por no máximo BATCH_CONCURRENCY workers, cada um limitado a
+// BATCH_ITEM_TIMEOUT, para que um lote grande não dispare centenas de
+// chamadas simultâneas aos provedores
+func (s *Server) batchHandler(w http.ResponseWriter, r *http.Request) {
+	units, ok := unitsFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	var request BatchRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		if isBodyTooLarge(err) {
+			writeResponse(w, r, http.StatusRequestEntityTooLarge, ErrorResponse{Message: "request body too large"})
+			return
+		}
+		writeResponse(w, r, http.StatusBadRequest, ErrorResponse{Message: "invalid request body"})
+		return
+	}
+	if len(request.CEPs) == 0 || len(request.CEPs) > batchMaxSize() {
+		log.Printf("Invalid batch size: %d", len(request.CEPs))
+		writeResponse(w, r, http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid batch size"})
+		return
+	}
+
+	results := s.runBatch(r.Context(), request.CEPs, units, batchConcurrency())
+	log.Printf("Processed batch of %d CEPs", len(results))
+	writeResponse(w, r, http.StatusOK, BatchResponse{Results: results})
+}
+
+// Distribui os CEPs entre os workers. Se o cliente desistir, os CEPs ainda
+// não iniciados ficam sem consulta
+func (s *Server) runBatch(ctx context.Context, ceps []string, units temperatureUnits, concurrency int) []BatchResult {
+	results := make([]BatchResult, len(ceps))
+	if concurrency > len(ceps) {
+		concurrency = len(ceps)
+	}
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for n := 0; n < concurrency; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results[i] = s.lookupBatchItem(ctx, ceps[i], units)
+			}
+		}()
+	}
+
+enqueue:
+	for i := range ceps {
+		select {
+		case jobs <- i:
+		case <-ctx.Done():
+			for ; i < len(ceps); i++ {
+				results[i] = BatchResult{CEP: ceps[i], Error: "request canceled"}
+			}
+			break enqueue
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+func (s *Server) lookupBatchItem(ctx context.Context, cep string, units temperatureUnits) BatchResult {
+	ctx, cancel := context.WithTimeout(ctx, config.Duration("BATCH_ITEM_TIMEOUT", defaultBatchItemTimeout))
+	defer cancel()
+
+	weather, err := s.lookupWeather(ctx, cep, units)
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			log.Printf("WARNING: Batch lookup for CEP %s timed out", cep)
+			return BatchResult{CEP: cep, Error: "timeout"}
+		}
+		return BatchResult{CEP: cep, Error: err.Error()}
+	}
+	return BatchResult{CEP: cep, Weather: weather}
+}
+
+// Uma linha por CEP, na ordem do pedido. CEPs com erro ficam com as
+// temperaturas vazias e o motivo na coluna error
+func (br BatchResponse) csvHeader() []string {
+	return []string{"cep", "city", "temp_C", "temp_F", "temp_K", "error"}
+}
+
+func (br BatchResponse) csvRecords() [][]string {
+	records := make([][]string, 0, len(br.Results))
+	for _, result := range br.Results {
+		if result.Weather == nil {
+			records = append(records, []string{result.CEP, "", "", "", "", result.Error})
+			continue
+		}
+		row := result.Weather.csvRecords()[0]
+		records = append(records, append(row, ""))
+	}
+	return records
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/internal/cep"
+	"github.com/weather-service/internal/weather"
+)
+
+func batchRequest(t *testing.T, s *Server, target, body string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("POST", target, strings.NewReader(body)))
+	return rr
+}
+
+func TestBatchHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+
+	rr := batchRequest(t, s, "/v1/weather/batch?units=c", `{"ceps":["01310100","123","99999999"]}`)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"results":[
+		{"cep":"01310100","weather":{"temp_C":25}},
+		{"cep":"123","error":"invalid zipcode"},
+		{"cep":"99999999","error":"can not find zipcode"}
+	]}`, rr.Body.String())
+}
+
+func TestBatchHandler_CSV(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+
+	req := httptest.NewRequest("POST", "/weather/batch?units=c", strings.NewReader(`{"ceps":["01310100","123"]}`))
+	req.Header.Set("Accept", "text/csv")
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+	assert.Equal(t, "cep,city,temp_C,temp_F,temp_K,error\n"+
+		"01310-100,São Paulo,25,,,\n"+
+		"123,,,,,invalid zipcode\n", rr.Body.String())
+}
+
+func TestBatchHandler_InvalidRequest(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("BATCH_MAX_SIZE", "2")
+
+	tests := []struct {
+		name    string
+		body    string
+		status  int
+		message string
+	}{
+		{"Malformed", `{"ceps":`, http.StatusBadRequest, "invalid request body"},
+		{"Empty", `{"ceps":[]}`, http.StatusUnprocessableEntity, "invalid batch size"},
+		{"Too many", `{"ceps":["01310100","01310100","01310100"]}`, http.StatusUnprocessableEntity, "invalid batch size"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := batchRequest(t, s, "/weather/batch", tt.body)
+			assert.Equal(t, tt.status, rr.Code)
+			var response ErrorResponse
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			assert.Equal(t, tt.message, response.Message)
+		})
+	}
+}
+
+// Nunca há mais que BATCH_CONCURRENCY consultas em andamento
+func TestRunBatch_BoundedConcurrency(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/ws/") {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for {
+				max := maxInFlight.Load()
+				if n <= max || maxInFlight.CompareAndSwap(max, n) {
+					break
+				}
+			}
+			time.Sleep(20 * time.Millisecond)
+			w.Write([]byte(`{"cep":"` + r.URL.Path[4:9] + `-` + r.URL.Path[9:12] + `","localidade":"São Paulo","uf":"SP"}`))
+			return
+		}
+		w.Write([]byte(`{"current":{"temp_c":25}}`))
+	}))
+	defer server.Close()
+	s.viaCEP = cep.NewViaCEP(server.URL+"/ws", nil)
+	s.weather = weather.NewClient(server.URL+"/v1", nil)
+
+	ceps := make([]string, 30)
+	for i := range ceps {
+		ceps[i] = "0131" + string(rune('0'+i/10)) + string(rune('0'+i%10)) + "00"
+	}
+
+	results := s.runBatch(context.Background(), ceps, defaultUnits, 4)
+	assert.Len(t, results, 30)
+	for i, result := range results {
+		assert.Equal(t, ceps[i], result.CEP)
+		assert.Empty(t, result.Error)
+	}
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(4))
+	assert.Greater(t, maxInFlight.Load(), int32(1))
+}
+
+func TestRunBatch_ItemTimeout(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("BATCH_ITEM_TIMEOUT", "50ms")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ws/01310100/json/" {
+			w.Write([]byte(viaCEPSaoPaulo))
+			return
+		}
+		select {
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	s.viaCEP = cep.NewViaCEP(server.URL+"/ws", nil)
+	s.weather = weather.NewClient(server.URL+"/v1", nil)
+
+	start := time.Now()
+	results := s.runBatch(context.Background(), []string{"01310100"}, defaultUnits, 1)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, []BatchResult{{CEP: "01310100", Error: "timeout"}}, results)
+}
@@ -0,0 +1,319 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/weather-service/config"
+	"github.com/weather-service/internal/cep"
+)
+
+// CEPs usados quando o bench não recebe nenhum
+var defaultBenchCEPs = []string{"01310100", "20040020", "30130100", "80010000", "90010000"}
+
+// Resultado de uma rodada do bench
+type BenchReport struct {
+	Target            string         `json:"target"`
+	Requests          int            `json:"requests"`
+	Concurrency       int            `json:"concurrency"`
+	Errors            int            `json:"errors"`
+	Statuses          map[string]int `json:"statuses"`
+	DurationMS        float64        `json:"duration_ms"`
+	RequestsPerSecond float64        `json:"requests_per_second"`
+	LatencyMS         BenchLatency   `json:"latency_ms"`
+}
+
+// Percentis da latência das requisições, em milissegundos
+type BenchLatency struct {
+	P50 float64 `json:"p50"`
+	P90 float64 `json:"p90"`
+	P95 float64 `json:"p95"`
+	P99 float64 `json:"p99"`
+	Max float64 `json:"max"`
+}
+
+type benchOptions struct {
+	url             string
+	apiKey          string
+	file            string
+	requests        int
+	concurrency     int
+	timeout         time.Duration
+	providerLatency time.Duration
+	json            bool
+}
+
+// Repete GET /weather/{cep} com a lista de CEPs contra uma instância no ar
+// (--url) ou, sem --url, contra o servidor no próprio processo com
+// provedores falsos, e mostra os percentis de latência
+func newBenchCommand() *cobra.Command {
+	var opts benchOptions
+
+	cmd := &cobra.Command{
+		Use:   "bench [CEP...]",
+		Short: "Mede a latência de /weather/{cep} com uma lista de CEPs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			ceps, err := benchCEPs(args, opts.file, cmd.InOrStdin())
+			if err != nil {
+				return err
+			}
+			if opts.requests < 1 || opts.concurrency < 1 {
+				return fmt.Errorf("--requests and --concurrency must be positive")
+			}
+
+			target, client := opts.url, &http.Client{Timeout: opts.timeout}
+			if target == "" {
+				server, restore := newInProcessBenchServer(opts.providerLatency)
+				defer restore()
+				target = server.URL
+			}
+
+			report := runBench(cmd.Context(), client, target, opts.apiKey, ceps, opts.requests, opts.concurrency)
+			if opts.url == "" {
+				report.Target = "in-process"
+			}
+			if opts.json {
+				encoder := json.NewEncoder(cmd.OutOrStdout())
+				encoder.SetIndent("", "  ")
+				return encoder.Encode(report)
+			}
+			return report.writeTable(cmd.OutOrStdout())
+		},
+	}
+
+	flags := cmd.Flags()
+	flags.StringVar(&opts.url, "url", "", "instância a medir (ex.: http://localhost:8080); vazio mede o servidor no próprio processo com provedores falsos")
+	flags.StringVar(&opts.apiKey, "api-key", "", "valor do X-API-Key enviado em cada requisição")
+	flags.StringVar(&opts.file, "file", "", "arquivo com um CEP por linha (- lê da entrada padrão)")
+	flags.IntVar(&opts.requests, "requests", 1000, "total de requisições")
+	flags.IntVar(&opts.concurrency, "concurrency", 10, "requisições simultâneas")
+	flags.DurationVar(&opts.timeout, "timeout", 30*time.Second, "tempo máximo de cada requisição")
+	flags.DurationVar(&opts.providerLatency, "provider-latency", 0, "latência simulada dos provedores falsos (sem --url)")
+	flags.BoolVar(&opts.json, "json", false, "mostra o resultado em JSON")
+	return cmd
+}
+
+// CEPs dos argumentos e do arquivo, nessa ordem; sem nenhum, os padrões
+func benchCEPs(args []string, file string, stdin io.Reader) ([]string, error) {
+	ceps := append([]string(nil), args...)
+	if file != "" {
+		var in io.Reader = stdin
+		if file != "-" {
+			f, err := os.Open(file)
+			if err != nil {
+				return nil, err
+			}
+			defer f.Close()
+			in = f
+		}
+
+		scanner := bufio.NewScanner(in)
+		for scanner.Scan() {
+			if line := strings.TrimSpace(scanner.Text()); line != "" && !strings.HasPrefix(line, "#") {
+				ceps = append(ceps, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, err
+		}
+	}
+	if len(ceps) == 0 {
+		return defaultBenchCEPs, nil
+	}
+	return ceps, nil
+}
+
+// Sobe o roteador completo, com os middlewares da configuração atual, mas com
+// ViaCEP, BrasilAPI e WeatherAPI em memória: mede só o custo do serviço.
+// restore derruba o servidor e devolve o log original
+func newInProcessBenchServer(latency time.Duration) (*httptest.Server, func()) {
+	s := New(Clients{
+		viaCEP:    benchCEPClient{latency: latency},
+		brasilAPI: benchCEPClient{latency: latency},
+		weather:   benchWeatherClient{latency: latency},
+	})
+	hasKey := config.String("WEATHER_API_KEY") != ""
+	if !hasKey {
+		config.Set(config.SourceFlag, "WEATHER_API_KEY", "bench")
+	}
+	// Um log por requisição pesaria mais que a própria requisição
+	logOutput := log.Writer()
+	log.SetOutput(io.Discard)
+
+	server := httptest.NewServer(s.router())
+	return server, func() {
+		server.Close()
+		log.SetOutput(logOutput)
+		if !hasKey {
+			config.Unset(config.SourceFlag, "WEATHER_API_KEY")
+		}
+	}
+}
+
+// ViaCEP falso: a cidade vem da tabela embutida, e CEPs fora dela ficam em São Paulo
+type benchCEPClient struct {
+	latency time.Duration
+}
+
+func (c benchCEPClient) Lookup(ctx context.Context, code string) (*cep.Address, error) {
+	if err := sleepContext(ctx, c.latency); err != nil {
+		return nil, err
+	}
+	if address, ok := cep.Embedded().Lookup(code); ok {
+		return address, nil
+	}
+	return &cep.Address{CEP: code[:5] + "-" + code[5:], City: "São Paulo", UF: "SP"}, nil
+}
+
+// WeatherAPI falsa, com a mesma temperatura para todas as localizações
+type benchWeatherClient struct {
+	latency time.Duration
+}
+
+func (c benchWeatherClient) Fetch(ctx context.Context, endpoint string, params url.Values, key string) ([]byte, error) {
+	if err := sleepContext(ctx, c.latency); err != nil {
+		return nil, err
+	}
+	return []byte(`{"location":{"name":"bench"},"current":{"last_updated_epoch":1700000000,"temp_c":25}}`), nil
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Faz as requisições com no máximo concurrency ao mesmo tempo, percorrendo
+// os CEPs em rodízio
+func runBench(ctx context.Context, client *http.Client, target, apiKey string, ceps []string, requests, concurrency int) BenchReport {
+	latencies := make([]time.Duration, requests)
+	statuses := make([]string, requests)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	start := time.Now()
+	for n := 0; n < concurrency; n++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				latencies[i], statuses[i] = benchRequest(ctx, client, target+"/weather/"+url.PathEscape(ceps[i%len(ceps)]), apiKey)
+			}
+		}()
+	}
+	for i := 0; i < requests; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	report := BenchReport{
+		Target:            target,
+		Requests:          requests,
+		Concurrency:       concurrency,
+		Statuses:          make(map[string]int),
+		DurationMS:        milliseconds(elapsed),
+		RequestsPerSecond: math.Round(float64(requests)/elapsed.Seconds()*10) / 10,
+		LatencyMS:         latencyPercentiles(latencies),
+	}
+	for _, status := range statuses {
+		report.Statuses[status]++
+		if code, err := strconv.Atoi(status); err != nil || code >= http.StatusBadRequest {
+			report.Errors++
+		}
+	}
+	return report
+}
+
+// Latência e status de uma requisição; falhas de rede aparecem como "error"
+func benchRequest(ctx context.Context, client *http.Client, target, apiKey string) (time.Duration, string) {
+	start := time.Now()
+	req, err := http.NewRequestWithContext(ctx, "GET", target, nil)
+	if err != nil {
+		return 0, "error"
+	}
+	if apiKey != "" {
+		req.Header.Set("X-API-Key", apiKey)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return time.Since(start), "error"
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	return time.Since(start), strconv.Itoa(resp.StatusCode)
+}
+
+// Percentis pelo método do posto mais próximo
+func latencyPercentiles(latencies []time.Duration) BenchLatency {
+	if len(latencies) == 0 {
+		return BenchLatency{}
+	}
+	sorted := append([]time.Duration(nil), latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	percentile := func(p float64) float64 {
+		rank := int(math.Ceil(p/100*float64(len(sorted)))) - 1
+		if rank < 0 {
+			rank = 0
+		}
+		return milliseconds(sorted[rank])
+	}
+	return BenchLatency{
+		P50: percentile(50),
+		P90: percentile(90),
+		P95: percentile(95),
+		P99: percentile(99),
+		Max: milliseconds(sorted[len(sorted)-1]),
+	}
+}
+
+func milliseconds(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Millisecond)*100) / 100
+}
+
+func (r BenchReport) writeTable(out io.Writer) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "target\t%s\n", r.Target)
+	fmt.Fprintf(w, "requests\t%d (concurrency %d, %d errors)\n", r.Requests, r.Concurrency, r.Errors)
+	fmt.Fprintf(w, "duration\t%.2fms (%.1f req/s)\n", r.DurationMS, r.RequestsPerSecond)
+
+	statuses := make([]string, 0, len(r.Statuses))
+	for status := range r.Statuses {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	for _, status := range statuses {
+		fmt.Fprintf(w, "status %s\t%d\n", status, r.Statuses[status])
+	}
+
+	l := r.LatencyMS
+	fmt.Fprintf(w, "latency\tp50 %.2fms  p90 %.2fms  p95 %.2fms  p99 %.2fms  max %.2fms\n", l.P50, l.P90, l.P95, l.P99, l.Max)
+	return w.Flush()
+}
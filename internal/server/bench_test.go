@@ -0,0 +1,77 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLatencyPercentiles(t *testing.T) {
+	var latencies []time.Duration
+	for i := 100; i >= 1; i-- {
+		latencies = append(latencies, time.Duration(i)*time.Millisecond)
+	}
+
+	assert.Equal(t, BenchLatency{P50: 50, P90: 90, P95: 95, P99: 99, Max: 100}, latencyPercentiles(latencies))
+	assert.Equal(t, BenchLatency{P50: 7, P90: 7, P95: 7, P99: 7, Max: 7}, latencyPercentiles([]time.Duration{7 * time.Millisecond}))
+	assert.Equal(t, BenchLatency{}, latencyPercentiles(nil))
+}
+
+func TestBenchCEPs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ceps.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("# capitais\n20040020\n\n30130100\n"), 0o600))
+
+	ceps, err := benchCEPs([]string{"01310100"}, path, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"01310100", "20040020", "30130100"}, ceps)
+
+	ceps, err = benchCEPs(nil, "-", strings.NewReader("80010000\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"80010000"}, ceps)
+
+	ceps, err = benchCEPs(nil, "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, defaultBenchCEPs, ceps)
+}
+
+// Sem --url, o bench mede o próprio roteador com provedores falsos
+func TestCLI_BenchInProcess(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+
+	out, err := runCLI(t, s, "bench", "01310100", "123", "--requests", "20", "--concurrency", "4", "--json")
+	assert.NoError(t, err)
+
+	var report BenchReport
+	assert.NoError(t, json.Unmarshal([]byte(out), &report))
+	assert.Equal(t, "in-process", report.Target)
+	assert.Equal(t, 20, report.Requests)
+	assert.Equal(t, map[string]int{"200": 10, "422": 10}, report.Statuses)
+	assert.Equal(t, 10, report.Errors)
+	assert.Greater(t, report.LatencyMS.Max, 0.0)
+}
+
+func TestCLI_BenchURL(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		assert.Equal(t, "bench-key", r.Header.Get("X-API-Key"))
+		assert.True(t, strings.HasPrefix(r.URL.Path, "/weather/"))
+		w.Write([]byte(`{"temp_C":25}`))
+	}))
+	defer server.Close()
+
+	out, err := runCLI(t, s, "bench", "--url", server.URL, "--api-key", "bench-key", "--requests", "15", "--concurrency", "3")
+	assert.NoError(t, err)
+	assert.EqualValues(t, 15, calls.Load())
+	assert.Contains(t, out, "status 200  15")
+	assert.Contains(t, out, "p99")
+}
@@ -0,0 +1,126 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+// Espera antes de consultar também a BrasilAPI (CEP_HEDGE_DELAY). Sem a
+// variável, só o ViaCEP é consultado; 0s consulta os dois ao mesmo tempo
+func cepHedgeDelay() (time.Duration, bool) {
+	if config.String("CEP_HEDGE_DELAY") == "" {
+		return 0, false
+	}
+	return config.Duration("CEP_HEDGE_DELAY", 0), true
+}
+
+// Provedor de CEP e a função que o consulta
+type cepSource struct {
+	provider *provider
+	query    func(context.Context, string) (*ViaCEPResponse, error)
+}
+
+// Provedores na ordem de CEP_PROVIDER_ORDER (padrão viacep,brasilapi): o
+// primeiro é sempre consultado e o segundo entra com CEP_HEDGE_DELAY. A ordem
+// é relida a cada consulta, para que um reload troque o provedor principal
+func (s *Server) cepSources() []cepSource {
+	sources := map[string]cepSource{
+		viaCEPProvider.name:    {viaCEPProvider, s.queryViaCEP},
+		brasilAPIProvider.name: {brasilAPIProvider, s.queryBrasilAPI},
+	}
+
+	var ordered []cepSource
+	for _, name := range config.List("CEP_PROVIDER_ORDER", []string{viaCEPProvider.name, brasilAPIProvider.name}) {
+		if source, ok := sources[name]; ok {
+			ordered = append(ordered, source)
+			delete(sources, name)
+		}
+	}
+	if len(ordered) == 0 {
+		return []cepSource{{viaCEPProvider, s.queryViaCEP}}
+	}
+	return ordered
+}
+
+// Provedores de CEP em uso com a configuração atual
+func (s *Server) cepProviders() []*provider {
+	sources := s.cepSources()
+	if _, hedged := cepHedgeDelay(); hedged && len(sources) > 1 {
+		return []*provider{sources[0].provider, sources[1].provider}
+	}
+	return []*provider{sources[0].provider}
+}
+
+type cepResult struct {
+	provider string
+	address  *ViaCEPResponse
+	err      error
+}
+
+// Consulta o provedor principal (o ViaCEP, por padrão) e, se ele não
+// responder em CEP_HEDGE_DELAY ou falhar, também o segundo, ficando com a
+// primeira resposta definitiva (endereço ou CEP inexistente). A consulta que
+// perde a corrida é cancelada
+func (s *Server) queryCEPProviders(ctx context.Context, cep string) (*ViaCEPResponse, error) {
+	sources := s.cepSources()
+	delay, hedged := cepHedgeDelay()
+	if !hedged || len(sources) < 2 {
+		return sources[0].query(ctx, cep)
+	}
+	primary, secondary := sources[0], sources[1]
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make(chan cepResult, 2)
+	query := func(name string, fn func(context.Context, string) (*ViaCEPResponse, error)) {
+		go func() {
+			address, err := fn(ctx, cep)
+			results <- cepResult{provider: name, address: address, err: err}
+		}()
+	}
+	query(primary.provider.name, primary.query)
+
+	hedge := time.NewTimer(delay)
+	defer hedge.Stop()
+	pending, hedgeStarted := 1, false
+	startHedge := func() {
+		if !hedgeStarted {
+			hedgeStarted = true
+			pending++
+			query(secondary.provider.name, secondary.query)
+		}
+	}
+
+	var firstErr error
+	for {
+		select {
+		case <-hedge.C:
+			startHedge()
+		case result := <-results:
+			pending--
+			if result.err == nil || errors.Is(result.err, ErrCEPNotFound) {
+				if hedgeStarted {
+					log.Printf("CEP %s answered first by %s", cep, result.provider)
+				}
+				return result.address, result.err
+			}
+			if firstErr == nil {
+				firstErr = result.err
+			}
+			log.Printf("WARNING: CEP lookup for %s failed on %s: %v", cep, result.provider, result.err)
+			startHedge()
+			if pending == 0 {
+				return nil, firstErr
+			}
+		}
+	}
+}
+
+func (s *Server) queryBrasilAPI(ctx context.Context, code string) (*ViaCEPResponse, error) {
+	return queryCEPProvider(ctx, brasilAPIProvider, s.brasilAPI, code)
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/config"
+	"github.com/weather-service/internal/cep"
+)
+
+const brasilAPISaoPaulo = `{"cep":"01310100","state":"SP","city":"São Paulo","neighborhood":"Bela Vista","street":"Avenida Paulista","service":"open-cep"}`
+
+// ViaCEP e BrasilAPI falsos; viaCEP responde com o status e o atraso dados
+func newCEPProvidersServer(t *testing.T, viaCEPStatus int, viaCEPDelay time.Duration) (*Server, *atomic.Int32) {
+	s := newFakeUpstreamServer(t, nil)
+
+	var brasilAPICalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ws/01310100/json/":
+			select {
+			case <-time.After(viaCEPDelay):
+			case <-r.Context().Done():
+				return
+			}
+			w.WriteHeader(viaCEPStatus)
+			w.Write([]byte(viaCEPSaoPaulo))
+		case "/brasilapi/cep/v2/01310100":
+			brasilAPICalls.Add(1)
+			w.Write([]byte(brasilAPISaoPaulo))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	s.viaCEP = cep.NewViaCEP(server.URL+"/ws", nil)
+	s.brasilAPI = cep.NewBrasilAPI(server.URL+"/brasilapi", nil)
+	return s, &brasilAPICalls
+}
+
+func TestQueryCEPProviders_WithoutHedge(t *testing.T) {
+	s, brasilAPICalls := newCEPProvidersServer(t, http.StatusInternalServerError, 0)
+
+	_, err := s.queryCEPProviders(context.Background(), "01310100")
+	assert.EqualError(t, err, "viacep returned status 500")
+	assert.Equal(t, int32(0), brasilAPICalls.Load())
+}
+
+// ViaCEP lento: depois do CEP_HEDGE_DELAY a BrasilAPI responde primeiro
+func TestQueryCEPProviders_SlowViaCEP(t *testing.T) {
+	s, brasilAPICalls := newCEPProvidersServer(t, http.StatusOK, time.Second)
+	t.Setenv("CEP_HEDGE_DELAY", "20ms")
+
+	start := time.Now()
+	address, err := s.queryCEPProviders(context.Background(), "01310100")
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, &ViaCEPResponse{Cep: "01310-100", Logradouro: "Avenida Paulista", Bairro: "Bela Vista", Localidade: "São Paulo", UF: "SP", provider: "brasilapi"}, address)
+	assert.Equal(t, int32(1), brasilAPICalls.Load())
+
+	// A consulta cancelada ao ViaCEP não conta como falha dele
+	assert.Eventually(t, func() bool {
+		state, failures := viaCEPProvider.breaker.state()
+		return state == breakerClosed && failures == 0
+	}, time.Second, 10*time.Millisecond)
+	calls, _, _ := viaCEPProvider.stats.summary()
+	assert.Equal(t, 0, calls)
+}
+
+// Com o ViaCEP respondendo rápido, a BrasilAPI nem é consultada
+func TestQueryCEPProviders_FastViaCEP(t *testing.T) {
+	s, brasilAPICalls := newCEPProvidersServer(t, http.StatusOK, 0)
+	t.Setenv("CEP_HEDGE_DELAY", "1s")
+
+	address, err := s.queryCEPProviders(context.Background(), "01310100")
+	assert.NoError(t, err)
+	assert.Equal(t, "Avenida Paulista", address.Logradouro)
+	assert.Equal(t, int32(0), brasilAPICalls.Load())
+}
+
+// Uma falha do ViaCEP dispara a BrasilAPI sem esperar o atraso
+func TestQueryCEPProviders_ViaCEPError(t *testing.T) {
+	s, brasilAPICalls := newCEPProvidersServer(t, http.StatusBadGateway, 0)
+	t.Setenv("CEP_HEDGE_DELAY", "1s")
+
+	start := time.Now()
+	address, err := s.queryCEPProviders(context.Background(), "01310100")
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, "São Paulo", address.Localidade)
+	assert.Equal(t, int32(1), brasilAPICalls.Load())
+}
+
+func TestQueryCEPProviders_NotFound(t *testing.T) {
+	s, _ := newCEPProvidersServer(t, http.StatusOK, 0)
+	t.Setenv("CEP_HEDGE_DELAY", "0s")
+
+	_, err := s.queryCEPProviders(context.Background(), "99999999")
+	assert.ErrorIs(t, err, ErrCEPNotFound)
+}
+
+// CEP_PROVIDER_ORDER escolhe o provedor principal, e um reload troca a ordem
+func TestQueryCEPProviders_ProviderOrder(t *testing.T) {
+	s, brasilAPICalls := newCEPProvidersServer(t, http.StatusOK, time.Second)
+	path := withConfigFile(t, "cep_provider_order: brasilapi,viacep\n", "CEP_PROVIDER_ORDER", "CEP_HEDGE_DELAY")
+
+	start := time.Now()
+	address, err := s.queryCEPProviders(context.Background(), "01310100")
+	assert.NoError(t, err)
+	assert.Less(t, time.Since(start), 500*time.Millisecond)
+	assert.Equal(t, "brasilapi", address.provider)
+	assert.Equal(t, int32(1), brasilAPICalls.Load())
+	assert.Equal(t, []*provider{brasilAPIProvider}, s.cepProviders())
+
+	// Com hedge, o ViaCEP lento passa a ser o provedor secundário
+	assert.NoError(t, os.WriteFile(path, []byte("cep_provider_order: viacep,brasilapi\ncep_hedge_delay: 20ms\n"), 0o600))
+	_, err = config.Reload()
+	assert.NoError(t, err)
+	assert.Equal(t, []*provider{viaCEPProvider, brasilAPIProvider}, s.cepProviders())
+	address, err = s.queryCEPProviders(context.Background(), "01310100")
+	assert.NoError(t, err)
+	assert.Equal(t, "brasilapi", address.provider)
+	assert.Equal(t, int32(2), brasilAPICalls.Load())
+}
+
+func TestStatusHandler_ListsBrasilAPIWhenHedged(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	names := func() []string {
+		var names []string
+		for _, p := range s.activeProviders() {
+			names = append(names, p.name)
+		}
+		return names
+	}
+	assert.Equal(t, []string{"viacep", "weather_api"}, names())
+
+	t.Setenv("CEP_HEDGE_DELAY", "100ms")
+	assert.Equal(t, []string{"viacep", "brasilapi", "weather_api"}, names())
+}
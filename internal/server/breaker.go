@@ -0,0 +1,121 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+const (
+	defaultCircuitBreakerThreshold = 5
+	defaultCircuitBreakerCooldown  = 30 * time.Second
+)
+
+// Estados do circuit breaker: "closed" deixa as chamadas passarem, "open"
+// recusa todas sem chamar o provedor e "half-open" deixa passar uma chamada
+// de teste depois do CIRCUIT_BREAKER_COOLDOWN
+const (
+	breakerClosed   = "closed"
+	breakerOpen     = "open"
+	breakerHalfOpen = "half-open"
+)
+
+// Retornado sem chamar o provedor enquanto o circuit breaker está aberto
+var errCircuitOpen = errors.New("circuit breaker open")
+
+// Abre depois de CIRCUIT_BREAKER_THRESHOLD falhas seguidas de um provedor
+// (erro de rede ou 5xx), para que um provedor fora do ar não prenda cada
+// requisição até o timeout
+type circuitBreaker struct {
+	name string
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	trial    bool
+	now      func() time.Time
+}
+
+func newCircuitBreaker(name string) *circuitBreaker {
+	return &circuitBreaker{name: name, now: time.Now}
+}
+
+func circuitBreakerThreshold() int {
+	threshold := config.Int("CIRCUIT_BREAKER_THRESHOLD", 0)
+	if threshold == 0 {
+		return defaultCircuitBreakerThreshold
+	}
+	return threshold
+}
+
+func (b *circuitBreaker) stateLocked() string {
+	if b.failures < circuitBreakerThreshold() {
+		return breakerClosed
+	}
+	if b.trial || b.now().Sub(b.openedAt) < config.Duration("CIRCUIT_BREAKER_COOLDOWN", defaultCircuitBreakerCooldown) {
+		return breakerOpen
+	}
+	return breakerHalfOpen
+}
+
+// Informa se a chamada pode ser feita. Com o breaker meio aberto, só a
+// primeira chamada passa; as demais esperam o resultado dela
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.stateLocked() {
+	case breakerClosed:
+		return true
+	case breakerHalfOpen:
+		b.trial = true
+		return true
+	default:
+		return false
+	}
+}
+
+// Registra o resultado de uma chamada permitida por allow
+func (b *circuitBreaker) record(ok bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	wasTrial := b.trial
+	b.trial = false
+	if ok {
+		if b.failures >= circuitBreakerThreshold() {
+			log.Printf("Circuit breaker for %s closed", b.name)
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures == circuitBreakerThreshold() || wasTrial {
+		log.Printf("WARNING: Circuit breaker for %s opened after %d consecutive failures", b.name, b.failures)
+		b.openedAt = b.now()
+	}
+}
+
+// Libera a chamada de teste de um breaker meio aberto sem contar sucesso
+// nem falha, para que a próxima chamada possa testar o provedor
+func (b *circuitBreaker) abandon() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.trial = false
+}
+
+func (b *circuitBreaker) state() (string, int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.stateLocked(), b.failures
+}
+
+func (b *circuitBreaker) reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures, b.trial = 0, false
+}
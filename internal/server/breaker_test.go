@@ -0,0 +1,115 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/internal/weather"
+)
+
+// Fecha os circuit breakers abertos e descarta as estatísticas de outros testes
+func resetProviders() {
+	for _, p := range providers {
+		p.breaker.reset()
+		p.stats.reset()
+	}
+}
+
+func TestCircuitBreaker(t *testing.T) {
+	t.Setenv("CIRCUIT_BREAKER_THRESHOLD", "2")
+	t.Setenv("CIRCUIT_BREAKER_COOLDOWN", "30s")
+	now := time.Unix(1760000000, 0)
+	breaker := newCircuitBreaker("test")
+	breaker.now = func() time.Time { return now }
+
+	assert.True(t, breaker.allow())
+	breaker.record(false)
+	assert.True(t, breaker.allow(), "one failure keeps it closed")
+	breaker.record(false)
+
+	state, failures := breaker.state()
+	assert.Equal(t, breakerOpen, state)
+	assert.Equal(t, 2, failures)
+	assert.False(t, breaker.allow())
+
+	// Depois do cooldown, só uma chamada de teste passa
+	now = now.Add(30 * time.Second)
+	state, _ = breaker.state()
+	assert.Equal(t, breakerHalfOpen, state)
+	assert.True(t, breaker.allow())
+	assert.False(t, breaker.allow())
+
+	// Falha no teste: volta a abrir por mais um cooldown
+	breaker.record(false)
+	assert.False(t, breaker.allow())
+	now = now.Add(30 * time.Second)
+	assert.True(t, breaker.allow())
+
+	breaker.record(true)
+	state, failures = breaker.state()
+	assert.Equal(t, breakerClosed, state)
+	assert.Equal(t, 0, failures)
+}
+
+// Com a WeatherAPI fora do ar, o breaker aberto poupa as chamadas e a resposta
+// em cache, mesmo antiga, continua sendo servida
+func TestCallWeatherAPI_CircuitBreaker(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	withQuota(t, newQuotaBudget(0, 0))
+	t.Setenv("CIRCUIT_BREAKER_THRESHOLD", "2")
+
+	var calls atomic.Int32
+	failing := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if failing {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		w.Write([]byte(`{"current":{"temp_c":25}}`))
+	}))
+	defer server.Close()
+	s.weather = weather.NewClient(server.URL+"/v1", nil)
+
+	_, err := s.getCurrentWeather(context.Background(), "São Paulo", false)
+	assert.NoError(t, err)
+
+	failing = true
+	for i := 0; i < 2; i++ {
+		_, err = s.getCurrentWeather(context.Background(), "Rio de Janeiro", false)
+		assert.EqualError(t, err, "weather API error: status 502")
+	}
+	assert.EqualValues(t, 3, calls.Load())
+
+	_, err = s.getCurrentWeather(context.Background(), "Rio de Janeiro", false)
+	assert.ErrorIs(t, err, errCircuitOpen)
+	current, err := s.getCurrentWeather(context.Background(), "São Paulo", false)
+	assert.NoError(t, err)
+	assert.Equal(t, 25.0, current.Current.TempC)
+	assert.EqualValues(t, 3, calls.Load(), "no calls while the breaker is open")
+
+	status, body := httpError(weatherAPIFailure(errCircuitOpen))
+	assert.Equal(t, http.StatusServiceUnavailable, status)
+	assert.Equal(t, "weather api unavailable", body.Message)
+}
+
+// Erros do cliente (CEP ou localização inexistente) não abrem o breaker
+func TestCircuitBreaker_IgnoresClientErrors(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("CIRCUIT_BREAKER_THRESHOLD", "1")
+
+	_, err := s.getCurrentWeather(context.Background(), "Lugar Nenhum", false)
+	assert.EqualError(t, err, "weather API error: status 400")
+	_, err = s.getAddressByCEP(context.Background(), "99999999")
+	assert.ErrorIs(t, err, ErrCEPNotFound)
+
+	for _, p := range providers {
+		state, _ := p.breaker.state()
+		assert.Equal(t, breakerClosed, state, p.name)
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+const (
+	defaultWeatherCacheMaxEntries = 10000
+	defaultWeatherCacheRetention  = 24 * time.Hour
+	weatherCachePruneInterval     = time.Minute
+)
+
+// Tempo em que uma resposta da WeatherAPI é reaproveitada sem nova chamada
+// (WEATHER_CACHE_TTL). Padrão 0: toda requisição consulta a WeatherAPI
+func weatherCacheTTL() time.Duration {
+	return config.Duration("WEATHER_CACHE_TTL", 0)
+}
+
+// TTL próprio de cada tipo de dado: o clima atual muda em minutos, a
+// previsão em horas. Os demais endpoints usam WEATHER_CACHE_TTL
+var weatherCacheTTLSettings = map[string]string{
+	"current.json":  "WEATHER_CACHE_TTL_CURRENT",
+	"forecast.json": "WEATHER_CACHE_TTL_FORECAST",
+}
+
+// Tempo de reaproveitamento das respostas de um endpoint da WeatherAPI,
+// com WEATHER_CACHE_TTL quando o endpoint não tem TTL próprio definido
+func weatherCacheTTLFor(endpoint string) time.Duration {
+	if setting, ok := weatherCacheTTLSettings[endpoint]; ok {
+		return config.Duration(setting, weatherCacheTTL())
+	}
+	return weatherCacheTTL()
+}
+
+// Por quanto tempo uma resposta fica guardada: WEATHER_CACHE_RETENTION
+// (padrão 24h), ou o maior TTL configurado, se for maior. Depois do TTL a
+// entrada só serve de reserva (stale.go); depois disso, é descartada
+func weatherCacheRetention() time.Duration {
+	retention := config.Duration("WEATHER_CACHE_RETENTION", defaultWeatherCacheRetention)
+	for _, ttl := range []time.Duration{weatherCacheTTL(), weatherCacheTTLFor("current.json"), weatherCacheTTLFor("forecast.json")} {
+		if ttl > retention {
+			retention = ttl
+		}
+	}
+	return retention
+}
+
+// Aplica o limite de entradas e descarta as vencidas há mais que a retenção
+func (s *Server) pruneWeatherCache() {
+	s.weatherCache.SetLimit(config.Int("WEATHER_CACHE_MAX_ENTRIES", defaultWeatherCacheMaxEntries))
+	if pruned := s.weatherCache.Prune(weatherCacheRetention()); pruned > 0 {
+		log.Printf("Pruned %d weather API cache entries", pruned)
+	}
+}
+
+// Limpa o cache da WeatherAPI a cada minuto até ctx terminar. A cada volta
+// relê as configurações, então um reload vale em até um minuto
+func (s *Server) runWeatherCachePruner(ctx context.Context) {
+	s.pruneWeatherCache()
+	ticker := time.NewTicker(weatherCachePruneInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.pruneWeatherCache()
+		}
+	}
+}
+
+type cacheRefreshContextKey struct{}
+
+// Consultas feitas com este contexto ignoram respostas em cache ainda dentro
+// do TTL e as substituem pela da WeatherAPI. Usado pelo agendador, que existe
+// justamente para renovar o cache antes que ele expire
+func withCacheRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, cacheRefreshContextKey{}, true)
+}
+
+func cacheRefresh(ctx context.Context) bool {
+	refresh, _ := ctx.Value(cacheRefreshContextKey{}).(bool)
+	return refresh
+}
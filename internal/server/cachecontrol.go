@@ -0,0 +1,90 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+// Tempo que navegadores e CDNs podem reaproveitar cada tipo de resposta.
+// O histórico é de dias passados e praticamente não muda
+var (
+	defaultWeatherMaxAge   = 5 * time.Minute
+	defaultAlertsMaxAge    = 5 * time.Minute
+	defaultAstronomyMaxAge = time.Hour
+	defaultHistoryMaxAge   = 24 * time.Hour
+)
+
+// Emite Cache-Control e Expires apenas em respostas de sucesso; erros não
+// devem ficar guardados em caches intermediários. O tempo é lido da variável
+// a cada requisição, para que um reload da configuração valha na hora. Com
+// alguma autenticação configurada a resposta é private: um cache
+// compartilhado (CDN, proxy) não pode entregá-la a quem não se autenticou
+func cacheControl(setting string, fallback time.Duration) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			maxAge := config.Duration(setting, fallback)
+			next.ServeHTTP(&cacheControlWriter{ResponseWriter: w, maxAge: maxAge, private: authConfigured()}, r)
+		})
+	}
+}
+
+// Se alguma forma de autenticação dos endpoints de dados está configurada:
+// chaves de API, tenants, JWT, assinatura HMAC ou certificado de cliente
+func authConfigured() bool {
+	for _, setting := range []string{
+		"API_KEYS", "API_KEYS_FILE", "API_KEYS_REDIS_URL", "TENANTS_FILE",
+		"JWT_HS256_SECRET", "JWT_JWKS_URL", "HMAC_SECRET", "TLS_CLIENT_CA_FILE",
+	} {
+		if config.String(setting) != "" {
+			return true
+		}
+	}
+	return false
+}
+
+type cacheControlWriter struct {
+	http.ResponseWriter
+	maxAge      time.Duration
+	private     bool
+	wroteHeader bool
+}
+
+func (w *cacheControlWriter) WriteHeader(status int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if status < http.StatusBadRequest {
+			w.setCacheHeaders()
+		}
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *cacheControlWriter) Write(p []byte) (int, error) {
+	if !w.wroteHeader {
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *cacheControlWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *cacheControlWriter) setCacheHeaders() {
+	if w.maxAge == 0 {
+		w.Header().Set("Cache-Control", "no-cache")
+		return
+	}
+
+	scope := "public"
+	if w.private {
+		scope = "private"
+		w.Header().Add("Vary", "Authorization, "+apiKeyHeader)
+	}
+	seconds := int(w.maxAge.Seconds())
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", scope, seconds))
+	w.Header().Set("Expires", time.Now().Add(w.maxAge).UTC().Format(http.TimeFormat))
+}
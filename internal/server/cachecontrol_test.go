@@ -0,0 +1,93 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCacheControl_Routes(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+		"/v1/alerts.json":    `{"alerts":{"alert":[]}}`,
+	})
+	t.Setenv("CACHE_MAX_AGE_ALERTS", "0")
+
+	tests := []struct {
+		name                 string
+		path                 string
+		expectedStatus       int
+		expectedCacheControl string
+	}{
+		{"Weather", "/v1/weather/01310100", http.StatusOK, "public, max-age=300"},
+		{"Alerts with cache disabled", "/v1/alerts/01310100", http.StatusOK, "no-cache"},
+		{"Errors are not cached", "/v1/weather/123", http.StatusUnprocessableEntity, ""},
+		{"Health has no cache headers", "/", http.StatusOK, ""},
+	}
+
+	router := s.router()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.path, nil)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedCacheControl, rr.Header().Get("Cache-Control"))
+		})
+	}
+}
+
+func TestCacheControl_Headers(t *testing.T) {
+	tests := []struct {
+		name                 string
+		maxAge               string
+		expectedCacheControl string
+		expectsExpires       bool
+	}{
+		{"Max age", "24h", "public, max-age=86400", true},
+		{"Disabled", "0", "no-cache", false},
+		{"Default", "", "public, max-age=3600", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CACHE_MAX_AGE_HISTORY", tt.maxAge)
+			handler := cacheControl("CACHE_MAX_AGE_HISTORY", time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Write([]byte("ok"))
+			}))
+
+			req, err := http.NewRequest("GET", "/", nil)
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedCacheControl, rr.Header().Get("Cache-Control"))
+			assert.Equal(t, tt.expectsExpires, rr.Header().Get("Expires") != "")
+		})
+	}
+}
+
+// Com autenticação configurada, só o cache do próprio cliente guarda a resposta
+func TestCacheControl_Private(t *testing.T) {
+	handler := cacheControl("CACHE_MAX_AGE_HISTORY", time.Hour)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	}))
+
+	for _, setting := range []string{"API_KEYS", "JWT_HS256_SECRET", "HMAC_SECRET", "TENANTS_FILE"} {
+		t.Run(setting, func(t *testing.T) {
+			t.Setenv(setting, "configured")
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+
+			assert.Equal(t, "private, max-age=3600", rr.Header().Get("Cache-Control"))
+			assert.Equal(t, "Authorization, X-API-Key", rr.Header().Get("Vary"))
+		})
+	}
+}
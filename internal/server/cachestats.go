@@ -0,0 +1,249 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/weather-service/internal/cache"
+)
+
+// Chaves listadas por cache em /admin/cache sem ?limit=
+const defaultCacheKeysLimit = 100
+
+const maxCacheKeysLimit = 10000
+
+type CacheStatsResponse struct {
+	Caches []CacheStats `json:"caches" xml:"cache"`
+}
+
+// Uso de um cache desde que o processo subiu
+type CacheStats struct {
+	Name string `json:"name" xml:"name"`
+	// 0 quando as entradas não vencem. Ausente quando o TTL varia com o tipo
+	// de dado, listado em TTLs
+	TTLSeconds *float64   `json:"ttl_seconds,omitempty" xml:"ttl_seconds,omitempty"`
+	TTLs       []CacheTTL `json:"ttls,omitempty" xml:"ttl,omitempty"`
+	Entries    int        `json:"entries" xml:"entries"`
+	Hits       int64      `json:"hits" xml:"hits"`
+	Misses     int64      `json:"misses" xml:"misses"`
+	// Entradas vencidas servidas porque o provedor falhou
+	Stale     int64      `json:"stale" xml:"stale"`
+	Evictions int64      `json:"evictions" xml:"evictions"`
+	HitRatio  float64    `json:"hit_ratio" xml:"hit_ratio"`
+	Keys      []CacheKey `json:"keys,omitempty" xml:"key,omitempty"`
+}
+
+// TTL de um tipo de dado de um cache
+type CacheTTL struct {
+	Data       string  `json:"data" xml:"data"`
+	TTLSeconds float64 `json:"ttl_seconds" xml:"ttl_seconds"`
+}
+
+type CacheKey struct {
+	Key      string     `json:"key" xml:"key"`
+	StoredAt *time.Time `json:"stored_at,omitempty" xml:"stored_at,omitempty"`
+	// Segundos até vencer; negativo quando já venceu e só serve de reserva
+	ExpiresInSeconds *float64 `json:"expires_in_seconds,omitempty" xml:"expires_in_seconds,omitempty"`
+	Size             int      `json:"size,omitempty" xml:"size,omitempty"`
+}
+
+// Um cache do serviço: como contar as entradas e listar as chaves
+type cacheSource struct {
+	name string
+	ttl  time.Duration
+	// Quando o TTL varia com o tipo de dado: o de cada tipo e o de cada
+	// chave. Sem eles vale ttl
+	dataTTLs []CacheTTL
+	keyTTL   func(key string) time.Duration
+	counters *cache.Counters
+	count    func() (int, error)
+	keys     func(limit int) ([]cache.Key, error)
+}
+
+// Caches em uso: as respostas da WeatherAPI, os CEPs inexistentes, os
+// municípios do IBGE e, quando configurado, os endereços
+func (s *Server) cacheSources() []cacheSource {
+	sources := []cacheSource{{
+		name:     "weather_api",
+		dataTTLs: weatherCacheDataTTLs(),
+		keyTTL:   weatherCacheKeyTTL,
+		counters: &s.weatherCache.Counters,
+		count:    func() (int, error) { return s.weatherCache.Len(), nil },
+		keys:     func(limit int) ([]cache.Key, error) { return s.weatherCache.Keys(limit), nil },
+	}}
+	if s.addresses != nil {
+		sources = append(sources, cacheSource{
+			name:     "address",
+			ttl:      cepCacheTTL(),
+			counters: &addressCacheCounters,
+			count:    s.addresses.count,
+			keys:     s.addresses.keys,
+		})
+	}
+	sources = append(sources, cacheSource{
+		name:     "cep_not_found",
+		ttl:      cepNotFoundTTL(),
+		counters: &s.notFoundCEPs.Counters,
+		count:    func() (int, error) { return s.notFoundCEPs.Len(), nil },
+		keys:     func(limit int) ([]cache.Key, error) { return s.notFoundCEPs.Keys(limit), nil },
+	})
+	return append(sources, cacheSource{
+		name:     "ibge",
+		counters: &ibgeCacheCounters,
+		count:    func() (int, error) { return len(s.ibgeCacheKeys(0)), nil },
+		keys:     func(limit int) ([]cache.Key, error) { return s.ibgeCacheKeys(limit), nil },
+	})
+}
+
+// TTL de cada tipo de dado do cache da WeatherAPI; other vale para os
+// endpoints sem TTL próprio (astronomia, alertas e histórico)
+func weatherCacheDataTTLs() []CacheTTL {
+	return []CacheTTL{
+		{Data: "current", TTLSeconds: weatherCacheTTLFor("current.json").Seconds()},
+		{Data: "forecast", TTLSeconds: weatherCacheTTLFor("forecast.json").Seconds()},
+		{Data: "other", TTLSeconds: weatherCacheTTL().Seconds()},
+	}
+}
+
+// TTL de uma resposta da WeatherAPI, pelo endpoint no início da chave
+func weatherCacheKeyTTL(key string) time.Duration {
+	endpoint, _, _ := strings.Cut(key, "?")
+	return weatherCacheTTLFor(endpoint)
+}
+
+// Códigos IBGE já resolvidos, sem momento de gravação: não vencem
+func (s *Server) ibgeCacheKeys(limit int) []cache.Key {
+	var keys []cache.Key
+	s.municipalities.Range(func(code, _ interface{}) bool {
+		keys = append(keys, cache.Key{Key: code.(string)})
+		return limit <= 0 || len(keys) < limit
+	})
+	return keys
+}
+
+// Estatísticas de um cache e, com limit maior que zero, até limit chaves
+func (s cacheSource) stats(limit int) (CacheStats, error) {
+	counters := s.counters.Stats()
+	stats := CacheStats{
+		Name:      s.name,
+		TTLs:      s.dataTTLs,
+		Hits:      counters.Hits,
+		Misses:    counters.Misses,
+		Stale:     counters.Stale,
+		Evictions: counters.Evictions,
+		HitRatio:  counters.HitRatio(),
+	}
+	if s.dataTTLs == nil {
+		ttl := s.ttl.Seconds()
+		stats.TTLSeconds = &ttl
+	}
+	entries, err := s.count()
+	if err != nil {
+		return stats, fmt.Errorf("failed to count %s cache entries: %w", s.name, err)
+	}
+	stats.Entries = entries
+	if limit <= 0 {
+		return stats, nil
+	}
+
+	keys, err := s.keys(limit)
+	if err != nil {
+		return stats, fmt.Errorf("failed to list %s cache keys: %w", s.name, err)
+	}
+	now := time.Now()
+	for _, key := range keys {
+		item := CacheKey{Key: key.Key, Size: key.Size}
+		if !key.StoredAt.IsZero() {
+			storedAt := key.StoredAt.UTC()
+			item.StoredAt = &storedAt
+			ttl := s.ttl
+			if s.keyTTL != nil {
+				ttl = s.keyTTL(key.Key)
+			}
+			if ttl > 0 {
+				expiresIn := key.StoredAt.Add(ttl).Sub(now).Round(time.Second).Seconds()
+				item.ExpiresInSeconds = &expiresIn
+			}
+		}
+		stats.Keys = append(stats.Keys, item)
+	}
+	return stats, nil
+}
+
+// GET /admin/cache: entradas, hits, misses e evictions de cada cache e as
+// chaves com o tempo até vencer, para ajustar os TTLs com dados. ?limit=
+// define quantas chaves listar por cache (padrão 100)
+func (s *Server) cacheStatsHandler(w http.ResponseWriter, r *http.Request) {
+	limit := defaultCacheKeysLimit
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 || n > maxCacheKeysLimit {
+			writeResponse(w, r, http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid pagination"})
+			return
+		}
+		limit = n
+	}
+
+	response := CacheStatsResponse{Caches: []CacheStats{}}
+	for _, source := range s.cacheSources() {
+		stats, err := source.stats(limit)
+		if err != nil {
+			log.Printf("ERROR: %v", err)
+			writeResponse(w, r, http.StatusInternalServerError, ErrorResponse{Message: "internal server error"})
+			return
+		}
+		response.Caches = append(response.Caches, stats)
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// GET /metrics: estatísticas dos caches no formato texto do Prometheus
+func (s *Server) metricsHandler(w http.ResponseWriter, r *http.Request) {
+	var caches []CacheStats
+	for _, source := range s.cacheSources() {
+		stats, err := source.stats(0)
+		if err != nil {
+			// Um cache ilegível não derruba as métricas dos demais
+			log.Printf("ERROR: %v", err)
+			continue
+		}
+		caches = append(caches, stats)
+	}
+
+	var b strings.Builder
+	metric := func(name, kind, help string, value func(CacheStats) float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+		for _, stats := range caches {
+			fmt.Fprintf(&b, "%s{cache=%q} %s\n", name, stats.Name, strconv.FormatFloat(value(stats), 'g', -1, 64))
+		}
+	}
+	metric("weather_cache_entries", "gauge", "Entries currently cached.", func(c CacheStats) float64 { return float64(c.Entries) })
+	metric("weather_cache_hits_total", "counter", "Lookups served by an entry within the TTL.", func(c CacheStats) float64 { return float64(c.Hits) })
+	metric("weather_cache_misses_total", "counter", "Lookups that went to the provider.", func(c CacheStats) float64 { return float64(c.Misses) })
+	metric("weather_cache_stale_total", "counter", "Expired entries served because the provider failed.", func(c CacheStats) float64 { return float64(c.Stale) })
+	metric("weather_cache_evictions_total", "counter", "Entries discarded by a cache flush or by pruning.", func(c CacheStats) float64 { return float64(c.Evictions) })
+	metric("weather_cache_hit_ratio", "gauge", "Hits over hits plus misses since startup.", func(c CacheStats) float64 { return c.HitRatio })
+	ttlMetric(&b, caches)
+
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	w.Write([]byte(b.String()))
+}
+
+// TTL de cada cache e, nos caches com TTL por tipo de dado, uma série por
+// tipo com o rótulo data
+func ttlMetric(b *strings.Builder, caches []CacheStats) {
+	const name = "weather_cache_ttl_seconds"
+	fmt.Fprintf(b, "# HELP %s Configured TTL, 0 when entries never expire.\n# TYPE %s gauge\n", name, name)
+	for _, stats := range caches {
+		if stats.TTLSeconds != nil {
+			fmt.Fprintf(b, "%s{cache=%q} %s\n", name, stats.Name, strconv.FormatFloat(*stats.TTLSeconds, 'g', -1, 64))
+		}
+		for _, ttl := range stats.TTLs {
+			fmt.Fprintf(b, "%s{cache=%q,data=%q} %s\n", name, stats.Name, ttl.Data, strconv.FormatFloat(ttl.TTLSeconds, 'g', -1, 64))
+		}
+	}
+}
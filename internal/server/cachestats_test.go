@@ -0,0 +1,127 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Estatísticas de um cache em /admin/cache
+func cacheStatsOf(t *testing.T, router http.Handler, name string) CacheStats {
+	t.Helper()
+	rr := adminRequest(t, router, "GET", "/admin/cache")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response CacheStatsResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	for _, stats := range response.Caches {
+		if stats.Name == name {
+			return stats
+		}
+	}
+	t.Fatalf("cache %s not listed", name)
+	return CacheStats{}
+}
+
+func TestCacheStatsHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+	t.Setenv("WEATHER_CACHE_TTL", "5m")
+	withAddressCache(t, s)
+
+	router := s.router()
+	before := cacheStatsOf(t, router, "weather_api")
+	beforeAddress := cacheStatsOf(t, router, "address")
+	for i := 0; i < 3; i++ {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	stats := cacheStatsOf(t, router, "weather_api")
+	assert.Nil(t, stats.TTLSeconds)
+	assert.Equal(t, []CacheTTL{{"current", 300}, {"forecast", 300}, {"other", 300}}, stats.TTLs)
+	assert.Equal(t, 1, stats.Entries)
+	assert.Equal(t, int64(2), stats.Hits-before.Hits)
+	assert.Equal(t, int64(1), stats.Misses-before.Misses)
+	if assert.Len(t, stats.Keys, 1) {
+		key := stats.Keys[0]
+		assert.Equal(t, "current.json?aqi=no&q=S%C3%A3o+Paulo%2CSP", key.Key)
+		assert.WithinDuration(t, time.Now(), *key.StoredAt, time.Minute)
+		assert.InDelta(t, 300, *key.ExpiresInSeconds, 5)
+		assert.Equal(t, len(`{"current":{"temp_c":25}}`), key.Size)
+	}
+
+	address := cacheStatsOf(t, router, "address")
+	assert.Equal(t, cepCacheTTL().Seconds(), *address.TTLSeconds)
+	assert.Equal(t, 1, address.Entries)
+	assert.Equal(t, int64(2), address.Hits-beforeAddress.Hits)
+	assert.Equal(t, "01310100", address.Keys[0].Key)
+
+	rr := adminRequest(t, router, "GET", "/admin/cache?limit=0")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), `"keys"`)
+
+	rr = adminRequest(t, router, "GET", "/admin/cache?limit=-1")
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+}
+
+func TestMetricsHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ibge/municipios/3550308": saoPauloIBGE,
+		"/v1/current.json":         `{"current":{"temp_c":25}}`,
+	})
+
+	router := s.router()
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/weather/ibge/3550308", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, strings.HasPrefix(rr.Header().Get("Content-Type"), "text/plain; version=0.0.4"))
+
+	body := rr.Body.String()
+	assert.Contains(t, body, "# TYPE weather_cache_hits_total counter\n")
+	assert.Contains(t, body, `weather_cache_entries{cache="weather_api"} 1`+"\n")
+	assert.Contains(t, body, `weather_cache_entries{cache="ibge"} 1`+"\n")
+	assert.Contains(t, body, `weather_cache_ttl_seconds{cache="ibge"} 0`+"\n")
+	assert.Contains(t, body, `weather_cache_ttl_seconds{cache="weather_api",data="current"} 0`+"\n")
+	assert.NotContains(t, body, `weather_cache_ttl_seconds{cache="weather_api"}`)
+	// Sem CEP_CACHE_PATH não há cache de endereços
+	assert.NotContains(t, body, `cache="address"`)
+}
+
+// Cada chave vence conforme o TTL do seu tipo de dado
+func TestCacheStatsHandler_KeyTTL(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+	t.Setenv("WEATHER_CACHE_TTL", "5m")
+	t.Setenv("WEATHER_CACHE_TTL_CURRENT", "10m")
+
+	router := s.router()
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	stats := cacheStatsOf(t, router, "weather_api")
+	assert.Equal(t, []CacheTTL{{"current", 600}, {"forecast", 300}, {"other", 300}}, stats.TTLs)
+	if assert.Len(t, stats.Keys, 1) {
+		assert.InDelta(t, 600, *stats.Keys[0].ExpiresInSeconds, 5)
+	}
+	rr = httptest.NewRecorder()
+	router.ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	assert.Contains(t, rr.Body.String(), `weather_cache_ttl_seconds{cache="weather_api",data="current"} 600`+"\n")
+	assert.Contains(t, rr.Body.String(), `weather_cache_ttl_seconds{cache="weather_api",data="forecast"} 300`+"\n")
+}
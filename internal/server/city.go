@@ -0,0 +1,61 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/weather-service/internal/cep"
+	"github.com/weather-service/internal/weather"
+)
+
+// Clima atual pela cidade e UF, sem passar pela consulta do CEP, com a mesma
+// resposta e os mesmos parâmetros de /weather/{cep}
+func (s *Server) weatherByCityHandler(w http.ResponseWriter, r *http.Request) {
+	r = r.WithContext(startReading(r.Context()))
+	units, ok := unitsFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	city, uf, err := cityParams(chi.URLParam(r, "city"), chi.URLParam(r, "uf"))
+	if err != nil {
+		log.Printf("Invalid city or state: %s/%s", chi.URLParam(r, "uf"), chi.URLParam(r, "city"))
+		status, body := httpError(err)
+		writeResponse(w, r, status, body)
+		return
+	}
+	location := city + "," + uf
+
+	response, tempC, ok := s.currentWeatherResponse(w, r, location, units)
+	if !ok {
+		return
+	}
+	response.city = city
+
+	log.Printf("Successfully processed city %s: %.1f°C, %.1f°F, %.1f°K", location, tempC, weather.CelsiusToFahrenheit(tempC), weather.CelsiusToKelvin(tempC))
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// Valida a UF contra a lista de estados e normaliza a cidade. Uma cidade da
+// tabela de CEPs embutida fica com o nome de lá ("sao paulo" vira "São
+// Paulo"), o mesmo que o ViaCEP devolve, então a consulta compartilha o cache
+// com as feitas por CEP
+func cityParams(city, uf string) (string, string, error) {
+	uf = strings.ToUpper(strings.TrimSpace(uf))
+	if !cep.ValidUF(uf) {
+		return "", "", ErrInvalidState
+	}
+
+	// A vírgula separaria cidade e UF na consulta à WeatherAPI
+	city = strings.TrimSpace(city)
+	if city == "" || strings.Contains(city, ",") {
+		return "", "", ErrInvalidCity
+	}
+	if address, ok := cep.Embedded().FindCity(city, uf); ok {
+		city = address.City
+	}
+	return city, uf, nil
+}
@@ -0,0 +1,48 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeatherByCityHandler(t *testing.T) {
+	s := newFakeClientsServer(t, nil, fakeWeatherClient{"São Paulo,SP": 25, "Maringá,PR": 30})
+
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		expected string
+	}{
+		{"city and state", "/weather/city/SP/S%C3%A3o%20Paulo", http.StatusOK, `{"temp_C":25,"temp_F":77,"temp_K":298.15}`},
+		{"name from the embedded table", "/weather/city/sp/sao%20paulo?units=c", http.StatusOK, `{"temp_C":25}`},
+		{"city outside the table", "/weather/city/PR/Maring%C3%A1", http.StatusOK, `{"temp_C":30,"temp_F":86,"temp_K":303.15}`},
+		{"unknown state", "/weather/city/XX/S%C3%A3o%20Paulo", http.StatusUnprocessableEntity, `{"message":"invalid state"}`},
+		{"blank city", "/weather/city/SP/%20", http.StatusUnprocessableEntity, `{"message":"invalid city"}`},
+		{"city with comma", "/weather/city/SP/Rio%20de%20Janeiro,RJ", http.StatusUnprocessableEntity, `{"message":"invalid city"}`},
+		{"unknown city", "/weather/city/SP/Nowhere", http.StatusInternalServerError, `{"message":"error fetching weather data"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+			assert.Equal(t, tt.status, rr.Code)
+			assert.JSONEq(t, tt.expected, rr.Body.String())
+		})
+	}
+}
+
+func TestWeatherByCityHandler_CSV(t *testing.T) {
+	s := newFakeClientsServer(t, nil, fakeWeatherClient{"São Paulo,SP": 25})
+
+	req := httptest.NewRequest("GET", "/v1/weather/city/SP/sao%20paulo", nil)
+	req.Header.Set("Accept", "text/csv")
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "cep,city,temp_C,temp_F,temp_K\n,São Paulo,25,77,298.15\n", rr.Body.String())
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/weather-service/config"
+)
+
+// Cliente do Vault configurado na inicialização, que mantém os segredos
+// atualizados enquanto o servidor roda
+var secretsVault *vaultClient
+
+// Versão do binário, recebida de cmd/server em Execute
+var version = "dev"
+
+// Executa o CLI com a versão do binário. Sem subcomando, sobe o servidor
+func (s *Server) Execute(binaryVersion string) error {
+	version = binaryVersion
+	return s.newRootCommand().Execute()
+}
+
+// Sem subcomando o binário sobe o servidor, como antes do CLI existir
+func (s *Server) newRootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:               "weather-service",
+		Short:             "Consulta de clima por CEP",
+		SilenceUsage:      true,
+		PersistentPreRunE: loadConfig,
+		RunE:              s.serve,
+	}
+
+	flags := root.PersistentFlags()
+	flags.String("config", "", "arquivo de configuração YAML/TOML (CONFIG_FILE)")
+	// Segredos ficam de fora: flags aparecem na lista de processos
+	for _, setting := range config.Settings {
+		if !setting.Secret {
+			flags.String(settingFlag(setting.Name), "", "sobrescreve "+setting.Name)
+		}
+	}
+
+	root.AddCommand(
+		&cobra.Command{
+			Use:   "serve",
+			Short: "Sobe o servidor HTTP",
+			Args:  cobra.NoArgs,
+			RunE:  s.serve,
+		},
+		s.newLookupCommand(),
+		newBenchCommand(),
+		&cobra.Command{
+			Use:   "version",
+			Short: "Mostra a versão do binário",
+			Args:  cobra.NoArgs,
+			// Não depende da configuração
+			PersistentPreRun: func(*cobra.Command, []string) {},
+			Run: func(cmd *cobra.Command, args []string) {
+				fmt.Fprintln(cmd.OutOrStdout(), versionString())
+			},
+		},
+	)
+	return root
+}
+
+// PORT vira --port, HTTP_READ_TIMEOUT vira --http-read-timeout
+func settingFlag(name string) string {
+	return strings.ToLower(strings.ReplaceAll(name, "_", "-"))
+}
+
+// Flags têm precedência sobre o ambiente, que tem precedência sobre o Vault
+// e o arquivo
+func loadConfig(cmd *cobra.Command, args []string) error {
+	flags := cmd.Flags()
+	for _, setting := range config.Settings {
+		if flag := flags.Lookup(settingFlag(setting.Name)); flag != nil && flag.Changed {
+			config.Set(config.SourceFlag, setting.Name, flag.Value.String())
+		}
+	}
+
+	path := config.String("CONFIG_FILE")
+	if flag := flags.Lookup("config"); flag != nil && flag.Changed {
+		path = flag.Value.String()
+	}
+	if err := config.Load(path); err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	if vault := vaultClientFromEnv(); vault != nil {
+		if err := vault.load(); err != nil {
+			return fmt.Errorf("failed to load secrets from vault: %w", err)
+		}
+		log.Printf("Loaded secrets from vault at %s", vault.addr)
+		secretsVault = vault
+
+		if err := config.Validate(); err != nil {
+			return fmt.Errorf("invalid configuration:\n%w", err)
+		}
+	}
+
+	if err := resolveSecretURIs(); err != nil {
+		return fmt.Errorf("failed to load secrets from Secret Manager: %w", err)
+	}
+	return nil
+}
+
+func (s *Server) serve(cmd *cobra.Command, args []string) error {
+	log.SetOutput(levelWriter{out: log.Writer()})
+	if err := s.checkStartup(); err != nil {
+		return err
+	}
+	reloadOnSignal()
+	if secretsVault != nil {
+		go secretsVault.keepFresh()
+	}
+	store, err := lookupStoreFromEnv()
+	if err != nil {
+		return err
+	}
+	if store != nil {
+		s.lookups = store
+		defer store.Close()
+		if sqlStore, ok := store.(*sqlLookupStore); ok {
+			s.subscriptions = newSQLSubscriptionStore(sqlStore)
+		}
+	}
+	publishers, err := readingPublishersFromEnv()
+	if err != nil {
+		return err
+	}
+	s.publishers = publishers
+	defer s.closeReadingPublishers()
+	cache, err := addressCacheFromEnv()
+	if err != nil {
+		return err
+	}
+	if cache != nil {
+		s.addresses = cache
+		defer cache.Close()
+	}
+	go s.warmCache(context.Background())
+	go s.runWeatherCachePruner(context.Background())
+	go s.runSubscriptionPoller(context.Background())
+	go s.runScheduledRefresh(context.Background())
+	if bot := telegramBotFromEnv(); bot != nil {
+		go s.runTelegramBot(context.Background(), bot)
+	}
+	nc, err := natsConnFromEnv()
+	if err != nil {
+		return fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	if nc != nil {
+		defer nc.Close()
+		go func() {
+			if err := s.runNATSResponder(context.Background(), nc); err != nil {
+				log.Printf("ERROR: NATS responder stopped: %v", err)
+			}
+		}()
+	}
+	s.storesReady.Store(true)
+	return runServer(s.router())
+}
+
+// Executa uma consulta CEP → temperatura sem subir o servidor
+func (s *Server) newLookupCommand() *cobra.Command {
+	var unitsFlag, output string
+
+	cmd := &cobra.Command{
+		Use:   "lookup CEP",
+		Short: "Consulta a temperatura atual de um CEP",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if output != "json" && output != "table" {
+				return fmt.Errorf("unknown output %q (json or table)", output)
+			}
+			units, err := parseUnits(unitsFlag)
+			if err != nil {
+				return err
+			}
+
+			response, err := s.lookupWeather(cmd.Context(), args[0], units)
+			if err != nil {
+				return err
+			}
+
+			if output == "table" {
+				return writeLookupTable(cmd.OutOrStdout(), response)
+			}
+			encoder := json.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent("", "  ")
+			return encoder.Encode(response)
+		},
+	}
+	cmd.Flags().StringVar(&unitsFlag, "units", "", "escalas de temperatura separadas por vírgula (c,f,k,r)")
+	cmd.Flags().StringVarP(&output, "output", "o", "json", "formato da saída: json ou table")
+	return cmd
+}
+
+// CEP, cidade e uma linha por escala pedida, para leitura no terminal
+func writeLookupTable(out io.Writer, response *WeatherResponse) error {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "cep\t%s\n", response.cep)
+	fmt.Fprintf(w, "city\t%s\n", response.city)
+	for _, temperature := range []struct {
+		name  string
+		value *float64
+	}{
+		{"temp_C", response.TempC},
+		{"temp_F", response.TempF},
+		{"temp_K", response.TempK},
+		{"temp_R", response.TempR},
+	} {
+		if temperature.value != nil {
+			fmt.Fprintf(w, "%s\t%s\n", temperature.name, formatTemperature(*temperature.value))
+		}
+	}
+	return w.Flush()
+}
+
+// Versão, revisão do git (quando o build foi feito a partir de um checkout)
+// e versão do Go
+func versionString() string {
+	parts := []string{"weather-service " + version}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" && len(setting.Value) >= 12 {
+				parts = append(parts, "("+setting.Value[:12]+")")
+			}
+		}
+	}
+	return strings.Join(append(parts, runtime.Version()), " ")
+}
@@ -0,0 +1,140 @@
+package server
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/config"
+)
+
+// Executa o CLI com os argumentos informados e retorna a saída padrão
+func runCLI(t *testing.T, s *Server, args ...string) (string, error) {
+	t.Helper()
+
+	clearConfigLayer(t, config.SourceFlag)
+
+	var out bytes.Buffer
+	cmd := s.newRootCommand()
+	cmd.SetArgs(args)
+	cmd.SetOut(&out)
+	cmd.SetErr(&bytes.Buffer{})
+	err := cmd.Execute()
+	return out.String(), err
+}
+
+// Os valores de flags, Vault e Secret Manager ficam na configuração do
+// processo; ao fim do teste a camada é esvaziada para não vazar para outros
+func clearConfigLayer(t *testing.T, source string) {
+	t.Cleanup(func() {
+		for _, setting := range config.Settings {
+			config.Unset(source, setting.Name)
+		}
+	})
+}
+
+func TestCLI_Version(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	out, err := runCLI(t, s, "version")
+	assert.NoError(t, err)
+	assert.Contains(t, out, "weather-service dev")
+}
+
+func TestCLI_Lookup(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+
+	out, err := runCLI(t, s, "lookup", "01310100", "--units", "c,f")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"temp_C":25,"temp_F":77}`, out)
+}
+
+func TestCLI_LookupTable(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+
+	out, err := runCLI(t, s, "lookup", "01310-100", "--units", "c,f,r", "--output", "table")
+	assert.NoError(t, err)
+	// Sem TEMP_PRECISION, os mesmos valores do JSON
+	assert.Equal(t, "cep     01310-100\ncity    São Paulo\ntemp_C  25\ntemp_F  77\ntemp_R  536.6700000000001\n", out)
+
+	// Com TEMP_PRECISION, as casas são as mesmas das respostas HTTP
+	t.Setenv("TEMP_PRECISION", "1")
+	out, err = runCLI(t, s, "lookup", "01310-100", "--units", "c,f,r", "--output", "table")
+	assert.NoError(t, err)
+	assert.Equal(t, "cep     01310-100\ncity    São Paulo\ntemp_C  25.0\ntemp_F  77.0\ntemp_R  536.7\n", out)
+}
+
+func TestCLI_LookupErrors(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	tests := []struct {
+		name     string
+		args     []string
+		expected string
+	}{
+		{"invalid CEP", []string{"lookup", "123"}, "invalid zipcode"},
+		{"invalid units", []string{"lookup", "01310100", "--units", "x"}, `unknown temperature unit "x"`},
+		{"missing CEP", []string{"lookup"}, "accepts 1 arg(s), received 0"},
+		{"invalid output", []string{"lookup", "01310100", "--output", "yaml"}, `unknown output "yaml" (json or table)`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := runCLI(t, s, tt.args...)
+			assert.EqualError(t, err, tt.expected)
+		})
+	}
+}
+
+func TestCLI_FlagsOverrideEnvironment(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25.26}}`,
+	})
+	t.Setenv("TEMP_PRECISION", "0")
+
+	out, err := runCLI(t, s, "--temp-precision", "1", "lookup", "01310100", "--units", "c")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"temp_C":25.3}`, out)
+	assert.Equal(t, "1", config.String("TEMP_PRECISION"))
+}
+
+// As variáveis globais do pacote são criadas antes das flags serem lidas;
+// a cota global ainda assim usa o orçamento da flag
+func TestCLI_FlagsReachPackageState(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	t.Setenv("WEATHER_API_DAILY_BUDGET", "")
+	withQuota(t, configuredQuotaBudget())
+
+	_, err := runCLI(t, s, "--weather-api-daily-budget", "5", "lookup", "01310100")
+	assert.NoError(t, err)
+	daily := weatherAPIQuota.status().Daily
+	assert.Equal(t, 5, daily.Budget)
+	assert.Equal(t, 1, daily.Used)
+}
+
+func TestCLI_InvalidConfiguration(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("GZIP_MIN_SIZE", "")
+
+	_, err := runCLI(t, s, "--gzip-min-size", "big", "lookup", "01310100")
+	assert.EqualError(t, err, "invalid configuration:\ninvalid GZIP_MIN_SIZE \"big\": must be an integer")
+}
+
+func TestCLI_SecretsAreNotFlags(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	_, err := runCLI(t, s, "--weather-api-key", "secret", "version")
+	assert.EqualError(t, err, "unknown flag: --weather-api-key")
+}
+
+func TestSettingFlag(t *testing.T) {
+	assert.Equal(t, "port", settingFlag("PORT"))
+	assert.Equal(t, "http-read-header-timeout", settingFlag("HTTP_READ_HEADER_TIMEOUT"))
+}
@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// Um dos lados da comparação
+type CompareLocation struct {
+	CEP   string  `json:"cep" xml:"cep"`
+	City  string  `json:"city" xml:"city"`
+	TempC float64 `json:"temp_C" xml:"temp_C"`
+	TempF float64 `json:"temp_F" xml:"temp_F"`
+	TempK float64 `json:"temp_K" xml:"temp_K"`
+}
+
+// Diferença cep2 − cep1: positiva quando o segundo CEP está mais quente
+type TemperatureDelta struct {
+	TempC float64 `json:"temp_C" xml:"temp_C"`
+	TempF float64 `json:"temp_F" xml:"temp_F"`
+	TempK float64 `json:"temp_K" xml:"temp_K"`
+}
+
+type CompareResponse struct {
+	CEP1  CompareLocation  `json:"cep1" xml:"cep1"`
+	CEP2  CompareLocation  `json:"cep2" xml:"cep2"`
+	Delta TemperatureDelta `json:"delta" xml:"delta"`
+}
+
+// Temperatura atual de dois CEPs e a diferença entre eles, para quem está
+// decidindo uma mudança ou uma viagem
+func (s *Server) compareHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	ceps := [2]string{strings.TrimSpace(query.Get("cep1")), strings.TrimSpace(query.Get("cep2"))}
+	log.Printf("Received comparison request for CEPs %s and %s", ceps[0], ceps[1])
+
+	locations, err := s.compareCEPs(r.Context(), ceps)
+	if err != nil {
+		status, body := httpError(err)
+		writeResponse(w, r, status, body)
+		return
+	}
+
+	response := CompareResponse{
+		CEP1: locations[0],
+		CEP2: locations[1],
+		Delta: TemperatureDelta{
+			TempC: roundTemperature(locations[1].TempC - locations[0].TempC),
+			TempF: roundTemperature(locations[1].TempF - locations[0].TempF),
+			TempK: roundTemperature(locations[1].TempK - locations[0].TempK),
+		},
+	}
+	log.Printf("Compared CEPs %s and %s: %+.1f°C", ceps[0], ceps[1], response.Delta.TempC)
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// Consulta os dois CEPs ao mesmo tempo
+func (s *Server) compareCEPs(ctx context.Context, ceps [2]string) ([2]CompareLocation, error) {
+	var locations [2]CompareLocation
+	err := forBothCEPs(ceps, func(i int, cep string) error {
+		response, err := s.lookupWeather(ctx, cep, defaultUnits)
+		if err != nil {
+			return err
+		}
+		locations[i] = CompareLocation{
+			CEP:   response.cep,
+			City:  response.city,
+			TempC: *response.TempC,
+			TempF: *response.TempF,
+			TempK: *response.TempK,
+		}
+		return nil
+	})
+	return locations, err
+}
+
+// Executa lookup para os dois CEPs em paralelo. Com os dois falhando, o erro
+// devolvido é o do primeiro, para que a resposta não dependa de qual terminou antes
+func forBothCEPs(ceps [2]string, lookup func(i int, cep string) error) error {
+	var (
+		errs [2]error
+		wg   sync.WaitGroup
+	)
+	for i, cep := range ceps {
+		wg.Add(1)
+		go func(i int, cep string) {
+			defer wg.Done()
+			errs[i] = lookup(i, cep)
+		}(i, cep)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
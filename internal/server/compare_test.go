@@ -0,0 +1,78 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var compareAddresses = fakeCEPClient{
+	"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"},
+	"20040020": {CEP: "20040-020", City: "Rio de Janeiro", UF: "RJ"},
+}
+
+// Só responde depois que as duas consultas chegaram: a comparação trava (e
+// falha por timeout) se os CEPs forem consultados um depois do outro
+type barrierWeatherClient struct {
+	fakeWeatherClient
+	arrived sync.WaitGroup
+}
+
+func (b *barrierWeatherClient) Fetch(ctx context.Context, endpoint string, params url.Values, key string) ([]byte, error) {
+	b.arrived.Done()
+	done := make(chan struct{})
+	go func() { b.arrived.Wait(); close(done) }()
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		return nil, context.DeadlineExceeded
+	}
+	return b.fakeWeatherClient.Fetch(ctx, endpoint, params, key)
+}
+
+func TestCompareHandler(t *testing.T) {
+	s := newFakeClientsServer(t, compareAddresses, nil)
+	barrier := &barrierWeatherClient{fakeWeatherClient: fakeWeatherClient{"São Paulo,SP": 22.5, "Rio de Janeiro,RJ": 30}}
+	barrier.arrived.Add(2)
+	s.weather = barrier
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/compare?cep1=01310-100&cep2=20040020", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"cep1": {"cep": "01310-100", "city": "São Paulo", "temp_C": 22.5, "temp_F": 72.5, "temp_K": 295.65},
+		"cep2": {"cep": "20040-020", "city": "Rio de Janeiro", "temp_C": 30, "temp_F": 86, "temp_K": 303.15},
+		"delta": {"temp_C": 7.5, "temp_F": 13.5, "temp_K": 7.5}
+	}`, rr.Body.String())
+}
+
+func TestCompareHandler_Errors(t *testing.T) {
+	s := newFakeClientsServer(t, compareAddresses, fakeWeatherClient{"São Paulo,SP": 25, "Rio de Janeiro,RJ": 30})
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		expected string
+	}{
+		{"missing cep2", "?cep1=01310100", http.StatusUnprocessableEntity, "invalid zipcode"},
+		{"invalid cep1", "?cep1=123&cep2=20040020", http.StatusUnprocessableEntity, "invalid zipcode"},
+		{"unknown cep2", "?cep1=01310100&cep2=99999999", http.StatusNotFound, "can not find zipcode"},
+		{"both fail: cep1 wins", "?cep1=99999999&cep2=123", http.StatusNotFound, "can not find zipcode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/compare"+tt.query, nil))
+			assert.Equal(t, tt.status, rr.Code)
+			assert.JSONEq(t, `{"message":"`+tt.expected+`"}`, rr.Body.String())
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"compress/gzip"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/weather-service/config"
+)
+
+const defaultGzipMinSize = 1024
+
+// Tamanho mínimo, em bytes, para comprimir uma resposta (GZIP_MIN_SIZE).
+// Respostas pequenas não compensam o custo da compressão
+func gzipMinSize() int {
+	value := config.String("GZIP_MIN_SIZE")
+	if value == "" {
+		return defaultGzipMinSize
+	}
+
+	size, err := strconv.Atoi(value)
+	if err != nil || size < 0 {
+		log.Printf("WARNING: ignoring invalid GZIP_MIN_SIZE %q", value)
+		return defaultGzipMinSize
+	}
+	return size
+}
+
+// Verifica se o cliente aceita gzip, respeitando q=0 como recusa
+func acceptsGzip(acceptEncoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.TrimSpace(coding)
+		if coding != "gzip" && coding != "*" {
+			continue
+		}
+
+		q := strings.TrimSpace(params)
+		if value, ok := strings.CutPrefix(q, "q="); ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil && parsed == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+func gzipMiddleware(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Add("Vary", "Accept-Encoding")
+
+			// Upgrades (WebSocket) precisam da conexão original
+			if !acceptsGzip(r.Header.Get("Accept-Encoding")) || r.Header.Get("Upgrade") != "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			gw := &gzipResponseWriter{ResponseWriter: w, minSize: minSize, status: http.StatusOK}
+			defer gw.close()
+			next.ServeHTTP(gw, r)
+		})
+	}
+}
+
+// Acumula a resposta até atingir o tamanho mínimo; a partir daí comprime.
+// Respostas menores são enviadas sem compressão ao final do handler
+type gzipResponseWriter struct {
+	http.ResponseWriter
+	minSize     int
+	status      int
+	buf         []byte
+	gz          *gzip.Writer
+	passthrough bool
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.passthrough {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+}
+
+func (w *gzipResponseWriter) Write(p []byte) (int, error) {
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(p)
+	case w.passthrough:
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) >= w.minSize {
+		if err := w.startGzip(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+func (w *gzipResponseWriter) startGzip() error {
+	// Respeita respostas já codificadas pelo handler
+	if w.Header().Get("Content-Encoding") != "" {
+		return w.startPassthrough()
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+
+	w.gz = gzip.NewWriter(w.ResponseWriter)
+	_, err := w.gz.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+func (w *gzipResponseWriter) startPassthrough() error {
+	w.passthrough = true
+	w.ResponseWriter.WriteHeader(w.status)
+	_, err := w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+	return err
+}
+
+// Permite ao http.ResponseController alcançar o writer original
+func (w *gzipResponseWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// Streams (SSE) fazem flush a cada evento; se a compressão ainda não começou,
+// a resposta segue sem compressão para não atrasar os eventos
+func (w *gzipResponseWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	} else if !w.passthrough {
+		w.startPassthrough()
+	}
+
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *gzipResponseWriter) close() {
+	if w.gz != nil {
+		w.gz.Close()
+		return
+	}
+	if !w.passthrough {
+		w.startPassthrough()
+	}
+}
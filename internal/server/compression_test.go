@@ -0,0 +1,67 @@
+package server
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGzip_CompressesLargeResponses(t *testing.T) {
+	t.Setenv("GZIP_MIN_SIZE", "10")
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+
+	req, err := http.NewRequest("GET", "/weather/01310100", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "gzip", rr.Header().Get("Content-Encoding"))
+	assert.Contains(t, rr.Header().Values("Vary"), "Accept-Encoding")
+
+	reader, err := gzip.NewReader(rr.Body)
+	assert.NoError(t, err)
+	body, err := io.ReadAll(reader)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"temp_C":25,"temp_F":77,"temp_K":298.15}`, string(body))
+}
+
+func TestGzip_SkipsSmallOrUnacceptedResponses(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	tests := []struct {
+		name           string
+		minSize        string
+		acceptEncoding string
+	}{
+		{"Below minimum size", "4096", "gzip"},
+		{"Client does not accept gzip", "10", ""},
+		{"Client refuses gzip", "10", "gzip;q=0, identity"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("GZIP_MIN_SIZE", tt.minSize)
+
+			req, err := http.NewRequest("GET", "/", nil)
+			assert.NoError(t, err)
+			req.Header.Set("Accept-Encoding", tt.acceptEncoding)
+
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Empty(t, rr.Header().Get("Content-Encoding"))
+			assert.True(t, strings.HasPrefix(rr.Body.String(), "{"))
+		})
+	}
+}
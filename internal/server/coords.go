@@ -0,0 +1,69 @@
+package server
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/weather-service/internal/weather"
+)
+
+// Casas decimais mantidas nas coordenadas (~1 km). Pontos vizinhos viram a
+// mesma consulta à WeatherAPI e aproveitam o cache
+const coordinatesPrecision = 2
+
+// Clima atual por latitude e longitude, sem passar pela consulta do CEP,
+// com a mesma resposta e os mesmos parâmetros de /weather/{cep}
+func (s *Server) weatherByCoordinatesHandler(w http.ResponseWriter, r *http.Request) {
+	r = r.WithContext(startReading(r.Context()))
+	units, ok := unitsFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	location, err := coordinatesLocation(chi.URLParam(r, "lat"), chi.URLParam(r, "lon"))
+	if err != nil {
+		log.Printf("Invalid coordinates: %s,%s", chi.URLParam(r, "lat"), chi.URLParam(r, "lon"))
+		status, body := httpError(err)
+		writeResponse(w, r, status, body)
+		return
+	}
+
+	response, tempC, ok := s.currentWeatherResponse(w, r, location, units)
+	if !ok {
+		return
+	}
+	log.Printf("Successfully processed coordinates %s: %.1f°C, %.1f°F, %.1f°K", location, tempC, weather.CelsiusToFahrenheit(tempC), weather.CelsiusToKelvin(tempC))
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// Localização "lat,lon" aceita pela WeatherAPI, com as coordenadas arredondadas
+func coordinatesLocation(lat, lon string) (string, error) {
+	latitude, err := parseCoordinate(lat, 90)
+	if err != nil {
+		return "", err
+	}
+	longitude, err := parseCoordinate(lon, 180)
+	if err != nil {
+		return "", err
+	}
+	return formatCoordinate(latitude) + "," + formatCoordinate(longitude), nil
+}
+
+func parseCoordinate(value string, limit float64) (float64, error) {
+	n, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(n) || math.Abs(n) > limit {
+		return 0, ErrInvalidCoordinates
+	}
+	return n, nil
+}
+
+func formatCoordinate(n float64) string {
+	scale := math.Pow(10, coordinatesPrecision)
+	// + 0 evita o "-0" de coordenadas que arredondam para zero
+	return strconv.FormatFloat(math.Round(n*scale)/scale+0, 'f', -1, 64)
+}
@@ -0,0 +1,51 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeatherByCoordinatesHandler(t *testing.T) {
+	// A WeatherAPI só conhece as coordenadas já arredondadas
+	s := newFakeClientsServer(t, nil, fakeWeatherClient{"-23.56,-46.66": 25, "0,-0.5": 30})
+
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		expected string
+	}{
+		{"rounded coordinates", "/weather/coords/-23.5614,-46.6559", http.StatusOK, `{"temp_C":25,"temp_F":77,"temp_K":298.15}`},
+		{"rounded to zero", "/weather/coords/0.001,-0.5", http.StatusOK, `{"temp_C":30,"temp_F":86,"temp_K":303.15}`},
+		{"units", "/weather/coords/-23.56,-46.66?units=c", http.StatusOK, `{"temp_C":25}`},
+		{"latitude out of range", "/weather/coords/-91,-46.66", http.StatusUnprocessableEntity, `{"message":"invalid coordinates"}`},
+		{"longitude out of range", "/weather/coords/-23.56,180.5", http.StatusUnprocessableEntity, `{"message":"invalid coordinates"}`},
+		{"not a number", "/weather/coords/abc,-46.66", http.StatusUnprocessableEntity, `{"message":"invalid coordinates"}`},
+		{"NaN", "/weather/coords/NaN,-46.66", http.StatusUnprocessableEntity, `{"message":"invalid coordinates"}`},
+		{"unknown location", "/weather/coords/10,10", http.StatusInternalServerError, `{"message":"error fetching weather data"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+			assert.Equal(t, tt.status, rr.Code)
+			assert.JSONEq(t, tt.expected, rr.Body.String())
+		})
+	}
+}
+
+func TestWeatherByCoordinatesHandler_DoesNotShadowCEP(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, fakeWeatherClient{"São Paulo,SP": 25})
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/v1/weather/01310100", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/v1/weather/coords/-23.56,-46.66", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
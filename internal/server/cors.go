@@ -0,0 +1,63 @@
+package server
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/weather-service/config"
+)
+
+var (
+	defaultCORSMethods = []string{http.MethodGet, http.MethodPost, http.MethodOptions}
+	defaultCORSHeaders = []string{"Accept", "Authorization", "Content-Type", apiKeyHeader, signatureHeader, signatureTimestampHeader}
+)
+
+type corsConfig struct {
+	origins []string
+	methods []string
+	headers []string
+}
+
+// Lê a configuração de CORS do ambiente; sem CORS_ALLOWED_ORIGINS o CORS fica desligado
+func corsConfigFromEnv() corsConfig {
+	return corsConfig{
+		origins: config.List("CORS_ALLOWED_ORIGINS", nil),
+		methods: config.List("CORS_ALLOWED_METHODS", defaultCORSMethods),
+		headers: config.List("CORS_ALLOWED_HEADERS", defaultCORSHeaders),
+	}
+}
+
+func (c corsConfig) allowsOrigin(origin string) bool {
+	for _, allowed := range c.origins {
+		if allowed == "*" || strings.EqualFold(allowed, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func corsMiddleware(config corsConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			if origin == "" || !config.allowsOrigin(origin) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			w.Header().Add("Vary", "Origin")
+
+			// Preflight do navegador: responde aqui, antes do roteamento por método
+			if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+				w.Header().Set("Access-Control-Allow-Methods", strings.Join(config.methods, ", "))
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(config.headers, ", "))
+				w.Header().Set("Access-Control-Max-Age", "600")
+				w.WriteHeader(http.StatusNoContent)
+				return
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
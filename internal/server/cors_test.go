@@ -0,0 +1,59 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCORS_Preflight(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("CORS_ALLOWED_ORIGINS", "https://app.example.com")
+	t.Setenv("CORS_ALLOWED_METHODS", "GET")
+
+	req, err := http.NewRequest("OPTIONS", "/v1/weather/01310100", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Origin", "https://app.example.com")
+	req.Header.Set("Access-Control-Request-Method", "GET")
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	assert.Equal(t, "https://app.example.com", rr.Header().Get("Access-Control-Allow-Origin"))
+	assert.Equal(t, "GET", rr.Header().Get("Access-Control-Allow-Methods"))
+	assert.Equal(t, "Accept, Authorization, Content-Type, X-API-Key, X-Signature, X-Signature-Timestamp", rr.Header().Get("Access-Control-Allow-Headers"))
+}
+
+func TestCORS_Origins(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	tests := []struct {
+		name           string
+		allowed        string
+		origin         string
+		expectedHeader string
+	}{
+		{"Allowed origin", "https://app.example.com, https://other.example.com", "https://other.example.com", "https://other.example.com"},
+		{"Wildcard", "*", "https://any.example.com", "https://any.example.com"},
+		{"Origin not allowed", "https://app.example.com", "https://evil.example.com", ""},
+		{"CORS disabled", "", "https://app.example.com", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("CORS_ALLOWED_ORIGINS", tt.allowed)
+
+			req, err := http.NewRequest("GET", "/", nil)
+			assert.NoError(t, err)
+			req.Header.Set("Origin", tt.origin)
+
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, tt.expectedHeader, rr.Header().Get("Access-Control-Allow-Origin"))
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"log"
+	"math"
+	"net/http"
+	"strings"
+)
+
+// Raio médio da Terra
+const earthRadiusKm = 6371.0
+
+// Município de um dos CEPs, com as coordenadas devolvidas pela WeatherAPI
+type DistanceLocation struct {
+	CEP  string  `json:"cep" xml:"cep"`
+	City string  `json:"city" xml:"city"`
+	UF   string  `json:"uf" xml:"uf"`
+	Lat  float64 `json:"lat" xml:"lat"`
+	Lon  float64 `json:"lon" xml:"lon"`
+}
+
+type DistanceResponse struct {
+	From       DistanceLocation `json:"from" xml:"from"`
+	To         DistanceLocation `json:"to" xml:"to"`
+	DistanceKm float64          `json:"distance_km" xml:"distance_km"`
+}
+
+// Distância em linha reta entre os municípios de dois CEPs
+func (s *Server) distanceHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	ceps := [2]string{strings.TrimSpace(query.Get("from")), strings.TrimSpace(query.Get("to"))}
+	log.Printf("Received distance request for CEPs %s and %s", ceps[0], ceps[1])
+
+	var locations [2]DistanceLocation
+	err := forBothCEPs(ceps, func(i int, cep string) (err error) {
+		locations[i], err = s.locateCEP(r.Context(), cep)
+		return err
+	})
+	if err != nil {
+		status, body := httpError(err)
+		writeResponse(w, r, status, body)
+		return
+	}
+
+	response := DistanceResponse{
+		From:       locations[0],
+		To:         locations[1],
+		DistanceKm: math.Round(haversineKm(locations[0], locations[1])*10) / 10,
+	}
+	log.Printf("Distance between CEPs %s and %s: %.1f km", ceps[0], ceps[1], response.DistanceKm)
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// Endereço do CEP e coordenadas do município. As coordenadas vêm da mesma
+// chamada current.json usada por /weather/{cep}, então aproveitam o cache
+func (s *Server) locateCEP(ctx context.Context, cep string) (DistanceLocation, error) {
+	address, err := s.lookupAddress(ctx, cep)
+	if err != nil {
+		return DistanceLocation{}, err
+	}
+	noteUsageAddress(ctx, address)
+
+	current, err := s.getCurrentWeather(ctx, address.location(), false)
+	if err != nil {
+		log.Printf("ERROR: Failed to get coordinates for location '%s': %v", address.location(), err)
+		return DistanceLocation{}, newPublicError(err)
+	}
+	return DistanceLocation{
+		CEP:  address.Cep,
+		City: address.Localidade,
+		UF:   address.UF,
+		Lat:  current.Location.Lat,
+		Lon:  current.Location.Lon,
+	}, nil
+}
+
+// Distância do círculo máximo pela fórmula de haversine
+func haversineKm(a, b DistanceLocation) float64 {
+	lat1, lat2 := a.Lat*math.Pi/180, b.Lat*math.Pi/180
+	dLat := lat2 - lat1
+	dLon := (b.Lon - a.Lon) * math.Pi / 180
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadiusKm * math.Asin(math.Sqrt(h))
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weather-service/internal/weather"
+)
+
+// WeatherAPI em memória que devolve as coordenadas de cada localização
+type fakeCoordinatesClient map[string][2]float64
+
+func (f fakeCoordinatesClient) Fetch(ctx context.Context, endpoint string, params url.Values, key string) ([]byte, error) {
+	coordinates, ok := f[params.Get("q")]
+	if !ok {
+		return nil, &weather.StatusError{Status: http.StatusBadRequest}
+	}
+	return []byte(fmt.Sprintf(`{"location":{"lat":%g,"lon":%g},"current":{"temp_c":25}}`, coordinates[0], coordinates[1])), nil
+}
+
+func TestDistanceHandler(t *testing.T) {
+	s := newFakeClientsServer(t, compareAddresses, nil)
+	s.weather = fakeCoordinatesClient{"São Paulo,SP": {-23.55, -46.63}, "Rio de Janeiro,RJ": {-22.91, -43.17}}
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/distance?from=01310100&to=20040-020", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{
+		"from": {"cep": "01310-100", "city": "São Paulo", "uf": "SP", "lat": -23.55, "lon": -46.63},
+		"to": {"cep": "20040-020", "city": "Rio de Janeiro", "uf": "RJ", "lat": -22.91, "lon": -43.17},
+		"distance_km": 360.6
+	}`, rr.Body.String())
+
+	// O mesmo município nas duas pontas
+	rr = httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/distance?from=01310100&to=01310100", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"distance_km":0`)
+}
+
+func TestDistanceHandler_Errors(t *testing.T) {
+	s := newFakeClientsServer(t, compareAddresses, nil)
+	s.weather = fakeCoordinatesClient{"São Paulo,SP": {-23.55, -46.63}}
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		expected string
+	}{
+		{"missing to", "?from=01310100", http.StatusUnprocessableEntity, "invalid zipcode"},
+		{"unknown from", "?from=99999999&to=01310100", http.StatusNotFound, "can not find zipcode"},
+		{"weather API failure", "?from=01310100&to=20040020", http.StatusInternalServerError, "error fetching weather data"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/distance"+tt.query, nil))
+			assert.Equal(t, tt.status, rr.Code)
+			assert.JSONEq(t, `{"message":"`+tt.expected+`"}`, rr.Body.String())
+		})
+	}
+}
+
+func TestHaversineKm(t *testing.T) {
+	// Um grau de longitude no equador
+	assert.InDelta(t, 111.19, haversineKm(DistanceLocation{Lat: 0, Lon: 0}, DistanceLocation{Lat: 0, Lon: 1}), 0.01)
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"net/http"
+
+	"github.com/weather-service/api"
+)
+
+// Inicialização do Swagger UI; o hash deste script é liberado no
+// Content-Security-Policy da página
+const swaggerUIInitScript = `
+    window.onload = function () {
+      SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+    };
+  `
+
+// Swagger UI carregado do CDN, apontando para a especificação servida em /openapi.json
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="pt-BR">
+<head>
+  <meta charset="utf-8">
+  <title>Weather Service - API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>` + swaggerUIInitScript + `</script>
+</body>
+</html>
+`
+
+func openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(api.OpenAPI)
+}
+
+func docsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte(swaggerUIPage))
+}
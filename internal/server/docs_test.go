@@ -0,0 +1,47 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPIHandler(t *testing.T) {
+	req, err := http.NewRequest("GET", "/openapi.json", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(openAPIHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+	var spec struct {
+		OpenAPI string                 `json:"openapi"`
+		Paths   map[string]interface{} `json:"paths"`
+	}
+	err = json.NewDecoder(rr.Body).Decode(&spec)
+	assert.NoError(t, err)
+	assert.Equal(t, "3.0.3", spec.OpenAPI)
+
+	// Toda rota registrada em main deve estar documentada
+	for _, path := range []string{"/weather/{cep}", "/weather/{cep}/stream", "/history/{cep}", "/astronomy/{cep}", "/alerts/{cep}", "/graphql", "/ws", "/"} {
+		assert.Contains(t, spec.Paths, path)
+	}
+}
+
+func TestDocsHandler(t *testing.T) {
+	req, err := http.NewRequest("GET", "/docs", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(docsHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.True(t, strings.HasPrefix(rr.Header().Get("Content-Type"), "text/html"))
+	assert.Contains(t, rr.Body.String(), `url: "/openapi.json"`)
+}
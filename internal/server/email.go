@@ -0,0 +1,207 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	htmltemplate "html/template"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+const defaultSMTPPort = "587"
+
+// Envia a mensagem pronta pelo servidor SMTP. Trocado nos testes
+var sendMail = smtp.SendMail
+
+// O canal email só é aceito com SMTP_HOST, SMTP_FROM e SMTP_ALLOWED_DOMAINS
+// configurados: sem a lista de domínios, qualquer cliente poderia usar o
+// servidor para mandar e-mail a qualquer endereço
+func emailConfigured() bool {
+	return config.String("SMTP_HOST") != "" && config.String("SMTP_FROM") != "" &&
+		len(config.List("SMTP_ALLOWED_DOMAINS", nil)) > 0
+}
+
+// O domínio do destinatário precisa estar em SMTP_ALLOWED_DOMAINS, sem
+// diferença de maiúsculas; subdomínios precisam ser listados à parte
+func allowedEmailRecipient(address string) bool {
+	at := strings.LastIndex(address, "@")
+	if at < 0 {
+		return false
+	}
+	domain := address[at+1:]
+	for _, allowed := range config.List("SMTP_ALLOWED_DOMAINS", nil) {
+		if strings.EqualFold(domain, strings.TrimPrefix(allowed, "@")) {
+			return true
+		}
+	}
+	return false
+}
+
+// Dados dos modelos do e-mail de alerta
+type alertEmail struct {
+	RuleID    string
+	City      string
+	CEP       string
+	Condition string
+	Value     float64
+	// Temperaturas atuais nas escalas disponíveis
+	Temperatures []alertTemperature
+	FiredAt      string
+}
+
+type alertTemperature struct {
+	Label string
+	Value float64
+	Unit  string
+}
+
+var alertEmailSubject = template.Must(template.New("subject").Parse(
+	`Alerta de temperatura: {{.City}} (CEP {{.CEP}}) {{.Condition}}`))
+
+var alertEmailText = template.Must(template.New("text").Parse(`Alerta de temperatura em {{.City}} (CEP {{.CEP}})
+
+Condição {{.Condition}} atingida: valor atual {{printf "%.1f" .Value}}.
+
+Temperaturas atuais:
+{{range .Temperatures}}  {{printf "%-11s" (print .Label ":")}} {{printf "%.1f" .Value}} {{.Unit}}
+{{end}}
+Regra {{.RuleID}}, disparada em {{.FiredAt}}.
+`))
+
+var alertEmailHTML = htmltemplate.Must(htmltemplate.New("html").Parse(`<!DOCTYPE html>
+<html>
+<body style="font-family: sans-serif">
+<h2>Alerta de temperatura em {{.City}} (CEP {{.CEP}})</h2>
+<p>Condição <code>{{.Condition}}</code> atingida: valor atual <strong>{{printf "%.1f" .Value}}</strong>.</p>
+<table cellpadding="4">
+{{range .Temperatures}}<tr><td>{{.Label}}</td><td>{{printf "%.1f" .Value}} {{.Unit}}</td></tr>
+{{end}}</table>
+<p style="color: #666">Regra {{.RuleID}}, disparada em {{.FiredAt}}.</p>
+</body>
+</html>
+`))
+
+func newAlertEmail(event WebhookEvent, weather WeatherResponse) alertEmail {
+	cep := weather.cep
+	if cep == "" {
+		cep = event.CEP
+	}
+	email := alertEmail{
+		RuleID:    event.SubscriptionID,
+		City:      event.City,
+		CEP:       cep,
+		Condition: fmt.Sprintf("%s %s %g", event.Metric, event.Operator, event.Threshold),
+		Value:     event.Value,
+		FiredAt:   event.FiredAt.Format(time.RFC3339),
+	}
+	for _, temp := range []struct {
+		label string
+		value *float64
+		unit  string
+	}{
+		{"Celsius", weather.TempC, "°C"},
+		{"Fahrenheit", weather.TempF, "°F"},
+		{"Kelvin", weather.TempK, "K"},
+	} {
+		if temp.value != nil {
+			email.Temperatures = append(email.Temperatures, alertTemperature{temp.label, *temp.value, temp.unit})
+		}
+	}
+	return email
+}
+
+// Mensagem MIME multipart/alternative, com as versões em texto e em HTML
+func buildAlertEmail(from, to string, data alertEmail) ([]byte, error) {
+	var subject, text, html bytes.Buffer
+	if err := alertEmailSubject.Execute(&subject, data); err != nil {
+		return nil, err
+	}
+	if err := alertEmailText.Execute(&text, data); err != nil {
+		return nil, err
+	}
+	if err := alertEmailHTML.Execute(&html, data); err != nil {
+		return nil, err
+	}
+
+	var body bytes.Buffer
+	parts := multipart.NewWriter(&body)
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=UTF-8", text.Bytes()},
+		{"text/html; charset=UTF-8", html.Bytes()},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		qp := quotedprintable.NewWriter(w)
+		qp.Write(part.content)
+		qp.Close()
+	}
+	parts.Close()
+
+	var message bytes.Buffer
+	fmt.Fprintf(&message, "From: %s\r\n", from)
+	fmt.Fprintf(&message, "To: %s\r\n", to)
+	fmt.Fprintf(&message, "Subject: %s\r\n", mime.QEncoding.Encode("UTF-8", subject.String()))
+	fmt.Fprintf(&message, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&message, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&message, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+	message.Write(body.Bytes())
+	return message.Bytes(), nil
+}
+
+// Envia o e-mail do disparo pelo SMTP_HOST:SMTP_PORT (padrão 587), com
+// SMTP_FROM como remetente ("Nome <endereço>" ou só o endereço). O envio
+// usa STARTTLS quando o servidor oferece e autentica com SMTP_USERNAME e
+// SMTP_PASSWORD quando definidos. smtp.SendMail não aceita contexto: o envio
+// não é interrompido quando ctx termina
+func deliverEmail(ctx context.Context, to string, event WebhookEvent, weather WeatherResponse) error {
+	host, from := config.String("SMTP_HOST"), config.String("SMTP_FROM")
+	if host == "" || from == "" {
+		return fmt.Errorf("SMTP_HOST and SMTP_FROM are required for email alerts")
+	}
+	port := config.String("SMTP_PORT")
+	if port == "" {
+		port = defaultSMTPPort
+	}
+
+	sender, err := mail.ParseAddress(from)
+	if err != nil {
+		return fmt.Errorf("invalid SMTP_FROM: %w", err)
+	}
+	// A lista pode ter mudado num reload depois que a regra foi criada
+	recipient, err := mail.ParseAddress(to)
+	if err != nil {
+		return fmt.Errorf("invalid recipient: %w", err)
+	}
+	if !allowedEmailRecipient(recipient.Address) {
+		return fmt.Errorf("recipient domain not in SMTP_ALLOWED_DOMAINS: %s", recipient.Address)
+	}
+	message, err := buildAlertEmail(sender.String(), to, newAlertEmail(event, weather))
+	if err != nil {
+		return err
+	}
+
+	var auth smtp.Auth
+	if username := config.String("SMTP_USERNAME"); username != "" {
+		auth = smtp.PlainAuth("", username, config.String("SMTP_PASSWORD"), host)
+	}
+	return sendMail(net.JoinHostPort(host, port), auth, sender.Address, []string{recipient.Address}, message)
+}
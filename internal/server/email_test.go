@@ -0,0 +1,157 @@
+package server
+
+import (
+	"context"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type sentEmail struct {
+	addr string
+	auth smtp.Auth
+	from string
+	to   []string
+	msg  []byte
+}
+
+// Troca o envio SMTP por uma captura das mensagens
+func withFakeSMTP(t *testing.T) *[]sentEmail {
+	t.Setenv("SMTP_HOST", "smtp.example.com")
+	t.Setenv("SMTP_FROM", "Alertas <alertas@example.com>")
+	t.Setenv("SMTP_ALLOWED_DOMAINS", "example.com")
+	var sent []sentEmail
+	old := sendMail
+	sendMail = func(addr string, auth smtp.Auth, from string, to []string, msg []byte) error {
+		sent = append(sent, sentEmail{addr, auth, from, to, msg})
+		return nil
+	}
+	t.Cleanup(func() { sendMail = old })
+	return &sent
+}
+
+// Partes text/plain e text/html da mensagem, já decodificadas
+func emailParts(t *testing.T, message *mail.Message) map[string]string {
+	_, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	assert.NoError(t, err)
+	parts := map[string]string{}
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		assert.NoError(t, err)
+		content, _ := io.ReadAll(part)
+		mediaType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parts[mediaType] = string(content)
+	}
+}
+
+func TestDeliverEmail(t *testing.T) {
+	sent := withFakeSMTP(t)
+	t.Setenv("SMTP_USERNAME", "alertas")
+	t.Setenv("SMTP_PASSWORD", "senha")
+
+	tempC, tempF, tempK := 36.2, 97.16, 309.35
+	event := WebhookEvent{SubscriptionID: "abc", CEP: "01310100", City: "São Paulo", Metric: "temp_C", Operator: ">", Threshold: 35, Value: 36.2,
+		FiredAt: time.Date(2026, 10, 17, 15, 5, 0, 0, time.UTC)}
+	weather := WeatherResponse{TempC: &tempC, TempF: &tempF, TempK: &tempK, cep: "01310-100", city: "São Paulo"}
+	assert.NoError(t, deliverEmail(context.Background(), "ops@example.com", event, weather))
+
+	if !assert.Len(t, *sent, 1) {
+		return
+	}
+	email := (*sent)[0]
+	assert.Equal(t, "smtp.example.com:587", email.addr)
+	assert.NotNil(t, email.auth)
+	assert.Equal(t, "alertas@example.com", email.from)
+	assert.Equal(t, []string{"ops@example.com"}, email.to)
+
+	message, err := mail.ReadMessage(strings.NewReader(string(email.msg)))
+	assert.NoError(t, err)
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	assert.NoError(t, err)
+	assert.Equal(t, "Alerta de temperatura: São Paulo (CEP 01310-100) temp_C > 35", subject)
+	assert.Equal(t, `"Alertas" <alertas@example.com>`, message.Header.Get("From"))
+
+	parts := emailParts(t, message)
+	assert.Equal(t, `Alerta de temperatura em São Paulo (CEP 01310-100)
+
+Condição temp_C > 35 atingida: valor atual 36.2.
+
+Temperaturas atuais:
+  Celsius:    36.2 °C
+  Fahrenheit: 97.2 °F
+  Kelvin:     309.4 K
+
+Regra abc, disparada em 2026-10-17T15:05:00Z.
+`, strings.ReplaceAll(parts["text/plain"], "\r\n", "\n"))
+	assert.Contains(t, parts["text/html"], "<code>temp_C &gt; 35</code>")
+	assert.Contains(t, parts["text/html"], "<td>Kelvin</td><td>309.4 K</td>")
+}
+
+// Uma regra com channel email guarda o endereço e recebe o aviso no disparo
+func TestAlertRules_Email(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, fakeWeatherClient{"São Paulo,SP": 36})
+
+	t.Setenv("API_KEYS", "key-a")
+	body := `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"channel":"email","email":"ops@example.com","callback_url":"https://ignored.example.com"}`
+	t.Setenv("SMTP_HOST", "")
+	rr := requestSubscriptionsAs(t, s, "key-a", "POST", "/alert-rules", body)
+	assert.JSONEq(t, `{"message":"email channel not configured"}`, rr.Body.String())
+
+	sent := withFakeSMTP(t)
+	rr = requestSubscriptionsAs(t, s, "key-a", "POST", "/alert-rules", `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"channel":"email","email":"ops"}`)
+	assert.JSONEq(t, `{"message":"invalid email"}`, rr.Body.String())
+
+	rr = requestSubscriptionsAs(t, s, "key-a", "POST", "/alert-rules", `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"channel":"email","email":"someone@elsewhere.com"}`)
+	assert.Equal(t, 422, rr.Code)
+	assert.JSONEq(t, `{"message":"email domain not allowed"}`, rr.Body.String())
+
+	rr = requestSubscriptionsAs(t, s, "key-a", "POST", "/alert-rules", body)
+	assert.Equal(t, 201, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"email":"ops@example.com"`)
+	assert.NotContains(t, rr.Body.String(), "callback_url")
+
+	s.pollSubscriptions(context.Background())
+	if assert.Len(t, *sent, 1) {
+		assert.Equal(t, []string{"ops@example.com"}, (*sent)[0].to)
+	}
+}
+
+// Sem autenticação configurada não há dono para a regra, e o canal email é
+// recusado
+func TestAlertRules_EmailRequiresAuthentication(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, nil)
+	withFakeSMTP(t)
+
+	rr := requestSubscriptions(t, s, "POST", "/alert-rules", `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"channel":"email","email":"ops@example.com"}`)
+	assert.Equal(t, http.StatusForbidden, rr.Code)
+	assert.JSONEq(t, `{"message":"email channel requires authentication"}`, rr.Body.String())
+}
+
+func TestAllowedEmailRecipient(t *testing.T) {
+	t.Setenv("SMTP_ALLOWED_DOMAINS", "example.com, @Empresa.com.br")
+	assert.True(t, allowedEmailRecipient("ops@example.com"))
+	assert.True(t, allowedEmailRecipient("ops@EXAMPLE.com"))
+	assert.True(t, allowedEmailRecipient("time@empresa.com.br"))
+	assert.False(t, allowedEmailRecipient("ops@mail.example.com"))
+	assert.False(t, allowedEmailRecipient("ops@example.com.evil.org"))
+	assert.False(t, allowedEmailRecipient("ops"))
+
+	// Um destinatário fora da lista, vindo de uma regra antiga, não recebe
+	sent := withFakeSMTP(t)
+	t.Setenv("SMTP_ALLOWED_DOMAINS", "example.com")
+	err := deliverEmail(context.Background(), "ops@outro.com", WebhookEvent{}, WeatherResponse{})
+	assert.Error(t, err)
+	assert.Empty(t, *sent)
+}
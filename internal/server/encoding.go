@@ -0,0 +1,282 @@
+package server
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"log"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+type responseEncoder func(w io.Writer, body interface{}) error
+
+// Formatos de resposta suportados, indexados pelo media type. JSON é o
+// padrão quando o cliente não envia Accept ou não aceita nenhum deles
+var responseEncoders = map[string]responseEncoder{
+	"application/json":      encodeJSON,
+	"application/xml":       encodeXML,
+	"text/xml":              encodeXML,
+	"text/csv":              encodeCSV,
+	"application/msgpack":   encodeMsgpack,
+	"application/x-msgpack": encodeMsgpack,
+}
+
+const defaultMediaType = "application/json"
+
+// Retornado por encoders que não sabem representar o tipo da resposta
+var errUnsupportedBody = errors.New("response type not supported by encoder")
+
+// Buffers maiores que isso (uma exportação grande, um lote de 500 CEPs) não
+// voltam para o pool, para não ficarem presos na memória
+const maxPooledBufferSize = 64 << 10
+
+// Buffer de resposta com um encoder JSON já associado a ele. Criar o encoder
+// a cada resposta era uma das maiores fontes de alocação no caminho quente
+type responseBuffer struct {
+	bytes.Buffer
+	json *json.Encoder
+}
+
+var responseBuffers = sync.Pool{
+	New: func() interface{} {
+		buf := &responseBuffer{}
+		buf.json = json.NewEncoder(&buf.Buffer)
+		return buf
+	},
+}
+
+func getResponseBuffer() *responseBuffer {
+	return responseBuffers.Get().(*responseBuffer)
+}
+
+func putResponseBuffer(buf *responseBuffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	buf.Reset()
+	responseBuffers.Put(buf)
+}
+
+// Mensagens de erro fixas, serializadas em JSON uma única vez. Mensagens
+// fora da lista (erros de validação, por exemplo) são serializadas na hora
+var errorBodies = marshalErrorBodies(
+	"invalid zipcode",
+	"can not find zipcode",
+	"error fetching weather data",
+	"weather api unavailable",
+	"weather api quota exhausted",
+	"internal server error",
+	"too many requests",
+	"not found",
+	"method not allowed",
+	"forbidden",
+	"missing api key",
+	"invalid api key",
+	"missing bearer token",
+	"invalid bearer token",
+	"unknown tenant",
+	"invalid units",
+	"invalid date",
+	"invalid pagination",
+	"invalid format",
+	"invalid request body",
+	"request body too large",
+	"invalid batch size",
+)
+
+func marshalErrorBodies(messages ...string) map[string][]byte {
+	bodies := make(map[string][]byte, len(messages))
+	for _, message := range messages {
+		var buf bytes.Buffer
+		encodeJSON(&buf, ErrorResponse{Message: message})
+		bodies[message] = buf.Bytes()
+	}
+	return bodies
+}
+
+// Serializa o corpo em JSON no buffer, usando o corpo pronto dos erros fixos
+func (buf *responseBuffer) encodeJSON(body interface{}) error {
+	if response, ok := body.(ErrorResponse); ok {
+		if encoded, ok := errorBodies[response.Message]; ok {
+			buf.Write(encoded)
+			return nil
+		}
+	}
+	return buf.json.Encode(body)
+}
+
+// Escreve a resposta no formato negociado pelo cabeçalho Accept. Quando o
+// formato escolhido não suporta o tipo da resposta, responde em JSON
+func writeResponse(w http.ResponseWriter, r *http.Request, status int, body interface{}) {
+	mediaType := negotiateMediaType(r.Header.Get("Accept"))
+
+	buf := getResponseBuffer()
+	defer putResponseBuffer(buf)
+
+	var err error
+	if mediaType == defaultMediaType {
+		err = buf.encodeJSON(body)
+	} else {
+		err = responseEncoders[mediaType](&buf.Buffer, body)
+	}
+	if err != nil {
+		if !errors.Is(err, errUnsupportedBody) {
+			log.Printf("ERROR: Failed to encode %s response: %v", mediaType, err)
+		}
+		mediaType = defaultMediaType
+		buf.Reset()
+		buf.encodeJSON(body)
+	}
+
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Add("Vary", "Accept")
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// Escreve a resposta em JSON, sem negociar o formato, para os endpoints que
+// só respondem JSON (GraphQL)
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	buf := getResponseBuffer()
+	defer putResponseBuffer(buf)
+
+	if err := buf.encodeJSON(body); err != nil {
+		log.Printf("ERROR: Failed to encode JSON response: %v", err)
+		buf.Reset()
+		buf.encodeJSON(ErrorResponse{Message: "internal server error"})
+		status = http.StatusInternalServerError
+	}
+
+	w.Header().Set("Content-Type", defaultMediaType)
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// Escolhe o formato suportado com maior prioridade (q) no Accept. JSON é o
+// padrão: outro formato só é usado com q estritamente maior que o do JSON,
+// seja ele pedido explicitamente ou no lugar de */*, application/* ou de um
+// tipo que o serviço não produz (text/html). Assim o Accept de um navegador,
+// que lista application/xml abaixo de text/html, continua recebendo JSON
+func negotiateMediaType(accept string) string {
+	explicitJSON, fallbackJSON := -1.0, 0.0
+	best, bestQ := "", 0.0
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+				q = parsed
+			}
+		}
+
+		switch {
+		case mediaType == defaultMediaType:
+			explicitJSON = math.Max(explicitJSON, q)
+		case responseEncoders[mediaType] != nil:
+			if q > bestQ {
+				best, bestQ = mediaType, q
+			}
+		default:
+			// Curingas e tipos não suportados são atendidos em JSON
+			fallbackJSON = math.Max(fallbackJSON, q)
+		}
+	}
+
+	// Um application/json explícito prevalece sobre os demais, mesmo com q=0
+	jsonQ := fallbackJSON
+	if explicitJSON >= 0 {
+		jsonQ = explicitJSON
+	}
+	if best != "" && bestQ > jsonQ {
+		return best
+	}
+	return defaultMediaType
+}
+
+func encodeJSON(w io.Writer, body interface{}) error {
+	return json.NewEncoder(w).Encode(body)
+}
+
+func encodeXML(w io.Writer, body interface{}) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	root := xml.StartElement{Name: xml.Name{Local: xmlRootName(body)}}
+	return xml.NewEncoder(w).EncodeElement(body, root)
+}
+
+// Usa as tags json para manter os mesmos nomes de campo da resposta JSON
+func encodeMsgpack(w io.Writer, body interface{}) error {
+	encoder := msgpack.NewEncoder(w)
+	encoder.SetCustomStructTag("json")
+	return encoder.Encode(body)
+}
+
+// Tipos que podem ser exportados como linhas de planilha
+type csvWriter interface {
+	csvHeader() []string
+	csvRecords() [][]string
+}
+
+func encodeCSV(w io.Writer, body interface{}) error {
+	rows, ok := body.(csvWriter)
+	if !ok {
+		return errUnsupportedBody
+	}
+
+	writer := csv.NewWriter(w)
+	writer.Write(rows.csvHeader())
+	writer.WriteAll(rows.csvRecords())
+	return writer.Error()
+}
+
+// Nome do elemento raiz de cada tipo de resposta
+func xmlRootName(body interface{}) string {
+	switch body.(type) {
+	case ErrorResponse:
+		return "error"
+	case WeatherResponse, *WeatherResponse:
+		return "weather"
+	case DailyTemperatures, *DailyTemperatures:
+		return "history"
+	case ReadingsResponse:
+		return "readings"
+	case StatsResponse:
+		return "stats"
+	case BatchResponse:
+		return "batch"
+	case AstronomyResponse, *AstronomyResponse:
+		return "astronomy"
+	case AlertsResponse, *AlertsResponse:
+		return "alerts"
+	case QuotaStatus:
+		return "quota"
+	case ReloadResponse:
+		return "reload"
+	case CacheFlushResponse:
+		return "cache"
+	case ConfigResponse:
+		return "config"
+	case ProvidersResponse:
+		return "providers"
+	case StatusResponse:
+		return "status"
+	default:
+		return "response"
+	}
+}
@@ -0,0 +1,180 @@
+package server
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+func TestNegotiateMediaType(t *testing.T) {
+	tests := []struct {
+		name     string
+		accept   string
+		expected string
+	}{
+		{"No Accept header", "", "application/json"},
+		{"JSON", "application/json", "application/json"},
+		{"XML", "application/xml", "application/xml"},
+		{"Text XML", "text/xml", "text/xml"},
+		{"Wildcard", "*/*", "application/json"},
+		{"Unsupported falls back to JSON", "text/html", "application/json"},
+		{"Highest q wins", "application/json;q=0.5, application/xml", "application/xml"},
+		{"q=0 is rejected", "application/xml;q=0, */*", "application/json"},
+		{"Browser style header", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "application/json"},
+		{"Browser style header without wildcard", "text/html,application/xhtml+xml,application/xml;q=0.9", "application/json"},
+		{"XML above unsupported types", "text/html;q=0.5, application/xml", "application/xml"},
+		{"Tie keeps JSON", "application/xml, application/json", "application/json"},
+		{"Tie with wildcard keeps JSON", "application/xml, */*", "application/json"},
+		{"XML above wildcard", "application/xml, */*;q=0.8", "application/xml"},
+		{"Explicit JSON overrides wildcard", "application/json;q=0.1, application/xml;q=0.5, */*", "application/xml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, negotiateMediaType(tt.accept))
+		})
+	}
+}
+
+func TestWeatherHandler_XML(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+
+	tests := []struct {
+		name           string
+		cep            string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Success", "01310100", http.StatusOK, `<weather><temp_C>25</temp_C><temp_F>77</temp_F><temp_K>298.15</temp_K></weather>`},
+		{"Invalid zipcode", "123", http.StatusUnprocessableEntity, `<error><message>invalid zipcode</message></error>`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/weather/"+tt.cep, nil)
+			assert.NoError(t, err)
+			req.Header.Set("Accept", "application/xml")
+
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, "application/xml", rr.Header().Get("Content-Type"))
+			assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>`+"\n"+tt.expectedBody, rr.Body.String())
+		})
+	}
+}
+
+func TestWeatherHandler_CSV(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		{"Success", "/weather/01310100", http.StatusOK, "cep,city,temp_C,temp_F,temp_K\n01310-100,São Paulo,25,77,298.15\n"},
+		{"Unselected units are empty", "/weather/01310100?units=c", http.StatusOK, "cep,city,temp_C,temp_F,temp_K\n01310-100,São Paulo,25,,\n"},
+		{"Error", "/weather/123", http.StatusUnprocessableEntity, "message\ninvalid zipcode\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.path, nil)
+			assert.NoError(t, err)
+			req.Header.Set("Accept", "text/csv")
+
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, "text/csv", rr.Header().Get("Content-Type"))
+			assert.Equal(t, tt.expectedBody, rr.Body.String())
+		})
+	}
+}
+
+func TestWriteResponse_UnsupportedTypeFallsBackToJSON(t *testing.T) {
+	req, err := http.NewRequest("GET", "/astronomy/01310100", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Accept", "text/csv")
+
+	rr := httptest.NewRecorder()
+	writeResponse(rr, req, http.StatusOK, AstronomyResponse{Sunrise: "05:12 AM"})
+
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"sunrise":"05:12 AM","sunset":"","moonrise":"","moonset":"","moon_phase":""}`, rr.Body.String())
+}
+
+func TestWeatherHandler_Msgpack(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+
+	req, err := http.NewRequest("GET", "/weather/01310100?units=c,k", nil)
+	assert.NoError(t, err)
+	req.Header.Set("Accept", "application/msgpack")
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/msgpack", rr.Header().Get("Content-Type"))
+
+	var response map[string]interface{}
+	err = msgpack.Unmarshal(rr.Body.Bytes(), &response)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"temp_C": 25.0, "temp_K": 298.15}, response)
+}
+
+// Os corpos prontos são idênticos aos serializados na hora
+func TestErrorBodies_MatchEncoder(t *testing.T) {
+	for message, body := range errorBodies {
+		var buf bytes.Buffer
+		assert.NoError(t, encodeJSON(&buf, ErrorResponse{Message: message}))
+		assert.Equal(t, buf.String(), string(body), message)
+	}
+}
+
+// Um buffer reaproveitado não carrega restos da resposta anterior
+func TestWriteResponse_ReusesBuffers(t *testing.T) {
+	r := httptest.NewRequest("GET", "/", nil)
+
+	rr := httptest.NewRecorder()
+	writeResponse(rr, r, http.StatusOK, newWeatherResponse(28.5, temperatureUnits{C: true}))
+	assert.JSONEq(t, `{"temp_C":28.5}`, rr.Body.String())
+
+	for _, message := range []string{"invalid zipcode", "invalid config: PORT"} {
+		rr = httptest.NewRecorder()
+		writeResponse(rr, r, http.StatusUnprocessableEntity, ErrorResponse{Message: message})
+		assert.Equal(t, `{"message":"`+message+`"}`+"\n", rr.Body.String())
+	}
+}
+
+func BenchmarkWriteResponse(b *testing.B) {
+	r := httptest.NewRequest("GET", "/", nil)
+	bodies := map[string]interface{}{
+		"weather": newWeatherResponse(28.5, defaultUnits),
+		"error":   ErrorResponse{Message: "invalid zipcode"},
+	}
+	for name, body := range bodies {
+		b.Run(name, func(b *testing.B) {
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				writeResponse(httptest.NewRecorder(), r, http.StatusOK, body)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/weather-service/internal/cep"
+)
+
+// Erros do fluxo CEP → temperatura. Quem precisa saber o que aconteceu usa
+// errors.Is/errors.As, e o status e a mensagem pública de cada um são
+// decididos só em httpError
+var (
+	// CEP fora do formato de 8 dígitos
+	ErrInvalidCEP = errors.New("invalid zipcode")
+	// ?country= que não é um código ISO de duas letras
+	ErrInvalidCountry = errors.New("invalid country")
+	// Sigla que não é de nenhuma das 27 UFs
+	ErrInvalidState = errors.New("invalid state")
+	// Nome de cidade vazio ou com vírgula
+	ErrInvalidCity = errors.New("invalid city")
+	// Código IBGE fora do formato de 7 dígitos com o código de uma UF
+	ErrInvalidIBGE = errors.New("invalid ibge code")
+	// O IBGE respondeu que o código não é de nenhum município
+	ErrMunicipalityNotFound = errors.New("municipality not found")
+	// Latitude ou longitude que não é um número ou está fora da faixa
+	ErrInvalidCoordinates = errors.New("invalid coordinates")
+	// O provedor de CEP respondeu que o CEP não existe
+	ErrCEPNotFound = cep.ErrNotFound
+	// Com CEP_STRICT, o CEP não cai na faixa de nenhuma UF e nem chega ao
+	// provedor. Continua sendo um ErrCEPNotFound, só com uma mensagem mais precisa
+	ErrCEPPrefixNotAllocated = fmt.Errorf("cep prefix not allocated: %w", ErrCEPNotFound)
+	// Um provedor externo não respondeu: rede, 5xx, circuit breaker aberto ou
+	// cota esgotada. Todo *UpstreamError é um ErrUpstreamUnavailable
+	ErrUpstreamUnavailable = errors.New("upstream unavailable")
+)
+
+// Nome do provedor em UpstreamError para as falhas na consulta de CEP, seja
+// qual for o provedor (ViaCEP ou BrasilAPI) que falhou
+const cepProvidersName = "cep"
+
+// Nome do provedor em UpstreamError para as falhas na API de localidades do IBGE
+const ibgeProviderName = "ibge"
+
+// Falha ao consultar um provedor externo. Provider é "cep", "ibge" ou "weather_api";
+// a mensagem é a do erro original, que já diz o que falhou
+type UpstreamError struct {
+	Provider string
+	Err      error
+}
+
+func (e *UpstreamError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+func (e *UpstreamError) Is(target error) bool {
+	return target == ErrUpstreamUnavailable
+}
+
+// Status HTTP e mensagem pública de um erro. A cota esgotada e o circuit
+// breaker da WeatherAPI aberto são temporários e viram 503; as demais falhas
+// da WeatherAPI e as do provedor de CEP, 500
+func httpError(err error) (int, ErrorResponse) {
+	var upstream *UpstreamError
+	switch {
+	case errors.Is(err, ErrInvalidCEP):
+		return http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid zipcode"}
+	case errors.Is(err, ErrInvalidCountry):
+		return http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid country"}
+	case errors.Is(err, ErrInvalidState):
+		return http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid state"}
+	case errors.Is(err, ErrInvalidCity):
+		return http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid city"}
+	case errors.Is(err, ErrInvalidCoordinates):
+		return http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid coordinates"}
+	case errors.Is(err, ErrInvalidIBGE):
+		return http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid ibge code"}
+	case errors.Is(err, ErrMunicipalityNotFound):
+		return http.StatusNotFound, ErrorResponse{Message: "can not find municipality"}
+	case errors.Is(err, ErrCEPPrefixNotAllocated):
+		return http.StatusNotFound, ErrorResponse{Message: "cep prefix not allocated"}
+	case errors.Is(err, ErrCEPNotFound):
+		return http.StatusNotFound, ErrorResponse{Message: "can not find zipcode"}
+	case errors.Is(err, errWeatherAPIKeyMissing):
+		return http.StatusInternalServerError, ErrorResponse{Message: "error fetching weather data"}
+	case errors.As(err, &upstream) && upstream.Provider == weatherAPIProvider.name:
+		if errors.Is(err, errQuotaExhausted) {
+			return http.StatusServiceUnavailable, ErrorResponse{Message: "weather api quota exhausted"}
+		}
+		if errors.Is(err, errCircuitOpen) {
+			return http.StatusServiceUnavailable, ErrorResponse{Message: "weather api unavailable"}
+		}
+		return http.StatusInternalServerError, ErrorResponse{Message: "error fetching weather data"}
+	default:
+		return http.StatusInternalServerError, ErrorResponse{Message: "internal server error"}
+	}
+}
+
+// Erro cuja mensagem é a mensagem pública, para quem mostra o erro fora de
+// uma resposta HTTP (GraphQL, WebSocket, lotes, CLI). O erro original
+// continua acessível por errors.Is/errors.As
+type publicError struct {
+	message string
+	err     error
+}
+
+func (e *publicError) Error() string {
+	return e.message
+}
+
+func (e *publicError) Unwrap() error {
+	return e.err
+}
+
+func newPublicError(err error) error {
+	_, body := httpError(err)
+	return &publicError{message: body.Message, err: err}
+}
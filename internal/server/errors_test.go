@@ -0,0 +1,71 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		status  int
+		message string
+	}{
+		{"Invalid CEP", ErrInvalidCEP, http.StatusUnprocessableEntity, "invalid zipcode"},
+		{"Invalid country", ErrInvalidCountry, http.StatusUnprocessableEntity, "invalid country"},
+		{"Invalid state", ErrInvalidState, http.StatusUnprocessableEntity, "invalid state"},
+		{"Invalid city", ErrInvalidCity, http.StatusUnprocessableEntity, "invalid city"},
+		{"Invalid coordinates", ErrInvalidCoordinates, http.StatusUnprocessableEntity, "invalid coordinates"},
+		{"Invalid IBGE code", ErrInvalidIBGE, http.StatusUnprocessableEntity, "invalid ibge code"},
+		{"Municipality not found", ErrMunicipalityNotFound, http.StatusNotFound, "can not find municipality"},
+		{"IBGE down", &UpstreamError{Provider: ibgeProviderName, Err: errors.New("ibge returned status 502")}, http.StatusInternalServerError, "internal server error"},
+		{"CEP prefix not allocated", ErrCEPPrefixNotAllocated, http.StatusNotFound, "cep prefix not allocated"},
+		{"CEP not found", ErrCEPNotFound, http.StatusNotFound, "can not find zipcode"},
+		{"Wrapped not found", fmt.Errorf("lookup 99999999: %w", ErrCEPNotFound), http.StatusNotFound, "can not find zipcode"},
+		{"CEP provider down", &UpstreamError{Provider: cepProvidersName, Err: errors.New("viacep returned status 502")}, http.StatusInternalServerError, "internal server error"},
+		{"CEP provider breaker open", &UpstreamError{Provider: cepProvidersName, Err: errCircuitOpen}, http.StatusInternalServerError, "internal server error"},
+		{"Weather API breaker open", weatherAPIFailure(errCircuitOpen), http.StatusServiceUnavailable, "weather api unavailable"},
+		{"Weather API quota exhausted", weatherAPIFailure(errQuotaExhausted), http.StatusServiceUnavailable, "weather api quota exhausted"},
+		{"Weather API error", weatherAPIFailure(errors.New("weather API error: status 500")), http.StatusInternalServerError, "error fetching weather data"},
+		{"Weather API key missing", errWeatherAPIKeyMissing, http.StatusInternalServerError, "error fetching weather data"},
+		{"Unknown", errors.New("boom"), http.StatusInternalServerError, "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := httpError(tt.err)
+			assert.Equal(t, tt.status, status)
+			assert.Equal(t, tt.message, body.Message)
+		})
+	}
+}
+
+func TestUpstreamError_Is(t *testing.T) {
+	err := weatherAPIFailure(errCircuitOpen)
+	assert.ErrorIs(t, err, ErrUpstreamUnavailable)
+	assert.ErrorIs(t, err, errCircuitOpen)
+	assert.NotErrorIs(t, ErrCEPNotFound, ErrUpstreamUnavailable)
+
+	var upstream *UpstreamError
+	assert.True(t, errors.As(err, &upstream))
+	assert.Equal(t, "weather_api", upstream.Provider)
+}
+
+// Fora de uma resposta HTTP o erro mostra a mensagem pública, sem perder o original
+func TestLookupAddress_PublicErrors(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{}, fakeWeatherClient{})
+
+	_, err := s.lookupAddress(context.Background(), "123")
+	assert.EqualError(t, err, "invalid zipcode")
+	assert.ErrorIs(t, err, ErrInvalidCEP)
+
+	_, err = s.lookupAddress(context.Background(), "99999999")
+	assert.EqualError(t, err, "can not find zipcode")
+	assert.ErrorIs(t, err, ErrCEPNotFound)
+}
@@ -0,0 +1,36 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// ETag da resposta de clima atual: muda quando a WeatherAPI publica uma nova
+// observação para a localização. Query, formato negociado pelo Accept e
+// TEMP_PRECISION entram no cálculo porque alteram a representação; é fraco
+// porque a compressão altera os bytes
+func weatherETag(r *http.Request, location string, observedAt int64) string {
+	precision := "none"
+	if digits, ok := temperaturePrecision(); ok {
+		precision = strconv.Itoa(digits)
+	}
+
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%d\n%s\n%s\n%s", location, observedAt, r.URL.RawQuery, negotiateMediaType(r.Header.Get("Accept")), precision)
+	return `W/"` + hex.EncodeToString(hash.Sum(nil))[:32] + `"`
+}
+
+// Compara o If-None-Match com o ETag atual usando comparação fraca (RFC 9110)
+func etagMatches(ifNoneMatch, etag string) bool {
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
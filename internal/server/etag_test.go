@@ -0,0 +1,91 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeatherHandler_ConditionalGet(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"last_updated_epoch":1760000000,"temp_c":25}}`,
+	})
+
+	router := s.router()
+
+	req, err := http.NewRequest("GET", "/weather/01310100", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	etag := rr.Header().Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	tests := []struct {
+		name           string
+		path           string
+		ifNoneMatch    string
+		expectedStatus int
+	}{
+		{"Matching ETag", "/weather/01310100", etag, http.StatusNotModified},
+		{"Matching ETag in list", "/weather/01310100", `W/"other", ` + etag, http.StatusNotModified},
+		{"Wildcard", "/weather/01310100", "*", http.StatusNotModified},
+		{"Stale ETag", "/weather/01310100", `W/"stale"`, http.StatusOK},
+		{"Different representation", "/weather/01310100?units=c", etag, http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.path, nil)
+			assert.NoError(t, err)
+			req.Header.Set("If-None-Match", tt.ifNoneMatch)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.NotEmpty(t, rr.Header().Get("ETag"))
+			if tt.expectedStatus == http.StatusNotModified {
+				assert.Empty(t, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestWeatherETag_ChangesWithObservation(t *testing.T) {
+	req, err := http.NewRequest("GET", "/weather/01310100", nil)
+	assert.NoError(t, err)
+
+	first := weatherETag(req, "São Paulo,SP", 1760000000)
+	assert.Equal(t, first, weatherETag(req, "São Paulo,SP", 1760000000))
+	assert.NotEqual(t, first, weatherETag(req, "São Paulo,SP", 1760000900))
+	assert.NotEqual(t, first, weatherETag(req, "Rio de Janeiro,RJ", 1760000000))
+}
+
+// Mudar a precisão ou o formato muda os bytes da resposta, e o ETag junto
+func TestWeatherETag_ChangesWithFormatting(t *testing.T) {
+	req, err := http.NewRequest("GET", "/weather/01310100", nil)
+	assert.NoError(t, err)
+	first := weatherETag(req, "São Paulo,SP", 1760000000)
+
+	t.Setenv("TEMP_PRECISION", "1")
+	rounded := weatherETag(req, "São Paulo,SP", 1760000000)
+	assert.NotEqual(t, first, rounded)
+	t.Setenv("TEMP_PRECISION", "2")
+	assert.NotEqual(t, rounded, weatherETag(req, "São Paulo,SP", 1760000000))
+
+	req.Header.Set("Accept", "text/csv")
+	csv := weatherETag(req, "São Paulo,SP", 1760000000)
+	req.Header.Set("Accept", "application/xml")
+	assert.NotEqual(t, csv, weatherETag(req, "São Paulo,SP", 1760000000))
+
+	// Accepts diferentes que levam ao mesmo formato compartilham o ETag
+	req.Header.Set("Accept", "application/xml;q=0.9, text/html;q=0.1")
+	xml := weatherETag(req, "São Paulo,SP", 1760000000)
+	req.Header.Set("Accept", "application/xml")
+	assert.Equal(t, xml, weatherETag(req, "São Paulo,SP", 1760000000))
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Linha da exportação das leituras registradas, com a temperatura em Celsius
+// como foi gravada
+type ExportedReading struct {
+	LookedUpAt time.Time `json:"looked_up_at"`
+	CEP        string    `json:"cep"`
+	City       string    `json:"city"`
+	UF         string    `json:"uf"`
+	TempC      float64   `json:"temp_C"`
+}
+
+// Escreve as leituras uma a uma no corpo da resposta
+type readingEncoder interface {
+	encode(reading ExportedReading) error
+	flush() error
+}
+
+type exportFormat struct {
+	contentType string
+	newEncoder  func(w io.Writer) readingEncoder
+}
+
+// Formatos aceitos em ?format=, CSV por padrão
+var exportFormats = map[string]exportFormat{
+	"csv":   {contentType: "text/csv; charset=utf-8", newEncoder: newCSVReadingEncoder},
+	"jsonl": {contentType: "application/x-ndjson", newEncoder: newJSONLReadingEncoder},
+}
+
+// GET /export: todas as leituras registradas no período from/to (YYYY-MM-DD,
+// inclusivos), da mais antiga para a mais recente, em CSV ou JSON Lines. As
+// linhas são escritas conforme saem do banco, sem montar o arquivo na memória
+func (s *Server) exportHandler(w http.ResponseWriter, r *http.Request) {
+	if s.lookups == nil {
+		notFoundHandler(w, r)
+		return
+	}
+
+	name := r.URL.Query().Get("format")
+	if name == "" {
+		name = "csv"
+	}
+	format, ok := exportFormats[name]
+	if !ok {
+		writeResponse(w, r, http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid format"})
+		return
+	}
+
+	var query lookupQuery
+	var err error
+	if query.From, query.To, err = readingsPeriod(r); err != nil {
+		writeResponse(w, r, http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid date"})
+		return
+	}
+
+	// O status só é enviado com a primeira linha, para que uma falha logo na
+	// consulta ainda possa virar um 500
+	var encoder readingEncoder
+	start := func() {
+		w.Header().Set("Content-Type", format.contentType)
+		w.Header().Set("Content-Disposition", `attachment; filename="readings.`+name+`"`)
+		w.WriteHeader(http.StatusOK)
+		encoder = format.newEncoder(w)
+	}
+
+	rows := 0
+	err = s.lookups.export(r.Context(), query, func(record lookupRecord) error {
+		if encoder == nil {
+			start()
+		}
+		rows++
+		return encoder.encode(ExportedReading{LookedUpAt: record.At, CEP: record.CEP, City: record.City, UF: record.UF, TempC: record.TempC})
+	})
+	if err != nil && encoder == nil {
+		log.Printf("ERROR: Failed to export readings: %v", err)
+		writeResponse(w, r, http.StatusInternalServerError, ErrorResponse{Message: "internal server error"})
+		return
+	}
+	if encoder == nil {
+		start()
+	}
+	if flushErr := encoder.flush(); err == nil {
+		err = flushErr
+	}
+	if err != nil {
+		// Com parte do arquivo já enviada, resta interromper a exportação
+		log.Printf("ERROR: Export interrupted after %d readings: %v", rows, err)
+		return
+	}
+
+	log.Printf("Exported %d readings as %s", rows, name)
+}
+
+type csvReadingEncoder struct {
+	writer *csv.Writer
+}
+
+func newCSVReadingEncoder(w io.Writer) readingEncoder {
+	writer := csv.NewWriter(w)
+	writer.Write([]string{"looked_up_at", "cep", "city", "uf", "temp_C"})
+	return &csvReadingEncoder{writer: writer}
+}
+
+func (e *csvReadingEncoder) encode(reading ExportedReading) error {
+	return e.writer.Write([]string{
+		reading.LookedUpAt.UTC().Format(time.RFC3339),
+		reading.CEP,
+		reading.City,
+		reading.UF,
+		strconv.FormatFloat(reading.TempC, 'f', -1, 64),
+	})
+}
+
+func (e *csvReadingEncoder) flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+type jsonlReadingEncoder struct {
+	buf     *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newJSONLReadingEncoder(w io.Writer) readingEncoder {
+	buf := bufio.NewWriter(w)
+	return &jsonlReadingEncoder{buf: buf, encoder: json.NewEncoder(buf)}
+}
+
+// json.Encoder termina cada valor com uma quebra de linha, como pede o JSON Lines
+func (e *jsonlReadingEncoder) encode(reading ExportedReading) error {
+	return e.encoder.Encode(reading)
+}
+
+func (e *jsonlReadingEncoder) flush() error {
+	return e.buf.Flush()
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func withExportReadings(t *testing.T, s *Server) {
+	store := withSQLiteLookups(t, s)
+	for _, record := range []lookupRecord{
+		{CEP: "01310100", City: "São Paulo", UF: "SP", TempC: 21.5, At: time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)},
+		{CEP: "20040002", City: "Rio de Janeiro, Centro", UF: "RJ", TempC: 30, At: time.Date(2026, 10, 16, 9, 30, 0, 0, time.UTC)},
+		{CEP: "01310100", City: "São Paulo", UF: "SP", TempC: 19, At: time.Date(2026, 10, 17, 8, 0, 0, 0, time.UTC)},
+	} {
+		assert.NoError(t, store.record(context.Background(), record))
+	}
+}
+
+func exportRequest(t *testing.T, s *Server, target string) *httptest.ResponseRecorder {
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", target, nil))
+	return rr
+}
+
+func TestExportHandler_CSV(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	withExportReadings(t, s)
+
+	rr := exportRequest(t, s, "/export?from=2026-10-15&to=2026-10-16")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `attachment; filename="readings.csv"`, rr.Header().Get("Content-Disposition"))
+	assert.Equal(t, "looked_up_at,cep,city,uf,temp_C\n"+
+		"2026-10-15T12:00:00Z,01310100,São Paulo,SP,21.5\n"+
+		"2026-10-16T09:30:00Z,20040002,\"Rio de Janeiro, Centro\",RJ,30\n", rr.Body.String())
+}
+
+func TestExportHandler_JSONL(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	withExportReadings(t, s)
+
+	rr := exportRequest(t, s, "/export?format=jsonl&from=2026-10-16")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "application/x-ndjson", rr.Header().Get("Content-Type"))
+	assert.Equal(t, `{"looked_up_at":"2026-10-16T09:30:00Z","cep":"20040002","city":"Rio de Janeiro, Centro","uf":"RJ","temp_C":30}`+"\n"+
+		`{"looked_up_at":"2026-10-17T08:00:00Z","cep":"01310100","city":"São Paulo","uf":"SP","temp_C":19}`+"\n", rr.Body.String())
+}
+
+// Um período sem leituras ainda gera o cabeçalho do CSV
+func TestExportHandler_Empty(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	withExportReadings(t, s)
+
+	rr := exportRequest(t, s, "/export?from=2025-01-01&to=2025-01-31")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "looked_up_at,cep,city,uf,temp_C\n", rr.Body.String())
+}
+
+func TestExportHandler_InvalidParams(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	withExportReadings(t, s)
+
+	tests := []struct {
+		target  string
+		status  int
+		message string
+	}{
+		{"/export?format=xlsx", http.StatusUnprocessableEntity, "invalid format"},
+		{"/export?from=15/10/2026", http.StatusUnprocessableEntity, "invalid date"},
+		{"/export?from=2026-10-17&to=2026-10-15", http.StatusUnprocessableEntity, "invalid date"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			rr := exportRequest(t, s, tt.target)
+			assert.Equal(t, tt.status, rr.Code)
+			assert.JSONEq(t, `{"message":"`+tt.message+`"}`, rr.Body.String())
+		})
+	}
+}
+
+func TestExportHandler_WithoutLookups(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	assert.Equal(t, http.StatusNotFound, exportRequest(t, s, "/export").Code)
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strconv"
+)
+
+// Máximo de dias aceito pelo forecast.json da WeatherAPI
+const maxForecastDays = 14
+
+// Temperaturas média, mínima e máxima de um dia
+type DailyTemperatures struct {
+	Date string          `json:"date" xml:"date"`
+	Avg  WeatherResponse `json:"avg" xml:"avg"`
+	Min  WeatherResponse `json:"min" xml:"min"`
+	Max  WeatherResponse `json:"max" xml:"max"`
+}
+
+// Formato compartilhado pelos endpoints forecast.json e history.json
+type WeatherAPIForecastResponse struct {
+	Forecast struct {
+		ForecastDay []struct {
+			Date string `json:"date"`
+			Day  struct {
+				MaxTempC float64 `json:"maxtemp_c"`
+				MinTempC float64 `json:"mintemp_c"`
+				AvgTempC float64 `json:"avgtemp_c"`
+			} `json:"day"`
+		} `json:"forecastday"`
+	} `json:"forecast"`
+}
+
+func (f WeatherAPIForecastResponse) dailyTemperatures(units temperatureUnits) []DailyTemperatures {
+	days := make([]DailyTemperatures, 0, len(f.Forecast.ForecastDay))
+	for _, forecastDay := range f.Forecast.ForecastDay {
+		days = append(days, DailyTemperatures{
+			Date: forecastDay.Date,
+			Avg:  newWeatherResponse(forecastDay.Day.AvgTempC, units),
+			Min:  newWeatherResponse(forecastDay.Day.MinTempC, units),
+			Max:  newWeatherResponse(forecastDay.Day.MaxTempC, units),
+		})
+	}
+	return days
+}
+
+func (s *Server) getForecast(ctx context.Context, location string, days int, units temperatureUnits) ([]DailyTemperatures, error) {
+	if days < 1 || days > maxForecastDays {
+		return nil, fmt.Errorf("forecast days must be between 1 and %d", maxForecastDays)
+	}
+
+	log.Printf("Fetching %d day forecast for location: %s", days, location)
+
+	var forecast WeatherAPIForecastResponse
+	params := url.Values{"q": {location}, "days": {strconv.Itoa(days)}, "aqi": {"no"}, "alerts": {"no"}}
+	if err := s.callWeatherAPI(ctx, "forecast.json", params, &forecast); err != nil {
+		return nil, err
+	}
+
+	return forecast.dailyTemperatures(units), nil
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/weather-service/internal/cep"
+)
+
+// Provedor de CEP em memória que guarda os CEPs que chegaram até ele
+type recordingCEPClient struct {
+	fakeCEPClient
+	mu      sync.Mutex
+	lookups []string
+}
+
+func (c *recordingCEPClient) Lookup(ctx context.Context, code string) (*cep.Address, error) {
+	c.mu.Lock()
+	c.lookups = append(c.lookups, code)
+	c.mu.Unlock()
+	return c.fakeCEPClient.Lookup(ctx, code)
+}
+
+// O trecho do path depois de /weather/ chega ao handler como veio, inclusive
+// com escapes (%2F, %00) e caracteres fora do ASCII. Nenhum deles pode
+// derrubar o handler, virar um 500 ou chegar ao provedor sem ser um CEP válido
+func FuzzWeatherPath(f *testing.F) {
+	for _, seed := range []string{
+		"01310100", "01310-100", "%2001310100", "01310100%20", "0131%2F0100", "01310100%2F..%2F",
+		"%30%31%33%31%30%31%30%30", "０１３１０１００", "0-1-3-1-0-1-0-0", "99999999", "%00",
+		"..%2F..%2Fadmin", strings.Repeat("0", 8192), strings.Repeat("%2F", 1024),
+	} {
+		f.Add(seed)
+	}
+
+	client := &recordingCEPClient{fakeCEPClient: fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}}
+	s := newFakeClientsServer(f, client.fakeCEPClient, fakeWeatherClient{"São Paulo,SP": 25})
+	s.viaCEP = client
+
+	router := chi.NewRouter()
+	router.Get("/weather/{cep}", s.weatherHandler)
+
+	f.Fuzz(func(t *testing.T, path string) {
+		req, err := http.NewRequest("GET", "/weather/"+path, nil)
+		if err != nil {
+			return
+		}
+
+		client.mu.Lock()
+		client.lookups = nil
+		client.mu.Unlock()
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		switch rr.Code {
+		case http.StatusOK, http.StatusNotFound, http.StatusUnprocessableEntity:
+		default:
+			t.Fatalf("GET /weather/%s = %d: %s", path, rr.Code, rr.Body.String())
+		}
+
+		client.mu.Lock()
+		defer client.mu.Unlock()
+		for _, code := range client.lookups {
+			if len(code) != 8 || !cep.Valid(code) {
+				t.Fatalf("GET /weather/%s looked up %q", path, code)
+			}
+		}
+	})
+}
@@ -0,0 +1,104 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/internal/cep"
+)
+
+// go test -run Golden -update regrava os arquivos de testdata/golden
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/golden")
+
+// Provedor de CEP fora do ar: toda consulta falha sem dizer se o CEP existe
+type failingCEPClient struct{}
+
+func (failingCEPClient) Lookup(ctx context.Context, code string) (*cep.Address, error) {
+	return nil, errors.New("connection reset by peer")
+}
+
+// Status, tipo e corpo de uma resposta, no formato dos arquivos golden
+type goldenResponse struct {
+	Status      int             `json:"status"`
+	ContentType string          `json:"content_type"`
+	Body        json.RawMessage `json:"body"`
+}
+
+// Compara a resposta com testdata/golden/<name>.json, ou a grava com -update
+func assertGolden(t *testing.T, name string, rr *httptest.ResponseRecorder) {
+	t.Helper()
+
+	var body bytes.Buffer
+	if err := json.Indent(&body, rr.Body.Bytes(), "  ", "  "); err != nil {
+		t.Fatalf("response is not JSON: %v: %s", err, rr.Body.String())
+	}
+	got, err := json.MarshalIndent(goldenResponse{
+		Status:      rr.Code,
+		ContentType: rr.Header().Get("Content-Type"),
+		Body:        body.Bytes(),
+	}, "", "  ")
+	assert.NoError(t, err)
+	got = append(got, '\n')
+
+	path := filepath.Join("testdata", "golden", name+".json")
+	if *updateGolden {
+		assert.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		assert.NoError(t, os.WriteFile(path, got, 0o644))
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("missing golden file (run go test -run Golden -update): %v", err)
+	}
+	assert.Equal(t, string(want), string(got), "response differs from %s; if the change is intended, run go test -run Golden -update", path)
+}
+
+// O contrato de /weather/{cep}: nomes dos campos, mensagens e status de cada
+// resposta. Renomear um campo ou trocar um status quebra estes testes
+func TestWeatherHandler_Golden(t *testing.T) {
+	addresses := fakeCEPClient{
+		"01310100": {CEP: "01310-100", Street: "Avenida Paulista", Neighborhood: "Bela Vista", City: "São Paulo", UF: "SP"},
+		// A WeatherAPI não conhece a localização
+		"69900000": {CEP: "69900-000", City: "Cidade Inexistente", UF: "XX"},
+	}
+	temperatures := fakeWeatherClient{"São Paulo,SP": 25}
+
+	tests := []struct {
+		name   string
+		path   string
+		failed bool
+	}{
+		{"weather_success", "/weather/01310100", false},
+		{"weather_success_all_units", "/weather/01310-100?units=c,f,k,r&include=location", false},
+		{"weather_invalid_zipcode", "/weather/0131010a", false},
+		{"weather_invalid_units", "/weather/01310100?units=x", false},
+		{"weather_not_found", "/weather/99999999", false},
+		{"weather_upstream_failure", "/weather/69900000", false},
+		{"weather_cep_provider_failure", "/weather/01310100", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := newFakeClientsServer(t, addresses, temperatures)
+			if tt.failed {
+				s.viaCEP, s.brasilAPI = failingCEPClient{}, failingCEPClient{}
+				// Sem a tabela de faixas como reserva, a falha chega ao cliente
+				t.Setenv("CEP_FALLBACK", "false")
+			}
+
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assertGolden(t, tt.name, rr)
+		})
+	}
+}
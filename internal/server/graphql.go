@@ -0,0 +1,263 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/graphql-go/graphql"
+	"github.com/graphql-go/graphql/language/ast"
+	"github.com/graphql-go/graphql/language/parser"
+)
+
+type GraphQLRequest struct {
+	Query         string                 `json:"query"`
+	OperationName string                 `json:"operationName"`
+	Variables     map[string]interface{} `json:"variables"`
+}
+
+// Todas as escalas ficam disponíveis; o cliente escolhe os campos na query
+var graphqlUnits = temperatureUnits{C: true, F: true, K: true, R: true}
+
+var temperaturesType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Temperatures",
+	Fields: graphql.Fields{
+		"temp_C": &graphql.Field{Type: graphql.Float},
+		"temp_F": &graphql.Field{Type: graphql.Float},
+		"temp_K": &graphql.Field{Type: graphql.Float},
+		"temp_R": &graphql.Field{Type: graphql.Float},
+	},
+})
+
+var addressType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Address",
+	Fields: graphql.Fields{
+		"cep":        &graphql.Field{Type: graphql.String},
+		"logradouro": &graphql.Field{Type: graphql.String},
+		"bairro":     &graphql.Field{Type: graphql.String},
+		"localidade": &graphql.Field{Type: graphql.String},
+		"uf":         &graphql.Field{Type: graphql.String},
+	},
+})
+
+var conditionsType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Conditions",
+	Fields: graphql.Fields{
+		"humidity":       &graphql.Field{Type: graphql.Int},
+		"wind_kph":       &graphql.Field{Type: graphql.Float},
+		"wind_dir":       &graphql.Field{Type: graphql.String},
+		"wind_degree":    &graphql.Field{Type: graphql.Int},
+		"pressure_mb":    &graphql.Field{Type: graphql.Float},
+		"cloud":          &graphql.Field{Type: graphql.Int},
+		"condition_text": &graphql.Field{Type: graphql.String},
+		"condition_code": &graphql.Field{Type: graphql.Int},
+	},
+})
+
+var weatherType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "Weather",
+	Fields: graphql.Fields{
+		"temp_C":       &graphql.Field{Type: graphql.Float},
+		"temp_F":       &graphql.Field{Type: graphql.Float},
+		"temp_K":       &graphql.Field{Type: graphql.Float},
+		"temp_R":       &graphql.Field{Type: graphql.Float},
+		"feels_like_C": &graphql.Field{Type: graphql.Float},
+		"feels_like_F": &graphql.Field{Type: graphql.Float},
+		"feels_like_K": &graphql.Field{Type: graphql.Float},
+		"feels_like_R": &graphql.Field{Type: graphql.Float},
+		"conditions":   &graphql.Field{Type: conditionsType},
+		"location":     &graphql.Field{Type: addressType},
+	},
+})
+
+var forecastDayType = graphql.NewObject(graphql.ObjectConfig{
+	Name: "ForecastDay",
+	Fields: graphql.Fields{
+		"date": &graphql.Field{Type: graphql.String},
+		"avg":  &graphql.Field{Type: temperaturesType},
+		"min":  &graphql.Field{Type: temperaturesType},
+		"max":  &graphql.Field{Type: temperaturesType},
+	},
+})
+
+var cepArgs = graphql.FieldConfigArgument{
+	"cep": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+}
+
+func (s *Server) mustGraphQLSchema() graphql.Schema {
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"weather": &graphql.Field{
+				Type:    weatherType,
+				Args:    cepArgs,
+				Resolve: s.resolveWeatherField,
+			},
+			"forecast": &graphql.Field{
+				Type: graphql.NewList(forecastDayType),
+				Args: graphql.FieldConfigArgument{
+					"cep":  &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+					"days": &graphql.ArgumentConfig{Type: graphql.Int, DefaultValue: 3},
+				},
+				Resolve: s.resolveForecastField,
+			},
+			"address": &graphql.Field{
+				Type:    addressType,
+				Args:    cepArgs,
+				Resolve: s.resolveAddressField,
+			},
+		},
+	})
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{Query: query})
+	if err != nil {
+		log.Fatalf("ERROR: Invalid GraphQL schema: %v", err)
+	}
+	return schema
+}
+
+func (s *Server) graphqlHandler(w http.ResponseWriter, r *http.Request) {
+	var request GraphQLRequest
+	if r.Method == http.MethodGet {
+		request.Query = r.URL.Query().Get("query")
+		request.OperationName = r.URL.Query().Get("operationName")
+	} else if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		if isBodyTooLarge(err) {
+			writeJSON(w, http.StatusRequestEntityTooLarge, ErrorResponse{Message: "request body too large"})
+			return
+		}
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Message: "invalid request body"})
+		return
+	}
+
+	// Cada campo da raiz (com aliases, cada repetição) é uma consulta aos
+	// provedores: acima do limite do lote, a query é recusada antes de rodar
+	if fields := graphqlRootFields(request.Query, request.OperationName); fields > batchMaxSize() {
+		log.Printf("GraphQL query rejected: %d root fields (limit %d)", fields, batchMaxSize())
+		writeJSON(w, http.StatusBadRequest, ErrorResponse{Message: fmt.Sprintf("too many fields in query (max %d)", batchMaxSize())})
+		return
+	}
+
+	result := graphql.Do(graphql.Params{
+		Schema:         s.graphqlSchema,
+		RequestString:  request.Query,
+		OperationName:  request.OperationName,
+		VariableValues: request.Variables,
+		Context:        r.Context(),
+	})
+	if result.HasErrors() {
+		log.Printf("GraphQL query returned errors: %v", result.Errors)
+	}
+
+	// Erros de execução seguem a convenção GraphQL: status 200 com o campo errors
+	writeJSON(w, http.StatusOK, result)
+}
+
+// Quantidade de campos na raiz da operação que será executada, contando os
+// que vêm de fragmentos. Uma query que não é válida conta 0 e segue para o
+// graphql.Do, que devolve o erro de sintaxe. O schema não tem tipos
+// recursivos, então a profundidade já é limitada por ele
+func graphqlRootFields(query, operationName string) int {
+	document, err := parser.Parse(parser.ParseParams{Source: query})
+	if err != nil {
+		return 0
+	}
+
+	fragments := make(map[string]*ast.FragmentDefinition)
+	for _, definition := range document.Definitions {
+		if fragment, ok := definition.(*ast.FragmentDefinition); ok && fragment.Name != nil {
+			fragments[fragment.Name.Value] = fragment
+		}
+	}
+
+	var count func(set *ast.SelectionSet, seen map[string]bool) int
+	count = func(set *ast.SelectionSet, seen map[string]bool) int {
+		if set == nil {
+			return 0
+		}
+		n := 0
+		for _, selection := range set.Selections {
+			switch selection := selection.(type) {
+			case *ast.Field:
+				n++
+			case *ast.InlineFragment:
+				n += count(selection.SelectionSet, seen)
+			case *ast.FragmentSpread:
+				// Fragmentos em ciclo são recusados pela validação; aqui só
+				// não podem travar a contagem
+				name := selection.Name.Value
+				if fragment, ok := fragments[name]; ok && !seen[name] {
+					seen[name] = true
+					n += count(fragment.SelectionSet, seen)
+					delete(seen, name)
+				}
+			}
+		}
+		return n
+	}
+
+	fields := 0
+	for _, definition := range document.Definitions {
+		operation, ok := definition.(*ast.OperationDefinition)
+		if !ok {
+			continue
+		}
+		if operationName != "" && (operation.Name == nil || operation.Name.Value != operationName) {
+			continue
+		}
+		fields += count(operation.SelectionSet, make(map[string]bool))
+	}
+	return fields
+}
+
+func (s *Server) resolveAddressField(p graphql.ResolveParams) (interface{}, error) {
+	address, err := s.lookupAddress(p.Context, p.Args["cep"].(string))
+	if err != nil {
+		return nil, err
+	}
+	return address.address(), nil
+}
+
+func (s *Server) resolveWeatherField(p graphql.ResolveParams) (interface{}, error) {
+	address, err := s.lookupAddress(p.Context, p.Args["cep"].(string))
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.getCurrentWeather(p.Context, address.location(), false)
+	if err != nil {
+		log.Printf("ERROR: Failed to get temperature for location '%s': %v", address.location(), err)
+		return nil, newPublicError(err)
+	}
+
+	response := newWeatherResponse(current.Current.TempC, graphqlUnits)
+	feelsLike := newWeatherResponse(current.Current.FeelsLikeC, graphqlUnits)
+	response.FeelsLikeC = feelsLike.TempC
+	response.FeelsLikeF = feelsLike.TempF
+	response.FeelsLikeK = feelsLike.TempK
+	response.FeelsLikeR = feelsLike.TempR
+	response.Conditions = current.conditions()
+	response.Location = address.address()
+	return response, nil
+}
+
+func (s *Server) resolveForecastField(p graphql.ResolveParams) (interface{}, error) {
+	days := p.Args["days"].(int)
+	if days < 1 || days > maxForecastDays {
+		return nil, errors.New("invalid days")
+	}
+
+	address, err := s.lookupAddress(p.Context, p.Args["cep"].(string))
+	if err != nil {
+		return nil, err
+	}
+
+	forecast, err := s.getForecast(p.Context, address.location(), days, graphqlUnits)
+	if err != nil {
+		log.Printf("ERROR: Failed to get forecast for location '%s': %v", address.location(), err)
+		return nil, newPublicError(err)
+	}
+	return forecast, nil
+}
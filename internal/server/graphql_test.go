@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGraphQLHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25,"feelslike_c":27,"humidity":62,"condition":{"text":"Sunny","code":1000}}}`,
+		"/v1/forecast.json":  `{"forecast":{"forecastday":[{"date":"2025-12-01","day":{"maxtemp_c":30,"mintemp_c":20,"avgtemp_c":25}}]}}`,
+	})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{
+			"Weather selecting fields",
+			`{ weather(cep: "01310100") { temp_C feels_like_F conditions { humidity condition_text } location { localidade uf } } }`,
+			`{"data":{"weather":{"temp_C":25,"feels_like_F":80.6,"conditions":{"humidity":62,"condition_text":"Sunny"},"location":{"localidade":"São Paulo","uf":"SP"}}}}`,
+		},
+		{
+			"Address only",
+			`{ address(cep: "01310-100") { logradouro bairro } }`,
+			`{"data":{"address":{"logradouro":"Avenida Paulista","bairro":"Bela Vista"}}}`,
+		},
+		{
+			"Forecast",
+			`{ forecast(cep: "01310100", days: 1) { date max { temp_C temp_K } } }`,
+			`{"data":{"forecast":[{"date":"2025-12-01","max":{"temp_C":30,"temp_K":303.15}}]}}`,
+		},
+		{
+			"Invalid zipcode",
+			`{ address(cep: "123") { uf } }`,
+			`{"data":{"address":null},"errors":[{"message":"invalid zipcode","locations":[{"line":1,"column":3}],"path":["address"]}]}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body, err := json.Marshal(GraphQLRequest{Query: tt.query})
+			assert.NoError(t, err)
+
+			req, err := http.NewRequest("POST", "/graphql", bytes.NewReader(body))
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			http.HandlerFunc(s.graphqlHandler).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.JSONEq(t, tt.expected, rr.Body.String())
+		})
+	}
+}
+
+func TestGraphQLHandler_InvalidBody(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	req, err := http.NewRequest("POST", "/graphql", bytes.NewBufferString("{"))
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(s.graphqlHandler).ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+}
+
+func TestGraphQLHandler_BodyTooLarge(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("MAX_BODY_SIZE", "16")
+
+	tests := []struct {
+		name          string
+		unknownLength bool
+	}{
+		{"Declared length over limit", false},
+		{"Unknown length", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("POST", "/graphql", bytes.NewBufferString(`{"query":"{ address(cep: \"01310100\") { uf } }"}`))
+			assert.NoError(t, err)
+			if tt.unknownLength {
+				req.ContentLength = -1
+			}
+
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusRequestEntityTooLarge, rr.Code)
+			assert.JSONEq(t, `{"message":"request body too large"}`, rr.Body.String())
+		})
+	}
+}
+
+// Aliases repetindo o mesmo campo contam cada um, como consultas separadas
+func TestGraphQLHandler_TooManyFields(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("BATCH_MAX_SIZE", "2")
+
+	var query strings.Builder
+	query.WriteString("{")
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(&query, ` w%d: weather(cep: "01310100") { temp_C }`, i)
+	}
+	query.WriteString(" }")
+	body, _ := json.Marshal(GraphQLRequest{Query: query.String()})
+
+	rr := httptest.NewRecorder()
+	http.HandlerFunc(s.graphqlHandler).ServeHTTP(rr, httptest.NewRequest("POST", "/graphql", bytes.NewReader(body)))
+
+	assert.Equal(t, http.StatusBadRequest, rr.Code)
+	assert.JSONEq(t, `{"message":"too many fields in query (max 2)"}`, rr.Body.String())
+}
+
+func TestGraphQLRootFields(t *testing.T) {
+	tests := []struct {
+		name      string
+		query     string
+		operation string
+		expected  int
+	}{
+		{"single field", `{ weather(cep: "01310100") { temp_C } }`, "", 1},
+		{"aliases", `{ a: weather(cep: "1") { temp_C } b: weather(cep: "2") { temp_C } c: address(cep: "3") { uf } }`, "", 3},
+		{"nested fields do not count", `{ weather(cep: "1") { temp_C temp_F location { uf } } }`, "", 1},
+		{"fragments", `query { ...two ... on Query { c: address(cep: "3") { uf } } } fragment two on Query { a: address(cep: "1") { uf } b: address(cep: "2") { uf } }`, "", 3},
+		{"fragment cycle", `{ ...a } fragment a on Query { x: address(cep: "1") { uf } ...a }`, "", 1},
+		{"named operation", `query A { a: address(cep: "1") { uf } } query B { a: address(cep: "1") { uf } b: address(cep: "2") { uf } }`, "B", 2},
+		{"syntax error", `{ weather(`, "", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, graphqlRootFields(tt.query, tt.operation))
+		})
+	}
+}
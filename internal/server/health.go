@@ -0,0 +1,169 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+const (
+	defaultHealthDeepTimeout  = 2 * time.Second
+	defaultHealthDeepCacheTTL = 30 * time.Second
+)
+
+// CEP consultado na ViaCEP pelo health check profundo
+const healthCheckCEP = "01001000"
+
+// Situação de uma dependência externa no health check profundo
+type DependencyStatus struct {
+	Status    string `json:"status"`
+	LatencyMS int64  `json:"latency_ms"`
+	Error     string `json:"error,omitempty"`
+}
+
+// Endereço consultado para saber se uma dependência está no ar. A WeatherAPI
+// é chamada sem chave: a resposta 401 já mostra que ela está acessível, sem
+// gastar cota
+type dependencyProbe struct {
+	name string
+	url  func() string
+	// Dependências que a configuração atual dispensa ficam fora da verificação
+	unused func() bool
+}
+
+// Dependências verificadas pelo health check profundo: os provedores de s
+func (s *Server) dependencyProbes() []dependencyProbe {
+	return []dependencyProbe{
+		{"viacep", func() string { return clientURL(s.viaCEP, healthCheckCEP) }, offlineCEP},
+		{"weather_api", func() string { return clientURL(s.weather, "current.json") }, nil},
+	}
+}
+
+// Endereço HTTP de um cliente de provedor. Clientes sem endereço, como as
+// implementações em memória dos testes, não têm o que verificar
+func clientURL(client interface{}, path string) string {
+	if client, ok := client.(interface{ URL(string) string }); ok {
+		return client.URL(path)
+	}
+	return ""
+}
+
+// Resultado da última verificação, reaproveitado por HEALTH_DEEP_CACHE_TTL
+// para que health checks frequentes não martelem as dependências
+type deepHealthCache struct {
+	mu        sync.Mutex
+	checkedAt time.Time
+	results   map[string]DependencyStatus
+	now       func() time.Time
+	probes    []dependencyProbe
+}
+
+// Verifica as dependências em paralelo, cada uma limitada a
+// HEALTH_DEEP_TIMEOUT. Requisições simultâneas esperam a mesma verificação
+func (c *deepHealthCache) check() map[string]DependencyStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.results != nil && c.now().Sub(c.checkedAt) < config.Duration("HEALTH_DEEP_CACHE_TTL", defaultHealthDeepCacheTTL) {
+		return c.results
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Duration("HEALTH_DEEP_TIMEOUT", defaultHealthDeepTimeout))
+	defer cancel()
+
+	results := make(map[string]DependencyStatus, len(c.probes))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, probe := range c.probes {
+		if probe.unused != nil && probe.unused() {
+			continue
+		}
+		url := probe.url()
+		if url == "" {
+			continue
+		}
+		wg.Add(1)
+		go func(probe dependencyProbe) {
+			defer wg.Done()
+			status := probeDependency(ctx, url)
+			mu.Lock()
+			results[probe.name] = status
+			mu.Unlock()
+		}(probe)
+	}
+	wg.Wait()
+
+	c.results, c.checkedAt = results, c.now()
+	return results
+}
+
+func (c *deepHealthCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.results = nil
+}
+
+// A dependência está no ar se responde sem erro de servidor (5xx)
+func probeDependency(ctx context.Context, url string) DependencyStatus {
+	start := time.Now()
+	status := DependencyStatus{Status: "ok"}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err == nil {
+		var resp *http.Response
+		resp, err = upstreamClient().Do(req)
+		if err == nil {
+			resp.Body.Close()
+			if resp.StatusCode >= http.StatusInternalServerError {
+				err = fmt.Errorf("status %d", resp.StatusCode)
+			}
+		}
+	}
+
+	status.LatencyMS = time.Since(start).Milliseconds()
+	if err != nil {
+		status.Status, status.Error = "down", err.Error()
+	}
+	return status
+}
+
+// Liveness: o processo está no ar e atendendo. Falhar aqui pede um restart
+func livezHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// Readiness: configuração válida, stores abertos e cache aquecido. Só olha o
+// estado da própria instância: uma falha dos provedores atinge todas as
+// réplicas ao mesmo tempo e é tratada pelo fallback e pelas respostas
+// vencidas do cache, não tirando as instâncias do balanceamento (para isso
+// existe o ?deep=true). Falhar aqui tira a instância do balanceamento sem
+// reiniciá-la. O motivo de uma configuração inválida vai só para o log
+func (s *Server) readyzHandler(w http.ResponseWriter, r *http.Request) {
+	ready := true
+	checks := map[string]string{"config": "ok", "stores": "ok", "cache": "ok"}
+
+	if err := config.Validate(); err != nil {
+		log.Printf("WARNING: Not ready, invalid configuration: %v", err)
+		ready = false
+		checks["config"] = "invalid"
+	}
+	if !s.storesReady.Load() {
+		ready = false
+		checks["stores"] = "starting"
+	}
+	if !s.cacheWarm.Load() {
+		ready = false
+		checks["cache"] = "warming up"
+	}
+
+	status, body := http.StatusOK, map[string]interface{}{"status": "ready", "checks": checks}
+	if !ready {
+		status, body["status"] = http.StatusServiceUnavailable, "not ready"
+	}
+	writeJSON(w, status, body)
+}
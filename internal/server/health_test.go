@@ -0,0 +1,214 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/internal/cep"
+	"github.com/weather-service/internal/weather"
+)
+
+type deepHealthResponse struct {
+	Status       string                      `json:"status"`
+	Dependencies map[string]DependencyStatus `json:"dependencies"`
+}
+
+func requestDeepHealth(t *testing.T, s *Server, path string) deepHealthResponse {
+	req, err := http.NewRequest("GET", path, nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response deepHealthResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	return response
+}
+
+func TestHealthHandler_Deep(t *testing.T) {
+	// A WeatherAPI sem chave responde 400/401, o que já prova que está acessível
+	s := newFakeUpstreamServer(t, map[string]string{"/ws/01001000/json/": `{"cep":"01001-000"}`})
+
+	response := requestDeepHealth(t, s, "/")
+	assert.Equal(t, "ok", response.Status)
+	assert.Nil(t, response.Dependencies, "dependencies are only checked with ?deep=true")
+
+	response = requestDeepHealth(t, s, "/?deep=true")
+	assert.Equal(t, "ok", response.Status)
+	assert.Equal(t, "ok", response.Dependencies["viacep"].Status)
+	assert.Equal(t, "ok", response.Dependencies["weather_api"].Status)
+}
+
+func TestHealthHandler_DeepDependencyDown(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	s.weather = weather.NewClient(failing.URL+"/v1", nil)
+
+	unreachable := httptest.NewServer(http.NotFoundHandler())
+	unreachable.Close()
+	s.viaCEP = cep.NewViaCEP(unreachable.URL+"/ws", nil)
+
+	response := requestDeepHealth(t, s, "/?deep=true")
+	assert.Equal(t, "degraded", response.Status)
+	assert.Equal(t, DependencyStatus{Status: "down", LatencyMS: response.Dependencies["weather_api"].LatencyMS, Error: "status 502"}, response.Dependencies["weather_api"])
+	assert.Equal(t, "down", response.Dependencies["viacep"].Status)
+	assert.Contains(t, response.Dependencies["viacep"].Error, "connection refused")
+}
+
+func TestHealthHandler_DeepTimeout(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("HEALTH_DEEP_TIMEOUT", "50ms")
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+	s.weather = weather.NewClient(slow.URL+"/v1", nil)
+
+	start := time.Now()
+	response := requestDeepHealth(t, s, "/?deep=true")
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, "down", response.Dependencies["weather_api"].Status)
+	assert.Contains(t, response.Dependencies["weather_api"].Error, "deadline exceeded")
+}
+
+// Dentro do HEALTH_DEEP_CACHE_TTL as dependências não são consultadas de novo
+func TestHealthHandler_DeepCached(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("HEALTH_DEEP_CACHE_TTL", "1m")
+
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+	}))
+	defer server.Close()
+	s.viaCEP = cep.NewViaCEP(server.URL+"/ws", nil)
+	s.weather = weather.NewClient(server.URL+"/v1", nil)
+
+	now := time.Unix(1760000000, 0)
+	s.deepHealth.now = func() time.Time { return now }
+	t.Cleanup(func() { s.deepHealth.now = time.Now })
+
+	requestDeepHealth(t, s, "/?deep=true")
+	requestDeepHealth(t, s, "/?deep=true")
+	assert.EqualValues(t, 2, calls.Load())
+
+	now = now.Add(time.Minute)
+	requestDeepHealth(t, s, "/?deep=true")
+	assert.EqualValues(t, 4, calls.Load())
+}
+
+func TestLivezHandler(t *testing.T) {
+	// O processo continua vivo mesmo com as dependências fora
+	s := newFakeUpstreamServer(t, nil)
+	s.viaCEP = cep.NewViaCEP("http://127.0.0.1:1", nil)
+	s.weather = weather.NewClient("http://127.0.0.1:1", nil)
+
+	req, err := http.NewRequest("GET", "/livez", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rr.Body.String())
+}
+
+func TestReadyzHandler(t *testing.T) {
+	requestReadyz := func(t *testing.T, s *Server) (int, map[string]interface{}) {
+		req, err := http.NewRequest("GET", "/readyz", nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		s.router().ServeHTTP(rr, req)
+		assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+
+		var body map[string]interface{}
+		assert.NoError(t, json.NewDecoder(rr.Body).Decode(&body))
+		return rr.Code, body
+	}
+
+	t.Run("Ready", func(t *testing.T) {
+		s := newFakeUpstreamServer(t, nil)
+		s.storesReady.Store(true)
+		s.cacheWarm.Store(true)
+
+		status, body := requestReadyz(t, s)
+		assert.Equal(t, http.StatusOK, status)
+		assert.Equal(t, "ready", body["status"])
+		assert.Equal(t, map[string]interface{}{"config": "ok", "stores": "ok", "cache": "ok"}, body["checks"])
+	})
+
+	t.Run("Cache warming up", func(t *testing.T) {
+		s := newFakeUpstreamServer(t, nil)
+		s.storesReady.Store(true)
+		s.cacheWarm.Store(false)
+
+		status, body := requestReadyz(t, s)
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.Equal(t, "not ready", body["status"])
+		assert.Equal(t, "warming up", body["checks"].(map[string]interface{})["cache"])
+	})
+
+	t.Run("Stores not open", func(t *testing.T) {
+		s := newFakeUpstreamServer(t, nil)
+		s.storesReady.Store(false)
+		s.cacheWarm.Store(true)
+
+		status, body := requestReadyz(t, s)
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.Equal(t, "starting", body["checks"].(map[string]interface{})["stores"])
+	})
+
+	// Uma falha dos provedores não tira a instância do balanceamento
+	t.Run("Upstream unreachable", func(t *testing.T) {
+		s := newFakeUpstreamServer(t, nil)
+		s.storesReady.Store(true)
+		s.cacheWarm.Store(true)
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer failing.Close()
+		s.viaCEP = cep.NewViaCEP(failing.URL+"/ws", nil)
+
+		status, body := requestReadyz(t, s)
+		assert.Equal(t, http.StatusOK, status)
+		assert.NotContains(t, body["checks"], "viacep")
+	})
+
+	t.Run("Invalid configuration", func(t *testing.T) {
+		s := newFakeUpstreamServer(t, nil)
+		s.storesReady.Store(true)
+		s.cacheWarm.Store(true)
+		t.Setenv("RATE_LIMIT_RPS", "fast")
+
+		status, body := requestReadyz(t, s)
+		assert.Equal(t, http.StatusServiceUnavailable, status)
+		assert.Equal(t, "invalid", body["checks"].(map[string]interface{})["config"])
+	})
+}
+
+func TestWarmCache(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	s.cacheWarm.Store(false)
+	t.Setenv("CACHE_WARMUP_CEPS", "01310100,99999999")
+
+	s.warmCache(context.Background())
+
+	assert.True(t, s.cacheWarm.Load())
+	_, cached := s.weatherCache.Get("current.json?aqi=no&q=S%C3%A3o+Paulo%2CSP")
+	assert.True(t, cached)
+}
@@ -0,0 +1,193 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+func (s *Server) historyHandler(w http.ResponseWriter, r *http.Request) {
+	// Validar a data antes de consultar qualquer API externa
+	date := r.URL.Query().Get("date")
+	if !isValidHistoryDate(date, time.Now()) {
+		log.Printf("Invalid history date: %q", date)
+		writeResponse(w, r, http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid date"})
+		return
+	}
+
+	units, ok := unitsFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	cep := cepParam(r)
+	location, ok := s.resolveLocation(w, r, cep)
+	if !ok {
+		return
+	}
+
+	history, err := s.getHistory(r.Context(), location, date, units)
+	if err != nil {
+		log.Printf("ERROR: Failed to get history for location '%s' on %s: %v", location, date, err)
+		status, body := httpError(err)
+		writeResponse(w, r, status, body)
+		return
+	}
+
+	log.Printf("Successfully processed history for CEP %s on %s", cep, date)
+
+	writeResponse(w, r, http.StatusOK, history)
+}
+
+// Aceita apenas datas no formato YYYY-MM-DD que não estejam no futuro
+func isValidHistoryDate(date string, now time.Time) bool {
+	day, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return false
+	}
+	return !day.After(now)
+}
+
+func (s *Server) getHistory(ctx context.Context, location, date string, units temperatureUnits) (*DailyTemperatures, error) {
+	log.Printf("Fetching weather history for location: %s on %s", location, date)
+
+	var history WeatherAPIForecastResponse
+	params := url.Values{"q": {location}, "dt": {date}}
+	if err := s.callWeatherAPI(ctx, "history.json", params, &history); err != nil {
+		return nil, err
+	}
+
+	days := history.dailyTemperatures(units)
+	if len(days) == 0 {
+		return nil, fmt.Errorf("no history data for %s", date)
+	}
+
+	return &days[0], nil
+}
+
+const (
+	defaultReadingsLimit = 50
+	maxReadingsLimit     = 500
+)
+
+// Leituras registradas de um CEP, da mais recente para a mais antiga
+type ReadingsResponse struct {
+	CEP      string    `json:"cep" xml:"cep"`
+	Page     int       `json:"page" xml:"page"`
+	Limit    int       `json:"limit" xml:"limit"`
+	Total    int       `json:"total" xml:"total"`
+	Readings []Reading `json:"readings" xml:"reading"`
+}
+
+type Reading struct {
+	LookedUpAt time.Time `json:"looked_up_at" xml:"looked_up_at"`
+	City       string    `json:"city" xml:"city"`
+	UF         string    `json:"uf" xml:"uf"`
+	WeatherResponse
+}
+
+// Sem date e com o registro das consultas ligado, /history/{cep} lista as
+// leituras registradas; com date, traz as temperaturas do dia na WeatherAPI
+func (s *Server) routeHistory(daily, readings http.Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.lookups != nil && !r.URL.Query().Has("date") {
+			readings.ServeHTTP(w, r)
+			return
+		}
+		daily.ServeHTTP(w, r)
+	}
+}
+
+// Leituras registradas, filtradas por from/to (YYYY-MM-DD, inclusivos) e
+// paginadas por page/limit
+func (s *Server) readingsHandler(w http.ResponseWriter, r *http.Request) {
+	units, ok := unitsFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	cep := strings.ReplaceAll(cepParam(r), "-", "")
+	if !isValidCEP(cep) {
+		status, body := httpError(ErrInvalidCEP)
+		writeResponse(w, r, status, body)
+		return
+	}
+
+	query := lookupQuery{CEP: cep}
+	var err error
+	if query.From, query.To, err = readingsPeriod(r); err != nil {
+		writeResponse(w, r, http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid date"})
+		return
+	}
+	page, limit, err := readingsPage(r)
+	if err != nil {
+		writeResponse(w, r, http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid pagination"})
+		return
+	}
+	query.Limit, query.Offset = limit, (page-1)*limit
+
+	records, total, err := s.lookups.history(r.Context(), query)
+	if err != nil {
+		log.Printf("ERROR: Failed to read lookup history for CEP %s: %v", cep, err)
+		writeResponse(w, r, http.StatusInternalServerError, ErrorResponse{Message: "internal server error"})
+		return
+	}
+
+	response := ReadingsResponse{CEP: cep, Page: page, Limit: limit, Total: total, Readings: []Reading{}}
+	for _, record := range records {
+		response.Readings = append(response.Readings, Reading{
+			LookedUpAt:      record.At,
+			City:            record.City,
+			UF:              record.UF,
+			WeatherResponse: newWeatherResponse(record.TempC, units),
+		})
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// Dias from e to (inclusivos) como início e fim exclusivo em UTC
+func readingsPeriod(r *http.Request) (time.Time, time.Time, error) {
+	var from, to time.Time
+	if value := r.URL.Query().Get("from"); value != "" {
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return from, to, err
+		}
+		from = day
+	}
+	if value := r.URL.Query().Get("to"); value != "" {
+		day, err := time.Parse("2006-01-02", value)
+		if err != nil {
+			return from, to, err
+		}
+		to = day.AddDate(0, 0, 1)
+	}
+	if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+		return from, to, fmt.Errorf("from %s is after to %s", from, to)
+	}
+	return from, to, nil
+}
+
+func readingsPage(r *http.Request) (int, int, error) {
+	page, limit := 1, defaultReadingsLimit
+	if value := r.URL.Query().Get("page"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return 0, 0, fmt.Errorf("invalid page %q", value)
+		}
+		page = n
+	}
+	if value := r.URL.Query().Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxReadingsLimit {
+			return 0, 0, fmt.Errorf("invalid limit %q", value)
+		}
+		limit = n
+	}
+	return page, limit, nil
+}
@@ -0,0 +1,160 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsValidHistoryDate(t *testing.T) {
+	now := time.Date(2025, 11, 30, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		date     string
+		expected bool
+	}{
+		{"Past date", "2025-11-25", true},
+		{"Today", "2025-11-30", true},
+		{"Future date", "2025-12-01", false},
+		{"Wrong format", "25/11/2025", false},
+		{"Empty date", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, isValidHistoryDate(tt.date, now))
+		})
+	}
+}
+
+func TestHistoryHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/history.json":   `{"forecast":{"forecastday":[{"date":"2025-11-25","day":{"maxtemp_c":30,"mintemp_c":18,"avgtemp_c":24}}]}}`,
+	})
+
+	req, err := http.NewRequest("GET", "/history/01310100?date=2025-11-25", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response DailyTemperatures
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, "2025-11-25", response.Date)
+	assert.Equal(t, 24.0, *response.Avg.TempC)
+	assert.Equal(t, 18.0, *response.Min.TempC)
+	assert.Equal(t, 86.0, *response.Max.TempF)
+}
+
+func TestHistoryHandler_InvalidDate(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	req, err := http.NewRequest("GET", "/history/01310100?date=amanha", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+
+	var response ErrorResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, "invalid date", response.Message)
+}
+
+func TestHistoryHandler_Readings(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	store := withSQLiteLookups(t, s)
+	day := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, store.record(context.Background(), lookupRecord{CEP: "01310100", City: "São Paulo", UF: "SP", TempC: float64(20 + i), At: day.AddDate(0, 0, i)}))
+	}
+
+	request := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		s.router().ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := request("/history/01310-100?units=c&limit=2")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "public, max-age=300", rr.Header().Get("Cache-Control"))
+	assert.JSONEq(t, `{"cep":"01310100","page":1,"limit":2,"total":3,"readings":[
+		{"looked_up_at":"2026-10-17T12:00:00Z","city":"São Paulo","uf":"SP","temp_C":22},
+		{"looked_up_at":"2026-10-16T12:00:00Z","city":"São Paulo","uf":"SP","temp_C":21}
+	]}`, rr.Body.String())
+
+	rr = request("/v1/history/01310100?units=c&limit=2&page=2")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response ReadingsResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Equal(t, 2, response.Page)
+	assert.Len(t, response.Readings, 1)
+	assert.Equal(t, 20.0, *response.Readings[0].TempC)
+
+	rr = request("/history/01310100?from=2026-10-16&to=2026-10-16")
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Equal(t, 1, response.Total)
+	assert.Equal(t, 21.0, *response.Readings[0].TempC)
+	assert.InDelta(t, 69.8, *response.Readings[0].TempF, 1e-9)
+}
+
+func TestHistoryHandler_ReadingsInvalidParams(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	withSQLiteLookups(t, s)
+
+	tests := []struct {
+		query   string
+		message string
+	}{
+		{"/history/123?from=2026-10-16", "invalid zipcode"},
+		{"/history/01310100?from=16/10/2026", "invalid date"},
+		{"/history/01310100?from=2026-10-17&to=2026-10-16", "invalid date"},
+		{"/history/01310100?page=0", "invalid pagination"},
+		{"/history/01310100?limit=1000", "invalid pagination"},
+		{"/history/01310100?units=x", "invalid units"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.query, nil)
+			assert.NoError(t, err)
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+			assert.JSONEq(t, `{"message":"`+tt.message+`"}`, rr.Body.String())
+		})
+	}
+}
+
+// Com date, ou sem o registro das consultas, vale o histórico da WeatherAPI
+func TestHistoryHandler_DateUsesWeatherAPI(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	req, err := http.NewRequest("GET", "/history/01310100", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.JSONEq(t, `{"message":"invalid date"}`, rr.Body.String())
+
+	withSQLiteLookups(t, s)
+	req, err = http.NewRequest("GET", "/history/01310100?date=amanha", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.JSONEq(t, `{"message":"invalid date"}`, rr.Body.String())
+}
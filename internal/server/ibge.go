@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/weather-service/config"
+	"github.com/weather-service/internal/cache"
+	"github.com/weather-service/internal/cep"
+	"github.com/weather-service/internal/weather"
+)
+
+// Município de um código IBGE (cep.IBGE)
+type municipalityClient interface {
+	Municipality(ctx context.Context, code string) (*cep.Address, error)
+}
+
+// Uso do cache de municípios, para /metrics e /admin/cache
+var ibgeCacheCounters cache.Counters
+
+// Clima atual pelo código IBGE do município, para integrações que
+// identificam os municípios pelo código em vez do CEP. A resposta e os
+// parâmetros são os de /weather/{cep}
+func (s *Server) weatherByIBGEHandler(w http.ResponseWriter, r *http.Request) {
+	r = r.WithContext(startReading(r.Context()))
+	units, ok := unitsFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	code := strings.TrimSpace(chi.URLParam(r, "code"))
+	log.Printf("Received request for IBGE code: %s", code)
+	municipality, err := s.lookupMunicipality(r.Context(), code)
+	if err != nil {
+		status, body := httpError(err)
+		writeResponse(w, r, status, body)
+		return
+	}
+	location := municipality.City + "," + municipality.UF
+
+	response, tempC, ok := s.currentWeatherResponse(w, r, location, units)
+	if !ok {
+		return
+	}
+	response.city = municipality.City
+	if queryFlag(r, "extended") {
+		response.IBGE = code
+	}
+	response.noteLocationProvider(locationProviderIBGE)
+
+	log.Printf("Successfully processed IBGE code %s (%s): %.1f°C, %.1f°F, %.1f°K", code, location, tempC, weather.CelsiusToFahrenheit(tempC), weather.CelsiusToKelvin(tempC))
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// Valida o código e busca o município no IBGE, usando os já resolvidos
+func (s *Server) lookupMunicipality(ctx context.Context, code string) (*cep.Address, error) {
+	if !cep.ValidIBGE(code) {
+		log.Printf("Invalid IBGE code format: %s", code)
+		return nil, ErrInvalidIBGE
+	}
+	if cached, ok := s.municipalities.Load(code); ok {
+		ibgeCacheCounters.Hit()
+		return cached.(*cep.Address), nil
+	}
+	ibgeCacheCounters.Miss()
+
+	// O IBGE responde em tempo parecido com o dos provedores de CEP
+	ctx, cancel := context.WithTimeout(ctx, config.Duration("VIACEP_TIMEOUT", defaultViaCEPTimeout))
+	defer cancel()
+	municipality, err := s.ibge.Municipality(ctx, code)
+	if errors.Is(err, cep.ErrNotFound) {
+		log.Printf("IBGE code not found: %s", code)
+		return nil, ErrMunicipalityNotFound
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to get municipality for IBGE code %s: %v", code, err)
+		return nil, &UpstreamError{Provider: ibgeProviderName, Err: err}
+	}
+
+	log.Printf("Found municipality for IBGE code %s: %s,%s", code, municipality.City, municipality.UF)
+	s.municipalities.Store(code, municipality)
+	return municipality, nil
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weather-service/internal/cep"
+)
+
+const saoPauloIBGE = `{"id":3550308,"nome":"São Paulo","regiao-imediata":{"regiao-intermediaria":{"UF":{"sigla":"SP"}}}}`
+
+func TestWeatherByIBGEHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ibge/municipios/3550308": saoPauloIBGE,
+		"/ibge/municipios/3599999": `[]`,
+		"/v1/current.json":         `{"current":{"temp_c":25,"humidity":60}}`,
+	})
+
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		expected string
+	}{
+		{"municipality", "/weather/ibge/3550308", http.StatusOK, `{"temp_C":25,"temp_F":77,"temp_K":298.15}`},
+		{"unknown code", "/weather/ibge/3599999", http.StatusNotFound, `{"message":"can not find municipality"}`},
+		{"too short", "/weather/ibge/355030", http.StatusUnprocessableEntity, `{"message":"invalid ibge code"}`},
+		{"unknown state code", "/weather/ibge/9950308", http.StatusUnprocessableEntity, `{"message":"invalid ibge code"}`},
+		{"IBGE down", "/weather/ibge/5300108", http.StatusInternalServerError, `{"message":"internal server error"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+			assert.Equal(t, tt.status, rr.Code)
+			assert.JSONEq(t, tt.expected, rr.Body.String())
+		})
+	}
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/ibge/3550308?units=c&extended=true", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"ibge":"3550308"`)
+	assert.Contains(t, rr.Body.String(), `"humidity":60`)
+}
+
+func TestWeatherByIBGEHandler_CachesMunicipality(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.Write([]byte(saoPauloIBGE))
+	}))
+	t.Cleanup(server.Close)
+	s := newFakeClientsServer(t, nil, fakeWeatherClient{"São Paulo,SP": 25})
+	s.ibge = cep.NewIBGE(server.URL, nil)
+
+	for i := 0; i < 3; i++ {
+		rr := httptest.NewRecorder()
+		s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/ibge/3550308", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestWeatherHandler_ExtendedIBGE(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": `{"cep":"01310-100","localidade":"São Paulo","uf":"SP","ibge":"3550308"}`,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100?units=c&extended=true", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Contains(t, rr.Body.String(), `"ibge":"3550308"`)
+
+	// Sem extended, o código fica de fora
+	rr = httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100?units=c", nil))
+	assert.JSONEq(t, `{"temp_C":25}`, rr.Body.String())
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/weather-service/config"
+	"github.com/weather-service/internal/cep"
+	"github.com/weather-service/internal/weather"
+)
+
+// Consulta de códigos postais de fora do Brasil (cep.Zippopotam)
+type postalCodeClient interface {
+	Lookup(ctx context.Context, country, code string) (*cep.Place, error)
+}
+
+// País pedido em ?country=, quando não é o Brasil. Sem o parâmetro ou com
+// BR, a consulta segue pelo ViaCEP
+func internationalCountry(r *http.Request) (string, bool) {
+	country := strings.TrimSpace(r.URL.Query().Get("country"))
+	if country == "" || strings.EqualFold(country, "BR") {
+		return "", false
+	}
+	return strings.ToUpper(country), true
+}
+
+// /weather/{cep}?country=XX: o código postal é resolvido no Zippopotam.us e
+// o clima é consultado pelas coordenadas do lugar, com o mesmo arredondamento
+// (e o mesmo cache) de /weather/coords
+func (s *Server) internationalWeatherHandler(w http.ResponseWriter, r *http.Request, country string, units temperatureUnits) {
+	// Sem cep.Clean: em alguns países o espaço faz parte do código postal
+	code := chi.URLParam(r, "cep")
+	if unescaped, err := url.PathUnescape(code); err == nil {
+		code = unescaped
+	}
+	code = strings.TrimSpace(code)
+	log.Printf("Received request for postal code %s in %s", code, country)
+
+	place, err := s.lookupPostalCode(r.Context(), country, code)
+	if err != nil {
+		status, body := httpError(err)
+		writeResponse(w, r, status, body)
+		return
+	}
+	location := formatCoordinate(place.Lat) + "," + formatCoordinate(place.Lon)
+
+	response, tempC, ok := s.currentWeatherResponse(w, r, location, units)
+	if !ok {
+		return
+	}
+	response.cep = place.PostalCode
+	response.city = place.City
+	response.noteLocationProvider(locationProviderZippopotam)
+	if queryIncludes(r, "location") {
+		response.Location = &Address{Cep: place.PostalCode, Localidade: place.City, UF: place.State, Country: place.Country}
+	}
+
+	log.Printf("Successfully processed postal code %s in %s: %.1f°C, %.1f°F, %.1f°K", code, country, tempC, weather.CelsiusToFahrenheit(tempC), weather.CelsiusToKelvin(tempC))
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// Valida o país e o código e busca o lugar no Zippopotam.us
+func (s *Server) lookupPostalCode(ctx context.Context, country, code string) (*cep.Place, error) {
+	if !cep.ValidCountry(country) {
+		log.Printf("Invalid country: %s", country)
+		return nil, ErrInvalidCountry
+	}
+	if !cep.ValidPostalCode(code) {
+		log.Printf("Invalid postal code format: %s", code)
+		return nil, ErrInvalidCEP
+	}
+
+	// O Zippopotam.us responde em tempo parecido com o dos provedores de CEP
+	ctx, cancel := context.WithTimeout(ctx, config.Duration("VIACEP_TIMEOUT", defaultViaCEPTimeout))
+	defer cancel()
+	place, err := s.zippopotam.Lookup(ctx, country, code)
+	if errors.Is(err, cep.ErrNotFound) {
+		log.Printf("Postal code not found: %s in %s", code, country)
+		return nil, ErrCEPNotFound
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to get location for postal code %s in %s: %v", code, country, err)
+		return nil, &UpstreamError{Provider: cepProvidersName, Err: err}
+	}
+	log.Printf("Found location for postal code %s in %s: %s", code, country, place.City)
+	return place, nil
+}
@@ -0,0 +1,57 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weather-service/internal/cep"
+)
+
+func TestWeatherHandler_Country(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/zippopotam/us/90210": `{"post code":"90210","country abbreviation":"US","places":[{"place name":"Beverly Hills","state abbreviation":"CA","latitude":"34.0901","longitude":"-118.4065"}]}`,
+		"/ws/01310100/json/":   `{"cep":"01310-100","localidade":"São Paulo","uf":"SP"}`,
+	})
+	// Só as coordenadas arredondadas do lugar e a cidade do ViaCEP
+	s.weather = fakeWeatherClient{"34.09,-118.41": 20, "São Paulo,SP": 25}
+
+	tests := []struct {
+		name     string
+		path     string
+		status   int
+		expected string
+	}{
+		{"postal code", "/weather/90210?country=us&units=c", http.StatusOK, `{"temp_C":20}`},
+		{"location", "/weather/90210?country=US&units=c&include=location", http.StatusOK, `{"temp_C":20,"location":{"cep":"90210","logradouro":"","bairro":"","localidade":"Beverly Hills","uf":"CA","country":"US"}}`},
+		{"Brazil keeps ViaCEP", "/weather/01310100?country=BR&units=c", http.StatusOK, `{"temp_C":25}`},
+		{"unknown postal code", "/weather/00000?country=US", http.StatusNotFound, `{"message":"can not find zipcode"}`},
+		{"invalid postal code", "/weather/902.10?country=US", http.StatusUnprocessableEntity, `{"message":"invalid zipcode"}`},
+		{"invalid country", "/weather/90210?country=USA", http.StatusUnprocessableEntity, `{"message":"invalid country"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+			assert.Equal(t, tt.status, rr.Code)
+			assert.JSONEq(t, tt.expected, rr.Body.String())
+		})
+	}
+}
+
+func TestWeatherHandler_CountryProviderDown(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	t.Cleanup(server.Close)
+	s.zippopotam = cep.NewZippopotam(server.URL, nil)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/10115?country=DE", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.JSONEq(t, `{"message":"internal server error"}`, rr.Body.String())
+}
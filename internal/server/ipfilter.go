@@ -0,0 +1,127 @@
+package server
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/weather-service/config"
+)
+
+// Restrição de acesso por faixas de IP. Com allowlist, apenas IPs contidos
+// nela são aceitos; IPs da denylist são sempre recusados
+type ipFilter struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+// Lê IP_ALLOWLIST/IP_DENYLIST (CIDRs ou IPs separados por vírgula) e os
+// arquivos IP_ALLOWLIST_FILE/IP_DENYLIST_FILE (um por linha). Retorna nil
+// quando nada está configurado
+func ipFilterFromEnv() (*ipFilter, error) {
+	allow, err := loadCIDRs("IP_ALLOWLIST", "IP_ALLOWLIST_FILE")
+	if err != nil {
+		return nil, err
+	}
+	deny, err := loadCIDRs("IP_DENYLIST", "IP_DENYLIST_FILE")
+	if err != nil {
+		return nil, err
+	}
+
+	if len(allow) == 0 && len(deny) == 0 {
+		return nil, nil
+	}
+	return &ipFilter{allow: allow, deny: deny}, nil
+}
+
+func loadCIDRs(listVar, fileVar string) ([]*net.IPNet, error) {
+	entries := config.List(listVar, nil)
+
+	if path := config.String(fileVar); path != "" {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open %s: %w", fileVar, err)
+		}
+		defer file.Close()
+
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if line != "" && !strings.HasPrefix(line, "#") {
+				entries = append(entries, line)
+			}
+		}
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read %s: %w", fileVar, err)
+		}
+	}
+
+	var networks []*net.IPNet
+	for _, entry := range entries {
+		network, err := parseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid entry %q in %s: %w", entry, listVar, err)
+		}
+		networks = append(networks, network)
+	}
+	return networks, nil
+}
+
+// Aceita CIDR ou IP isolado (tratado como /32 ou /128)
+func parseCIDR(entry string) (*net.IPNet, error) {
+	if !strings.Contains(entry, "/") {
+		ip := net.ParseIP(entry)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid IP address")
+		}
+		bits := 128
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+
+	_, network, err := net.ParseCIDR(entry)
+	return network, err
+}
+
+func (f *ipFilter) allows(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+	if containsIP(f.deny, ip) {
+		return false
+	}
+	return len(f.allow) == 0 || containsIP(f.allow, ip)
+}
+
+func containsIP(networks []*net.IPNet, ip net.IP) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func filterIPs(filter *ipFilter, proxyHops int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if filter == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			client := clientIP(r, proxyHops)
+			if !filter.allows(net.ParseIP(client)) {
+				log.Printf("Blocked request from IP %s", client)
+				writeResponse(w, r, http.StatusForbidden, ErrorResponse{Message: "forbidden"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
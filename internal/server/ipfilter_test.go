@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFilterIPs(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	denyFile := filepath.Join(t.TempDir(), "deny.txt")
+	assert.NoError(t, os.WriteFile(denyFile, []byte("# bloqueados\n10.1.2.3\n"), 0o600))
+	t.Setenv("IP_ALLOWLIST", "10.0.0.0/8, 2001:db8::/32")
+	t.Setenv("IP_DENYLIST_FILE", denyFile)
+
+	tests := []struct {
+		name           string
+		remoteAddr     string
+		expectedStatus int
+	}{
+		{"Allowed range", "10.20.30.40:1234", http.StatusOK},
+		{"Allowed IPv6 range", "[2001:db8::1]:1234", http.StatusOK},
+		{"Outside allowlist", "203.0.113.7:1234", http.StatusForbidden},
+		{"Denied inside allowlist", "10.1.2.3:1234", http.StatusForbidden},
+	}
+
+	router := s.router()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/", nil)
+			assert.NoError(t, err)
+			req.RemoteAddr = tt.remoteAddr
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			if tt.expectedStatus == http.StatusForbidden {
+				assert.JSONEq(t, `{"message":"forbidden"}`, rr.Body.String())
+			}
+		})
+	}
+}
+
+func TestIPFilterFromEnv(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		filter, err := ipFilterFromEnv()
+		assert.NoError(t, err)
+		assert.Nil(t, filter)
+	})
+
+	t.Run("Invalid entry", func(t *testing.T) {
+		t.Setenv("IP_DENYLIST", "10.0.0.0/33")
+		_, err := ipFilterFromEnv()
+		assert.Error(t, err)
+	})
+
+	t.Run("Denylist only", func(t *testing.T) {
+		t.Setenv("IP_DENYLIST", "203.0.113.0/24")
+		filter, err := ipFilterFromEnv()
+		assert.NoError(t, err)
+
+		req, err := http.NewRequest("GET", "/", nil)
+		assert.NoError(t, err)
+		req.RemoteAddr = "192.0.2.1:1234"
+		assert.True(t, filter.allows(net.ParseIP(clientIP(req, 0))))
+	})
+}
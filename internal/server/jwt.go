@@ -0,0 +1,264 @@
+package server
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/weather-service/config"
+	"golang.org/x/sync/singleflight"
+)
+
+// Intervalos de atualização do JWKS: as chaves são relidas periodicamente e,
+// quando chega um kid desconhecido, no máximo uma vez por minuto
+const (
+	jwksRefreshInterval    = time.Hour
+	jwksMinRefreshInterval = time.Minute
+)
+
+// Um JWKS fora do ar não pode prender as requisições que esperam pelas chaves
+var jwksClient = &http.Client{Timeout: 10 * time.Second}
+
+type (
+	subjectContextKey     struct{}
+	subjectSlotContextKey struct{}
+)
+
+// Subject (sub) do JWT que autenticou a requisição, ou vazio
+func requestSubject(ctx context.Context) string {
+	if subject, ok := ctx.Value(subjectContextKey{}).(string); ok {
+		return subject
+	}
+	if slot, ok := ctx.Value(subjectSlotContextKey{}).(*string); ok {
+		return *slot
+	}
+	return ""
+}
+
+// Reserva no contexto um lugar para o subject, preenchido por requireJWT,
+// para que os middlewares anteriores à autenticação (ex.: o de panics)
+// também enxerguem o subject depois que a requisição passou por ela
+func withSubjectSlot(ctx context.Context) context.Context {
+	return context.WithValue(ctx, subjectSlotContextKey{}, new(string))
+}
+
+// Subject para os logs, ou "-" sem JWT
+func subjectLabel(ctx context.Context) string {
+	if subject := requestSubject(ctx); subject != "" {
+		return subject
+	}
+	return "-"
+}
+
+type jwtVerifier struct {
+	secret   []byte
+	jwks     *jwksKeySet
+	issuer   string
+	audience string
+}
+
+// Configura a validação de JWT a partir do ambiente. Retorna nil quando nem
+// JWT_HS256_SECRET nem JWT_JWKS_URL estão definidos
+func jwtVerifierFromEnv() *jwtVerifier {
+	secret := config.String("JWT_HS256_SECRET")
+	jwksURL := config.String("JWT_JWKS_URL")
+	if secret == "" && jwksURL == "" {
+		return nil
+	}
+
+	verifier := &jwtVerifier{
+		issuer:   config.String("JWT_ISSUER"),
+		audience: config.String("JWT_AUDIENCE"),
+	}
+	if secret != "" {
+		verifier.secret = []byte(secret)
+	}
+	if jwksURL != "" {
+		verifier.jwks = &jwksKeySet{url: jwksURL}
+	}
+	return verifier
+}
+
+func (v *jwtVerifier) verify(tokenString string) (string, error) {
+	var methods []string
+	if v.secret != nil {
+		methods = append(methods, jwt.SigningMethodHS256.Alg())
+	}
+	if v.jwks != nil {
+		methods = append(methods, jwt.SigningMethodRS256.Alg())
+	}
+
+	options := []jwt.ParserOption{jwt.WithValidMethods(methods), jwt.WithExpirationRequired()}
+	if v.issuer != "" {
+		options = append(options, jwt.WithIssuer(v.issuer))
+	}
+	if v.audience != "" {
+		options = append(options, jwt.WithAudience(v.audience))
+	}
+
+	token, err := jwt.Parse(tokenString, v.key, options...)
+	if err != nil {
+		return "", err
+	}
+	return token.Claims.GetSubject()
+}
+
+func (v *jwtVerifier) key(token *jwt.Token) (interface{}, error) {
+	if token.Method.Alg() == jwt.SigningMethodHS256.Alg() {
+		return v.secret, nil
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	return v.jwks.key(kid)
+}
+
+func requireJWT(verifier *jwtVerifier) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if verifier == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || tokenString == "" {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				writeResponse(w, r, http.StatusUnauthorized, ErrorResponse{Message: "missing bearer token"})
+				return
+			}
+
+			subject, err := verifier.verify(tokenString)
+			if err != nil {
+				log.Printf("Rejected bearer token: %v", err)
+				w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+				writeResponse(w, r, http.StatusUnauthorized, ErrorResponse{Message: "invalid bearer token"})
+				return
+			}
+
+			log.Printf("Request %s from subject %s: %s %s", middleware.GetReqID(r.Context()), subject, r.Method, r.URL.Path)
+			if slot, ok := r.Context().Value(subjectSlotContextKey{}).(*string); ok {
+				*slot = subject
+			}
+			noteUsageSubject(r.Context(), subject)
+			ctx := context.WithValue(r.Context(), subjectContextKey{}, subject)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// Chaves públicas RSA publicadas em um endpoint JWKS, indexadas pelo kid.
+// A busca acontece fora do lock, e requisições simultâneas que precisam de
+// chaves novas esperam pela mesma busca
+type jwksKeySet struct {
+	url    string
+	flight singleflight.Group
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func (s *jwksKeySet) key(kid string) (*rsa.PublicKey, error) {
+	s.mu.Lock()
+	key, ok := s.keys[kid]
+	age := time.Since(s.fetchedAt)
+	s.mu.Unlock()
+
+	if ok && age < jwksRefreshInterval {
+		return key, nil
+	}
+	if !ok && age < jwksMinRefreshInterval {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	fetched, err, _ := s.flight.Do(s.url, func() (interface{}, error) {
+		keys, err := fetchJWKS(s.url)
+		if err != nil {
+			return nil, err
+		}
+		s.mu.Lock()
+		s.keys, s.fetchedAt = keys, time.Now()
+		s.mu.Unlock()
+		return keys, nil
+	})
+	if err != nil {
+		// Mantém as chaves anteriores se o endpoint estiver fora do ar
+		if ok {
+			log.Printf("WARNING: Failed to refresh JWKS, using cached keys: %v", err)
+			return key, nil
+		}
+		return nil, err
+	}
+
+	if key, ok = fetched.(map[string]*rsa.PublicKey)[kid]; !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+func fetchJWKS(url string) (map[string]*rsa.PublicKey, error) {
+	resp, err := jwksClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JWKS returned status %d", resp.StatusCode)
+	}
+
+	var document struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&document); err != nil {
+		return nil, fmt.Errorf("failed to decode JWKS: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range document.Keys {
+		if jwk.Kty != "RSA" {
+			continue
+		}
+		key, err := rsaPublicKey(jwk.N, jwk.E)
+		if err != nil {
+			log.Printf("WARNING: Skipping invalid JWKS key %q: %v", jwk.Kid, err)
+			continue
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func rsaPublicKey(n, e string) (*rsa.PublicKey, error) {
+	modulus, err := base64.RawURLEncoding.DecodeString(n)
+	if err != nil {
+		return nil, err
+	}
+	exponent, err := base64.RawURLEncoding.DecodeString(e)
+	if err != nil {
+		return nil, err
+	}
+	if len(exponent) == 0 || len(exponent) > 4 {
+		return nil, errors.New("invalid exponent")
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(modulus),
+		E: int(new(big.Int).SetBytes(exponent).Int64()),
+	}, nil
+}
@@ -0,0 +1,172 @@
+package server
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func signedToken(t *testing.T, method jwt.SigningMethod, key interface{}, kid string, claims jwt.MapClaims) string {
+	token := jwt.NewWithClaims(method, claims)
+	if kid != "" {
+		token.Header["kid"] = kid
+	}
+	signed, err := token.SignedString(key)
+	assert.NoError(t, err)
+	return signed
+}
+
+func TestRequireJWT_HS256(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("JWT_HS256_SECRET", "segredo")
+	t.Setenv("JWT_ISSUER", "https://auth.example.com")
+
+	valid := jwt.MapClaims{"sub": "user-1", "iss": "https://auth.example.com", "exp": time.Now().Add(time.Hour).Unix()}
+	expired := jwt.MapClaims{"sub": "user-1", "iss": "https://auth.example.com", "exp": time.Now().Add(-time.Hour).Unix()}
+	wrongIssuer := jwt.MapClaims{"sub": "user-1", "iss": "https://other.example.com", "exp": time.Now().Add(time.Hour).Unix()}
+
+	tests := []struct {
+		name            string
+		authorization   string
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{"Missing token", "", http.StatusUnauthorized, "missing bearer token"},
+		{"Valid token", "Bearer " + signedToken(t, jwt.SigningMethodHS256, []byte("segredo"), "", valid), http.StatusUnprocessableEntity, "invalid zipcode"},
+		{"Wrong secret", "Bearer " + signedToken(t, jwt.SigningMethodHS256, []byte("outro"), "", valid), http.StatusUnauthorized, "invalid bearer token"},
+		{"Expired token", "Bearer " + signedToken(t, jwt.SigningMethodHS256, []byte("segredo"), "", expired), http.StatusUnauthorized, "invalid bearer token"},
+		{"Wrong issuer", "Bearer " + signedToken(t, jwt.SigningMethodHS256, []byte("segredo"), "", wrongIssuer), http.StatusUnauthorized, "invalid bearer token"},
+	}
+
+	router := s.router()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/v1/weather/123", nil)
+			assert.NoError(t, err)
+			req.Header.Set("Authorization", tt.authorization)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Contains(t, rr.Body.String(), tt.expectedMessage)
+		})
+	}
+}
+
+func TestRequireJWT_RS256WithJWKS(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "key-1",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(jwks.Close)
+	t.Setenv("JWT_JWKS_URL", jwks.URL)
+
+	claims := jwt.MapClaims{"sub": "user-2", "exp": time.Now().Add(time.Hour).Unix()}
+
+	var subject string
+	handler := requireJWT(jwtVerifierFromEnv())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		subject = requestSubject(r.Context())
+	}))
+
+	tests := []struct {
+		name           string
+		token          string
+		expectedStatus int
+	}{
+		{"Known key", signedToken(t, jwt.SigningMethodRS256, privateKey, "key-1", claims), http.StatusOK},
+		{"Unknown key id", signedToken(t, jwt.SigningMethodRS256, privateKey, "key-2", claims), http.StatusUnauthorized},
+		{"HS256 not accepted", signedToken(t, jwt.SigningMethodHS256, []byte("segredo"), "", claims), http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/", nil)
+			assert.NoError(t, err)
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+
+	assert.Equal(t, "user-2", subject)
+}
+
+// Requisições simultâneas com um kid novo esperam pela mesma busca do JWKS,
+// e um JWKS que não responde falha no timeout do cliente
+func TestJWKSKeySet_Fetch(t *testing.T) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err)
+
+	var fetches atomic.Int32
+	release := make(chan struct{})
+	jwks := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fetches.Add(1)
+		<-release
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"keys": []map[string]string{{
+				"kid": "key-1",
+				"kty": "RSA",
+				"n":   base64.RawURLEncoding.EncodeToString(privateKey.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(privateKey.E)).Bytes()),
+			}},
+		})
+	}))
+	t.Cleanup(jwks.Close)
+
+	t.Run("Concurrent lookups share one fetch", func(t *testing.T) {
+		keys := &jwksKeySet{url: jwks.URL}
+		var wg sync.WaitGroup
+		for i := 0; i < 5; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				key, err := keys.key("key-1")
+				assert.NoError(t, err)
+				assert.Equal(t, privateKey.N, key.N)
+			}()
+		}
+		assert.Eventually(t, func() bool { return fetches.Load() == 1 }, time.Second, time.Millisecond)
+		time.Sleep(10 * time.Millisecond)
+		close(release)
+		wg.Wait()
+		assert.Equal(t, int32(1), fetches.Load())
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		}))
+		t.Cleanup(hung.Close)
+
+		old := jwksClient
+		jwksClient = &http.Client{Timeout: 50 * time.Millisecond}
+		t.Cleanup(func() { jwksClient = old })
+
+		_, err := (&jwksKeySet{url: hung.URL}).key("key-1")
+		assert.ErrorContains(t, err, "failed to fetch JWKS")
+	})
+}
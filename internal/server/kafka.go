@@ -0,0 +1,230 @@
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+	"github.com/weather-service/config"
+)
+
+const (
+	defaultKafkaTopic    = "weather.lookups"
+	defaultKafkaClientID = "weather-service"
+	defaultKafkaTimeout  = 10 * time.Second
+	// Eventos à espera do envio; com a fila cheia (Kafka fora do ar), os
+	// novos são descartados em vez de segurar as consultas
+	kafkaQueueSize = 1000
+)
+
+// Envio de um registro ao Kafka. Nos testes, um produtor em memória
+type kafkaProducer interface {
+	Produce(ctx context.Context, topic string, key, value []byte) error
+	Close() error
+}
+
+// Produtor do kafka-go. Cada registro é enviado assim que chega (lote de um),
+// já que o publicador envia um evento de cada vez
+type kafkaWriter struct {
+	*kafka.Writer
+}
+
+func (w kafkaWriter) Produce(ctx context.Context, topic string, key, value []byte) error {
+	return w.WriteMessages(ctx, kafka.Message{Topic: topic, Key: key, Value: value})
+}
+
+// Evento de uma consulta no tópico do Kafka, para pipelines de análise.
+// A chave do registro é o CEP, então as consultas de um CEP ficam em ordem
+// na mesma partição
+type lookupEvent struct {
+	CEP         string             `json:"cep"`
+	Location    lookupLocation     `json:"location"`
+	Temperature lookupTemperatures `json:"temperature"`
+	Source      string             `json:"source"`
+	LatencyMs   float64            `json:"latency_ms"`
+	At          time.Time          `json:"at"`
+}
+
+type lookupLocation struct {
+	City string `json:"city"`
+	UF   string `json:"uf"`
+}
+
+type lookupTemperatures struct {
+	TempC float64 `json:"temp_C"`
+	TempF float64 `json:"temp_F"`
+	TempK float64 `json:"temp_K"`
+}
+
+func newLookupEvent(reading ReadingEvent) lookupEvent {
+	return lookupEvent{
+		CEP:         reading.CEP,
+		Location:    lookupLocation{City: reading.City, UF: reading.UF},
+		Temperature: lookupTemperatures{TempC: reading.TempC, TempF: reading.TempF, TempK: reading.TempK},
+		Source:      reading.Source,
+		LatencyMs:   reading.LatencyMs,
+		At:          reading.At,
+	}
+}
+
+// Envia cada consulta ao tópico KAFKA_TOPIC. Os eventos passam por uma fila
+// e são enviados por uma goroutine, um de cada vez, sem atrasar a resposta
+type kafkaPublisher struct {
+	producer kafkaProducer
+	topic    string
+	timeout  time.Duration
+	done     chan struct{}
+
+	mu     sync.Mutex
+	events chan ReadingEvent
+	closed bool
+}
+
+// Retorna nil quando KAFKA_BROKERS não está definido
+func kafkaPublisherFromEnv() (*kafkaPublisher, error) {
+	brokers := config.List("KAFKA_BROKERS", nil)
+	if len(brokers) == 0 {
+		return nil, nil
+	}
+
+	topic := config.String("KAFKA_TOPIC")
+	if topic == "" {
+		topic = defaultKafkaTopic
+	}
+	clientID := config.String("KAFKA_CLIENT_ID")
+	if clientID == "" {
+		clientID = defaultKafkaClientID
+	}
+	timeout := config.Duration("KAFKA_TIMEOUT", defaultKafkaTimeout)
+
+	transport, err := kafkaTransportFromEnv(clientID, timeout)
+	if err != nil {
+		return nil, err
+	}
+	writer := &kafka.Writer{
+		Addr: kafka.TCP(brokers...),
+		// Mesmo particionamento do produtor Java, para que os registros de um
+		// CEP caiam na mesma partição que os de outros clientes
+		Balancer:     &kafka.Murmur2Balancer{},
+		RequiredAcks: kafka.RequireOne,
+		BatchSize:    1,
+		Transport:    transport,
+	}
+
+	log.Printf("Publishing lookups to Kafka topic %s (brokers %v)", topic, brokers)
+	return newKafkaPublisher(kafkaWriter{writer}, topic, timeout), nil
+}
+
+// Conexão com os brokers: TLS com KAFKA_TLS (e a CA de KAFKA_TLS_CA_FILE,
+// quando os brokers usam uma CA privada) e autenticação SASL com
+// KAFKA_SASL_MECHANISM
+func kafkaTransportFromEnv(clientID string, timeout time.Duration) (*kafka.Transport, error) {
+	transport := &kafka.Transport{ClientID: clientID, DialTimeout: timeout}
+
+	caFile := config.String("KAFKA_TLS_CA_FILE")
+	if config.Bool("KAFKA_TLS", false) || caFile != "" {
+		transport.TLS = &tls.Config{MinVersion: tls.VersionTLS12}
+		if caFile != "" {
+			caPEM, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read KAFKA_TLS_CA_FILE: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(caPEM) {
+				return nil, errors.New("KAFKA_TLS_CA_FILE contains no valid certificates")
+			}
+			transport.TLS.RootCAs = pool
+		}
+	}
+
+	mechanism, err := kafkaSASLMechanism(config.String("KAFKA_SASL_MECHANISM"), config.String("KAFKA_SASL_USERNAME"), config.String("KAFKA_SASL_PASSWORD"))
+	if err != nil {
+		return nil, err
+	}
+	transport.SASL = mechanism
+	return transport, nil
+}
+
+// Mecanismo SASL pelo nome (plain, scram-sha-256 ou scram-sha-512); vazio
+// desliga a autenticação
+func kafkaSASLMechanism(name, username, password string) (sasl.Mechanism, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "plain":
+		return plain.Mechanism{Username: username, Password: password}, nil
+	case "scram-sha-256":
+		return scram.Mechanism(scram.SHA256, username, password)
+	case "scram-sha-512":
+		return scram.Mechanism(scram.SHA512, username, password)
+	default:
+		return nil, fmt.Errorf("unsupported KAFKA_SASL_MECHANISM %q", name)
+	}
+}
+
+func newKafkaPublisher(producer kafkaProducer, topic string, timeout time.Duration) *kafkaPublisher {
+	p := &kafkaPublisher{
+		producer: producer,
+		topic:    topic,
+		timeout:  timeout,
+		done:     make(chan struct{}),
+		events:   make(chan ReadingEvent, kafkaQueueSize),
+	}
+	go p.run()
+	return p
+}
+
+func (p *kafkaPublisher) publish(ctx context.Context, reading ReadingEvent) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	select {
+	case p.events <- reading:
+	default:
+		log.Printf("WARNING: Kafka queue full, dropping lookup event for CEP %s", reading.CEP)
+	}
+}
+
+func (p *kafkaPublisher) run() {
+	defer close(p.done)
+	for reading := range p.events {
+		value, err := json.Marshal(newLookupEvent(reading))
+		if err != nil {
+			log.Printf("ERROR: Failed to encode Kafka lookup event for CEP %s: %v", reading.CEP, err)
+			continue
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), p.timeout)
+		err = p.producer.Produce(ctx, p.topic, []byte(reading.CEP), value)
+		cancel()
+		if err != nil {
+			log.Printf("WARNING: Failed to publish lookup event for CEP %s to Kafka topic %s: %v", reading.CEP, p.topic, err)
+		}
+	}
+}
+
+// Envia os eventos que ainda estão na fila antes de fechar as conexões
+func (p *kafkaPublisher) Close() error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.events)
+	}
+	p.mu.Unlock()
+
+	<-p.done
+	return p.producer.Close()
+}
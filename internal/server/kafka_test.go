@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type kafkaRecord struct {
+	topic      string
+	key, value []byte
+}
+
+// Produtor em memória
+type fakeKafkaProducer struct {
+	mu      sync.Mutex
+	records []kafkaRecord
+	closed  bool
+}
+
+func (p *fakeKafkaProducer) Produce(ctx context.Context, topic string, key, value []byte) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.records = append(p.records, kafkaRecord{topic, key, value})
+	return nil
+}
+
+func (p *fakeKafkaProducer) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.closed = true
+	return nil
+}
+
+// Cada consulta vira um evento no tópico, com a chave no CEP, a origem da
+// temperatura e a latência da consulta
+func TestKafkaPublisher(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, fakeWeatherClient{"São Paulo,SP": 25})
+	t.Setenv("WEATHER_CACHE_TTL", "1h")
+	producer := &fakeKafkaProducer{}
+	publisher := newKafkaPublisher(producer, "weather.lookups", time.Second)
+	s.publishers = []readingPublisher{publisher}
+
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+	// Close espera o envio dos eventos que estão na fila
+	require.NoError(t, publisher.Close())
+	assert.True(t, producer.closed)
+	publisher.publish(context.Background(), ReadingEvent{CEP: "01310100"})
+
+	require.Len(t, producer.records, 2)
+	assert.Equal(t, "weather.lookups", producer.records[0].topic)
+	assert.Equal(t, "01310100", string(producer.records[0].key))
+
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal(producer.records[0].value, &event))
+	assert.GreaterOrEqual(t, event["latency_ms"], 0.0)
+	delete(event, "latency_ms")
+	delete(event, "at")
+	expected := `{
+		"cep": "01310100",
+		"location": {"city": "São Paulo", "uf": "SP"},
+		"temperature": {"temp_C": 25, "temp_F": 77, "temp_K": 298.15},
+		"source": "weather_api"
+	}`
+	actual, _ := json.Marshal(event)
+	assert.JSONEq(t, expected, string(actual))
+
+	var cached lookupEvent
+	require.NoError(t, json.Unmarshal(producer.records[1].value, &cached))
+	assert.Equal(t, "cache", cached.Source)
+}
+
+func TestKafkaPublisherFromEnv(t *testing.T) {
+	t.Setenv("KAFKA_BROKERS", "")
+	publisher, err := kafkaPublisherFromEnv()
+	assert.NoError(t, err)
+	assert.Nil(t, publisher)
+
+	// A conexão com os brokers só acontece no primeiro envio
+	t.Setenv("KAFKA_BROKERS", "127.0.0.1:9092")
+	publisher, err = kafkaPublisherFromEnv()
+	require.NoError(t, err)
+	assert.Equal(t, defaultKafkaTopic, publisher.topic)
+	assert.NoError(t, publisher.Close())
+}
+
+func TestKafkaTransportFromEnv(t *testing.T) {
+	t.Run("Plaintext", func(t *testing.T) {
+		transport, err := kafkaTransportFromEnv("weather-service", time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "weather-service", transport.ClientID)
+		assert.Nil(t, transport.TLS)
+		assert.Nil(t, transport.SASL)
+	})
+
+	t.Run("TLS with system CAs", func(t *testing.T) {
+		t.Setenv("KAFKA_TLS", "true")
+		transport, err := kafkaTransportFromEnv("weather-service", time.Second)
+		require.NoError(t, err)
+		require.NotNil(t, transport.TLS)
+		assert.Nil(t, transport.TLS.RootCAs)
+	})
+
+	t.Run("Invalid CA file", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "ca.pem")
+		require.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0o600))
+		t.Setenv("KAFKA_TLS_CA_FILE", path)
+		_, err := kafkaTransportFromEnv("weather-service", time.Second)
+		assert.EqualError(t, err, "KAFKA_TLS_CA_FILE contains no valid certificates")
+	})
+
+	t.Run("SASL", func(t *testing.T) {
+		t.Setenv("KAFKA_SASL_MECHANISM", "scram-sha-512")
+		t.Setenv("KAFKA_SASL_USERNAME", "weather")
+		t.Setenv("KAFKA_SASL_PASSWORD", "segredo")
+		transport, err := kafkaTransportFromEnv("weather-service", time.Second)
+		require.NoError(t, err)
+		assert.Equal(t, "SCRAM-SHA-512", transport.SASL.Name())
+	})
+}
+
+func TestKafkaSASLMechanism(t *testing.T) {
+	mechanism, err := kafkaSASLMechanism("plain", "weather", "segredo")
+	require.NoError(t, err)
+	assert.Equal(t, plain.Mechanism{Username: "weather", Password: "segredo"}, mechanism)
+
+	mechanism, err = kafkaSASLMechanism("scram-sha-256", "weather", "segredo")
+	require.NoError(t, err)
+	assert.Equal(t, "SCRAM-SHA-256", mechanism.Name())
+
+	mechanism, err = kafkaSASLMechanism("", "", "")
+	assert.NoError(t, err)
+	assert.Nil(t, mechanism)
+
+	_, err = kafkaSASLMechanism("gssapi", "weather", "segredo")
+	assert.EqualError(t, err, `unsupported KAFKA_SASL_MECHANISM "gssapi"`)
+}
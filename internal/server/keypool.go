@@ -0,0 +1,68 @@
+package server
+
+import (
+	"sync"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+const defaultWeatherAPIKeyCooldown = time.Hour
+
+// Rodízio entre as chaves da WeatherAPI, para somar os limites do plano
+// gratuito de várias chaves. Chaves recusadas ficam desativadas por um tempo
+type apiKeyPool struct {
+	mu       sync.Mutex
+	next     int
+	disabled map[string]time.Time
+	now      func() time.Time
+}
+
+var weatherAPIKeyPool = newAPIKeyPool()
+
+func newAPIKeyPool() *apiKeyPool {
+	return &apiKeyPool{disabled: map[string]time.Time{}, now: time.Now}
+}
+
+// Ordem em que as chaves devem ser tentadas nesta chamada: começa pela vez
+// da próxima chave no rodízio e pula as desativadas. Se todas estiverem
+// desativadas, tenta todas assim mesmo, já que a WeatherAPI pode tê-las
+// liberado
+func (p *apiKeyPool) candidates(keys []string) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	start := p.next % len(keys)
+	p.next++
+
+	now := p.now()
+	var enabled, all []string
+	for i := range keys {
+		key := keys[(start+i)%len(keys)]
+		all = append(all, key)
+		if until, ok := p.disabled[key]; ok && now.Before(until) {
+			continue
+		}
+		delete(p.disabled, key)
+		enabled = append(enabled, key)
+	}
+
+	if len(enabled) == 0 {
+		return all
+	}
+	return enabled
+}
+
+func (p *apiKeyPool) disable(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.disabled[key] = p.now().Add(config.Duration("WEATHER_API_KEY_COOLDOWN", defaultWeatherAPIKeyCooldown))
+}
+
+// Identifica a chave nos logs sem expô-la
+func maskKey(key string) string {
+	if len(key) <= 4 {
+		return "****"
+	}
+	return "****" + key[len(key)-4:]
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/internal/weather"
+)
+
+// Descarta chaves desativadas por outros testes
+func withFreshKeyPool(t *testing.T) {
+	old := weatherAPIKeyPool
+	weatherAPIKeyPool = newAPIKeyPool()
+	t.Cleanup(func() { weatherAPIKeyPool = old })
+}
+
+func TestAPIKeyPool_Rotation(t *testing.T) {
+	pool := newAPIKeyPool()
+	keys := []string{"a", "b", "c"}
+
+	assert.Equal(t, []string{"a", "b", "c"}, pool.candidates(keys))
+	assert.Equal(t, []string{"b", "c", "a"}, pool.candidates(keys))
+	assert.Equal(t, []string{"c", "a", "b"}, pool.candidates(keys))
+	assert.Equal(t, []string{"a", "b", "c"}, pool.candidates(keys))
+}
+
+func TestAPIKeyPool_Cooldown(t *testing.T) {
+	t.Setenv("WEATHER_API_KEY_COOLDOWN", "10m")
+	now := time.Unix(1760000000, 0)
+	pool := newAPIKeyPool()
+	pool.now = func() time.Time { return now }
+	keys := []string{"a", "b"}
+
+	pool.disable("a")
+	assert.Equal(t, []string{"b"}, pool.candidates(keys))
+	assert.Equal(t, []string{"b"}, pool.candidates(keys))
+
+	// Todas desativadas: tenta todas mesmo assim
+	pool.disable("b")
+	assert.Equal(t, []string{"a", "b"}, pool.candidates(keys))
+
+	now = now.Add(10 * time.Minute)
+	assert.Equal(t, []string{"b", "a"}, pool.candidates(keys))
+}
+
+// Uma chave recusada pela WeatherAPI cede a vez para a próxima
+func TestCallWeatherAPI_FailsOverToNextKey(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	withFreshKeyPool(t)
+
+	var usedKeys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := r.URL.Query().Get("key")
+		usedKeys = append(usedKeys, key)
+		if key == "exhausted-key" {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(`{"error":{"code":2007,"message":"API key has exceeded calls per month quota."}}`))
+			return
+		}
+		w.Write([]byte(`{"current":{"temp_c":25}}`))
+	}))
+	defer server.Close()
+	s.weather = weather.NewClient(server.URL+"/v1", nil)
+	t.Setenv("WEATHER_API_KEY", "exhausted-key,good-key")
+
+	_, err := s.getCurrentWeather(context.Background(), "São Paulo", false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"exhausted-key", "good-key"}, usedKeys)
+
+	// A chave recusada fica fora do rodízio
+	usedKeys = nil
+	_, err = s.getCurrentWeather(context.Background(), "Rio de Janeiro", false)
+	assert.NoError(t, err)
+	_, err = s.getCurrentWeather(context.Background(), "Curitiba", false)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"good-key", "good-key"}, usedKeys)
+}
+
+func TestCallWeatherAPI_DoesNotFailOverOnOtherErrors(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	withFreshKeyPool(t)
+	t.Setenv("WEATHER_API_KEY", "key-a,key-b")
+
+	// Localização desconhecida (400) não é problema da chave
+	_, err := s.getCurrentWeather(context.Background(), "Lugar Nenhum", false)
+	assert.EqualError(t, err, "weather API error: status 400")
+	assert.Empty(t, weatherAPIKeyPool.disabled)
+}
+
+func TestMaskKey(t *testing.T) {
+	assert.Equal(t, "****cdef", maskKey("0123456789abcdef"))
+	assert.Equal(t, "****", maskKey("abc"))
+}
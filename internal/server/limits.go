@@ -0,0 +1,48 @@
+package server
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+
+	"github.com/weather-service/config"
+)
+
+const defaultMaxBodySize = 1 << 20
+
+// Tamanho máximo, em bytes, do corpo aceito pelos endpoints POST (MAX_BODY_SIZE)
+func maxBodySize() int64 {
+	value := config.String("MAX_BODY_SIZE")
+	if value == "" {
+		return defaultMaxBodySize
+	}
+
+	size, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || size <= 0 {
+		log.Printf("WARNING: ignoring invalid MAX_BODY_SIZE %q", value)
+		return defaultMaxBodySize
+	}
+	return size
+}
+
+// Limita o corpo da requisição; a leitura além do limite falha com
+// *http.MaxBytesError, tratado pelo handler com isBodyTooLarge
+func limitBody(maxBytes int64) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.ContentLength > maxBytes {
+				writeResponse(w, r, http.StatusRequestEntityTooLarge, ErrorResponse{Message: "request body too large"})
+				return
+			}
+
+			r.Body = http.MaxBytesReader(w, r.Body, maxBytes)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func isBodyTooLarge(err error) bool {
+	var maxBytesErr *http.MaxBytesError
+	return errors.As(err, &maxBytesErr)
+}
@@ -0,0 +1,76 @@
+package server
+
+import (
+	"bytes"
+	"io"
+	"log"
+
+	"github.com/weather-service/config"
+)
+
+// Níveis aceitos em LOG_LEVEL, do mais detalhado ao mais restrito. O nível
+// de cada linha vem do prefixo da mensagem ("ERROR:", "WARNING:", "PANIC");
+// as demais são info
+const (
+	logLevelInfo = iota
+	logLevelWarning
+	logLevelError
+)
+
+var logLevels = map[string]int{
+	"info":    logLevelInfo,
+	"warning": logLevelWarning,
+	"error":   logLevelError,
+}
+
+// Nível mínimo das linhas registradas (LOG_LEVEL, padrão info)
+func logLevel() int {
+	if level, ok := logLevels[config.String("LOG_LEVEL")]; ok {
+		return level
+	}
+	return logLevelInfo
+}
+
+// Saída do log que descarta as linhas abaixo de LOG_LEVEL. O nível é relido a
+// cada linha, para que um reload da configuração o altere sem reiniciar
+type levelWriter struct {
+	out io.Writer
+}
+
+func (w levelWriter) Write(line []byte) (int, error) {
+	if logLineLevel(line) < logLevel() {
+		return len(line), nil
+	}
+	return w.out.Write(line)
+}
+
+func logLineLevel(line []byte) int {
+	message := logMessage(line)
+	switch {
+	case bytes.HasPrefix(message, []byte("ERROR:")), bytes.HasPrefix(message, []byte("PANIC")):
+		return logLevelError
+	case bytes.HasPrefix(message, []byte("WARNING:")):
+		return logLevelWarning
+	default:
+		return logLevelInfo
+	}
+}
+
+// Mensagem sem a data e a hora que o pacote log acrescenta no início da linha
+func logMessage(line []byte) []byte {
+	flags := log.Flags()
+	skip := len(log.Prefix())
+	if flags&log.Ldate != 0 {
+		skip += len("2006/01/02 ")
+	}
+	if flags&(log.Ltime|log.Lmicroseconds) != 0 {
+		skip += len("15:04:05 ")
+		if flags&log.Lmicroseconds != 0 {
+			skip += len(".000000")
+		}
+	}
+	if skip > len(line) {
+		return line
+	}
+	return line[skip:]
+}
@@ -0,0 +1,86 @@
+package server
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/config"
+)
+
+// As linhas abaixo de LOG_LEVEL são descartadas, e um reload muda o nível
+func TestLevelWriter(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(levelWriter{out: &logs})
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	path := withConfigFile(t, "log_level: warning\n", "LOG_LEVEL")
+
+	log.Printf("Calling weather API current.json")
+	log.Printf("WARNING: Kafka queue full")
+	log.Printf("ERROR: Failed to record request")
+	log.Printf("PANIC [request req-123 subject %q] GET /: boom", "")
+	assert.NotContains(t, logs.String(), "Calling weather API")
+	assert.Contains(t, logs.String(), "WARNING: Kafka queue full")
+	assert.Contains(t, logs.String(), "ERROR: Failed to record request")
+	assert.Contains(t, logs.String(), "PANIC [request req-123")
+
+	assert.NoError(t, os.WriteFile(path, []byte("log_level: error\n"), 0o600))
+	_, err := config.Reload()
+	assert.NoError(t, err)
+	logs.Reset()
+	log.Printf("WARNING: Kafka queue full")
+	log.Printf("ERROR: Failed to record request")
+	assert.Equal(t, 1, bytes.Count(logs.Bytes(), []byte("\n")))
+
+	assert.NoError(t, os.WriteFile(path, []byte("{}\n"), 0o600))
+	_, err = config.Reload()
+	assert.NoError(t, err)
+	log.Printf("Calling weather API current.json")
+	assert.Contains(t, logs.String(), "Calling weather API")
+}
+
+func TestLogLineLevel(t *testing.T) {
+	flags := log.Flags()
+	t.Cleanup(func() { log.SetFlags(flags) })
+
+	tests := []struct {
+		flags    int
+		line     string
+		expected int
+	}{
+		{log.LstdFlags, "2026/10/17 12:00:00 ERROR: boom\n", logLevelError},
+		{log.LstdFlags, "2026/10/17 12:00:00 WARNING: slow\n", logLevelWarning},
+		{log.LstdFlags, "2026/10/17 12:00:00 Calling weather API\n", logLevelInfo},
+		{log.LstdFlags | log.Lmicroseconds, "2026/10/17 12:00:00.123456 ERROR: boom\n", logLevelError},
+		{0, "WARNING: slow\n", logLevelWarning},
+		// A palavra no meio da mensagem não muda o nível
+		{log.LstdFlags, "2026/10/17 12:00:00 Retrying after ERROR: boom\n", logLevelInfo},
+	}
+	for _, tt := range tests {
+		log.SetFlags(tt.flags)
+		assert.Equal(t, tt.expected, logLineLevel([]byte(tt.line)), tt.line)
+	}
+}
+
+// log.Fatal encerra o processo: sem o prefixo ERROR:, a mensagem seria
+// descartada com LOG_LEVEL=warning ou error e o serviço sairia sem explicação
+func TestFatalLogsAreErrors(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	assert.NoError(t, err)
+	fatal := regexp.MustCompile(`log\.Fatal(f|ln)?\(\s*"([^"]*)`)
+	for _, file := range files {
+		if strings.HasSuffix(file, "_test.go") {
+			continue
+		}
+		source, err := os.ReadFile(file)
+		assert.NoError(t, err)
+		for _, match := range fatal.FindAllStringSubmatch(string(source), -1) {
+			assert.True(t, strings.HasPrefix(match[2], "ERROR:"), "%s: %s", file, match[0])
+		}
+	}
+}
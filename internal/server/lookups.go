@@ -0,0 +1,451 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+	"github.com/weather-service/config"
+	_ "modernc.org/sqlite"
+)
+
+const defaultLookupsDBMaxConns = 10
+
+// Consulta CEP → temperatura concluída com sucesso
+type lookupRecord struct {
+	CEP   string
+	City  string
+	UF    string
+	TempC float64
+	At    time.Time
+}
+
+// Filtro das consultas registradas. CEP vazio traz todos os CEPs; From e To
+// zerados não limitam o período; To é exclusivo
+type lookupQuery struct {
+	CEP    string
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Offset int
+}
+
+// Destino das consultas registradas
+type lookupStore interface {
+	record(ctx context.Context, lookup lookupRecord) error
+	// Consultas do filtro, da mais recente para a mais antiga, e o total
+	// encontrado antes da paginação
+	history(ctx context.Context, query lookupQuery) ([]lookupRecord, int, error)
+	// Percorre as consultas do filtro, da mais antiga para a mais recente, sem
+	// carregá-las todas na memória. Limit e Offset são ignorados
+	export(ctx context.Context, query lookupQuery, each func(lookupRecord) error) error
+	recordRequest(ctx context.Context, request requestRecord) error
+	// Totais das requisições a partir de since, com os top maiores CEPs e cidades
+	usage(ctx context.Context, since time.Time, top int) (usageSummary, error)
+	Close() error
+}
+
+// Abre o banco configurado no ambiente: SQLite para uma instância só, ou
+// PostgreSQL quando várias réplicas precisam do mesmo histórico. Retorna nil
+// quando nenhum está configurado
+func lookupStoreFromEnv() (lookupStore, error) {
+	sqlitePath, postgresURL := config.String("LOOKUPS_SQLITE_PATH"), config.String("LOOKUPS_POSTGRES_URL")
+	switch {
+	case sqlitePath != "" && postgresURL != "":
+		return nil, errors.New("LOOKUPS_SQLITE_PATH and LOOKUPS_POSTGRES_URL cannot be used together")
+	case sqlitePath != "":
+		return openSQLiteLookupStore(sqlitePath)
+	case postgresURL != "":
+		return openPostgresLookupStore(postgresURL)
+	}
+	return nil, nil
+}
+
+// Registra a consulta sem afetar a resposta: uma falha no banco só vai para o log
+func (s *Server) recordLookup(ctx context.Context, address *ViaCEPResponse, tempC float64) {
+	if s.lookups == nil {
+		return
+	}
+
+	lookup := lookupRecord{
+		CEP:   strings.ReplaceAll(address.Cep, "-", ""),
+		City:  address.Localidade,
+		UF:    address.UF,
+		TempC: tempC,
+		At:    time.Now().UTC(),
+	}
+	if err := s.lookups.record(ctx, lookup); err != nil {
+		log.Printf("ERROR: Failed to record lookup for CEP %s: %v", lookup.CEP, err)
+	}
+}
+
+// O que muda entre os bancos suportados: driver, migrações e o formato dos
+// parâmetros. As consultas são escritas com "?" e convertidas por bind
+type sqlDialect struct {
+	name       string
+	driver     string
+	migrations []string
+	// Executado na transação das migrações para que réplicas subindo ao
+	// mesmo tempo não apliquem a mesma migração duas vezes
+	lockMigrations string
+	numbered       bool
+	// Bancos com tipo próprio para data e hora recebem time.Time; os demais,
+	// texto RFC 3339
+	nativeTime bool
+}
+
+// Migrações em ordem; cada uma é aplicada uma única vez e registrada em
+// schema_migrations. Novas migrações entram sempre no fim da lista
+var sqliteDialect = &sqlDialect{
+	name:   "sqlite",
+	driver: "sqlite",
+	migrations: []string{
+		`CREATE TABLE IF NOT EXISTS lookups (
+			id           INTEGER PRIMARY KEY AUTOINCREMENT,
+			cep          TEXT NOT NULL,
+			city         TEXT NOT NULL,
+			uf           TEXT NOT NULL,
+			temp_c       REAL NOT NULL,
+			looked_up_at TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS lookups_cep_looked_up_at ON lookups (cep, looked_up_at);`,
+		`CREATE TABLE IF NOT EXISTS requests (
+			id           INTEGER PRIMARY KEY AUTOINCREMENT,
+			status       INTEGER NOT NULL,
+			cep          TEXT NOT NULL,
+			city         TEXT NOT NULL,
+			uf           TEXT NOT NULL,
+			cache        TEXT NOT NULL,
+			requested_at TEXT NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS requests_requested_at ON requests (requested_at);`,
+		`CREATE TABLE IF NOT EXISTS alert_rules (
+			id            TEXT PRIMARY KEY,
+			cep           TEXT NOT NULL,
+			metric        TEXT NOT NULL,
+			operator      TEXT NOT NULL,
+			threshold     REAL NOT NULL,
+			callback_url  TEXT NOT NULL,
+			status        TEXT NOT NULL,
+			created_at    TEXT NOT NULL,
+			last_fired_at TEXT
+		);`,
+		`ALTER TABLE alert_rules ADD COLUMN channel TEXT NOT NULL DEFAULT 'webhook';`,
+		`ALTER TABLE alert_rules ADD COLUMN email TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE requests ADD COLUMN subject TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE alert_rules ADD COLUMN owner TEXT NOT NULL DEFAULT '';`,
+	},
+}
+
+var postgresDialect = &sqlDialect{
+	name:   "postgres",
+	driver: "pgx",
+	migrations: []string{
+		`CREATE TABLE IF NOT EXISTS lookups (
+			id           BIGSERIAL PRIMARY KEY,
+			cep          TEXT NOT NULL,
+			city         TEXT NOT NULL,
+			uf           TEXT NOT NULL,
+			temp_c       DOUBLE PRECISION NOT NULL,
+			looked_up_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS lookups_cep_looked_up_at ON lookups (cep, looked_up_at);`,
+		`CREATE TABLE IF NOT EXISTS requests (
+			id           BIGSERIAL PRIMARY KEY,
+			status       INTEGER NOT NULL,
+			cep          TEXT NOT NULL,
+			city         TEXT NOT NULL,
+			uf           TEXT NOT NULL,
+			cache        TEXT NOT NULL,
+			requested_at TIMESTAMPTZ NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS requests_requested_at ON requests (requested_at);`,
+		`CREATE TABLE IF NOT EXISTS alert_rules (
+			id            TEXT PRIMARY KEY,
+			cep           TEXT NOT NULL,
+			metric        TEXT NOT NULL,
+			operator      TEXT NOT NULL,
+			threshold     DOUBLE PRECISION NOT NULL,
+			callback_url  TEXT NOT NULL,
+			status        TEXT NOT NULL,
+			created_at    TIMESTAMPTZ NOT NULL,
+			last_fired_at TIMESTAMPTZ
+		);`,
+		`ALTER TABLE alert_rules ADD COLUMN channel TEXT NOT NULL DEFAULT 'webhook';`,
+		`ALTER TABLE alert_rules ADD COLUMN email TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE requests ADD COLUMN subject TEXT NOT NULL DEFAULT '';`,
+		`ALTER TABLE alert_rules ADD COLUMN owner TEXT NOT NULL DEFAULT '';`,
+	},
+	lockMigrations: "LOCK TABLE schema_migrations IN EXCLUSIVE MODE",
+	numbered:       true,
+	nativeTime:     true,
+}
+
+// Troca os "?" da consulta por $1, $2... nos bancos que usam parâmetros numerados
+func (d *sqlDialect) bind(query string) string {
+	if !d.numbered {
+		return query
+	}
+
+	var b strings.Builder
+	n := 0
+	for _, r := range query {
+		if r == '?' {
+			n++
+			b.WriteString("$" + strconv.Itoa(n))
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func (d *sqlDialect) timestamp(t time.Time) interface{} {
+	if d.nativeTime {
+		return t.UTC()
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// Consultas gravadas em um banco SQL. No SQLite o horário fica em texto
+// RFC 3339 (UTC), o que permite usar as funções de data do próprio banco nas
+// análises; no PostgreSQL, em TIMESTAMPTZ
+type sqlLookupStore struct {
+	db      *sql.DB
+	dialect *sqlDialect
+}
+
+// Arquivo SQLite local, sem infraestrutura externa
+func openSQLiteLookupStore(path string) (*sqlLookupStore, error) {
+	// WAL permite ler o arquivo para análises enquanto o serviço grava
+	dsn := "file:" + path + "?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)"
+	return openSQLLookupStore(sqliteDialect, dsn)
+}
+
+// PostgreSQL compartilhado entre réplicas, com até LOOKUPS_DB_MAX_CONNS
+// conexões abertas por instância
+func openPostgresLookupStore(url string) (*sqlLookupStore, error) {
+	store, err := openSQLLookupStore(postgresDialect, url)
+	if err != nil {
+		return nil, err
+	}
+
+	maxConns := config.Int("LOOKUPS_DB_MAX_CONNS", 0)
+	if maxConns == 0 {
+		maxConns = defaultLookupsDBMaxConns
+	}
+	store.db.SetMaxOpenConns(maxConns)
+	store.db.SetMaxIdleConns(maxConns)
+	store.db.SetConnMaxIdleTime(5 * time.Minute)
+	return store, nil
+}
+
+func openSQLLookupStore(dialect *sqlDialect, dsn string) (*sqlLookupStore, error) {
+	db, err := sql.Open(dialect.driver, dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s lookups database: %w", dialect.name, err)
+	}
+
+	store := &sqlLookupStore{db: db, dialect: dialect}
+	if err := store.migrate(context.Background()); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to migrate %s lookups database: %w", dialect.name, err)
+	}
+	return store, nil
+}
+
+// Aplica as migrações que ainda não constam em schema_migrations
+func (s *sqlLookupStore) migrate(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    INTEGER PRIMARY KEY,
+		applied_at TEXT NOT NULL
+	)`); err != nil {
+		return err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if s.dialect.lockMigrations != "" {
+		if _, err := tx.ExecContext(ctx, s.dialect.lockMigrations); err != nil {
+			return err
+		}
+	}
+
+	var applied int
+	if err := tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&applied); err != nil {
+		return err
+	}
+	for version := applied + 1; version <= len(s.dialect.migrations); version++ {
+		if _, err := tx.ExecContext(ctx, s.dialect.migrations[version-1]); err != nil {
+			return fmt.Errorf("migration %d: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, s.dialect.bind("INSERT INTO schema_migrations (version, applied_at) VALUES (?, ?)"),
+			version, time.Now().UTC().Format(time.RFC3339)); err != nil {
+			return err
+		}
+		log.Printf("Applied %s lookups migration %d", s.dialect.name, version)
+	}
+	return tx.Commit()
+}
+
+func (s *sqlLookupStore) record(ctx context.Context, lookup lookupRecord) error {
+	_, err := s.db.ExecContext(ctx,
+		s.dialect.bind("INSERT INTO lookups (cep, city, uf, temp_c, looked_up_at) VALUES (?, ?, ?, ?, ?)"),
+		lookup.CEP, lookup.City, lookup.UF, lookup.TempC, s.dialect.timestamp(lookup.At))
+	return err
+}
+
+func (s *sqlLookupStore) history(ctx context.Context, query lookupQuery) ([]lookupRecord, int, error) {
+	where, args := s.lookupFilter(query)
+
+	var total int
+	if err := s.db.QueryRowContext(ctx, s.dialect.bind("SELECT COUNT(*) FROM lookups WHERE "+where), args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	records := []lookupRecord{}
+	err := s.scanRows(ctx, func(rows *sql.Rows) error {
+		record, err := scanLookup(rows)
+		records = append(records, record)
+		return err
+	}, "SELECT cep, city, uf, temp_c, looked_up_at FROM lookups WHERE "+where+" ORDER BY looked_up_at DESC, id DESC LIMIT ? OFFSET ?",
+		append(args, query.Limit, query.Offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	return records, total, nil
+}
+
+func (s *sqlLookupStore) export(ctx context.Context, query lookupQuery, each func(lookupRecord) error) error {
+	where, args := s.lookupFilter(query)
+	return s.scanRows(ctx, func(rows *sql.Rows) error {
+		record, err := scanLookup(rows)
+		if err != nil {
+			return err
+		}
+		return each(record)
+	}, "SELECT cep, city, uf, temp_c, looked_up_at FROM lookups WHERE "+where+" ORDER BY looked_up_at, id", args...)
+}
+
+// Condição WHERE e argumentos do filtro, com "?" ainda não convertidos por bind
+func (s *sqlLookupStore) lookupFilter(query lookupQuery) (string, []interface{}) {
+	where, args := "1 = 1", []interface{}{}
+	if query.CEP != "" {
+		where += " AND cep = ?"
+		args = append(args, query.CEP)
+	}
+	if !query.From.IsZero() {
+		where += " AND looked_up_at >= ?"
+		args = append(args, s.dialect.timestamp(query.From))
+	}
+	if !query.To.IsZero() {
+		where += " AND looked_up_at < ?"
+		args = append(args, s.dialect.timestamp(query.To))
+	}
+	return where, args
+}
+
+func scanLookup(rows *sql.Rows) (lookupRecord, error) {
+	var record lookupRecord
+	var at string
+	if err := rows.Scan(&record.CEP, &record.City, &record.UF, &record.TempC, &at); err != nil {
+		return record, err
+	}
+	var err error
+	if record.At, err = time.Parse(time.RFC3339Nano, at); err != nil {
+		return record, fmt.Errorf("invalid looked_up_at %q: %w", at, err)
+	}
+	return record, nil
+}
+
+func (s *sqlLookupStore) recordRequest(ctx context.Context, request requestRecord) error {
+	_, err := s.db.ExecContext(ctx,
+		s.dialect.bind("INSERT INTO requests (status, cep, city, uf, cache, subject, requested_at) VALUES (?, ?, ?, ?, ?, ?, ?)"),
+		request.Status, request.CEP, request.City, request.UF, request.Cache, request.Subject, s.dialect.timestamp(request.At))
+	return err
+}
+
+func (s *sqlLookupStore) usage(ctx context.Context, since time.Time, top int) (usageSummary, error) {
+	summary := usageSummary{TopCEPs: []CEPCount{}, TopCities: []CityCount{}, TopSubjects: []SubjectCount{}, Errors: []ErrorCount{}}
+	from := s.dialect.timestamp(since)
+
+	err := s.db.QueryRowContext(ctx, s.dialect.bind(`SELECT COUNT(*),
+			COALESCE(SUM(CASE WHEN cache = 'hit' THEN 1 ELSE 0 END), 0),
+			COALESCE(SUM(CASE WHEN cache <> '' THEN 1 ELSE 0 END), 0)
+		FROM requests WHERE requested_at >= ?`), from).Scan(&summary.Total, &summary.CacheHits, &summary.CacheLookups)
+	if err != nil {
+		return summary, err
+	}
+
+	err = s.scanRows(ctx, func(rows *sql.Rows) error {
+		var count CEPCount
+		err := rows.Scan(&count.CEP, &count.Requests)
+		summary.TopCEPs = append(summary.TopCEPs, count)
+		return err
+	}, `SELECT cep, COUNT(*) AS n FROM requests WHERE requested_at >= ? AND cep <> ''
+		GROUP BY cep ORDER BY n DESC, cep LIMIT ?`, from, top)
+	if err != nil {
+		return summary, err
+	}
+
+	err = s.scanRows(ctx, func(rows *sql.Rows) error {
+		var count CityCount
+		err := rows.Scan(&count.City, &count.UF, &count.Requests)
+		summary.TopCities = append(summary.TopCities, count)
+		return err
+	}, `SELECT city, uf, COUNT(*) AS n FROM requests WHERE requested_at >= ? AND city <> ''
+		GROUP BY city, uf ORDER BY n DESC, city, uf LIMIT ?`, from, top)
+	if err != nil {
+		return summary, err
+	}
+
+	err = s.scanRows(ctx, func(rows *sql.Rows) error {
+		var count SubjectCount
+		err := rows.Scan(&count.Subject, &count.Requests)
+		summary.TopSubjects = append(summary.TopSubjects, count)
+		return err
+	}, `SELECT subject, COUNT(*) AS n FROM requests WHERE requested_at >= ? AND subject <> ''
+		GROUP BY subject ORDER BY n DESC, subject LIMIT ?`, from, top)
+	if err != nil {
+		return summary, err
+	}
+
+	err = s.scanRows(ctx, func(rows *sql.Rows) error {
+		var count ErrorCount
+		err := rows.Scan(&count.Status, &count.Requests)
+		summary.Errors = append(summary.Errors, count)
+		return err
+	}, `SELECT status, COUNT(*) FROM requests WHERE requested_at >= ? AND status >= 400
+		GROUP BY status ORDER BY status`, from)
+	return summary, err
+}
+
+// Executa a consulta e chama scan para cada linha
+func (s *sqlLookupStore) scanRows(ctx context.Context, scan func(*sql.Rows) error, query string, args ...interface{}) error {
+	rows, err := s.db.QueryContext(ctx, s.dialect.bind(query), args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		if err := scan(rows); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func (s *sqlLookupStore) Close() error {
+	return s.db.Close()
+}
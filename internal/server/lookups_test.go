@@ -0,0 +1,200 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Registra as consultas de s em um SQLite temporário
+func withSQLiteLookups(t *testing.T, s *Server) *sqlLookupStore {
+	t.Helper()
+	store, err := openSQLiteLookupStore(filepath.Join(t.TempDir(), "lookups.db"))
+	assert.NoError(t, err)
+	t.Cleanup(func() { store.Close() })
+
+	s.lookups = store
+	return store
+}
+
+type storedLookup struct {
+	cep, city, uf, at string
+	tempC             float64
+}
+
+func storedLookups(t *testing.T, db *sql.DB) []storedLookup {
+	rows, err := db.Query("SELECT cep, city, uf, temp_c, looked_up_at FROM lookups ORDER BY id")
+	assert.NoError(t, err)
+	defer rows.Close()
+
+	var stored []storedLookup
+	for rows.Next() {
+		var lookup storedLookup
+		assert.NoError(t, rows.Scan(&lookup.cep, &lookup.city, &lookup.uf, &lookup.tempC, &lookup.at))
+		stored = append(stored, lookup)
+	}
+	return stored
+}
+
+func TestLookupStoreFromEnv(t *testing.T) {
+	t.Setenv("LOOKUPS_SQLITE_PATH", "")
+	store, err := lookupStoreFromEnv()
+	assert.NoError(t, err)
+	assert.Nil(t, store)
+
+	path := filepath.Join(t.TempDir(), "lookups.db")
+	t.Setenv("LOOKUPS_SQLITE_PATH", path)
+	store, err = lookupStoreFromEnv()
+	assert.NoError(t, err)
+	assert.NoError(t, store.Close())
+
+	// Reabrir o mesmo arquivo não reaplica as migrações
+	store, err = lookupStoreFromEnv()
+	assert.NoError(t, err)
+	defer store.Close()
+	var migrations int
+	assert.NoError(t, store.(*sqlLookupStore).db.QueryRow("SELECT COUNT(*) FROM schema_migrations").Scan(&migrations))
+	assert.Equal(t, len(sqliteDialect.migrations), migrations)
+
+	t.Setenv("LOOKUPS_POSTGRES_URL", "postgres://localhost/weather")
+	_, err = lookupStoreFromEnv()
+	assert.EqualError(t, err, "LOOKUPS_SQLITE_PATH and LOOKUPS_POSTGRES_URL cannot be used together")
+}
+
+// Bancos criados antes das migrações já têm a tabela lookups
+func TestSQLLookupStore_MigratesExistingDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lookups.db")
+	db, err := sql.Open("sqlite", path)
+	assert.NoError(t, err)
+	_, err = db.Exec(sqliteDialect.migrations[0])
+	assert.NoError(t, err)
+	_, err = db.Exec("INSERT INTO lookups (cep, city, uf, temp_c, looked_up_at) VALUES ('01310100', 'São Paulo', 'SP', 20, '2026-01-01T00:00:00Z')")
+	assert.NoError(t, err)
+	db.Close()
+
+	store, err := openSQLiteLookupStore(path)
+	assert.NoError(t, err)
+	defer store.Close()
+	assert.Len(t, storedLookups(t, store.db), 1)
+}
+
+func TestSQLDialect_Bind(t *testing.T) {
+	query := "INSERT INTO lookups (cep, temp_c) VALUES (?, ?)"
+	assert.Equal(t, query, sqliteDialect.bind(query))
+	assert.Equal(t, "INSERT INTO lookups (cep, temp_c) VALUES ($1, $2)", postgresDialect.bind(query))
+}
+
+// Requer um PostgreSQL de teste em TEST_POSTGRES_URL
+func TestPostgresLookupStore(t *testing.T) {
+	url := os.Getenv("TEST_POSTGRES_URL")
+	if url == "" {
+		t.Skip("TEST_POSTGRES_URL not set")
+	}
+
+	store, err := openPostgresLookupStore(url)
+	assert.NoError(t, err)
+	defer store.Close()
+	_, err = store.db.Exec("DELETE FROM lookups WHERE cep = '00000000'")
+	assert.NoError(t, err)
+
+	at := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	assert.NoError(t, store.record(context.Background(), lookupRecord{CEP: "00000000", City: "Teste", UF: "SP", TempC: 21.5, At: at}))
+
+	var tempC float64
+	var lookedUpAt time.Time
+	assert.NoError(t, store.db.QueryRow("SELECT temp_c, looked_up_at FROM lookups WHERE cep = '00000000'").Scan(&tempC, &lookedUpAt))
+	assert.Equal(t, 21.5, tempC)
+	assert.True(t, at.Equal(lookedUpAt))
+
+	// Uma segunda instância encontra as migrações já aplicadas
+	other, err := openPostgresLookupStore(url)
+	assert.NoError(t, err)
+	other.Close()
+}
+
+func TestLookupStoreFromEnv_InvalidPath(t *testing.T) {
+	t.Setenv("LOOKUPS_SQLITE_PATH", filepath.Join(t.TempDir(), "missing", "lookups.db"))
+	_, err := lookupStoreFromEnv()
+	assert.ErrorContains(t, err, "failed to migrate sqlite lookups database")
+}
+
+func TestWeatherHandler_RecordsLookup(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25.5}}`,
+	})
+	store := withSQLiteLookups(t, s)
+
+	router := s.router()
+	for _, path := range []string{"/weather/01310-100", "/weather/99999999"} {
+		req, err := http.NewRequest("GET", path, nil)
+		assert.NoError(t, err)
+		router.ServeHTTP(httptest.NewRecorder(), req)
+	}
+	_, err := s.lookupWeather(context.Background(), "01310100", defaultUnits)
+	assert.NoError(t, err)
+
+	// Só as consultas bem-sucedidas são registradas
+	stored := storedLookups(t, store.db)
+	assert.Len(t, stored, 2)
+	for _, lookup := range stored {
+		assert.Equal(t, "01310100", lookup.cep)
+		assert.Equal(t, "São Paulo", lookup.city)
+		assert.Equal(t, "SP", lookup.uf)
+		assert.Equal(t, 25.5, lookup.tempC)
+		assert.Regexp(t, `^\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z$`, lookup.at)
+	}
+}
+
+// Uma falha no banco não afeta a resposta
+func TestWeatherHandler_LookupStoreFailure(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	store := withSQLiteLookups(t, s)
+	store.Close()
+
+	req, err := http.NewRequest("GET", "/weather/01310100", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestSQLLookupStore_History(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	store := withSQLiteLookups(t, s)
+	ctx := context.Background()
+	day := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, store.record(ctx, lookupRecord{CEP: "01310100", City: "São Paulo", UF: "SP", TempC: float64(20 + i), At: day.AddDate(0, 0, i)}))
+	}
+	assert.NoError(t, store.record(ctx, lookupRecord{CEP: "20040020", City: "Rio de Janeiro", UF: "RJ", TempC: 30, At: day}))
+
+	records, total, err := store.history(ctx, lookupQuery{CEP: "01310100", Limit: 2, Offset: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 5, total)
+	assert.Equal(t, []lookupRecord{
+		{CEP: "01310100", City: "São Paulo", UF: "SP", TempC: 23, At: day.AddDate(0, 0, 3)},
+		{CEP: "01310100", City: "São Paulo", UF: "SP", TempC: 22, At: day.AddDate(0, 0, 2)},
+	}, records)
+
+	records, total, err = store.history(ctx, lookupQuery{CEP: "01310100", From: day.AddDate(0, 0, 1), To: day.AddDate(0, 0, 3), Limit: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, 22.0, records[0].TempC)
+	assert.Equal(t, 21.0, records[1].TempC)
+
+	records, total, err = store.history(ctx, lookupQuery{CEP: "99999999", Limit: 10})
+	assert.NoError(t, err)
+	assert.Zero(t, total)
+	assert.Empty(t, records)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Origens do endereço informadas em meta.location_provider, além dos nomes
+// dos provedores de CEP (viacep, brasilapi)
+const (
+	locationProviderAddressCache = "address_cache"
+	locationProviderEmbedded     = "embedded"
+	locationProviderIBGE         = "ibge"
+	locationProviderZippopotam   = "zippopotam"
+)
+
+// Como a resposta foi montada, com ?meta=true, para que o cliente veja de
+// onde vieram os dados e quão antigos eles são sem olhar os logs do servidor
+type ResponseMeta struct {
+	// Provedor do clima (weather_api)
+	Provider string `json:"provider" xml:"provider"`
+	// Origem do endereço: viacep, brasilapi, address_cache, embedded, ibge ou
+	// zippopotam. Ausente nas consultas por coordenadas e por cidade
+	LocationProvider string `json:"location_provider,omitempty" xml:"location_provider,omitempty"`
+	// hit quando a temperatura veio do cache de respostas da WeatherAPI, stale
+	// quando veio de uma resposta vencida, servida no lugar da WeatherAPI
+	Cache string `json:"cache" xml:"cache"`
+	// Horário da observação publicada pela WeatherAPI
+	ObservedAt *time.Time `json:"observed_at,omitempty" xml:"observed_at,omitempty"`
+	// Tempo desde o início da consulta até a montagem da resposta
+	DurationMs float64 `json:"duration_ms" xml:"duration_ms"`
+}
+
+// Bloco meta da resposta, quando a requisição pede ?meta=true. Depende do
+// startReading feito pelo handler para saber o início e o uso do cache
+func responseMeta(r *http.Request, current *WeatherAPIResponse) *ResponseMeta {
+	if !queryFlag(r, "meta") {
+		return nil
+	}
+
+	meta := &ResponseMeta{Provider: weatherAPIProvider.name, Cache: usageCacheMiss}
+	if epoch := current.Current.LastUpdatedEpoch; epoch > 0 {
+		observedAt := time.Unix(epoch, 0).UTC()
+		meta.ObservedAt = &observedAt
+	}
+	if source, started, ok := readingTraceOf(r.Context()); ok {
+		if source == readingSourceCache {
+			meta.Cache = usageCacheHit
+		}
+		if staleReading(r.Context()) {
+			meta.Cache = metaCacheStale
+		}
+		meta.DurationMs = float64(time.Since(started).Microseconds()) / 1000
+	}
+	return meta
+}
+
+// Anota a origem do endereço no bloco meta, quando ele foi pedido
+func (wr *WeatherResponse) noteLocationProvider(provider string) {
+	if wr.Meta != nil {
+		wr.Meta.LocationProvider = provider
+	}
+}
+
+// Origem da temperatura e início da consulta anotados por startReading
+func readingTraceOf(ctx context.Context) (string, time.Time, bool) {
+	trace, _ := ctx.Value(readingTraceKey{}).(*readingTrace)
+	if trace == nil {
+		return "", time.Time{}, false
+	}
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	return trace.source, trace.started, true
+}
@@ -0,0 +1,74 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeatherHandler_Meta(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/":       viaCEPSaoPaulo,
+		"/ibge/municipios/3550308": saoPauloIBGE,
+		"/v1/current.json":         `{"current":{"last_updated_epoch":1760000000,"temp_c":25}}`,
+	})
+	t.Setenv("WEATHER_CACHE_TTL", "1m")
+
+	// Sem meta=true a resposta não muda. Esta consulta põe São Paulo no cache
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.NotContains(t, rr.Body.String(), `"meta"`)
+
+	tests := []struct {
+		name             string
+		path             string
+		locationProvider string
+		cache            string
+	}{
+		{"CEP", "/weather/01310100?meta=true", "viacep", usageCacheHit},
+		{"IBGE", "/weather/ibge/3550308?meta=true", locationProviderIBGE, usageCacheHit},
+		{"coordinates", "/weather/coords/-23.55,-46.63?meta=true", "", usageCacheMiss},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, httptest.NewRequest("GET", tt.path, nil))
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response WeatherResponse
+			assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+			if assert.NotNil(t, response.Meta) {
+				assert.Equal(t, "weather_api", response.Meta.Provider)
+				assert.Equal(t, tt.locationProvider, response.Meta.LocationProvider)
+				assert.Equal(t, tt.cache, response.Meta.Cache)
+				assert.Equal(t, time.Unix(1760000000, 0).UTC(), *response.Meta.ObservedAt)
+				assert.GreaterOrEqual(t, response.Meta.DurationMs, 0.0)
+			}
+		})
+	}
+}
+
+// Com a cidade da tabela embutida, o bloco meta indica embedded
+func TestWeatherHandler_MetaEmbedded(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/v1/current.json": `{"current":{"temp_c":25}}`,
+	})
+	t.Setenv("OFFLINE_CEP", "true")
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310-100?meta=true", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response WeatherResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Equal(t, locationProviderEmbedded, response.Meta.LocationProvider)
+	assert.Equal(t, usageCacheMiss, response.Meta.Cache)
+	// Sem last_updated_epoch, não há horário de observação
+	assert.Nil(t, response.Meta.ObservedAt)
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/weather-service/config"
+)
+
+const (
+	defaultMQTTTopicPrefix = "weather"
+	// Espera pela confirmação do broker antes de registrar a publicação como falha
+	mqttPublishTimeout = 10 * time.Second
+)
+
+// Parte do cliente Paho usada pelo publicador. Nos testes, um cliente em memória
+type mqttClient interface {
+	Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token
+	Disconnect(quiesce uint)
+}
+
+// Publica cada leitura em <MQTT_TOPIC_PREFIX>/<uf>/<cep>, em JSON, para que
+// automação residencial e dispositivos assinem o tópico em vez de consultar
+// a API periodicamente
+type mqttPublisher struct {
+	client mqttClient
+	prefix string
+	qos    byte
+	retain bool
+}
+
+// Conecta ao broker de MQTT_BROKER_URL (tcp://, ssl://, ws:// ou wss://).
+// Retorna nil quando não há broker configurado. A conexão não bloqueia a
+// inicialização: o Paho tenta de novo em segundo plano até o broker responder
+// e reconecta sozinho depois de uma queda
+func mqttPublisherFromEnv() (*mqttPublisher, error) {
+	broker := config.String("MQTT_BROKER_URL")
+	if broker == "" {
+		return nil, nil
+	}
+
+	clientID := config.String("MQTT_CLIENT_ID")
+	if clientID == "" {
+		hostname, _ := os.Hostname()
+		clientID = "weather-service-" + hostname
+	}
+
+	options := mqtt.NewClientOptions().
+		AddBroker(broker).
+		SetClientID(clientID).
+		SetUsername(config.String("MQTT_USERNAME")).
+		SetPassword(config.String("MQTT_PASSWORD")).
+		SetAutoReconnect(true).
+		SetConnectRetry(true).
+		SetOnConnectHandler(func(mqtt.Client) { log.Printf("Connected to MQTT broker %s", broker) }).
+		SetConnectionLostHandler(func(_ mqtt.Client, err error) {
+			log.Printf("WARNING: Lost connection to MQTT broker %s: %v", broker, err)
+		})
+	client := mqtt.NewClient(options)
+	client.Connect()
+
+	prefix := strings.TrimSuffix(config.String("MQTT_TOPIC_PREFIX"), "/")
+	if prefix == "" {
+		prefix = defaultMQTTTopicPrefix
+	}
+	return &mqttPublisher{
+		client: client,
+		prefix: prefix,
+		// 0, 1 ou 2, validado por config.Validate
+		qos:    byte(config.Int("MQTT_QOS", 0)),
+		retain: config.Bool("MQTT_RETAIN", false),
+	}, nil
+}
+
+func (p *mqttPublisher) topic(reading ReadingEvent) string {
+	return p.prefix + "/" + reading.UF + "/" + reading.CEP
+}
+
+func (p *mqttPublisher) publish(ctx context.Context, reading ReadingEvent) {
+	payload, err := json.Marshal(reading)
+	if err != nil {
+		log.Printf("ERROR: Failed to encode MQTT reading for CEP %s: %v", reading.CEP, err)
+		return
+	}
+
+	topic := p.topic(reading)
+	token := p.client.Publish(topic, p.qos, p.retain, payload)
+	go func() {
+		if !token.WaitTimeout(mqttPublishTimeout) {
+			log.Printf("WARNING: MQTT publish to %s timed out", topic)
+			return
+		}
+		if err := token.Error(); err != nil {
+			log.Printf("WARNING: Failed to publish MQTT reading to %s: %v", topic, err)
+		}
+	}()
+}
+
+// Espera até 1s pelas publicações em andamento antes de desconectar
+func (p *mqttPublisher) Close() error {
+	p.client.Disconnect(1000)
+	return nil
+}
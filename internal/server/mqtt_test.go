@@ -0,0 +1,112 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/stretchr/testify/assert"
+)
+
+// Token já concluído, com o erro informado
+type doneToken struct{ err error }
+
+func (t doneToken) Wait() bool                     { return true }
+func (t doneToken) WaitTimeout(time.Duration) bool { return true }
+func (t doneToken) Error() error                   { return t.err }
+func (t doneToken) Done() <-chan struct{} {
+	done := make(chan struct{})
+	close(done)
+	return done
+}
+
+type mqttMessage struct {
+	topic    string
+	qos      byte
+	retained bool
+	payload  []byte
+}
+
+// Broker em memória: guarda as publicações
+type fakeMQTTClient struct {
+	mu       sync.Mutex
+	messages []mqttMessage
+}
+
+func (c *fakeMQTTClient) Publish(topic string, qos byte, retained bool, payload interface{}) mqtt.Token {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.messages = append(c.messages, mqttMessage{topic, qos, retained, payload.([]byte)})
+	return doneToken{}
+}
+
+func (c *fakeMQTTClient) Disconnect(uint) {}
+
+func (c *fakeMQTTClient) published() []mqttMessage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]mqttMessage(nil), c.messages...)
+}
+
+// Troca os publicadores de s por um MQTT em memória
+func withFakeMQTT(s *Server) *fakeMQTTClient {
+	client := &fakeMQTTClient{}
+	s.publishers = []readingPublisher{&mqttPublisher{client: client, prefix: defaultMQTTTopicPrefix, qos: 1, retain: true}}
+	return client
+}
+
+// Cada consulta concluída, pela API ou fora dela, vira uma mensagem em weather/<uf>/<cep>
+func TestMQTTPublisher(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, fakeWeatherClient{"São Paulo,SP": 25})
+	client := withFakeMQTT(s)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	_, err := s.lookupWeather(context.Background(), "01310100", defaultUnits)
+	assert.NoError(t, err)
+
+	messages := client.published()
+	if !assert.Len(t, messages, 2) {
+		return
+	}
+	assert.Equal(t, "weather/SP/01310100", messages[0].topic)
+	assert.Equal(t, byte(1), messages[0].qos)
+	assert.True(t, messages[0].retained)
+
+	var reading ReadingEvent
+	assert.NoError(t, json.Unmarshal(messages[0].payload, &reading))
+	assert.Equal(t, "01310100", reading.CEP)
+	assert.Equal(t, "São Paulo", reading.City)
+	assert.Equal(t, "SP", reading.UF)
+	assert.Equal(t, 25.0, reading.TempC)
+	assert.Equal(t, 77.0, reading.TempF)
+	assert.Equal(t, 298.15, reading.TempK)
+	assert.WithinDuration(t, time.Now(), reading.At, time.Minute)
+}
+
+// Erros não publicam nada
+func TestMQTTPublisher_NotFound(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{}, fakeWeatherClient{})
+	client := withFakeMQTT(s)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/99999999", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.Empty(t, client.published())
+}
+
+func TestMQTTPublisherFromEnv(t *testing.T) {
+	t.Setenv("MQTT_BROKER_URL", "")
+	publisher, err := mqttPublisherFromEnv()
+	assert.NoError(t, err)
+	assert.Nil(t, publisher)
+
+	publisher = &mqttPublisher{prefix: "casa/clima"}
+	assert.Equal(t, "casa/clima/RJ/20040002", publisher.topic(ReadingEvent{CEP: "20040002", UF: "RJ"}))
+}
@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/weather-service/config"
+)
+
+const (
+	defaultNATSSubject = "weather.by-cep"
+	// Grupo de fila: com várias réplicas, cada requisição é atendida por uma só
+	defaultNATSQueue = "weather-service"
+	// Consultas atendidas ao mesmo tempo; as demais esperam na fila do cliente NATS
+	natsMaxInFlight = 64
+	// Limite de cada consulta; quem pergunta costuma desistir antes disso
+	natsLookupTimeout = 30 * time.Second
+	// Status equivalente ao da API HTTP, para quem pergunta decidir sem ler o corpo
+	natsStatusHeader = "Weather-Status"
+)
+
+// Requisição em JSON; também é aceito só o CEP, em texto
+type natsRequest struct {
+	CEP   string `json:"cep"`
+	Units string `json:"units"`
+}
+
+// Conecta ao servidor de NATS_URL (aceita uma lista separada por vírgula).
+// Retorna nil quando NATS_URL não está definido. Como no MQTT, a conexão
+// não bloqueia a inicialização e é refeita sozinha depois de uma queda
+func natsConnFromEnv() (*nats.Conn, error) {
+	servers := config.String("NATS_URL")
+	if servers == "" {
+		return nil, nil
+	}
+
+	options := []nats.Option{
+		nats.Name("weather-service"),
+		nats.RetryOnFailedConnect(true),
+		nats.MaxReconnects(-1),
+		nats.ConnectHandler(func(*nats.Conn) { log.Println("Connected to NATS") }),
+		nats.DisconnectErrHandler(func(_ *nats.Conn, err error) {
+			if err != nil {
+				log.Printf("WARNING: Lost connection to NATS: %v", err)
+			}
+		}),
+		nats.ReconnectHandler(func(*nats.Conn) { log.Println("Reconnected to NATS") }),
+	}
+	if creds := config.String("NATS_CREDS_FILE"); creds != "" {
+		options = append(options, nats.UserCredentials(creds))
+	}
+	return nats.Connect(servers, options...)
+}
+
+// Responde as requisições do assunto NATS_SUBJECT (padrão weather.by-cep)
+// com o mesmo corpo de GET /weather/{cep}, até o contexto acabar
+func (s *Server) runNATSResponder(ctx context.Context, conn *nats.Conn) error {
+	subject := config.String("NATS_SUBJECT")
+	if subject == "" {
+		subject = defaultNATSSubject
+	}
+	queue := config.String("NATS_QUEUE")
+	if queue == "" {
+		queue = defaultNATSQueue
+	}
+
+	inFlight := make(chan struct{}, natsMaxInFlight)
+	subscription, err := conn.QueueSubscribe(subject, queue, func(msg *nats.Msg) {
+		inFlight <- struct{}{}
+		go func() {
+			defer func() { <-inFlight }()
+			s.respondNATS(ctx, msg)
+		}()
+	})
+	if err != nil {
+		return err
+	}
+	log.Printf("Answering NATS requests on %s (queue %s)", subject, queue)
+
+	<-ctx.Done()
+	return subscription.Drain()
+}
+
+func (s *Server) respondNATS(ctx context.Context, msg *nats.Msg) {
+	if msg.Reply == "" {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, natsLookupTimeout)
+	defer cancel()
+
+	status, body := s.natsReply(ctx, msg.Data)
+	data, err := json.Marshal(body)
+	if err != nil {
+		log.Printf("ERROR: Failed to encode NATS reply: %v", err)
+		return
+	}
+	reply := nats.NewMsg(msg.Reply)
+	reply.Data = data
+	reply.Header.Set(natsStatusHeader, strconv.Itoa(status))
+	if err := msg.RespondMsg(reply); err != nil {
+		log.Printf("ERROR: Failed to send NATS reply: %v", err)
+	}
+}
+
+// Status e corpo da resposta a uma requisição, com as mensagens de erro da API HTTP
+func (s *Server) natsReply(ctx context.Context, data []byte) (int, interface{}) {
+	request := natsRequest{CEP: string(data)}
+	if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "{") {
+		request = natsRequest{}
+		if err := json.Unmarshal(data, &request); err != nil {
+			return http.StatusBadRequest, ErrorResponse{Message: "invalid request body"}
+		}
+	}
+
+	units, err := parseUnits(request.Units)
+	if err != nil {
+		return http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid units"}
+	}
+
+	cep := strings.TrimSpace(request.CEP)
+	weather, err := s.lookupWeather(ctx, cep, units)
+	if err != nil {
+		if !errors.Is(err, ErrInvalidCEP) && !errors.Is(err, ErrCEPNotFound) {
+			log.Printf("ERROR: NATS lookup for CEP %s failed: %v", cep, err)
+		}
+		return httpError(err)
+	}
+	return http.StatusOK, weather
+}
@@ -0,0 +1,45 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNATSReply(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, fakeWeatherClient{"São Paulo,SP": 25})
+
+	tests := []struct {
+		name     string
+		data     string
+		status   int
+		expected string
+	}{
+		{"plain CEP", "01310-100", http.StatusOK, `{"temp_C":25,"temp_F":77,"temp_K":298.15}`},
+		{"JSON with units", `{"cep":"01310100","units":"c,k"}`, http.StatusOK, `{"temp_C":25,"temp_K":298.15}`},
+		{"invalid CEP", "123", http.StatusUnprocessableEntity, `{"message":"invalid zipcode"}`},
+		{"unknown CEP", `{"cep":"99999999"}`, http.StatusNotFound, `{"message":"can not find zipcode"}`},
+		{"invalid units", `{"cep":"01310100","units":"x"}`, http.StatusUnprocessableEntity, `{"message":"invalid units"}`},
+		{"malformed JSON", `{"cep":`, http.StatusBadRequest, `{"message":"invalid request body"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, body := s.natsReply(context.Background(), []byte(tt.data))
+			assert.Equal(t, tt.status, status)
+			data, err := json.Marshal(body)
+			assert.NoError(t, err)
+			assert.JSONEq(t, tt.expected, string(data))
+		})
+	}
+}
+
+func TestNATSConnFromEnv(t *testing.T) {
+	t.Setenv("NATS_URL", "")
+	conn, err := natsConnFromEnv()
+	assert.NoError(t, err)
+	assert.Nil(t, conn)
+}
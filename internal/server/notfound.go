@@ -0,0 +1,46 @@
+package server
+
+import (
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+const defaultCEPNotFoundTTL = time.Minute
+
+// Limite de CEPs inexistentes lembrados, para que uma varredura de CEPs
+// aleatórios não faça o cache crescer sem fim
+const maxNotFoundCEPs = 10000
+
+// Tempo em que um CEP inexistente dispensa a consulta aos provedores
+// (CEP_NOT_FOUND_TTL). 0 desliga o cache negativo
+func cepNotFoundTTL() time.Duration {
+	return config.Duration("CEP_NOT_FOUND_TTL", defaultCEPNotFoundTTL)
+}
+
+// O CEP foi dado como inexistente há menos de CEP_NOT_FOUND_TTL
+func (s *Server) knownNotFoundCEP(code string) bool {
+	ttl := cepNotFoundTTL()
+	if ttl <= 0 {
+		return false
+	}
+	if entry, ok := s.notFoundCEPs.Get(code); ok && s.notFoundCEPs.Fresh(entry, ttl) {
+		s.notFoundCEPs.Hit()
+		return true
+	}
+	s.notFoundCEPs.Miss()
+	return false
+}
+
+// Lembra que os provedores não conhecem o CEP. Cheio, o cache descarta os
+// vencidos e, se ainda não houver espaço, deixa o CEP de fora
+func (s *Server) rememberNotFoundCEP(code string) {
+	ttl := cepNotFoundTTL()
+	if ttl <= 0 {
+		return
+	}
+	if s.notFoundCEPs.Len() >= maxNotFoundCEPs && s.notFoundCEPs.Prune(ttl) == 0 {
+		return
+	}
+	s.notFoundCEPs.Set(code, nil)
+}
@@ -0,0 +1,61 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWeatherHandler_NotFoundCached(t *testing.T) {
+	client := &recordingCEPClient{fakeCEPClient: fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}}
+	s := newFakeClientsServer(t, client.fakeCEPClient, fakeWeatherClient{"São Paulo,SP": 25})
+	s.viaCEP = client
+
+	router := s.router()
+	for i := 0; i < 3; i++ {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/weather/99999-999", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+		assert.JSONEq(t, `{"message":"can not find zipcode"}`, rr.Body.String())
+	}
+	// Só a primeira requisição chega ao ViaCEP
+	assert.Equal(t, []string{"99999999"}, client.lookups)
+
+	// CEPs existentes não entram no cache negativo
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+	assert.Equal(t, []string{"99999999", "01310100", "01310100"}, client.lookups)
+	assert.Equal(t, 1, s.notFoundCEPs.Len())
+}
+
+func TestWeatherHandler_NotFoundCacheDisabled(t *testing.T) {
+	client := &recordingCEPClient{}
+	s := newFakeClientsServer(t, fakeCEPClient{}, fakeWeatherClient{})
+	s.viaCEP = client
+	t.Setenv("CEP_NOT_FOUND_TTL", "0")
+
+	router := s.router()
+	for i := 0; i < 2; i++ {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", "/weather/99999999", nil))
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	}
+	assert.Equal(t, []string{"99999999", "99999999"}, client.lookups)
+	assert.Equal(t, 0, s.notFoundCEPs.Len())
+}
+
+// Uma falha do provedor não é um CEP inexistente e não entra no cache
+func TestWeatherHandler_NotFoundCacheIgnoresFailures(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{}, fakeWeatherClient{})
+	s.viaCEP, s.brasilAPI = failingCEPClient{}, failingCEPClient{}
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+	assert.NotEqual(t, http.StatusNotFound, rr.Code)
+	assert.Equal(t, 0, s.notFoundCEPs.Len())
+}
@@ -0,0 +1,40 @@
+package server
+
+import (
+	"github.com/weather-service/config"
+	"github.com/weather-service/internal/cep"
+)
+
+// Origem informada em X-Location-Source e no campo source da localização
+// quando o endereço vem da tabela embutida
+const approximateSource = "approximate"
+
+// OFFLINE_CEP=true resolve os CEPs só pela tabela embutida, sem consultar o
+// ViaCEP, com precisão de cidade
+func offlineCEP() bool {
+	return config.Bool("OFFLINE_CEP", false)
+}
+
+// Com CEP_STRICT=true, um CEP fora das faixas das UFs é recusado sem
+// consultar os provedores
+func strictCEP() bool {
+	return config.Bool("CEP_STRICT", false)
+}
+
+// O CEP cai na faixa de alguma UF
+func allocatedCEP(code string) bool {
+	_, ok := cep.StateOf(code)
+	return ok
+}
+
+// Município do CEP (só dígitos) na tabela embutida
+func offlineAddress(code string) (*ViaCEPResponse, bool) {
+	address, ok := cep.Embedded().Lookup(code)
+	if !ok {
+		return nil, false
+	}
+	approximate := viaCEPResponse(address)
+	approximate.approximate = true
+	approximate.provider = locationProviderEmbedded
+	return approximate, true
+}
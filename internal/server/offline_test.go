@@ -0,0 +1,102 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/internal/cep"
+)
+
+// Com OFFLINE_CEP=true o ViaCEP não é consultado
+func TestWeatherHandler_OfflineCEP(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/v1/current.json": `{"current":{"temp_c":25}}`,
+	})
+	t.Setenv("OFFLINE_CEP", "true")
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310-100?include=location", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response WeatherResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Equal(t, 25.0, *response.TempC)
+	// Só a cidade: a tabela não tem bairro nem logradouro
+	assert.Equal(t, &Address{Cep: "01310-100", Localidade: "São Paulo", UF: "SP", Source: "approximate"}, response.Location)
+
+	rr = httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/06500000", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.JSONEq(t, `{"message":"can not find zipcode"}`, rr.Body.String())
+}
+
+// Com o ViaCEP fora do ar, a cidade vem da tabela embutida e a resposta avisa
+func TestWeatherHandler_CEPFallback(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/v1/current.json": `{"current":{"temp_c":25}}`,
+	})
+	s.viaCEP = cep.NewViaCEP("http://127.0.0.1:1", nil)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/20040002?include=location", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "approximate", rr.Header().Get("X-Location-Source"))
+	var response WeatherResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Equal(t, &Address{Cep: "20040-002", Localidade: "Rio de Janeiro", UF: "RJ", Source: "approximate"}, response.Location)
+
+	// Fora da tabela, a falha do ViaCEP continua sendo um 500
+	rr = httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/06500000", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	t.Setenv("CEP_FALLBACK", "false")
+	rr = httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/20040002", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+}
+
+// Um 5xx do ViaCEP é indisponibilidade, não CEP inexistente
+func TestWeatherHandler_CEPFallbackOnServerError(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/v1/current.json": `{"current":{"temp_c":25}}`,
+	})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer server.Close()
+	s.viaCEP = cep.NewViaCEP(server.URL+"/ws", nil)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "approximate", rr.Header().Get("X-Location-Source"))
+}
+
+// Com CEP_STRICT=true, um CEP fora das faixas das UFs nem chega ao provedor
+func TestWeatherHandler_StrictCEP(t *testing.T) {
+	client := &recordingCEPClient{fakeCEPClient: fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}}
+	s := newFakeClientsServer(t, client.fakeCEPClient, fakeWeatherClient{"São Paulo,SP": 25})
+	s.viaCEP = client
+	t.Setenv("CEP_STRICT", "true")
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/00123456", nil))
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.JSONEq(t, `{"message":"cep prefix not allocated"}`, rr.Body.String())
+	assert.Empty(t, client.lookups)
+
+	rr = httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, []string{"01310100"}, client.lookups)
+
+	// Sem o modo estrito, o provedor decide
+	t.Setenv("CEP_STRICT", "false")
+	rr = httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/00123456", nil))
+	assert.JSONEq(t, `{"message":"can not find zipcode"}`, rr.Body.String())
+	assert.Equal(t, []string{"01310100", "00123456"}, client.lookups)
+}
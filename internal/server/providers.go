@@ -0,0 +1,181 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+const (
+	defaultProviderStatsWindow = 5 * time.Minute
+	maxProviderCallSamples     = 1000
+)
+
+// Provedor externo consultado pelo serviço, com seu circuit breaker e as
+// estatísticas das chamadas recentes
+type provider struct {
+	name    string
+	kind    string
+	breaker *circuitBreaker
+	stats   *callStats
+}
+
+var (
+	viaCEPProvider     = newProvider("viacep", "cep")
+	brasilAPIProvider  = newProvider("brasilapi", "cep")
+	weatherAPIProvider = newProvider("weather_api", "weather")
+	providers          = []*provider{viaCEPProvider, brasilAPIProvider, weatherAPIProvider}
+)
+
+// Provedores consultados com a configuração atual; a BrasilAPI só entra com
+// CEP_HEDGE_DELAY definido
+func (s *Server) activeProviders() []*provider {
+	return append(s.cepProviders(), weatherAPIProvider)
+}
+
+func newProvider(name, kind string) *provider {
+	return &provider{name: name, kind: kind, breaker: newCircuitBreaker(name), stats: newCallStats()}
+}
+
+// Registra o resultado de uma chamada. Só erros de rede e 5xx contam como
+// falha; respostas 4xx mostram que o provedor está funcionando
+func (p *provider) record(ok bool, latency time.Duration) {
+	p.breaker.record(ok)
+	p.stats.add(ok, latency)
+}
+
+// Como record, mas ignora chamadas canceladas por quem as fez, como a
+// consulta que perdeu a corrida entre provedores: elas não dizem nada sobre
+// o provedor
+func (p *provider) recordCall(ctx context.Context, ok bool, latency time.Duration) {
+	if !ok && errors.Is(ctx.Err(), context.Canceled) {
+		p.abandon()
+		return
+	}
+	p.record(ok, latency)
+}
+
+// Desiste de uma chamada permitida pelo circuit breaker sem registrar resultado
+func (p *provider) abandon() {
+	p.breaker.abandon()
+}
+
+type callSample struct {
+	at      time.Time
+	ok      bool
+	latency time.Duration
+}
+
+// Chamadas feitas dentro da janela PROVIDER_STATS_WINDOW, limitadas às
+// maxProviderCallSamples mais recentes
+type callStats struct {
+	mu      sync.Mutex
+	samples []callSample
+	now     func() time.Time
+}
+
+func newCallStats() *callStats {
+	return &callStats{now: time.Now}
+}
+
+func (s *callStats) add(ok bool, latency time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.samples = append(s.samples, callSample{at: s.now(), ok: ok, latency: latency})
+	s.pruneLocked()
+}
+
+func (s *callStats) pruneLocked() {
+	cutoff := s.now().Add(-config.Duration("PROVIDER_STATS_WINDOW", defaultProviderStatsWindow))
+	start := len(s.samples) - maxProviderCallSamples
+	if start < 0 {
+		start = 0
+	}
+	for start < len(s.samples) && s.samples[start].at.Before(cutoff) {
+		start++
+	}
+	if start > 0 {
+		s.samples = append([]callSample(nil), s.samples[start:]...)
+	}
+}
+
+// Quantidade de chamadas, fração de falhas e latência média na janela
+func (s *callStats) summary() (int, float64, time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruneLocked()
+	if len(s.samples) == 0 {
+		return 0, 0, 0
+	}
+
+	var failures int
+	var total time.Duration
+	for _, sample := range s.samples {
+		if !sample.ok {
+			failures++
+		}
+		total += sample.latency
+	}
+	calls := len(s.samples)
+	return calls, float64(failures) / float64(calls), total / time.Duration(calls)
+}
+
+func (s *callStats) reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.samples = nil
+}
+
+type StatusResponse struct {
+	Providers []ProviderStatus `json:"providers" xml:"provider"`
+}
+
+type ProviderStatus struct {
+	Name               string  `json:"name" xml:"name"`
+	Kind               string  `json:"kind" xml:"kind"`
+	Status             string  `json:"status" xml:"status"`
+	Calls              int     `json:"calls" xml:"calls"`
+	ErrorRate          float64 `json:"error_rate" xml:"error_rate"`
+	AvgLatencyMS       float64 `json:"avg_latency_ms" xml:"avg_latency_ms"`
+	CircuitBreakerOpen bool    `json:"circuit_breaker_open" xml:"circuit_breaker_open"`
+}
+
+// "down" com o circuit breaker aberto ou meio aberto, "degraded" quando ao
+// menos metade das chamadas recentes falhou e "ok" nos demais casos
+func (p *provider) status() ProviderStatus {
+	calls, errorRate, latency := p.stats.summary()
+	state, _ := p.breaker.state()
+
+	status := "ok"
+	switch {
+	case state != breakerClosed:
+		status = "down"
+	case errorRate >= 0.5:
+		status = "degraded"
+	}
+
+	return ProviderStatus{
+		Name:               p.name,
+		Kind:               p.kind,
+		Status:             status,
+		Calls:              calls,
+		ErrorRate:          errorRate,
+		AvgLatencyMS:       float64(latency.Microseconds()) / 1000,
+		CircuitBreakerOpen: state != breakerClosed,
+	}
+}
+
+// GET /status: situação de cada provedor de CEP e de clima
+func (s *Server) statusHandler(w http.ResponseWriter, r *http.Request) {
+	var response StatusResponse
+	for _, p := range s.activeProviders() {
+		response.Providers = append(response.Providers, p.status())
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
@@ -0,0 +1,93 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCallStats_Window(t *testing.T) {
+	t.Setenv("PROVIDER_STATS_WINDOW", "1m")
+	now := time.Unix(1760000000, 0)
+	stats := newCallStats()
+	stats.now = func() time.Time { return now }
+
+	stats.add(false, 300*time.Millisecond)
+	now = now.Add(30 * time.Second)
+	stats.add(true, 100*time.Millisecond)
+	stats.add(true, 200*time.Millisecond)
+	stats.add(false, 400*time.Millisecond)
+
+	calls, errorRate, latency := stats.summary()
+	assert.Equal(t, 4, calls)
+	assert.Equal(t, 0.5, errorRate)
+	assert.Equal(t, 250*time.Millisecond, latency)
+
+	// A primeira chamada sai da janela
+	now = now.Add(45 * time.Second)
+	calls, errorRate, latency = stats.summary()
+	assert.Equal(t, 3, calls)
+	assert.InDelta(t, 1.0/3, errorRate, 1e-9)
+	assert.Equal(t, 700*time.Millisecond/3, latency)
+
+	now = now.Add(time.Minute)
+	calls, _, _ = stats.summary()
+	assert.Zero(t, calls)
+}
+
+func TestCallStats_KeepsMostRecentSamples(t *testing.T) {
+	stats := newCallStats()
+	for i := 0; i < maxProviderCallSamples+10; i++ {
+		stats.add(i >= 10, time.Millisecond)
+	}
+
+	calls, errorRate, _ := stats.summary()
+	assert.Equal(t, maxProviderCallSamples, calls)
+	assert.Zero(t, errorRate)
+}
+
+func TestStatusHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("CIRCUIT_BREAKER_THRESHOLD", "2")
+
+	viaCEPProvider.record(true, 40*time.Millisecond)
+	viaCEPProvider.record(false, 20*time.Millisecond)
+	weatherAPIProvider.record(false, 10*time.Millisecond)
+	weatherAPIProvider.record(false, 30*time.Millisecond)
+
+	req, err := http.NewRequest("GET", "/status", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response StatusResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Equal(t, []ProviderStatus{
+		{Name: "viacep", Kind: "cep", Status: "degraded", Calls: 2, ErrorRate: 0.5, AvgLatencyMS: 30},
+		{Name: "weather_api", Kind: "weather", Status: "down", Calls: 2, ErrorRate: 1, AvgLatencyMS: 20, CircuitBreakerOpen: true},
+	}, response.Providers)
+}
+
+// As chamadas reais aos provedores entram nas estatísticas
+func TestStatusHandler_RecordsLookups(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+
+	router := s.router()
+	req, err := http.NewRequest("GET", "/weather/01310100", nil)
+	assert.NoError(t, err)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	for _, p := range s.activeProviders() {
+		status := p.status()
+		assert.Equal(t, "ok", status.Status, p.name)
+		assert.Equal(t, 1, status.Calls, p.name)
+	}
+}
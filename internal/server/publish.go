@@ -0,0 +1,144 @@
+package server
+
+import (
+	"context"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weather-service/internal/weather"
+)
+
+// Leitura CEP → temperatura concluída, entregue aos publicadores configurados
+type ReadingEvent struct {
+	CEP   string  `json:"cep"`
+	City  string  `json:"city"`
+	UF    string  `json:"uf"`
+	TempC float64 `json:"temp_C"`
+	TempF float64 `json:"temp_F"`
+	TempK float64 `json:"temp_K"`
+	// weather_api ou cache
+	Source    string    `json:"source"`
+	LatencyMs float64   `json:"latency_ms"`
+	At        time.Time `json:"at"`
+}
+
+// Início e origem da temperatura de uma consulta, anotados no contexto para
+// acompanhar a leitura publicada
+type readingTrace struct {
+	started time.Time
+
+	mu     sync.Mutex
+	source string
+	// Alguma temperatura veio de uma resposta vencida do cache
+	stale bool
+}
+
+type readingTraceKey struct{}
+
+// Marca o início de uma consulta CEP → temperatura
+func startReading(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readingTraceKey{}, &readingTrace{started: time.Now()})
+}
+
+// Anota se a temperatura veio do cache. Como em noteUsageCache, basta uma ida
+// à WeatherAPI para a leitura contar como dela
+func noteReadingSource(ctx context.Context, cached bool) {
+	trace, _ := ctx.Value(readingTraceKey{}).(*readingTrace)
+	if trace == nil {
+		return
+	}
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	if !cached {
+		trace.source = weatherAPIProvider.name
+	} else if trace.source == "" {
+		trace.source = readingSourceCache
+	}
+}
+
+const readingSourceCache = "cache"
+
+// Destino das leituras (broker MQTT, Kafka, Pub/Sub...). publish não pode bloquear a
+// requisição: a entrega, e o log de uma falha, ficam com o publicador
+type readingPublisher interface {
+	publish(ctx context.Context, reading ReadingEvent)
+	Close() error
+}
+
+// Entrega a leitura a todos os publicadores, sem afetar a resposta
+func (s *Server) publishReading(ctx context.Context, address *ViaCEPResponse, tempC float64) {
+	if len(s.publishers) == 0 {
+		return
+	}
+
+	reading := ReadingEvent{
+		CEP:   strings.ReplaceAll(address.Cep, "-", ""),
+		City:  address.Localidade,
+		UF:    address.UF,
+		TempC: tempC,
+		TempF: weather.CelsiusToFahrenheit(tempC),
+		TempK: weather.CelsiusToKelvin(tempC),
+		At:    time.Now().UTC(),
+	}
+	if trace, _ := ctx.Value(readingTraceKey{}).(*readingTrace); trace != nil {
+		trace.mu.Lock()
+		reading.Source = trace.source
+		trace.mu.Unlock()
+		reading.LatencyMs = float64(time.Since(trace.started).Microseconds()) / 1000
+	}
+	for _, publisher := range s.publishers {
+		publisher.publish(ctx, reading)
+	}
+}
+
+// Publicador que também recebe os disparos das regras de alerta
+type alertPublisher interface {
+	publishAlert(ctx context.Context, event WebhookEvent)
+}
+
+// Entrega o disparo aos publicadores que aceitam alertas
+func (s *Server) publishAlert(ctx context.Context, event WebhookEvent) {
+	for _, publisher := range s.publishers {
+		if alerts, ok := publisher.(alertPublisher); ok {
+			alerts.publishAlert(ctx, event)
+		}
+	}
+}
+
+// Abre os publicadores configurados no ambiente
+func readingPublishersFromEnv() ([]readingPublisher, error) {
+	var publishers []readingPublisher
+	mqtt, err := mqttPublisherFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if mqtt != nil {
+		publishers = append(publishers, mqtt)
+	}
+	kafka, err := kafkaPublisherFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if kafka != nil {
+		publishers = append(publishers, kafka)
+	}
+	pubsub, err := pubsubPublisherFromEnv()
+	if err != nil {
+		return nil, err
+	}
+	if pubsub != nil {
+		publishers = append(publishers, pubsub)
+	}
+	return publishers, nil
+}
+
+func (s *Server) closeReadingPublishers() {
+	for _, publisher := range s.publishers {
+		if err := publisher.Close(); err != nil {
+			log.Printf("WARNING: Failed to close reading publisher: %v", err)
+		}
+	}
+}
@@ -0,0 +1,238 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+const (
+	defaultPubSubEndpoint = "https://pubsub.googleapis.com/v1"
+	// Mensagens à espera do envio; com a fila cheia, as novas são descartadas
+	pubsubQueueSize = 1000
+	// Mensagens por chamada a topics.publish (o limite da API é 1000)
+	pubsubBatchSize = 100
+	pubsubTimeout   = 10 * time.Second
+	// Os tokens do servidor de metadados valem por cerca de uma hora; um novo
+	// é pedido bem antes disso, e também quando o Pub/Sub recusa o atual
+	pubsubTokenTTL = 5 * time.Minute
+	// Um lote recusado por falha temporária é reenviado até
+	// pubsubMaxAttempts vezes, com espera dobrando a cada tentativa
+	pubsubMaxAttempts       = 5
+	defaultPubSubRetryDelay = time.Second
+)
+
+// Mensagem de topics.publish (https://cloud.google.com/pubsub/docs/reference/rest/v1/PubsubMessage)
+type pubsubMessage struct {
+	Data        string            `json:"data"`
+	Attributes  map[string]string `json:"attributes,omitempty"`
+	OrderingKey string            `json:"orderingKey,omitempty"`
+}
+
+// Publica as consultas e os disparos das regras de alerta em um tópico do
+// Pub/Sub, pela API REST e com a conta de serviço do Cloud Run, como o
+// Secret Manager. O atributo event diz o tipo (lookup ou alert) e a chave de
+// ordenação é o CEP, para que assinaturas com ordenação recebam os eventos
+// de cada CEP na ordem em que aconteceram
+type pubsubPublisher struct {
+	topic    string
+	endpoint string
+	client   *http.Client
+	done     chan struct{}
+	// Fechado no Close: as esperas entre tentativas são interrompidas
+	quit       chan struct{}
+	retryDelay time.Duration
+
+	mu       sync.Mutex
+	messages chan pubsubMessage
+	closed   bool
+
+	// Usados só pela goroutine de envio
+	token   string
+	tokenAt time.Time
+}
+
+// Retorna nil quando PUBSUB_TOPIC (projects/P/topics/T) não está definido
+func pubsubPublisherFromEnv() (*pubsubPublisher, error) {
+	topic := config.String("PUBSUB_TOPIC")
+	if topic == "" {
+		return nil, nil
+	}
+	endpoint := strings.TrimSuffix(config.String("PUBSUB_ENDPOINT"), "/")
+	if endpoint == "" {
+		endpoint = defaultPubSubEndpoint
+	}
+
+	log.Printf("Publishing lookups and alerts to Pub/Sub topic %s", topic)
+	return newPubSubPublisher(topic, endpoint), nil
+}
+
+func newPubSubPublisher(topic, endpoint string) *pubsubPublisher {
+	p := &pubsubPublisher{
+		topic:      topic,
+		endpoint:   endpoint,
+		client:     &http.Client{Timeout: pubsubTimeout},
+		done:       make(chan struct{}),
+		quit:       make(chan struct{}),
+		retryDelay: defaultPubSubRetryDelay,
+		messages:   make(chan pubsubMessage, pubsubQueueSize),
+	}
+	go p.run()
+	return p
+}
+
+func (p *pubsubPublisher) publish(ctx context.Context, reading ReadingEvent) {
+	p.enqueue("lookup", reading.CEP, newLookupEvent(reading), nil)
+}
+
+func (p *pubsubPublisher) publishAlert(ctx context.Context, event WebhookEvent) {
+	p.enqueue("alert", event.CEP, event, map[string]string{"subscription_id": event.SubscriptionID})
+}
+
+func (p *pubsubPublisher) enqueue(kind, cep string, event interface{}, attributes map[string]string) {
+	data, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("ERROR: Failed to encode Pub/Sub %s event for CEP %s: %v", kind, cep, err)
+		return
+	}
+	message := pubsubMessage{
+		Data:        base64.StdEncoding.EncodeToString(data),
+		Attributes:  map[string]string{"event": kind, "cep": cep},
+		OrderingKey: cep,
+	}
+	for name, value := range attributes {
+		message.Attributes[name] = value
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	select {
+	case p.messages <- message:
+	default:
+		log.Printf("WARNING: Pub/Sub queue full, dropping %s event for CEP %s", kind, cep)
+	}
+}
+
+// Envia as mensagens da fila em lotes, na ordem em que chegaram. Um lote só
+// é abandonado depois de esgotar as tentativas, e o seguinte não sai antes
+// disso, para não inverter a ordem dos eventos de um CEP
+func (p *pubsubPublisher) run() {
+	defer close(p.done)
+	for message := range p.messages {
+		batch := []pubsubMessage{message}
+	drain:
+		for len(batch) < pubsubBatchSize {
+			select {
+			case message, ok := <-p.messages:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, message)
+			default:
+				break drain
+			}
+		}
+
+		if err := p.sendWithRetry(batch); err != nil {
+			log.Printf("ERROR: Dropping %d events after failing to publish to Pub/Sub topic %s: %v", len(batch), p.topic, err)
+		}
+	}
+}
+
+func (p *pubsubPublisher) sendWithRetry(batch []pubsubMessage) error {
+	delay := p.retryDelay
+	for attempt := 1; ; attempt++ {
+		status, err := p.send(batch)
+		if err == nil || !retryablePubSubStatus(status) || attempt == pubsubMaxAttempts {
+			return err
+		}
+		log.Printf("WARNING: Failed to publish %d events to Pub/Sub topic %s (attempt %d of %d): %v", len(batch), p.topic, attempt, pubsubMaxAttempts, err)
+
+		select {
+		case <-p.quit:
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// Falhas de rede (status 0), limite de cota e erros do servidor passam com o
+// tempo; um 400 se repetiria em todas as tentativas
+func retryablePubSubStatus(status int) bool {
+	switch {
+	case status == 0, status == http.StatusRequestTimeout, status == http.StatusTooManyRequests:
+		return true
+	default:
+		return status >= 500
+	}
+}
+
+func (p *pubsubPublisher) send(batch []pubsubMessage) (int, error) {
+	body, err := json.Marshal(map[string][]pubsubMessage{"messages": batch})
+	if err != nil {
+		return http.StatusBadRequest, err
+	}
+
+	status, err := p.post(body)
+	if status == http.StatusUnauthorized {
+		p.token = ""
+		status, err = p.post(body)
+	}
+	return status, err
+}
+
+func (p *pubsubPublisher) post(body []byte) (int, error) {
+	if p.token == "" || time.Since(p.tokenAt) > pubsubTokenTTL {
+		token, err := gcpAccessToken()
+		if err != nil {
+			return 0, err
+		}
+		p.token, p.tokenAt = token, time.Now()
+	}
+
+	req, err := http.NewRequest("POST", p.endpoint+"/"+p.topic+":publish", bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Authorization", "Bearer "+p.token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("status %d: %s", resp.StatusCode, bytes.TrimSpace(message))
+	}
+	return resp.StatusCode, nil
+}
+
+// Envia o que ainda está na fila, com uma tentativa por lote
+func (p *pubsubPublisher) Close() error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.quit)
+		close(p.messages)
+	}
+	p.mu.Unlock()
+
+	<-p.done
+	return nil
+}
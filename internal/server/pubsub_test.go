@@ -0,0 +1,170 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// topics.publish fake: guarda as mensagens recebidas. unauthorized requisições
+// são recusadas com 401 e failures com 503 antes de aceitar
+type fakePubSub struct {
+	*httptest.Server
+	mu           sync.Mutex
+	messages     []pubsubMessage
+	unauthorized int
+	failures     int
+	requests     int
+}
+
+func newFakePubSub(t *testing.T) *fakePubSub {
+	t.Setenv("GCP_ACCESS_TOKEN", "local-token")
+	pubsub := &fakePubSub{}
+	pubsub.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pubsub.mu.Lock()
+		defer pubsub.mu.Unlock()
+		assert.Equal(t, "POST", r.Method)
+		assert.Equal(t, "/v1/projects/labs/topics/weather:publish", r.URL.Path)
+		assert.Equal(t, "Bearer local-token", r.Header.Get("Authorization"))
+		pubsub.requests++
+		if pubsub.failures > 0 {
+			pubsub.failures--
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		if pubsub.unauthorized > 0 {
+			pubsub.unauthorized--
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var body struct {
+			Messages []pubsubMessage `json:"messages"`
+		}
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		pubsub.messages = append(pubsub.messages, body.Messages...)
+		w.Write([]byte(`{"messageIds":["1"]}`))
+	}))
+	t.Cleanup(pubsub.Close)
+	return pubsub
+}
+
+// Troca os publicadores de s por um Pub/Sub apontado para o fake
+func withFakePubSubPublisher(s *Server, pubsub *fakePubSub) *pubsubPublisher {
+	publisher := newPubSubPublisher("projects/labs/topics/weather", pubsub.URL+"/v1")
+	s.publishers = []readingPublisher{publisher}
+	return publisher
+}
+
+func decodePubSubData(t *testing.T, message pubsubMessage, out interface{}) {
+	t.Helper()
+	data, err := base64.StdEncoding.DecodeString(message.Data)
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, out))
+}
+
+func TestPubSubPublisher_Lookups(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, fakeWeatherClient{"São Paulo,SP": 25})
+	pubsub := newFakePubSub(t)
+	pubsub.unauthorized = 1
+	publisher := withFakePubSubPublisher(s, pubsub)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310-100", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	require.NoError(t, publisher.Close())
+
+	// A primeira tentativa foi recusada; o envio é repetido com um novo token
+	require.Len(t, pubsub.messages, 1)
+	message := pubsub.messages[0]
+	assert.Equal(t, "01310100", message.OrderingKey)
+	assert.Equal(t, map[string]string{"event": "lookup", "cep": "01310100"}, message.Attributes)
+
+	var event lookupEvent
+	decodePubSubData(t, message, &event)
+	assert.Equal(t, "01310100", event.CEP)
+	assert.Equal(t, lookupLocation{City: "São Paulo", UF: "SP"}, event.Location)
+	assert.Equal(t, 25.0, event.Temperature.TempC)
+	assert.Equal(t, "weather_api", event.Source)
+}
+
+// O disparo de uma regra vira um evento alert, depois da consulta que o causou
+func TestPubSubPublisher_Alerts(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, fakeWeatherClient{"São Paulo,SP": 36})
+	receiver := newWebhookReceiver(t)
+	pubsub := newFakePubSub(t)
+	publisher := withFakePubSubPublisher(s, pubsub)
+
+	ctx := context.Background()
+	require.NoError(t, s.subscriptions.create(ctx, Subscription{ID: "hot", CEP: "01310100", Metric: "temp_C", Operator: ">", Threshold: 35, CallbackURL: receiver.URL}))
+	s.pollSubscriptions(ctx)
+	require.NoError(t, publisher.Close())
+
+	require.Len(t, pubsub.messages, 2)
+	assert.Equal(t, "lookup", pubsub.messages[0].Attributes["event"])
+	message := pubsub.messages[1]
+	assert.Equal(t, "01310100", message.OrderingKey)
+	assert.Equal(t, map[string]string{"event": "alert", "cep": "01310100", "subscription_id": "hot"}, message.Attributes)
+
+	var event WebhookEvent
+	decodePubSubData(t, message, &event)
+	assert.Equal(t, "hot", event.SubscriptionID)
+	assert.Equal(t, 36.0, event.Value)
+	assert.WithinDuration(t, time.Now(), event.FiredAt, time.Minute)
+}
+
+// Um lote recusado com 503 é reenviado antes dos seguintes, mantendo a ordem
+func TestPubSubPublisher_Retry(t *testing.T) {
+	pubsub := newFakePubSub(t)
+	pubsub.failures = 2
+	publisher := newPubSubPublisher("projects/labs/topics/weather", pubsub.URL+"/v1")
+	publisher.retryDelay = time.Millisecond
+
+	ctx := context.Background()
+	publisher.publish(ctx, ReadingEvent{CEP: "01310100"})
+	publisher.publish(ctx, ReadingEvent{CEP: "20040020"})
+	require.Eventually(t, func() bool {
+		pubsub.mu.Lock()
+		defer pubsub.mu.Unlock()
+		return len(pubsub.messages) == 2
+	}, time.Second, 5*time.Millisecond)
+	require.NoError(t, publisher.Close())
+
+	assert.Equal(t, "01310100", pubsub.messages[0].OrderingKey)
+	assert.Equal(t, "20040020", pubsub.messages[1].OrderingKey)
+}
+
+// Erros permanentes não são repetidos, e as tentativas têm limite
+func TestPubSubPublisher_GivesUp(t *testing.T) {
+	pubsub := newFakePubSub(t)
+	pubsub.failures = 100
+	publisher := newPubSubPublisher("projects/labs/topics/weather", pubsub.URL+"/v1")
+	publisher.retryDelay = time.Millisecond
+
+	_, err := publisher.send([]pubsubMessage{{Data: "e30=", OrderingKey: "01310100"}})
+	assert.Error(t, err)
+	assert.Error(t, publisher.sendWithRetry([]pubsubMessage{{Data: "e30=", OrderingKey: "01310100"}}))
+	require.NoError(t, publisher.Close())
+	assert.Equal(t, 1+pubsubMaxAttempts, pubsub.requests)
+
+	assert.True(t, retryablePubSubStatus(0))
+	assert.True(t, retryablePubSubStatus(http.StatusTooManyRequests))
+	assert.True(t, retryablePubSubStatus(http.StatusBadGateway))
+	assert.False(t, retryablePubSubStatus(http.StatusBadRequest))
+	assert.False(t, retryablePubSubStatus(http.StatusForbidden))
+}
+
+func TestPubSubPublisherFromEnv(t *testing.T) {
+	t.Setenv("PUBSUB_TOPIC", "")
+	publisher, err := pubsubPublisherFromEnv()
+	assert.NoError(t, err)
+	assert.Nil(t, publisher)
+}
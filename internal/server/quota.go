@@ -0,0 +1,195 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+// Retornado quando a cota configurada para a WeatherAPI acabou e não há
+// resposta em cache para a chamada
+var errQuotaExhausted = errors.New("weather API quota exhausted")
+
+// Contagem de chamadas à WeatherAPI no dia e no mês correntes (UTC),
+// comparada com os orçamentos configurados. Orçamento 0 é ilimitado
+var weatherAPIQuota = configuredQuotaBudget()
+
+type quotaBudget struct {
+	// Orçamentos diário e mensal, consultados a cada chamada
+	budgets func() (daily, monthly int)
+	now     func() time.Time
+
+	mu         sync.Mutex
+	day        string
+	dayCount   int
+	month      string
+	monthCount int
+}
+
+type QuotaStatus struct {
+	Daily   QuotaUsage `json:"daily" xml:"daily"`
+	Monthly QuotaUsage `json:"monthly" xml:"monthly"`
+}
+
+// Remaining é omitido quando não há orçamento configurado
+type QuotaUsage struct {
+	Period    string `json:"period" xml:"period"`
+	Budget    int    `json:"budget" xml:"budget"`
+	Used      int    `json:"used" xml:"used"`
+	Remaining *int   `json:"remaining,omitempty" xml:"remaining,omitempty"`
+}
+
+// Orçamentos fixos, como os de um tenant
+func newQuotaBudget(daily, monthly int) *quotaBudget {
+	return &quotaBudget{
+		budgets: func() (int, int) { return daily, monthly },
+		now:     time.Now,
+	}
+}
+
+// Orçamentos em WEATHER_API_DAILY_BUDGET e WEATHER_API_MONTHLY_BUDGET,
+// relidos a cada chamada: valem os definidos por flag, no arquivo de
+// configuração ou em um reload, que só são aplicados depois que as
+// variáveis globais do pacote já foram criadas
+func configuredQuotaBudget() *quotaBudget {
+	return &quotaBudget{
+		budgets: func() (int, int) {
+			return config.Int("WEATHER_API_DAILY_BUDGET", 0), config.Int("WEATHER_API_MONTHLY_BUDGET", 0)
+		},
+		now: time.Now,
+	}
+}
+
+// Orçamentos de cada subject de JWT, em JWT_SUBJECT_DAILY_BUDGET e
+// JWT_SUBJECT_MONTHLY_BUDGET, para que um único usuário não esgote a cota
+// do tenant ou a global. Os contadores são criados no primeiro uso
+var subjectQuotas = &subjectQuotaRegistry{budgets: make(map[string]*quotaBudget)}
+
+type subjectQuotaRegistry struct {
+	mu      sync.Mutex
+	budgets map[string]*quotaBudget
+}
+
+func newSubjectQuotaBudget() *quotaBudget {
+	return &quotaBudget{
+		budgets: func() (int, int) {
+			return config.Int("JWT_SUBJECT_DAILY_BUDGET", 0), config.Int("JWT_SUBJECT_MONTHLY_BUDGET", 0)
+		},
+		now: time.Now,
+	}
+}
+
+// Orçamento do subject, criado se ainda não existir
+func (s *subjectQuotaRegistry) get(subject string) *quotaBudget {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	quota, ok := s.budgets[subject]
+	if !ok {
+		quota = newSubjectQuotaBudget()
+		s.budgets[subject] = quota
+	}
+	return quota
+}
+
+// Consumo do subject; um subject sem chamadas não é registrado
+func (s *subjectQuotaRegistry) status(subject string) QuotaStatus {
+	s.mu.Lock()
+	quota, ok := s.budgets[subject]
+	s.mu.Unlock()
+
+	if !ok {
+		quota = newSubjectQuotaBudget()
+	}
+	return quota.status()
+}
+
+// Orçamento do subject do JWT da requisição, ou nil sem JWT
+func subjectQuota(ctx context.Context) *quotaBudget {
+	subject := requestSubject(ctx)
+	if subject == "" {
+		return nil
+	}
+	return subjectQuotas.get(subject)
+}
+
+// Registra a chamada em todos os orçamentos ou, se algum tiver acabado, em
+// nenhum. Orçamentos nil são ignorados
+func reserveQuotas(quotas ...*quotaBudget) bool {
+	for i, quota := range quotas {
+		if quota == nil || quota.reserve() {
+			continue
+		}
+		for _, reserved := range quotas[:i] {
+			if reserved != nil {
+				reserved.release()
+			}
+		}
+		return false
+	}
+	return true
+}
+
+// Reinicia os contadores na virada do dia ou do mês
+func (q *quotaBudget) rollover() {
+	now := q.now().UTC()
+	if day := now.Format("2006-01-02"); day != q.day {
+		q.day, q.dayCount = day, 0
+	}
+	if month := now.Format("2006-01"); month != q.month {
+		q.month, q.monthCount = month, 0
+	}
+}
+
+// Registra uma chamada se ainda houver orçamento; caso contrário retorna false
+func (q *quotaBudget) reserve() bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	daily, monthly := q.budgets()
+	if (daily > 0 && q.dayCount >= daily) || (monthly > 0 && q.monthCount >= monthly) {
+		return false
+	}
+	q.dayCount++
+	q.monthCount++
+	return true
+}
+
+// Desfaz um reserve cuja chamada não foi feita
+func (q *quotaBudget) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	if q.dayCount > 0 {
+		q.dayCount--
+	}
+	if q.monthCount > 0 {
+		q.monthCount--
+	}
+}
+
+func (q *quotaBudget) status() QuotaStatus {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.rollover()
+	daily, monthly := q.budgets()
+	return QuotaStatus{
+		Daily:   newQuotaUsage(q.day, daily, q.dayCount),
+		Monthly: newQuotaUsage(q.month, monthly, q.monthCount),
+	}
+}
+
+func newQuotaUsage(period string, budget, used int) QuotaUsage {
+	usage := QuotaUsage{Period: period, Budget: budget, Used: used}
+	if budget > 0 {
+		remaining := budget - used
+		usage.Remaining = &remaining
+	}
+	return usage
+}
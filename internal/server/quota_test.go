@@ -0,0 +1,295 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/config"
+	"github.com/weather-service/internal/cache"
+)
+
+// Substitui a cota global durante o teste
+func withQuota(t *testing.T, quota *quotaBudget) {
+	old := weatherAPIQuota
+	weatherAPIQuota = quota
+	t.Cleanup(func() { weatherAPIQuota = old })
+}
+
+// Descarta os orçamentos por subject criados durante o teste
+func withSubjectQuotas(t *testing.T) {
+	old := subjectQuotas
+	subjectQuotas = &subjectQuotaRegistry{budgets: make(map[string]*quotaBudget)}
+	t.Cleanup(func() { subjectQuotas = old })
+}
+
+// O orçamento global vem da configuração efetiva, não do ambiente no momento
+// em que o pacote foi inicializado: vale o arquivo e o reload
+func TestConfiguredQuotaBudget_ConfigFile(t *testing.T) {
+	withQuota(t, configuredQuotaBudget())
+	path := withConfigFile(t, "weather_api_daily_budget: 2\nweather_api_monthly_budget: 100\n",
+		"WEATHER_API_DAILY_BUDGET", "WEATHER_API_MONTHLY_BUDGET")
+
+	status := weatherAPIQuota.status()
+	assert.Equal(t, 2, status.Daily.Budget)
+	assert.Equal(t, 100, status.Monthly.Budget)
+	assert.True(t, weatherAPIQuota.reserve())
+	assert.True(t, weatherAPIQuota.reserve())
+	assert.False(t, weatherAPIQuota.reserve())
+
+	assert.NoError(t, os.WriteFile(path, []byte("weather_api_daily_budget: 3\n"), 0o600))
+	_, err := config.Reload()
+	assert.NoError(t, err)
+	assert.True(t, weatherAPIQuota.reserve())
+	assert.Equal(t, 0, weatherAPIQuota.status().Monthly.Budget)
+}
+
+func TestQuotaBudget_Rollover(t *testing.T) {
+	now := time.Date(2026, 1, 31, 23, 0, 0, 0, time.UTC)
+	quota := newQuotaBudget(2, 3)
+	quota.now = func() time.Time { return now }
+
+	assert.True(t, quota.reserve())
+	assert.True(t, quota.reserve())
+	assert.False(t, quota.reserve(), "daily budget exhausted")
+
+	// Novo dia e novo mês zeram os dois contadores
+	now = now.Add(2 * time.Hour)
+	assert.True(t, quota.reserve())
+
+	status := quota.status()
+	assert.Equal(t, "2026-02-01", status.Daily.Period)
+	assert.Equal(t, 1, status.Daily.Used)
+	assert.Equal(t, 1, *status.Daily.Remaining)
+	assert.Equal(t, "2026-02", status.Monthly.Period)
+	assert.Equal(t, 2, *status.Monthly.Remaining)
+}
+
+func TestQuotaBudget_Unlimited(t *testing.T) {
+	quota := newQuotaBudget(0, 0)
+	for i := 0; i < 100; i++ {
+		assert.True(t, quota.reserve())
+	}
+	assert.Nil(t, quota.status().Daily.Remaining)
+}
+
+func TestWeatherHandler_QuotaExhausted(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/ws/20040020/json/": `{"cep":"20040-020","localidade":"Rio de Janeiro","uf":"RJ"}`,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	withQuota(t, newQuotaBudget(1, 0))
+
+	router := s.router()
+	request := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusOK, request("/weather/01310100").Code)
+
+	// Sem cota, a mesma localização é servida do cache
+	rr := request("/weather/01310100")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"temp_C":25,"temp_F":77,"temp_K":298.15}`, rr.Body.String())
+
+	// Localização sem cache recebe 503
+	rr = request("/weather/20040020")
+	assert.Equal(t, http.StatusServiceUnavailable, rr.Code)
+	assert.JSONEq(t, `{"message":"weather api quota exhausted"}`, rr.Body.String())
+}
+
+// Cada subject de JWT tem seu orçamento, verificado junto com o global
+func TestWeatherHandler_SubjectQuota(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/ws/20040020/json/": `{"cep":"20040-020","localidade":"Rio de Janeiro","uf":"RJ"}`,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	quota := newQuotaBudget(0, 0)
+	withQuota(t, quota)
+	withSubjectQuotas(t)
+	t.Setenv("JWT_HS256_SECRET", "segredo")
+	t.Setenv("JWT_SUBJECT_DAILY_BUDGET", "1")
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+
+	router := s.router()
+	request := func(subject, path string) *httptest.ResponseRecorder {
+		claims := jwt.MapClaims{"sub": subject, "exp": time.Now().Add(time.Hour).Unix()}
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+signedToken(t, jwt.SigningMethodHS256, []byte("segredo"), "", claims))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusOK, request("user-1", "/weather/01310100").Code)
+	assert.Equal(t, http.StatusServiceUnavailable, request("user-1", "/weather/20040020").Code)
+	// Outro subject tem saldo próprio
+	assert.Equal(t, http.StatusOK, request("user-2", "/weather/20040020").Code)
+	// A chamada recusada ao user-1 não consumiu a cota global
+	assert.Equal(t, 2, quota.status().Daily.Used)
+
+	req := httptest.NewRequest("GET", "/admin/quota?subject=user-1", nil)
+	req.Header.Set("X-API-Key", "admin-secret")
+	rr := httptest.NewRecorder()
+	router.ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var status QuotaStatus
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&status))
+	assert.Equal(t, 1, status.Daily.Budget)
+	assert.Equal(t, 1, status.Daily.Used)
+	assert.Equal(t, 0, *status.Daily.Remaining)
+}
+
+// Um orçamento esgotado desfaz a reserva feita nos anteriores
+func TestReserveQuotas_AllOrNothing(t *testing.T) {
+	global, subject := newQuotaBudget(5, 0), newQuotaBudget(1, 0)
+	assert.True(t, reserveQuotas(global, subject))
+	assert.False(t, reserveQuotas(global, subject))
+	assert.Equal(t, 1, global.status().Daily.Used)
+	assert.Equal(t, 1, subject.status().Daily.Used)
+
+	assert.True(t, reserveQuotas(global, nil))
+	assert.Equal(t, 2, global.status().Daily.Used)
+}
+
+func TestWeatherHandler_CacheTTL(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	t.Setenv("WEATHER_CACHE_TTL", "5m")
+	quota := newQuotaBudget(0, 0)
+	withQuota(t, quota)
+
+	router := s.router()
+	for i := 0; i < 3; i++ {
+		req, err := http.NewRequest("GET", "/weather/01310100", nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusOK, rr.Code)
+	}
+
+	assert.Equal(t, 1, quota.status().Daily.Used)
+}
+
+func TestCallWeatherAPI_CacheTTLPerDataType(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+		"/v1/forecast.json":  `{"forecast":{"forecastday":[]}}`,
+		"/v1/astronomy.json": `{"astronomy":{"astro":{}}}`,
+	})
+	t.Setenv("WEATHER_CACHE_TTL_CURRENT", "1m")
+	quota := newQuotaBudget(0, 0)
+	withQuota(t, quota)
+
+	call := func(endpoint string) {
+		var out interface{}
+		assert.NoError(t, s.callWeatherAPI(context.Background(), endpoint, url.Values{"q": {"São Paulo,SP"}}, &out))
+	}
+
+	// Sem WEATHER_CACHE_TTL, só o clima atual é reaproveitado
+	for i := 0; i < 2; i++ {
+		call("current.json")
+		call("forecast.json")
+		call("astronomy.json")
+	}
+	assert.Equal(t, 5, quota.status().Daily.Used)
+
+	// O TTL global vale para os endpoints sem TTL próprio
+	t.Setenv("WEATHER_CACHE_TTL", "1h")
+	t.Setenv("WEATHER_CACHE_TTL_FORECAST", "0")
+	call("forecast.json")
+	call("astronomy.json")
+	assert.Equal(t, 6, quota.status().Daily.Used)
+
+	assert.Equal(t, time.Minute, weatherCacheTTLFor("current.json"))
+	assert.Equal(t, time.Duration(0), weatherCacheTTLFor("forecast.json"))
+	assert.Equal(t, time.Hour, weatherCacheTTLFor("history.json"))
+}
+
+// As entradas que passam da retenção são descartadas, e o limite de entradas
+// vale para as próximas gravações
+func TestPruneWeatherCache(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	s.weatherCache = cache.NewWithClock(func() time.Time { return now })
+	t.Setenv("WEATHER_CACHE_RETENTION", "1h")
+	t.Setenv("WEATHER_CACHE_MAX_ENTRIES", "2")
+
+	s.weatherCache.Set("current.json?q=a", nil)
+	now = now.Add(90 * time.Minute)
+	s.weatherCache.Set("current.json?q=b", nil)
+	s.pruneWeatherCache()
+	assert.Equal(t, 1, s.weatherCache.Len())
+
+	s.weatherCache.Set("current.json?q=c", nil)
+	s.weatherCache.Set("current.json?q=d", nil)
+	assert.Equal(t, 2, s.weatherCache.Len())
+	_, ok := s.weatherCache.Get("current.json?q=b")
+	assert.False(t, ok)
+	assert.Equal(t, int64(2), s.weatherCache.Stats().Evictions)
+
+	// Um TTL maior que a retenção a estende
+	t.Setenv("WEATHER_CACHE_TTL_FORECAST", "3h")
+	assert.Equal(t, 3*time.Hour, weatherCacheRetention())
+}
+
+func TestAdminQuotaHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	withQuota(t, newQuotaBudget(100, 0))
+	weatherAPIQuota.reserve()
+
+	t.Run("Disabled without admin key", func(t *testing.T) {
+		req, err := http.NewRequest("GET", "/admin/quota", nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		s.router().ServeHTTP(rr, req)
+		assert.Equal(t, http.StatusNotFound, rr.Code)
+	})
+
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+	tests := []struct {
+		name           string
+		key            string
+		expectedStatus int
+	}{
+		{"Missing key", "", http.StatusUnauthorized},
+		{"Wrong key", "client-key", http.StatusUnauthorized},
+		{"Admin key", "admin-secret", http.StatusOK},
+	}
+
+	router := s.router()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/admin/quota", nil)
+			assert.NoError(t, err)
+			req.Header.Set("X-API-Key", tt.key)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			if tt.expectedStatus == http.StatusOK {
+				var status QuotaStatus
+				assert.NoError(t, json.NewDecoder(rr.Body).Decode(&status))
+				assert.Equal(t, 1, status.Daily.Used)
+				assert.Equal(t, 99, *status.Daily.Remaining)
+				assert.Nil(t, status.Monthly.Remaining)
+			}
+		})
+	}
+}
@@ -0,0 +1,195 @@
+package server
+
+import (
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+// Buckets sem uso há mais tempo que isso são descartados
+const rateLimitIdleTTL = 10 * time.Minute
+
+// Token bucket por cliente (veja clientKey): cada cliente acumula até burst
+// tokens, repostos à taxa de rate por segundo, e cada requisição consome um
+// token
+type rateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	return &rateLimiter{
+		rate:    rate,
+		burst:   float64(burst),
+		now:     time.Now,
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// Limite configurado em RATE_LIMIT_RPS e RATE_LIMIT_BURST (padrão: RPS
+// arredondado para cima); sem RATE_LIMIT_RPS o limite fica desligado
+func rateLimitSettings() (rate float64, burst int, ok bool) {
+	value := config.String("RATE_LIMIT_RPS")
+	if value == "" {
+		return 0, 0, false
+	}
+
+	rate, err := strconv.ParseFloat(value, 64)
+	if err != nil || rate <= 0 {
+		log.Printf("WARNING: ignoring invalid RATE_LIMIT_RPS %q", value)
+		return 0, 0, false
+	}
+
+	burst = int(math.Ceil(rate))
+	if value := config.String("RATE_LIMIT_BURST"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed < 1 {
+			log.Printf("WARNING: ignoring invalid RATE_LIMIT_BURST %q", value)
+		} else {
+			burst = parsed
+		}
+	}
+	return rate, burst, true
+}
+
+// Atualiza o limite sem descartar os tokens já acumulados pelos clientes
+func (l *rateLimiter) configure(rate float64, burst int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rate = rate
+	l.burst = float64(burst)
+}
+
+// Consome um token do cliente. Quando não há token disponível, retorna o
+// tempo até o próximo
+func (l *rateLimiter) allow(client string) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	l.sweep(now)
+
+	bucket, ok := l.buckets[client]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[client] = bucket
+	}
+
+	bucket.tokens = math.Min(l.burst, bucket.tokens+now.Sub(bucket.last).Seconds()*l.rate)
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / l.rate * float64(time.Second))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+
+	for client, bucket := range l.buckets {
+		if now.Sub(bucket.last) > rateLimitIdleTTL {
+			delete(l.buckets, client)
+		}
+	}
+}
+
+// As configurações são relidas a cada requisição, para que um reload da
+// configuração ligue, desligue ou ajuste o limite sem reiniciar
+func rateLimit(proxyHops int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		limiter := newRateLimiter(0, 0)
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			rate, burst, ok := rateLimitSettings()
+			if !ok {
+				next.ServeHTTP(w, r)
+				return
+			}
+			limiter.configure(rate, burst)
+
+			client := clientKey(r, proxyHops)
+			if ok, wait := limiter.allow(client); !ok {
+				log.Printf("Rate limit exceeded for client %s", client)
+				w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+				writeResponse(w, r, http.StatusTooManyRequests, ErrorResponse{Message: "too many requests"})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// Identifica o cliente para o limite de requisições: o subject do JWT ou,
+// com mTLS, o CN do certificado, assim a cota acompanha o usuário mesmo que
+// ele mude de IP ou divida o IP com outros (NAT, proxy corporativo)
+func clientKey(r *http.Request, proxyHops int) string {
+	if subject := requestSubject(r.Context()); subject != "" {
+		return "sub:" + subject
+	}
+	if cn := clientCertCN(r); cn != "" {
+		return "cn:" + cn
+	}
+	return clientIP(r, proxyHops)
+}
+
+// IP do cliente. Atrás de proxies (ex.: Cloud Run), proxyHops indica quantos
+// proxies confiáveis acrescentam entradas ao X-Forwarded-For; o IP é lido
+// dessa posição a partir do fim, já que o início da lista é controlado pelo
+// cliente
+func clientIP(r *http.Request, proxyHops int) string {
+	if proxyHops > 0 {
+		var forwarded []string
+		for _, header := range r.Header.Values("X-Forwarded-For") {
+			for _, ip := range strings.Split(header, ",") {
+				forwarded = append(forwarded, strings.TrimSpace(ip))
+			}
+		}
+		if len(forwarded) >= proxyHops {
+			return forwarded[len(forwarded)-proxyHops]
+		}
+	}
+
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// Quantidade de proxies confiáveis à frente do serviço (TRUSTED_PROXY_HOPS)
+func trustedProxyHops() int {
+	value := config.String("TRUSTED_PROXY_HOPS")
+	if value == "" {
+		return 0
+	}
+
+	hops, err := strconv.Atoi(value)
+	if err != nil || hops < 0 {
+		log.Printf("WARNING: ignoring invalid TRUSTED_PROXY_HOPS %q", value)
+		return 0
+	}
+	return hops
+}
@@ -0,0 +1,133 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateLimiter_TokenBucket(t *testing.T) {
+	now := time.Unix(1760000000, 0)
+	limiter := newRateLimiter(2, 2)
+	limiter.now = func() time.Time { return now }
+
+	ok, _ := limiter.allow("10.0.0.1")
+	assert.True(t, ok)
+	ok, _ = limiter.allow("10.0.0.1")
+	assert.True(t, ok)
+
+	ok, wait := limiter.allow("10.0.0.1")
+	assert.False(t, ok)
+	assert.Equal(t, 500*time.Millisecond, wait)
+
+	// Outros clientes têm seu próprio bucket
+	ok, _ = limiter.allow("10.0.0.2")
+	assert.True(t, ok)
+
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = limiter.allow("10.0.0.1")
+	assert.True(t, ok)
+}
+
+func TestRateLimit_Middleware(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("RATE_LIMIT_RPS", "0.5")
+	t.Setenv("RATE_LIMIT_BURST", "1")
+
+	router := s.router()
+	request := func(remoteAddr string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/v1/weather/123", nil)
+		assert.NoError(t, err)
+		req.RemoteAddr = remoteAddr
+
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	assert.Equal(t, http.StatusUnprocessableEntity, request("10.0.0.1:1234").Code)
+
+	rr := request("10.0.0.1:5678")
+	assert.Equal(t, http.StatusTooManyRequests, rr.Code)
+	assert.Equal(t, "2", rr.Header().Get("Retry-After"))
+	assert.JSONEq(t, `{"message":"too many requests"}`, rr.Body.String())
+
+	assert.Equal(t, http.StatusUnprocessableEntity, request("10.0.0.2:1234").Code)
+}
+
+// Com JWT o limite é por subject: usuários atrás do mesmo IP não dividem o
+// bucket, e o mesmo usuário não ganha outro bucket ao trocar de IP
+func TestRateLimit_PerSubject(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("RATE_LIMIT_RPS", "0.5")
+	t.Setenv("RATE_LIMIT_BURST", "1")
+	t.Setenv("JWT_HS256_SECRET", "segredo")
+
+	router := s.router()
+	request := func(subject, remoteAddr string) int {
+		claims := jwt.MapClaims{"sub": subject, "exp": time.Now().Add(time.Hour).Unix()}
+		req := httptest.NewRequest("GET", "/v1/weather/123", nil)
+		req.RemoteAddr = remoteAddr
+		req.Header.Set("Authorization", "Bearer "+signedToken(t, jwt.SigningMethodHS256, []byte("segredo"), "", claims))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusUnprocessableEntity, request("user-1", "10.0.0.1:1234"))
+	assert.Equal(t, http.StatusUnprocessableEntity, request("user-2", "10.0.0.1:1234"))
+	assert.Equal(t, http.StatusTooManyRequests, request("user-1", "10.0.0.2:1234"))
+}
+
+func TestClientIP(t *testing.T) {
+	tests := []struct {
+		name      string
+		forwarded []string
+		proxyHops int
+		expected  string
+	}{
+		{"Remote address", []string{"203.0.113.7"}, 0, "192.0.2.1"},
+		{"One trusted proxy", []string{"198.51.100.9, 203.0.113.7"}, 1, "203.0.113.7"},
+		{"Two trusted proxies across headers", []string{"198.51.100.9, 203.0.113.7", "10.0.0.5"}, 2, "203.0.113.7"},
+		{"Header shorter than hops", []string{"203.0.113.7"}, 2, "192.0.2.1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/", nil)
+			assert.NoError(t, err)
+			req.RemoteAddr = "192.0.2.1:4321"
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+
+			assert.Equal(t, tt.expected, clientIP(req, tt.proxyHops))
+		})
+	}
+}
+
+// O limite acompanha mudanças na configuração sem recriar o router
+func TestRateLimit_FollowsConfiguration(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("RATE_LIMIT_RPS", "0.5")
+	t.Setenv("RATE_LIMIT_BURST", "1")
+
+	router := s.router()
+	request := func() int {
+		req := httptest.NewRequest("GET", "/v1/weather/123", nil)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusUnprocessableEntity, request())
+	assert.Equal(t, http.StatusTooManyRequests, request())
+
+	os.Unsetenv("RATE_LIMIT_RPS")
+	assert.Equal(t, http.StatusUnprocessableEntity, request())
+}
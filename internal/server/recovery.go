@@ -0,0 +1,43 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Converte panics dos handlers em um 500 em JSON, registrando o stack trace
+// com o ID da requisição para facilitar a investigação
+func recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r = r.WithContext(withSubjectSlot(r.Context()))
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// Usado pelo net/http para abortar a resposta de propósito
+			if rec == http.ErrAbortHandler {
+				panic(rec)
+			}
+
+			log.Printf("PANIC [request %s subject %q] %s %s: %v\n%s", middleware.GetReqID(r.Context()), requestSubject(r.Context()), r.Method, r.URL.Path, rec, debug.Stack())
+			writeResponse(w, r, http.StatusInternalServerError, ErrorResponse{Message: "internal server error"})
+		}()
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Propaga o ID da requisição (recebido em X-Request-Id ou gerado) na resposta
+func exposeRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set(middleware.RequestIDHeader, middleware.GetReqID(r.Context()))
+		if cn := clientCertCN(r); cn != "" {
+			log.Printf("Request %s from client certificate CN=%s: %s %s", middleware.GetReqID(r.Context()), cn, r.Method, r.URL.Path)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
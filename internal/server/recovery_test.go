@@ -0,0 +1,73 @@
+package server
+
+import (
+	"bytes"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecoverPanics(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	handler := middleware.RequestID(exposeRequestID(recoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))))
+
+	req, err := http.NewRequest("GET", "/weather/01310100", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-Request-Id", "req-123")
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Equal(t, "req-123", rr.Header().Get("X-Request-Id"))
+	assert.JSONEq(t, `{"message":"internal server error"}`, rr.Body.String())
+	assert.Contains(t, logs.String(), `PANIC [request req-123 subject ""] GET /weather/01310100: boom`)
+	assert.Contains(t, logs.String(), "goroutine")
+}
+
+// O panic acontece depois da autenticação, mas é registrado com o subject
+func TestRecoverPanics_LogsSubject(t *testing.T) {
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+	t.Setenv("JWT_HS256_SECRET", "segredo")
+
+	handler := middleware.RequestID(recoverPanics(requireJWT(jwtVerifierFromEnv())(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic("boom")
+	}))))
+
+	claims := jwt.MapClaims{"sub": "user-1", "exp": time.Now().Add(time.Hour).Unix()}
+	req := httptest.NewRequest("GET", "/weather/01310100", nil)
+	req.Header.Set("X-Request-Id", "req-123")
+	req.Header.Set("Authorization", "Bearer "+signedToken(t, jwt.SigningMethodHS256, []byte("segredo"), "", claims))
+
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.Contains(t, logs.String(), "Request req-123 from subject user-1: GET /weather/01310100")
+	assert.Contains(t, logs.String(), `PANIC [request req-123 subject "user-1"] GET /weather/01310100: boom`)
+}
+
+func TestRouter_GeneratesRequestID(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	req, err := http.NewRequest("GET", "/", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.NotEmpty(t, rr.Header().Get("X-Request-Id"))
+}
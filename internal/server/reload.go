@@ -0,0 +1,56 @@
+package server
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/weather-service/config"
+)
+
+type ReloadResponse struct {
+	Changed []string `json:"changed" xml:"changed>setting"`
+}
+
+// Relê o arquivo de configuração. Valem sem reiniciar as configurações lidas
+// a cada requisição: TTL do cache da WeatherAPI, Cache-Control, limite de
+// requisições, ordem dos provedores de CEP, nível do log, precisão das
+// temperaturas e intervalo das atualizações ao vivo
+func reloadConfig() ([]string, error) {
+	changed, err := config.Reload()
+	if err != nil {
+		log.Printf("ERROR: Configuration reload failed, keeping current configuration: %v", err)
+		return nil, err
+	}
+	log.Printf("Configuration reloaded, changed settings: %v", changed)
+	return changed, nil
+}
+
+// Recarrega a configuração a cada SIGHUP, sem derrubar requisições em andamento
+func reloadOnSignal() {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGHUP)
+
+	go func() {
+		for range signals {
+			log.Println("Received SIGHUP, reloading configuration")
+			reloadConfig()
+		}
+	}()
+}
+
+// POST /admin/reload: mesmo efeito do SIGHUP, para ambientes sem acesso ao
+// processo (ex.: Cloud Run)
+func reloadHandler(w http.ResponseWriter, r *http.Request) {
+	changed, err := reloadConfig()
+	if err != nil {
+		writeResponse(w, r, http.StatusUnprocessableEntity, ErrorResponse{Message: err.Error()})
+		return
+	}
+	if changed == nil {
+		changed = []string{}
+	}
+	writeResponse(w, r, http.StatusOK, ReloadResponse{Changed: changed})
+}
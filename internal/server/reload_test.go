@@ -0,0 +1,84 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/config"
+)
+
+// Carrega um arquivo de configuração e o descarta ao fim do teste
+func withConfigFile(t *testing.T, content string, names ...string) string {
+	t.Helper()
+	for _, name := range names {
+		t.Setenv(name, "")
+		os.Unsetenv(name)
+	}
+
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	assert.NoError(t, config.Load(path))
+	t.Cleanup(func() { config.Load("") })
+	return path
+}
+
+func TestReloadHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+	path := withConfigFile(t, "cache_max_age_weather: 1m\n", "CACHE_MAX_AGE_WEATHER", "TEMP_PRECISION")
+
+	router := s.router()
+	request := func(method, target string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, target, nil)
+		req.Header.Set("X-API-Key", "admin-secret")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	assert.Equal(t, "public, max-age=60", request("GET", "/weather/01310100").Header().Get("Cache-Control"))
+
+	assert.NoError(t, os.WriteFile(path, []byte("cache_max_age_weather: 2m\ntemp_precision: 1\n"), 0o600))
+	rr := request("POST", "/admin/reload")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"changed":["CACHE_MAX_AGE_WEATHER","TEMP_PRECISION"]}`, rr.Body.String())
+
+	// O mesmo router passa a usar a nova configuração
+	assert.Equal(t, "public, max-age=120", request("GET", "/weather/01310100").Header().Get("Cache-Control"))
+
+	// Configuração inválida é recusada e a anterior continua valendo
+	assert.NoError(t, os.WriteFile(path, []byte("cache_max_age_weather: soon\n"), 0o600))
+	rr = request("POST", "/admin/reload")
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.JSONEq(t, `{"message":"invalid CACHE_MAX_AGE_WEATHER \"soon\": must be a duration such as 30s or 5m"}`, rr.Body.String())
+	assert.Equal(t, "public, max-age=120", request("GET", "/weather/01310100").Header().Get("Cache-Control"))
+}
+
+func TestReloadHandler_RequiresAdminKey(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("POST", "/admin/reload", nil))
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+}
+
+func TestReloadOnSignal(t *testing.T) {
+	path := withConfigFile(t, "weather_cache_ttl: 1m\n", "WEATHER_CACHE_TTL")
+	reloadOnSignal()
+
+	assert.NoError(t, os.WriteFile(path, []byte("weather_cache_ttl: 3m\n"), 0o600))
+	assert.NoError(t, syscall.Kill(os.Getpid(), syscall.SIGHUP))
+
+	assert.Eventually(t, func() bool {
+		return weatherCacheTTL() == 3*time.Minute
+	}, time.Second, 10*time.Millisecond)
+}
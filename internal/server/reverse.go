@@ -0,0 +1,103 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/weather-service/config"
+	"github.com/weather-service/internal/cep"
+)
+
+// Trecho de logradouro usado na busca do ViaCEP, que exige pelo menos 3
+// letras. Quase todo município tem uma "Rua", e o primeiro resultado serve de
+// CEP aproximado
+const reverseSearchStreet = "Rua"
+
+// Município das coordenadas e um CEP aproximado dele
+type ReverseCEPResponse struct {
+	CEP  string `json:"cep" xml:"cep"`
+	City string `json:"city" xml:"city"`
+	UF   string `json:"uf" xml:"uf"`
+}
+
+// Busca de CEPs por endereço (cep.ViaCEP)
+type cepSearcher interface {
+	Search(ctx context.Context, uf, city, street string) ([]*cep.Address, error)
+}
+
+// CEP aproximado e município de uma latitude e longitude, para clientes
+// móveis que só têm a posição do GPS
+func (s *Server) reverseCEPHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	log.Printf("Received reverse CEP request for coordinates %s,%s", query.Get("lat"), query.Get("lon"))
+
+	response, err := s.reverseCEP(r.Context(), query.Get("lat"), query.Get("lon"))
+	if err != nil {
+		status, body := httpError(err)
+		writeResponse(w, r, status, body)
+		return
+	}
+	log.Printf("Coordinates %s,%s resolved to CEP %s (%s,%s)", query.Get("lat"), query.Get("lon"), response.CEP, response.City, response.UF)
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// Nem o ViaCEP nem a BrasilAPI consultam por coordenadas: o município vem da
+// WeatherAPI (a mesma chamada de /weather/coords, então aproveita o cache) e
+// o CEP, da tabela embutida ou de uma busca por endereço no ViaCEP
+func (s *Server) reverseCEP(ctx context.Context, lat, lon string) (*ReverseCEPResponse, error) {
+	location, err := coordinatesLocation(lat, lon)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.getCurrentWeather(ctx, location, false)
+	if err != nil {
+		log.Printf("ERROR: Failed to resolve municipality for coordinates %s: %v", location, err)
+		return nil, newPublicError(err)
+	}
+	uf, ok := cep.StateUF(current.Location.Region)
+	if current.Location.Country != "Brazil" || !ok {
+		log.Printf("Coordinates %s are outside Brazil (%s, %s)", location, current.Location.Region, current.Location.Country)
+		return nil, ErrCEPNotFound
+	}
+
+	address, err := s.municipalityCEP(ctx, current.Location.Name, uf)
+	if err != nil {
+		if !errors.Is(err, ErrCEPNotFound) {
+			log.Printf("ERROR: Failed to find a CEP for %s,%s: %v", current.Location.Name, uf, err)
+		}
+		return nil, newPublicError(err)
+	}
+	return &ReverseCEPResponse{CEP: address.CEP, City: address.City, UF: address.UF}, nil
+}
+
+// Um CEP do município. A tabela embutida resolve as maiores cidades sem rede;
+// as demais passam pela busca do ViaCEP, com o circuit breaker dele
+func (s *Server) municipalityCEP(ctx context.Context, city, uf string) (*cep.Address, error) {
+	if address, ok := cep.Embedded().FindCity(city, uf); ok {
+		return address, nil
+	}
+	searcher, ok := s.viaCEP.(cepSearcher)
+	if offlineCEP() || !ok {
+		return nil, ErrCEPNotFound
+	}
+	if !viaCEPProvider.breaker.allow() {
+		return nil, &UpstreamError{Provider: cepProvidersName, Err: errCircuitOpen}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, config.Duration("VIACEP_TIMEOUT", defaultViaCEPTimeout))
+	defer cancel()
+	start := time.Now()
+	addresses, err := searcher.Search(ctx, uf, city, reverseSearchStreet)
+	viaCEPProvider.recordCall(ctx, err == nil || errors.Is(err, cep.ErrNotFound), time.Since(start))
+	if err != nil {
+		if errors.Is(err, cep.ErrNotFound) {
+			return nil, err
+		}
+		return nil, &UpstreamError{Provider: cepProvidersName, Err: err}
+	}
+	return addresses[0], nil
+}
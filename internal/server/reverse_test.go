@@ -0,0 +1,70 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weather-service/internal/weather"
+)
+
+// WeatherAPI em memória que devolve o objeto location de cada consulta
+type fakeLocationClient map[string]string
+
+func (f fakeLocationClient) Fetch(ctx context.Context, endpoint string, params url.Values, key string) ([]byte, error) {
+	location, ok := f[params.Get("q")]
+	if !ok {
+		return nil, &weather.StatusError{Status: http.StatusBadRequest}
+	}
+	return []byte(`{"location":` + location + `,"current":{"temp_c":25}}`), nil
+}
+
+func TestReverseCEPHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/PR/Maringa/Rua/json/":  `[{"cep":"87013-010","logradouro":"Rua Santos Dumont","bairro":"Zona 01","localidade":"Maringá","uf":"PR"}]`,
+		"/ws/PR/Cascavel/Rua/json/": `[]`,
+	})
+	s.weather = fakeLocationClient{
+		"-23.56,-46.66": `{"name":"Sao Paulo","region":"Sao Paulo","country":"Brazil"}`,
+		"-23.42,-51.94": `{"name":"Maringa","region":"Parana","country":"Brazil"}`,
+		"-24.96,-53.46": `{"name":"Cascavel","region":"Parana","country":"Brazil"}`,
+		"-34.6,-58.38":  `{"name":"Buenos Aires","region":"Distrito Federal","country":"Argentina"}`,
+	}
+
+	tests := []struct {
+		name     string
+		query    string
+		status   int
+		expected string
+	}{
+		{"embedded table", "?lat=-23.5614&lon=-46.6559", http.StatusOK, `{"cep":"01000-000","city":"São Paulo","uf":"SP"}`},
+		{"viacep search", "?lat=-23.42&lon=-51.94", http.StatusOK, `{"cep":"87013-010","city":"Maringá","uf":"PR"}`},
+		{"no address found", "?lat=-24.96&lon=-53.46", http.StatusNotFound, `{"message":"can not find zipcode"}`},
+		{"outside Brazil", "?lat=-34.6&lon=-58.38", http.StatusNotFound, `{"message":"can not find zipcode"}`},
+		{"missing lon", "?lat=-23.56", http.StatusUnprocessableEntity, `{"message":"invalid coordinates"}`},
+		{"weather API failure", "?lat=10&lon=10", http.StatusInternalServerError, `{"message":"error fetching weather data"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/cep/reverse"+tt.query, nil))
+			assert.Equal(t, tt.status, rr.Code)
+			assert.JSONEq(t, tt.expected, rr.Body.String())
+		})
+	}
+}
+
+func TestReverseCEPHandler_ViaCEPDown(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{})
+	s.weather = fakeLocationClient{"-23.42,-51.94": `{"name":"Maringa","region":"Parana","country":"Brazil"}`}
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/cep/reverse?lat=-23.42&lon=-51.94", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+	assert.JSONEq(t, `{"message":"internal server error"}`, rr.Body.String())
+}
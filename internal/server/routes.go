@@ -0,0 +1,155 @@
+package server
+
+import (
+	"log"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/weather-service/config"
+)
+
+func (s *Server) router() http.Handler {
+	ips, err := ipFilterFromEnv()
+	if err != nil {
+		log.Fatalf("ERROR: Invalid IP filter configuration: %v", err)
+	}
+	apiKeys, err := apiKeyStoreFromEnv()
+	if err != nil {
+		log.Fatalf("ERROR: Invalid API key configuration: %v", err)
+	}
+	tenants, err := tenantRegistryFromEnv()
+	if err != nil {
+		log.Fatalf("ERROR: Invalid tenant configuration: %v", err)
+	}
+	if tenants != nil {
+		// Com tenants configurados a autenticação fica sempre ligada
+		stores := apiKeyStores{tenants}
+		if apiKeys != nil {
+			stores = append(stores, apiKeys)
+		}
+		apiKeys = stores
+	}
+	proxyHops := trustedProxyHops()
+	security := securityHeadersFromEnv()
+
+	r := chi.NewRouter()
+	r.Use(middleware.RequestID, exposeRequestID)
+	r.Use(security.middleware)
+	r.Use(filterIPs(ips, proxyHops))
+	r.Use(corsMiddleware(corsConfigFromEnv()))
+	r.Use(gzipMiddleware(gzipMinSize()))
+	// Dentro do gzip, para que o 500 não seja precedido pela resposta comprimida
+	r.Use(recoverPanics)
+	r.NotFound(notFoundHandler)
+	r.MethodNotAllowed(methodNotAllowedHandler(r))
+
+	// Endpoints de dados exigem X-API-Key, JWT e/ou assinatura HMAC quando configurados;
+	// health check e documentação continuam públicos
+	r.Group(func(r chi.Router) {
+		// Antes da autenticação, para que as recusas entrem nas estatísticas
+		r.Use(s.recordUsage)
+		r.Use(requireAPIKey(apiKeys), requireJWT(jwtVerifierFromEnv()), requireSignature(requestSignerFromEnv()))
+		// Depois da autenticação, para limitar pelo subject do JWT
+		r.Use(rateLimit(proxyHops))
+		r.Use(identifyTenant(tenants))
+
+		// Uma futura v2 com outro formato de resposta ganha sua própria função de
+		// registro e é montada em /v2, sem afetar a v1
+		r.Route("/v1", func(r chi.Router) {
+			r.Use(apiVersion("v1"))
+			s.v1Routes(r)
+		})
+
+		// Caminhos sem prefixo de versão, usados pelos clientes anteriores ao /v1
+		r.Group(func(r chi.Router) {
+			r.Use(apiVersion("v1"))
+			s.v1Routes(r)
+		})
+
+		r.Get("/graphql", s.graphqlHandler)
+		r.With(limitBody(maxBodySize())).Post("/graphql", s.graphqlHandler)
+		r.Get("/ws", s.wsHandler)
+		r.Get("/stats", s.statsHandler)
+		r.Get("/export", s.exportHandler)
+		r.Route("/alert-rules", s.subscriptionRoutes)
+		r.Route("/subscriptions", s.subscriptionRoutes)
+	})
+
+	// Rotas administrativas só existem com ADMIN_API_KEY configurada
+	if adminKey := config.String("ADMIN_API_KEY"); adminKey != "" {
+		r.Route("/admin", func(r chi.Router) {
+			r.Use(requireAPIKey(staticAPIKeys{adminKey: {}}))
+			r.Get("/quota", quotaHandler(tenants))
+			r.Post("/reload", reloadHandler)
+			r.Get("/cache", s.cacheStatsHandler)
+			r.Post("/cache/flush", s.cacheFlushHandler)
+			r.Get("/config", configHandler)
+			r.Get("/providers", s.providersHandler)
+		})
+	}
+
+	r.Get("/openapi.json", openAPIHandler)
+	r.With(security.htmlPage).Get("/docs", docsHandler)
+	r.Get("/", s.healthHandler)
+	r.Get("/livez", livezHandler)
+	r.Get("/readyz", s.readyzHandler)
+	r.Get("/status", s.statusHandler)
+	r.Get("/metrics", s.metricsHandler)
+
+	return r
+}
+
+// Endpoints REST da versão 1
+func (s *Server) v1Routes(r chi.Router) {
+	weatherCache := cacheControl("CACHE_MAX_AGE_WEATHER", defaultWeatherMaxAge)
+
+	r.With(weatherCache).Get("/weather/{cep}", s.weatherHandler)
+	r.Get("/weather/{cep}/stream", s.weatherStreamHandler)
+	r.With(weatherCache).Get("/weather/coords/{lat},{lon}", s.weatherByCoordinatesHandler)
+	r.With(weatherCache).Get("/weather/city/{uf}/{city}", s.weatherByCityHandler)
+	r.With(weatherCache).Get("/weather/ibge/{code}", s.weatherByIBGEHandler)
+	r.With(limitBody(maxBodySize())).Post("/weather/batch", s.batchHandler)
+	// As leituras registradas mudam a cada consulta, como o clima atual
+	r.Get("/history/{cep}", s.routeHistory(
+		cacheControl("CACHE_MAX_AGE_HISTORY", defaultHistoryMaxAge)(http.HandlerFunc(s.historyHandler)),
+		weatherCache(http.HandlerFunc(s.readingsHandler)),
+	))
+	r.With(cacheControl("CACHE_MAX_AGE_ASTRONOMY", defaultAstronomyMaxAge)).Get("/astronomy/{cep}", s.astronomyHandler)
+	r.With(cacheControl("CACHE_MAX_AGE_ALERTS", defaultAlertsMaxAge)).Get("/alerts/{cep}", s.alertsHandler)
+	r.With(weatherCache).Get("/compare", s.compareHandler)
+	r.Get("/distance", s.distanceHandler)
+	r.Get("/cep/reverse", s.reverseCEPHandler)
+
+	// CEP vazio é um CEP inválido (422), não uma rota inexistente
+	r.With(weatherCache).Get("/weather/", s.weatherHandler)
+}
+
+// Informa ao cliente qual versão da API atendeu a requisição
+func apiVersion(version string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("API-Version", version)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func notFoundHandler(w http.ResponseWriter, r *http.Request) {
+	writeResponse(w, r, http.StatusNotFound, ErrorResponse{Message: "not found"})
+}
+
+// Métodos registrados para algum caminho, na ordem em que aparecem no Allow
+var routeMethods = []string{http.MethodGet, http.MethodPost, http.MethodDelete}
+
+// Responde 405 informando no cabeçalho Allow os métodos aceitos pelo caminho
+func methodNotAllowedHandler(routes chi.Routes) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		for _, method := range routeMethods {
+			if routes.Match(chi.NewRouteContext(), method, r.URL.Path) {
+				w.Header().Add("Allow", method)
+			}
+		}
+		writeResponse(w, r, http.StatusMethodNotAllowed, ErrorResponse{Message: "method not allowed"})
+	}
+}
@@ -0,0 +1,70 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouter_VersionedAndLegacyPaths(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+
+	tests := []struct {
+		name            string
+		path            string
+		expectedStatus  int
+		expectedVersion string
+	}{
+		{"Versioned weather", "/v1/weather/01310100?units=c", http.StatusOK, "v1"},
+		{"Legacy weather alias", "/weather/01310100?units=c", http.StatusOK, "v1"},
+		{"Versioned invalid CEP", "/v1/weather/123", http.StatusUnprocessableEntity, "v1"},
+		{"Versioned history validation", "/v1/history/01310100", http.StatusUnprocessableEntity, "v1"},
+		{"Unknown version route", "/v1/unknown", http.StatusNotFound, "v1"},
+		{"Health is unversioned", "/", http.StatusOK, ""},
+	}
+
+	router := s.router()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.path, nil)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Equal(t, tt.expectedVersion, rr.Header().Get("API-Version"))
+		})
+	}
+}
+
+func TestRouter_MethodNotAllowed(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	req, err := http.NewRequest("POST", "/v1/weather/01310100", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Equal(t, "GET", rr.Header().Get("Allow"))
+	assert.Equal(t, "application/json", rr.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"message":"method not allowed"}`, rr.Body.String())
+}
+
+func TestRouter_AllowHeaderListsRouteMethods(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	req, err := http.NewRequest("DELETE", "/graphql", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusMethodNotAllowed, rr.Code)
+	assert.Equal(t, []string{"GET", "POST"}, rr.Header().Values("Allow"))
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/weather-service/config"
+	"github.com/weather-service/internal/schedule"
+)
+
+// A cada 15 minutos, bem dentro de um WEATHER_CACHE_TTL típico
+const defaultScheduleCron = "*/15 * * * *"
+
+// Expressão de SCHEDULE_CRON, validada na inicialização por config.Validate
+func refreshSchedule() schedule.Schedule {
+	expr := config.String("SCHEDULE_CRON")
+	if expr == "" {
+		expr = defaultScheduleCron
+	}
+	s, err := schedule.Parse(expr)
+	if err != nil {
+		log.Printf("WARNING: invalid SCHEDULE_CRON %q, using %q: %v", expr, defaultScheduleCron, err)
+		s, _ = schedule.Parse(defaultScheduleCron)
+	}
+	return s
+}
+
+// Atualiza a temperatura dos CEPs de SCHEDULE_CEPS nos horários de
+// SCHEDULE_CRON até ctx terminar. Sem CEPs configurados, não faz nada. Uma
+// execução que passa do horário seguinte não se sobrepõe à próxima: o horário
+// é calculado de novo quando ela termina
+func (s *Server) runScheduledRefresh(ctx context.Context) {
+	ceps := config.List("SCHEDULE_CEPS", nil)
+	if len(ceps) == 0 {
+		return
+	}
+	cron := refreshSchedule()
+
+	for {
+		next := cron.Next(time.Now())
+		if next.IsZero() {
+			log.Println("WARNING: SCHEDULE_CRON never fires, scheduled refresh disabled")
+			return
+		}
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			s.refreshCEPs(ctx, ceps)
+		}
+	}
+}
+
+// Consulta os CEPs ignorando o cache, com o mesmo paralelismo dos lotes. Cada
+// consulta renova a resposta em cache e entra no histórico de consultas
+func (s *Server) refreshCEPs(ctx context.Context, ceps []string) (failed int) {
+	start := time.Now()
+	for _, result := range s.runBatch(withCacheRefresh(ctx), ceps, defaultUnits, batchConcurrency()) {
+		if result.Error != "" {
+			log.Printf("WARNING: Scheduled refresh failed for CEP %s: %s", result.CEP, result.Error)
+			failed++
+		}
+	}
+	log.Printf("Scheduled refresh of %d CEPs finished in %s (%d failed)", len(ceps), time.Since(start).Round(time.Millisecond), failed)
+	return failed
+}
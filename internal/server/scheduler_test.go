@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// A atualização agendada ignora o cache ainda válido, renova a resposta
+// guardada e registra a consulta no histórico
+func TestRefreshCEPs(t *testing.T) {
+	temperatures := fakeWeatherClient{"São Paulo,SP": 25}
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, temperatures)
+	store := withSQLiteLookups(t, s)
+	t.Setenv("WEATHER_CACHE_TTL", "1h")
+	ctx := context.Background()
+
+	_, err := s.lookupWeather(ctx, "01310100", defaultUnits)
+	assert.NoError(t, err)
+
+	temperatures["São Paulo,SP"] = 30
+	assert.Equal(t, 1, s.refreshCEPs(ctx, []string{"01310100", "99999999"}))
+
+	response, err := s.lookupWeather(ctx, "01310100", defaultUnits)
+	assert.NoError(t, err)
+	assert.Equal(t, 30.0, *response.TempC, "the cached response was refreshed")
+
+	stored := storedLookups(t, store.db)
+	if assert.Len(t, stored, 3) {
+		assert.Equal(t, 30.0, stored[1].tempC)
+	}
+}
+
+func TestRunScheduledRefresh(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Run("without CEPs", func(t *testing.T) {
+		t.Setenv("SCHEDULE_CEPS", "")
+		done := make(chan struct{})
+		go func() {
+			s.runScheduledRefresh(context.Background())
+			close(done)
+		}()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("runScheduledRefresh should return when SCHEDULE_CEPS is empty")
+		}
+	})
+
+	t.Run("stops with the context", func(t *testing.T) {
+		t.Setenv("SCHEDULE_CEPS", "01310100")
+		t.Setenv("SCHEDULE_CRON", "@daily")
+		ctx, cancel := context.WithCancel(context.Background())
+		done := make(chan struct{})
+		go func() {
+			s.runScheduledRefresh(ctx)
+			close(done)
+		}()
+		cancel()
+		select {
+		case <-done:
+		case <-time.After(time.Second):
+			t.Fatal("runScheduledRefresh did not stop")
+		}
+	})
+}
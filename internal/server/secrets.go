@@ -0,0 +1,174 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+// Segredo lido de um arquivo montado (Docker/Kubernetes secrets). O arquivo
+// é relido quando muda, assim a rotação do segredo não exige reinício
+type secretFile struct {
+	mu      sync.Mutex
+	path    string
+	modTime time.Time
+	value   string
+}
+
+func (f *secretFile) read(path string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	// Os secrets do Kubernetes trocam um symlink; Stat segue o link
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if path == f.path && info.ModTime().Equal(f.modTime) {
+		return f.value, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if f.path == path {
+		log.Printf("Secret file %s changed, using the new value", path)
+	}
+	f.path, f.modTime, f.value = path, info.ModTime(), strings.TrimSpace(string(data))
+	return f.value, nil
+}
+
+var weatherAPIKeyFile = &secretFile{}
+
+// Chaves da WeatherAPI, separadas por vírgula ou uma por linha no arquivo.
+// WEATHER_API_KEY_FILE tem precedência sobre WEATHER_API_KEY, para que as
+// chaves não precisem ficar no ambiente
+func weatherAPIKeys() ([]string, error) {
+	value := config.String("WEATHER_API_KEY")
+	if path := config.String("WEATHER_API_KEY_FILE"); path != "" {
+		content, err := weatherAPIKeyFile.read(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read WEATHER_API_KEY_FILE: %w", err)
+		}
+		value = content
+	}
+
+	var keys []string
+	for _, key := range strings.FieldsFunc(value, func(r rune) bool { return r == ',' || r == '\n' }) {
+		if key = strings.TrimSpace(key); key != "" {
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// Endereços do Google Cloud, substituídos nos testes
+var (
+	gcpMetadataURL      = "http://metadata.google.internal/computeMetadata/v1"
+	gcpSecretManagerURL = "https://secretmanager.googleapis.com/v1"
+)
+
+const gcpSecretScheme = "gcp-sm://"
+
+// Configurações secretas no formato gcp-sm://projects/P/secrets/S[/versions/V]
+// são trocadas pelo valor guardado no Secret Manager (padrão: versão latest)
+func resolveSecretURIs() error {
+	for _, setting := range config.Settings {
+		if !setting.Secret {
+			continue
+		}
+		name, ok := strings.CutPrefix(config.String(setting.Name), gcpSecretScheme)
+		if !ok {
+			continue
+		}
+
+		value, err := fetchGCPSecret(name)
+		if err != nil {
+			return fmt.Errorf("%s: %w", setting.Name, err)
+		}
+		config.Set(config.SourceSecret, setting.Name, value)
+		log.Printf("Loaded %s from Secret Manager", setting.Name)
+	}
+	return nil
+}
+
+func fetchGCPSecret(name string) (string, error) {
+	if !strings.HasPrefix(name, "projects/") || !strings.Contains(name, "/secrets/") {
+		return "", fmt.Errorf("invalid secret URI %q (expected %sprojects/P/secrets/S)", gcpSecretScheme+name, gcpSecretScheme)
+	}
+	if !strings.Contains(name, "/versions/") {
+		name += "/versions/latest"
+	}
+
+	token, err := gcpAccessToken()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("GET", gcpSecretManagerURL+"/"+name+":access", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	var response struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := getGCPJSON(req, &response); err != nil {
+		return "", fmt.Errorf("failed to access secret %s: %w", name, err)
+	}
+
+	data, err := base64.StdEncoding.DecodeString(response.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode secret %s: %w", name, err)
+	}
+	return string(data), nil
+}
+
+// Token da conta de serviço do Cloud Run, obtido no servidor de metadados.
+// Fora do Google Cloud, GCP_ACCESS_TOKEN (ex.: gcloud auth print-access-token)
+// é usado no lugar
+func gcpAccessToken() (string, error) {
+	if token := config.String("GCP_ACCESS_TOKEN"); token != "" {
+		return token, nil
+	}
+
+	req, err := http.NewRequest("GET", gcpMetadataURL+"/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	var response struct {
+		AccessToken string `json:"access_token"`
+	}
+	if err := getGCPJSON(req, &response); err != nil {
+		return "", fmt.Errorf("failed to get access token from metadata server: %w", err)
+	}
+	return response.AccessToken, nil
+}
+
+func getGCPJSON(req *http.Request, out interface{}) error {
+	client := &http.Client{Timeout: 10 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
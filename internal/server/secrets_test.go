@@ -0,0 +1,165 @@
+package server
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/config"
+	"github.com/weather-service/internal/weather"
+)
+
+func TestWeatherAPIKeys_FromFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weather_api_key")
+	assert.NoError(t, os.WriteFile(path, []byte("file-key\n"), 0o600))
+	t.Setenv("WEATHER_API_KEY", "env-key")
+	t.Setenv("WEATHER_API_KEY_FILE", path)
+
+	keys, err := weatherAPIKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"file-key"}, keys)
+
+	// Rotação do segredo: o novo conteúdo vale sem reiniciar
+	assert.NoError(t, os.WriteFile(path, []byte("rotated-key"), 0o600))
+	later := time.Now().Add(time.Minute)
+	assert.NoError(t, os.Chtimes(path, later, later))
+
+	keys, err = weatherAPIKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"rotated-key"}, keys)
+}
+
+func TestWeatherAPIKeys_FromEnvironment(t *testing.T) {
+	t.Setenv("WEATHER_API_KEY", "env-key")
+	t.Setenv("WEATHER_API_KEY_FILE", "")
+
+	keys, err := weatherAPIKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"env-key"}, keys)
+}
+
+func TestWeatherAPIKeys_List(t *testing.T) {
+	t.Setenv("WEATHER_API_KEY", " key-a, key-b,,key-c ")
+	t.Setenv("WEATHER_API_KEY_FILE", "")
+
+	keys, err := weatherAPIKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"key-a", "key-b", "key-c"}, keys)
+
+	// No arquivo, uma chave por linha
+	path := filepath.Join(t.TempDir(), "weather_api_keys")
+	assert.NoError(t, os.WriteFile(path, []byte("key-d\nkey-e\n"), 0o600))
+	t.Setenv("WEATHER_API_KEY_FILE", path)
+
+	keys, err = weatherAPIKeys()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"key-d", "key-e"}, keys)
+}
+
+func TestWeatherAPIKeys_MissingFile(t *testing.T) {
+	t.Setenv("WEATHER_API_KEY_FILE", filepath.Join(t.TempDir(), "missing"))
+
+	_, err := weatherAPIKeys()
+	assert.ErrorContains(t, err, "failed to read WEATHER_API_KEY_FILE")
+}
+
+func TestCallWeatherAPI_UsesKeyFile(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+
+	var receivedKey string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedKey = r.URL.Query().Get("key")
+		w.Write([]byte(`{"current":{"temp_c":25}}`))
+	}))
+	defer server.Close()
+	s.weather = weather.NewClient(server.URL+"/v1", nil)
+
+	path := filepath.Join(t.TempDir(), "weather_api_key")
+	assert.NoError(t, os.WriteFile(path, []byte("file-key"), 0o600))
+	t.Setenv("WEATHER_API_KEY", "")
+	t.Setenv("WEATHER_API_KEY_FILE", path)
+
+	_, err := s.getCurrentWeather(context.Background(), "São Paulo", false)
+	assert.NoError(t, err)
+	assert.Equal(t, "file-key", receivedKey)
+}
+
+// Servidor de metadados e Secret Manager fakes
+func withFakeGoogleCloud(t *testing.T, secrets map[string]string) {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/metadata/instance/service-accounts/default/token" {
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.Write([]byte(`{"access_token":"metadata-token","expires_in":3599,"token_type":"Bearer"}`))
+			return
+		}
+
+		auth := r.Header.Get("Authorization")
+		if auth != "Bearer metadata-token" && auth != "Bearer local-token" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		value, ok := secrets[strings.TrimPrefix(r.URL.Path, "/secretmanager/")]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		fmt.Fprintf(w, `{"name":"x","payload":{"data":%q}}`, base64.StdEncoding.EncodeToString([]byte(value)))
+	}))
+	t.Cleanup(server.Close)
+
+	oldMetadata, oldSecretManager := gcpMetadataURL, gcpSecretManagerURL
+	gcpMetadataURL, gcpSecretManagerURL = server.URL+"/metadata", server.URL+"/secretmanager"
+	t.Cleanup(func() { gcpMetadataURL, gcpSecretManagerURL = oldMetadata, oldSecretManager })
+	t.Setenv("GCP_ACCESS_TOKEN", "")
+	clearConfigLayer(t, config.SourceSecret)
+}
+
+func TestResolveSecretURIs(t *testing.T) {
+	withFakeGoogleCloud(t, map[string]string{
+		"projects/labs/secrets/weather-api-key/versions/latest:access": "sm-key",
+		"projects/labs/secrets/admin-key/versions/3:access":            "admin-v3",
+	})
+	t.Setenv("WEATHER_API_KEY", "gcp-sm://projects/labs/secrets/weather-api-key")
+	t.Setenv("ADMIN_API_KEY", "gcp-sm://projects/labs/secrets/admin-key/versions/3")
+	t.Setenv("HMAC_SECRET", "plain-secret")
+
+	assert.NoError(t, resolveSecretURIs())
+	assert.Equal(t, "sm-key", config.String("WEATHER_API_KEY"))
+	assert.Equal(t, "admin-v3", config.String("ADMIN_API_KEY"))
+	assert.Equal(t, "plain-secret", config.String("HMAC_SECRET"))
+}
+
+func TestResolveSecretURIs_LocalAccessToken(t *testing.T) {
+	withFakeGoogleCloud(t, map[string]string{
+		"projects/labs/secrets/weather-api-key/versions/latest:access": "sm-key",
+	})
+	gcpMetadataURL = "http://127.0.0.1:1"
+	t.Setenv("GCP_ACCESS_TOKEN", "local-token")
+	t.Setenv("WEATHER_API_KEY", "gcp-sm://projects/labs/secrets/weather-api-key")
+
+	assert.NoError(t, resolveSecretURIs())
+	assert.Equal(t, "sm-key", config.String("WEATHER_API_KEY"))
+}
+
+func TestResolveSecretURIs_Errors(t *testing.T) {
+	withFakeGoogleCloud(t, nil)
+
+	t.Setenv("WEATHER_API_KEY", "gcp-sm://projects/labs/secrets/missing")
+	assert.EqualError(t, resolveSecretURIs(), "WEATHER_API_KEY: failed to access secret projects/labs/secrets/missing/versions/latest: status 404")
+
+	t.Setenv("WEATHER_API_KEY", "gcp-sm://weather-api-key")
+	assert.EqualError(t, resolveSecretURIs(), `WEATHER_API_KEY: invalid secret URI "gcp-sm://weather-api-key" (expected gcp-sm://projects/P/secrets/S)`)
+}
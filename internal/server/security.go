@@ -0,0 +1,92 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+// Cabeçalhos de segurança enviados em todas as respostas
+type securityHeaders struct {
+	enabled      bool
+	frameOptions string
+	hstsMaxAge   time.Duration
+	htmlPolicy   string
+}
+
+// Política das páginas HTML: só o Swagger UI do CDN e o script de
+// inicialização embutido (pelo hash) podem executar
+var docsContentSecurityPolicy = fmt.Sprintf(
+	"default-src 'none'; script-src https://unpkg.com 'sha256-%s'; style-src https://unpkg.com 'unsafe-inline'; img-src 'self' data: https://unpkg.com; connect-src 'self'; frame-ancestors 'none'; base-uri 'none'",
+	scriptHash(swaggerUIInitScript),
+)
+
+// SECURITY_HEADERS=false desliga os cabeçalhos; X_FRAME_OPTIONS troca o
+// padrão DENY, HSTS_MAX_AGE (ex.: 8760h) liga o Strict-Transport-Security,
+// que só faz sentido quando o serviço é acessado por HTTPS, e
+// CONTENT_SECURITY_POLICY substitui a política das páginas HTML
+func securityHeadersFromEnv() securityHeaders {
+	headers := securityHeaders{enabled: true, frameOptions: "DENY", htmlPolicy: docsContentSecurityPolicy}
+
+	if value := config.String("SECURITY_HEADERS"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			log.Printf("WARNING: ignoring invalid SECURITY_HEADERS %q", value)
+		} else {
+			headers.enabled = enabled
+		}
+	}
+	if value := config.String("X_FRAME_OPTIONS"); value != "" {
+		headers.frameOptions = value
+	}
+	if value := config.String("CONTENT_SECURITY_POLICY"); value != "" {
+		headers.htmlPolicy = value
+	}
+	if value := config.String("HSTS_MAX_AGE"); value != "" {
+		maxAge, err := time.ParseDuration(value)
+		if err != nil || maxAge < 0 {
+			log.Printf("WARNING: ignoring invalid HSTS_MAX_AGE %q", value)
+		} else {
+			headers.hstsMaxAge = maxAge
+		}
+	}
+	return headers
+}
+
+func (s securityHeaders) middleware(next http.Handler) http.Handler {
+	if !s.enabled {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("X-Frame-Options", s.frameOptions)
+		if s.hstsMaxAge > 0 {
+			w.Header().Set("Strict-Transport-Security", fmt.Sprintf("max-age=%d; includeSubDomains", int(s.hstsMaxAge.Seconds())))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Content-Security-Policy das páginas HTML (/docs)
+func (s securityHeaders) htmlPage(next http.Handler) http.Handler {
+	if !s.enabled {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Security-Policy", s.htmlPolicy)
+		next.ServeHTTP(w, r)
+	})
+}
+
+func scriptHash(script string) string {
+	sum := sha256.Sum256([]byte(script))
+	return base64.StdEncoding.EncodeToString(sum[:])
+}
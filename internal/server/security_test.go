@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("HSTS_MAX_AGE", "8760h")
+
+	router := s.router()
+	tests := []struct {
+		path        string
+		expectsCSP  bool
+		contentType string
+	}{
+		{"/", false, "application/json"},
+		{"/docs", true, "text/html; charset=utf-8"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.path, nil)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.Equal(t, "nosniff", rr.Header().Get("X-Content-Type-Options"))
+			assert.Equal(t, "DENY", rr.Header().Get("X-Frame-Options"))
+			assert.Equal(t, "max-age=31536000; includeSubDomains", rr.Header().Get("Strict-Transport-Security"))
+			assert.Equal(t, tt.expectsCSP, rr.Header().Get("Content-Security-Policy") != "")
+		})
+	}
+}
+
+func TestSecurityHeaders_DocsPolicyAllowsInitScript(t *testing.T) {
+	// O hash liberado precisa corresponder ao script inline da página
+	start := strings.Index(swaggerUIPage, "<script>") + len("<script>")
+	end := strings.Index(swaggerUIPage[start:], "</script>") + start
+
+	assert.Contains(t, docsContentSecurityPolicy, "'sha256-"+scriptHash(swaggerUIPage[start:end])+"'")
+}
+
+func TestSecurityHeaders_Configuration(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Run("Disabled", func(t *testing.T) {
+		t.Setenv("SECURITY_HEADERS", "false")
+
+		req, err := http.NewRequest("GET", "/docs", nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		s.router().ServeHTTP(rr, req)
+
+		assert.Empty(t, rr.Header().Get("X-Content-Type-Options"))
+		assert.Empty(t, rr.Header().Get("Content-Security-Policy"))
+	})
+
+	t.Run("Overrides", func(t *testing.T) {
+		t.Setenv("X_FRAME_OPTIONS", "SAMEORIGIN")
+		t.Setenv("CONTENT_SECURITY_POLICY", "default-src 'self'")
+
+		req, err := http.NewRequest("GET", "/docs", nil)
+		assert.NoError(t, err)
+		rr := httptest.NewRecorder()
+		s.router().ServeHTTP(rr, req)
+
+		assert.Equal(t, "SAMEORIGIN", rr.Header().Get("X-Frame-Options"))
+		assert.Equal(t, "default-src 'self'", rr.Header().Get("Content-Security-Policy"))
+		assert.Empty(t, rr.Header().Get("Strict-Transport-Security"))
+	})
+}
@@ -0,0 +1,289 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/graphql-go/graphql"
+	"github.com/weather-service/config"
+	"github.com/weather-service/internal/cache"
+	"github.com/weather-service/internal/cep"
+	"github.com/weather-service/internal/weather"
+	"golang.org/x/crypto/acme/autocert"
+	"golang.org/x/sync/singleflight"
+)
+
+const defaultACMECacheDir = "acme-cache"
+
+// Limites de tempo das conexões. Protegem contra clientes lentos que
+// seguram conexões abertas (slowloris); o stream SSE remove o limite de
+// escrita da própria resposta
+var (
+	defaultReadHeaderTimeout = 10 * time.Second
+	defaultReadTimeout       = 30 * time.Second
+	defaultWriteTimeout      = 60 * time.Second
+	defaultIdleTimeout       = 120 * time.Second
+)
+
+// Estado compartilhado pelos handlers: clientes dos provedores, caches,
+// stores e publicadores. cmd/server monta um com New; os testes montam o
+// seu, com clientes apontando para servidores falsos ou em memória
+type Server struct {
+	viaCEP     cepClient
+	brasilAPI  cepClient
+	weather    weatherAPIClient
+	ibge       municipalityClient
+	zippopotam postalCodeClient
+
+	// Respostas da WeatherAPI guardadas em memória, indexadas pelo endpoint e
+	// pelos parâmetros da chamada. Além de evitar chamadas repetidas dentro do
+	// TTL, servem de reserva quando a cota da WeatherAPI se esgota. O tamanho é
+	// limitado por WEATHER_CACHE_MAX_ENTRIES e runWeatherCachePruner descarta as
+	// entradas velhas demais até para servir de reserva
+	weatherCache *cache.Store
+	// Agrupa as chamadas simultâneas à WeatherAPI com o mesmo tenant, subject,
+	// endpoint e parâmetros
+	weatherFlight singleflight.Group
+	viaCEPFlight  singleflight.Group
+	// CEPs (só dígitos) que os provedores disseram não existir. Clientes que
+	// repetem a requisição de um CEP bem formado mas inexistente recebem o 404
+	// sem nova consulta ao ViaCEP
+	notFoundCEPs *cache.Store
+	// Municípios já resolvidos por código. Os códigos não mudam, então a
+	// resposta do IBGE vale enquanto o processo estiver no ar
+	municipalities sync.Map
+	// Cache de endereços, configurado em serve. Nil desliga o cache
+	addresses addressCache
+
+	// Registro das consultas, configurado em serve. Nil desliga o registro
+	lookups lookupStore
+	// Regras de alerta. Em memória, perdidas ao reiniciar, a menos que o
+	// registro das consultas esteja ligado: aí ficam no mesmo banco (alertrules.go)
+	subscriptions subscriptionStore
+	// Publicadores configurados em serve. Vazio desliga a publicação
+	publishers []readingPublisher
+
+	graphqlSchema graphql.Schema
+	deepHealth    *deepHealthCache
+	// Indica que o aquecimento do cache terminou e a instância pode receber tráfego
+	cacheWarm atomic.Bool
+	// Indica que serve abriu os stores (registro das consultas, regras de alerta,
+	// cache de endereços, publicadores)
+	storesReady atomic.Bool
+}
+
+// Provedores externos de um Server
+type Clients struct {
+	viaCEP     cepClient
+	brasilAPI  cepClient
+	weather    weatherAPIClient
+	ibge       municipalityClient
+	zippopotam postalCodeClient
+}
+
+// Clientes dos serviços reais, com o transporte compartilhado
+func DefaultClients() Clients {
+	return Clients{
+		viaCEP:     cep.NewViaCEP(cep.DefaultViaCEPURL, sharedTransport{}),
+		brasilAPI:  cep.NewBrasilAPI(cep.DefaultBrasilAPIURL, sharedTransport{}),
+		weather:    weather.NewClient(weather.DefaultBaseURL, sharedTransport{}),
+		ibge:       cep.NewIBGE(cep.DefaultIBGEURL, sharedTransport{}),
+		zippopotam: cep.NewZippopotam(cep.DefaultZippopotamURL, sharedTransport{}),
+	}
+}
+
+// Server com os clientes informados, caches vazios e as regras de alerta em
+// memória. O registro das consultas, o cache de endereços e os publicadores
+// são abertos em serve, conforme a configuração
+func New(clients Clients) *Server {
+	s := &Server{
+		viaCEP:        clients.viaCEP,
+		brasilAPI:     clients.brasilAPI,
+		weather:       clients.weather,
+		ibge:          clients.ibge,
+		zippopotam:    clients.zippopotam,
+		weatherCache:  cache.New(),
+		notFoundCEPs:  cache.New(),
+		subscriptions: newMemorySubscriptionStore(),
+	}
+	s.graphqlSchema = s.mustGraphQLSchema()
+	s.deepHealth = &deepHealthCache{now: time.Now, probes: s.dependencyProbes()}
+	return s
+}
+
+// Sobe o servidor na porta PORT (padrão 8080). O HTTPS pode ser terminado
+// pelo próprio binário, para implantações sem load balancer:
+//   - TLS_CERT_FILE e TLS_KEY_FILE usam um certificado existente
+//   - ACME_DOMAINS obtém e renova certificados do Let's Encrypt (PORT passa
+//     a ser 443 por padrão e o desafio HTTP é atendido na porta 80)
+//
+// HTTP_REDIRECT_PORT sobe junto um listener HTTP que redireciona para o
+// HTTPS, e TLS_CLIENT_CA_FILE passa a exigir certificados de cliente
+// assinados por essa CA (mTLS). Com LISTEN_SOCKET o serviço escuta em um
+// socket Unix no lugar da porta TCP, para ficar atrás de um proxy local
+func runServer(handler http.Handler) error {
+	certFile, keyFile := config.String("TLS_CERT_FILE"), config.String("TLS_KEY_FILE")
+	socketPath := config.String("LISTEN_SOCKET")
+	acmeDomains := config.List("ACME_DOMAINS", nil)
+	clientCAFile := config.String("TLS_CLIENT_CA_FILE")
+	redirectPort := config.String("HTTP_REDIRECT_PORT")
+
+	port := config.String("PORT")
+	if port == "" {
+		port = "8080"
+		if len(acmeDomains) > 0 {
+			port = "443"
+		}
+	}
+
+	switch {
+	case len(acmeDomains) > 0 && (certFile != "" || keyFile != ""):
+		return errors.New("ACME_DOMAINS cannot be combined with TLS_CERT_FILE and TLS_KEY_FILE")
+	case len(acmeDomains) == 0 && certFile == "" && keyFile == "":
+		if clientCAFile != "" {
+			return errors.New("TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE or ACME_DOMAINS")
+		}
+		listener, err := listen(port, socketPath)
+		if err != nil {
+			return err
+		}
+		log.Printf("Server starting on %s", listener.Addr())
+		return newHTTPServer(":"+port, handler).Serve(listener)
+	case len(acmeDomains) == 0 && (certFile == "" || keyFile == ""):
+		return errors.New("TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+	}
+
+	server := newHTTPServer(":"+port, handler)
+	server.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+	redirect := httpsRedirect(port)
+
+	if len(acmeDomains) > 0 {
+		manager := acmeManager(acmeDomains)
+		server.TLSConfig = manager.TLSConfig()
+		server.TLSConfig.MinVersion = tls.VersionTLS12
+
+		// O desafio HTTP-01 do Let's Encrypt chega pela porta 80
+		redirect = manager.HTTPHandler(redirect)
+		if redirectPort == "" {
+			redirectPort = "80"
+		}
+		log.Printf("Obtaining certificates via ACME for %v", acmeDomains)
+	}
+
+	if clientCAFile != "" {
+		if err := requireClientCerts(server.TLSConfig, clientCAFile); err != nil {
+			return err
+		}
+		log.Printf("Requiring client certificates signed by %s", clientCAFile)
+	}
+
+	if redirectPort != "" {
+		go func() {
+			log.Printf("Redirecting HTTP on port %s to HTTPS", redirectPort)
+			if err := newHTTPServer(":"+redirectPort, redirect).ListenAndServe(); err != nil {
+				log.Printf("ERROR: HTTP redirect listener stopped: %v", err)
+			}
+		}()
+	}
+
+	listener, err := listen(port, socketPath)
+	if err != nil {
+		return err
+	}
+	log.Printf("Server starting with TLS on %s", listener.Addr())
+	return server.ServeTLS(listener, certFile, keyFile)
+}
+
+// Escuta na porta TCP ou, se definido, no socket Unix. Um socket que
+// sobrou de uma execução anterior é removido antes
+func listen(port, socketPath string) (net.Listener, error) {
+	if socketPath == "" {
+		return net.Listen("tcp", ":"+port)
+	}
+
+	if info, err := os.Stat(socketPath); err == nil && info.Mode()&os.ModeSocket != 0 {
+		if err := os.Remove(socketPath); err != nil {
+			return nil, fmt.Errorf("failed to remove stale LISTEN_SOCKET: %w", err)
+		}
+	}
+	return net.Listen("unix", socketPath)
+}
+
+// Servidor com os limites de tempo de HTTP_READ_HEADER_TIMEOUT,
+// HTTP_READ_TIMEOUT, HTTP_WRITE_TIMEOUT e HTTP_IDLE_TIMEOUT
+func newHTTPServer(addr string, handler http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: config.Duration("HTTP_READ_HEADER_TIMEOUT", defaultReadHeaderTimeout),
+		ReadTimeout:       config.Duration("HTTP_READ_TIMEOUT", defaultReadTimeout),
+		WriteTimeout:      config.Duration("HTTP_WRITE_TIMEOUT", defaultWriteTimeout),
+		IdleTimeout:       config.Duration("HTTP_IDLE_TIMEOUT", defaultIdleTimeout),
+	}
+}
+
+// Certificados automáticos para os domínios configurados, guardados em
+// ACME_CACHE_DIR para sobreviver a reinícios sem estourar os limites do
+// Let's Encrypt. ACME_EMAIL recebe os avisos de expiração
+func acmeManager(domains []string) *autocert.Manager {
+	cacheDir := config.String("ACME_CACHE_DIR")
+	if cacheDir == "" {
+		cacheDir = defaultACMECacheDir
+	}
+
+	return &autocert.Manager{
+		Prompt:     autocert.AcceptTOS,
+		HostPolicy: autocert.HostWhitelist(domains...),
+		Cache:      autocert.DirCache(cacheDir),
+		Email:      config.String("ACME_EMAIL"),
+	}
+}
+
+// Exige e valida certificados de cliente emitidos pelas CAs do arquivo PEM
+func requireClientCerts(config *tls.Config, caFile string) error {
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return fmt.Errorf("failed to read TLS_CLIENT_CA_FILE: %w", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return errors.New("TLS_CLIENT_CA_FILE contains no valid certificates")
+	}
+
+	config.ClientCAs = pool
+	config.ClientAuth = tls.RequireAndVerifyClientCert
+	return nil
+}
+
+// Common Name do certificado apresentado pelo cliente via mTLS, ou vazio
+func clientCertCN(r *http.Request) string {
+	if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+		return ""
+	}
+	return r.TLS.PeerCertificates[0].Subject.CommonName
+}
+
+// Redireciona para o mesmo caminho em HTTPS. 308 preserva o método e o corpo
+func httpsRedirect(httpsPort string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		if httpsPort != "443" {
+			host = net.JoinHostPort(host, httpsPort)
+		}
+
+		http.Redirect(w, r, "https://"+host+r.URL.RequestURI(), http.StatusPermanentRedirect)
+	})
+}
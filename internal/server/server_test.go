@@ -0,0 +1,183 @@
+package server
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPSRedirect(t *testing.T) {
+	tests := []struct {
+		name      string
+		host      string
+		target    string
+		httpsPort string
+		expected  string
+	}{
+		{"Default HTTPS port", "weather.example.com", "/v1/weather/01310100?units=c", "443", "https://weather.example.com/v1/weather/01310100?units=c"},
+		{"Custom HTTPS port", "weather.example.com:8080", "/docs", "8443", "https://weather.example.com:8443/docs"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest("POST", tt.target, nil)
+			req.Host = tt.host
+
+			rr := httptest.NewRecorder()
+			httpsRedirect(tt.httpsPort).ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusPermanentRedirect, rr.Code)
+			assert.Equal(t, tt.expected, rr.Header().Get("Location"))
+		})
+	}
+}
+
+func TestRunServer_IncompleteTLSConfig(t *testing.T) {
+	t.Setenv("TLS_CERT_FILE", "cert.pem")
+
+	err := runServer(http.NotFoundHandler())
+	assert.EqualError(t, err, "TLS_CERT_FILE and TLS_KEY_FILE must be set together")
+}
+
+func TestRunServer_ClientCAWithoutTLS(t *testing.T) {
+	t.Setenv("TLS_CLIENT_CA_FILE", "ca.pem")
+
+	err := runServer(http.NotFoundHandler())
+	assert.EqualError(t, err, "TLS_CLIENT_CA_FILE requires TLS_CERT_FILE and TLS_KEY_FILE or ACME_DOMAINS")
+}
+
+func TestRunServer_ACMEWithCertificateFiles(t *testing.T) {
+	t.Setenv("ACME_DOMAINS", "weather.example.com")
+	t.Setenv("TLS_CERT_FILE", "cert.pem")
+	t.Setenv("TLS_KEY_FILE", "key.pem")
+
+	err := runServer(http.NotFoundHandler())
+	assert.EqualError(t, err, "ACME_DOMAINS cannot be combined with TLS_CERT_FILE and TLS_KEY_FILE")
+}
+
+func TestNewHTTPServer_Timeouts(t *testing.T) {
+	server := newHTTPServer(":8080", http.NotFoundHandler())
+	assert.Equal(t, defaultReadHeaderTimeout, server.ReadHeaderTimeout)
+	assert.Equal(t, defaultReadTimeout, server.ReadTimeout)
+	assert.Equal(t, defaultWriteTimeout, server.WriteTimeout)
+	assert.Equal(t, defaultIdleTimeout, server.IdleTimeout)
+
+	t.Setenv("HTTP_READ_HEADER_TIMEOUT", "2s")
+	t.Setenv("HTTP_READ_TIMEOUT", "5s")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "0")
+	t.Setenv("HTTP_IDLE_TIMEOUT", "invalid")
+
+	server = newHTTPServer(":8080", http.NotFoundHandler())
+	assert.Equal(t, 2*time.Second, server.ReadHeaderTimeout)
+	assert.Equal(t, 5*time.Second, server.ReadTimeout)
+	assert.Equal(t, time.Duration(0), server.WriteTimeout)
+	assert.Equal(t, defaultIdleTimeout, server.IdleTimeout)
+}
+
+func TestListen_UnixSocket(t *testing.T) {
+	socketPath := filepath.Join(t.TempDir(), "weather.sock")
+
+	// Socket deixado por uma execução anterior
+	stale, err := net.Listen("unix", socketPath)
+	assert.NoError(t, err)
+	stale.(*net.UnixListener).SetUnlinkOnClose(false)
+	stale.Close()
+
+	listener, err := listen("8080", socketPath)
+	assert.NoError(t, err)
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok"))
+	})}
+	go server.Serve(listener)
+	defer server.Close()
+
+	client := &http.Client{Transport: &http.Transport{
+		DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+			return (&net.Dialer{}).DialContext(ctx, "unix", socketPath)
+		},
+	}}
+	resp, err := client.Get("http://weather/")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "ok", string(body))
+}
+
+func TestListen_SocketPathIsRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "weather.sock")
+	assert.NoError(t, os.WriteFile(path, []byte("data"), 0o600))
+
+	_, err := listen("8080", path)
+	assert.Error(t, err)
+
+	// O arquivo não é apagado
+	_, statErr := os.Stat(path)
+	assert.NoError(t, statErr)
+}
+
+func TestACMEManager(t *testing.T) {
+	t.Setenv("ACME_CACHE_DIR", t.TempDir())
+	t.Setenv("ACME_EMAIL", "ops@example.com")
+
+	manager := acmeManager([]string{"weather.example.com"})
+	assert.Equal(t, "ops@example.com", manager.Email)
+	assert.NoError(t, manager.HostPolicy(context.Background(), "weather.example.com"))
+	assert.Error(t, manager.HostPolicy(context.Background(), "other.example.com"))
+}
+
+func TestRequireClientCerts(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Weather Test CA"},
+		NotBefore:             time.Now(),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	assert.NoError(t, err)
+
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	invalidFile := filepath.Join(dir, "invalid.pem")
+	assert.NoError(t, os.WriteFile(invalidFile, []byte("not a certificate"), 0o600))
+
+	config := &tls.Config{}
+	assert.NoError(t, requireClientCerts(config, caFile))
+	assert.Equal(t, tls.RequireAndVerifyClientCert, config.ClientAuth)
+	assert.NotNil(t, config.ClientCAs)
+
+	assert.Error(t, requireClientCerts(&tls.Config{}, invalidFile))
+	assert.Error(t, requireClientCerts(&tls.Config{}, filepath.Join(dir, "missing.pem")))
+}
+
+func TestClientKey_UsesCertificateCN(t *testing.T) {
+	req := httptest.NewRequest("GET", "/", nil)
+	req.RemoteAddr = "192.0.2.1:1234"
+	assert.Equal(t, "192.0.2.1", clientKey(req, 0))
+
+	req.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "billing-service"}}}}
+	assert.Equal(t, "billing-service", clientCertCN(req))
+	assert.Equal(t, "cn:billing-service", clientKey(req, 0))
+}
@@ -0,0 +1,122 @@
+package server
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"log"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+const (
+	signatureHeader          = "X-Signature"
+	signatureTimestampHeader = "X-Signature-Timestamp"
+	defaultSignatureMaxSkew  = 5 * time.Minute
+)
+
+// Verificação de requisições assinadas com um segredo compartilhado. O
+// cliente envia em X-Signature-Timestamp o horário Unix (segundos) e em
+// X-Signature o HMAC-SHA256 em hexadecimal de "<timestamp>\n<path?query>"
+type requestSigner struct {
+	secret  []byte
+	maxSkew time.Duration
+	now     func() time.Time
+
+	// Assinaturas já aceitas dentro da janela de tolerância, para recusar replays
+	mu   sync.Mutex
+	seen map[string]time.Time
+}
+
+// Ligada por HMAC_SECRET; HMAC_MAX_SKEW define a tolerância de relógio
+func requestSignerFromEnv() *requestSigner {
+	secret := config.String("HMAC_SECRET")
+	if secret == "" {
+		return nil
+	}
+
+	maxSkew := defaultSignatureMaxSkew
+	if value := config.String("HMAC_MAX_SKEW"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			log.Printf("WARNING: ignoring invalid HMAC_MAX_SKEW %q", value)
+		} else {
+			maxSkew = parsed
+		}
+	}
+	return newRequestSigner(secret, maxSkew)
+}
+
+func newRequestSigner(secret string, maxSkew time.Duration) *requestSigner {
+	return &requestSigner{
+		secret:  []byte(secret),
+		maxSkew: maxSkew,
+		now:     time.Now,
+		seen:    make(map[string]time.Time),
+	}
+}
+
+func (s *requestSigner) sign(timestamp, requestURI string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(timestamp + "\n" + requestURI))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Retorna a mensagem de erro pública, ou vazio quando a assinatura é válida
+func (s *requestSigner) verify(r *http.Request) string {
+	timestamp := r.Header.Get(signatureTimestampHeader)
+	signature := r.Header.Get(signatureHeader)
+	if timestamp == "" || signature == "" {
+		return "missing request signature"
+	}
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return "invalid request signature"
+	}
+	now := s.now()
+	signedAt := time.Unix(seconds, 0)
+	if signedAt.Before(now.Add(-s.maxSkew)) || signedAt.After(now.Add(s.maxSkew)) {
+		return "request signature expired"
+	}
+
+	expected := s.sign(timestamp, r.URL.RequestURI())
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return "invalid request signature"
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for seen, at := range s.seen {
+		if now.Sub(at) > 2*s.maxSkew {
+			delete(s.seen, seen)
+		}
+	}
+	if _, replayed := s.seen[signature]; replayed {
+		return "request signature already used"
+	}
+	s.seen[signature] = now
+	return ""
+}
+
+func requireSignature(signer *requestSigner) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if signer == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if message := signer.verify(r); message != "" {
+				log.Printf("Rejected signed request to %s: %s", r.URL.Path, message)
+				writeResponse(w, r, http.StatusUnauthorized, ErrorResponse{Message: message})
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireSignature(t *testing.T) {
+	now := time.Unix(1760000000, 0)
+	signer := newRequestSigner("segredo", 5*time.Minute)
+	signer.now = func() time.Time { return now }
+
+	handler := requireSignature(signer)(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+
+	fresh := strconv.FormatInt(now.Unix(), 10)
+	stale := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name            string
+		path            string
+		timestamp       string
+		signature       string
+		expectedStatus  int
+		expectedMessage string
+	}{
+		{"Missing signature", "/v1/weather/01310100", "", "", http.StatusUnauthorized, "missing request signature"},
+		{"Valid signature", "/v1/weather/01310100?units=c", fresh, signer.sign(fresh, "/v1/weather/01310100?units=c"), http.StatusOK, ""},
+		{"Replayed signature", "/v1/weather/01310100?units=c", fresh, signer.sign(fresh, "/v1/weather/01310100?units=c"), http.StatusUnauthorized, "request signature already used"},
+		{"Signature for another path", "/v1/weather/20040020", fresh, signer.sign(fresh, "/v1/weather/01310100"), http.StatusUnauthorized, "invalid request signature"},
+		{"Expired timestamp", "/v1/weather/01310100", stale, signer.sign(stale, "/v1/weather/01310100"), http.StatusUnauthorized, "request signature expired"},
+		{"Invalid timestamp", "/v1/weather/01310100", "ontem", "abc", http.StatusUnauthorized, "invalid request signature"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", tt.path, nil)
+			assert.NoError(t, err)
+			req.Header.Set("X-Signature-Timestamp", tt.timestamp)
+			req.Header.Set("X-Signature", tt.signature)
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.Contains(t, rr.Body.String(), tt.expectedMessage)
+		})
+	}
+}
+
+func TestRequireSignature_Router(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("HMAC_SECRET", "segredo")
+
+	req, err := http.NewRequest("GET", "/v1/weather/123", nil)
+	assert.NoError(t, err)
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusUnauthorized, rr.Code)
+
+	// Health check continua público
+	req, err = http.NewRequest("GET", "/", nil)
+	assert.NoError(t, err)
+	rr = httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
@@ -0,0 +1,77 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// Corpo aceito pelos incoming webhooks do Slack. Text é o resumo mostrado nas
+// notificações; Blocks, a mensagem formatada no canal
+type slackMessage struct {
+	Text   string       `json:"text"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string      `json:"type"`
+	Text     *slackText  `json:"text,omitempty"`
+	Fields   []slackText `json:"fields,omitempty"`
+	Elements []slackText `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+func slackMarkdown(text string) slackText {
+	return slackText{Type: "mrkdwn", Text: text}
+}
+
+// Mensagem do disparo de uma regra: cidade, CEP, condição e as temperaturas
+// atuais em cada escala
+func slackAlertMessage(event WebhookEvent, weather WeatherResponse) slackMessage {
+	cep := weather.cep
+	if cep == "" {
+		cep = event.CEP
+	}
+	condition := fmt.Sprintf("%s %s %g", event.Metric, event.Operator, event.Threshold)
+
+	var temperatures []slackText
+	for _, temp := range []struct {
+		label string
+		value *float64
+		unit  string
+	}{
+		{"Celsius", weather.TempC, "°C"},
+		{"Fahrenheit", weather.TempF, "°F"},
+		{"Kelvin", weather.TempK, "K"},
+	} {
+		if temp.value != nil {
+			temperatures = append(temperatures, slackMarkdown(fmt.Sprintf("*%s*\n%.1f %s", temp.label, *temp.value, temp.unit)))
+		}
+	}
+
+	return slackMessage{
+		Text: fmt.Sprintf("Alerta de temperatura em %s (CEP %s): %s, valor atual %.1f", event.City, cep, condition, event.Value),
+		Blocks: []slackBlock{
+			{Type: "section", Text: &slackText{Type: "mrkdwn", Text: fmt.Sprintf(
+				":thermometer: *Alerta de temperatura em %s* (CEP %s)\nCondição `%s` atingida: valor atual *%.1f*",
+				event.City, cep, condition, event.Value)}},
+			{Type: "section", Fields: temperatures},
+			{Type: "context", Elements: []slackText{slackMarkdown(fmt.Sprintf(
+				"Regra `%s` · %s", event.SubscriptionID, event.FiredAt.Format(time.RFC3339)))}},
+		},
+	}
+}
+
+// POST da mensagem no incoming webhook da regra
+func deliverSlack(ctx context.Context, webhookURL string, message slackMessage) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	return postAlert(ctx, webhookURL, body, nil)
+}
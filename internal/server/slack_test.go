@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSlackAlertMessage(t *testing.T) {
+	tempC, tempF, tempK := 36.2, 97.16, 309.35
+	event := WebhookEvent{SubscriptionID: "abc", CEP: "01310100", City: "São Paulo", Metric: "temp_C", Operator: ">", Threshold: 35, Value: 36.2,
+		FiredAt: time.Date(2026, 10, 17, 15, 5, 0, 0, time.UTC)}
+	weather := WeatherResponse{TempC: &tempC, TempF: &tempF, TempK: &tempK, cep: "01310-100", city: "São Paulo"}
+
+	message := slackAlertMessage(event, weather)
+	body, err := json.Marshal(message)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{
+		"text": "Alerta de temperatura em São Paulo (CEP 01310-100): temp_C > 35, valor atual 36.2",
+		"blocks": [
+			{"type": "section", "text": {"type": "mrkdwn", "text": ":thermometer: *Alerta de temperatura em São Paulo* (CEP 01310-100)\nCondição `+"`temp_C > 35`"+` atingida: valor atual *36.2*"}},
+			{"type": "section", "fields": [
+				{"type": "mrkdwn", "text": "*Celsius*\n36.2 °C"},
+				{"type": "mrkdwn", "text": "*Fahrenheit*\n97.2 °F"},
+				{"type": "mrkdwn", "text": "*Kelvin*\n309.4 K"}
+			]},
+			{"type": "context", "elements": [{"type": "mrkdwn", "text": "Regra `+"`abc`"+` · 2026-10-17T15:05:00Z"}]}
+		]
+	}`, string(body))
+}
+
+// Uma regra com channel slack recebe a mensagem formatada, sem assinatura
+func TestPollSubscriptions_Slack(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, fakeWeatherClient{"São Paulo,SP": 36})
+	t.Setenv("WEBHOOK_SECRET", "webhook-secret")
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE", "true")
+
+	received := make(chan *http.Request, 1)
+	var message slackMessage
+	slack := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &message))
+		received <- r
+		w.Write([]byte("ok"))
+	}))
+	defer slack.Close()
+
+	ctx := context.Background()
+	assert.NoError(t, s.subscriptions.create(ctx, Subscription{ID: "hot", CEP: "01310100", Metric: "temp_C", Operator: ">", Threshold: 35, Channel: alertChannelSlack, CallbackURL: slack.URL}))
+	s.pollSubscriptions(ctx)
+
+	select {
+	case r := <-received:
+		assert.Empty(t, r.Header.Get(webhookSignatureHeader))
+		assert.Equal(t, "Alerta de temperatura em São Paulo (CEP 01310-100): temp_C > 35, valor atual 36.0", message.Text)
+	default:
+		t.Fatal("Slack webhook was not called")
+	}
+	stored, _, _ := s.subscriptions.get(ctx, "hot")
+	assert.Equal(t, alertStatusFiring, stored.Status)
+}
@@ -0,0 +1,85 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/weather-service/config"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cabeçalho das respostas montadas com uma resposta vencida do cache
+const staleHeader = "X-Weather-Stale"
+
+// Valor de meta.cache para uma resposta vencida servida no lugar da WeatherAPI
+const metaCacheStale = "stale"
+
+// Quanto esperar pela WeatherAPI antes de servir a resposta vencida do cache
+// (WEATHER_CACHE_STALE_TIMEOUT). Padrão 0: sem resposta vencida, salvo com o
+// circuit breaker aberto ou a cota esgotada
+func weatherCacheStaleTimeout() time.Duration {
+	return config.Duration("WEATHER_CACHE_STALE_TIMEOUT", 0)
+}
+
+// Serve a resposta vencida do cache no lugar da WeatherAPI e marca a
+// consulta, para que a resposta avise que os dados podem estar desatualizados
+func (s *Server) serveStaleWeather(ctx context.Context, body []byte, out interface{}) error {
+	s.weatherCache.Stale()
+	noteUsageCache(ctx, true)
+	noteReadingSource(ctx, true)
+	noteStaleReading(ctx)
+	return weatherAPIFailure(decodeWeatherAPIBody(body, out))
+}
+
+// Espera em segundo plano a chamada que demorou demais e guarda a resposta,
+// para que as próximas requisições já encontrem o cache renovado
+func (s *Server) refreshStaleWeather(cacheKey string, flight <-chan singleflight.Result) {
+	call := <-flight
+	if call.Err != nil {
+		log.Printf("WARNING: Background refresh of %s failed: %v", cacheKey, call.Err)
+		return
+	}
+	result := call.Val.(weatherAPIResult)
+	if result.cached {
+		return
+	}
+	var body interface{}
+	if err := decodeWeatherAPIBody(result.body, &body); err != nil {
+		return
+	}
+	serviceHealth.resolve("weather_api")
+	s.weatherCache.Set(cacheKey, result.body)
+}
+
+// Anota que a temperatura veio de uma resposta vencida do cache
+func noteStaleReading(ctx context.Context) {
+	trace, _ := ctx.Value(readingTraceKey{}).(*readingTrace)
+	if trace == nil {
+		return
+	}
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	trace.stale = true
+}
+
+// Se alguma temperatura da consulta veio de uma resposta vencida do cache
+func staleReading(ctx context.Context) bool {
+	trace, _ := ctx.Value(readingTraceKey{}).(*readingTrace)
+	if trace == nil {
+		return false
+	}
+
+	trace.mu.Lock()
+	defer trace.mu.Unlock()
+	return trace.stale
+}
+
+// Avisa no cabeçalho que a resposta foi montada com dados vencidos
+func writeStaleHeader(w http.ResponseWriter, r *http.Request) {
+	if staleReading(r.Context()) {
+		w.Header().Set(staleHeader, "true")
+	}
+}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/weather-service/internal/weather"
+)
+
+// WeatherAPI cujas respostas o teste troca entre as requisições
+type switchableWeatherClient struct {
+	fetch atomic.Value
+}
+
+func (s *switchableWeatherClient) Fetch(ctx context.Context, endpoint string, params url.Values, key string) ([]byte, error) {
+	return s.fetch.Load().(func() ([]byte, error))()
+}
+
+func (s *switchableWeatherClient) respond(fetch func() ([]byte, error)) {
+	s.fetch.Store(fetch)
+}
+
+func newSwitchableWeatherServer(t *testing.T) (*Server, *switchableWeatherClient) {
+	t.Helper()
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, nil)
+	client := &switchableWeatherClient{}
+	client.respond(func() ([]byte, error) {
+		return []byte(`{"current":{"last_updated_epoch":1760000000,"temp_c":25}}`), nil
+	})
+	s.weather = client
+
+	// Põe São Paulo no cache. Sem WEATHER_CACHE_TTL, a resposta já nasce vencida
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Empty(t, rr.Header().Get(staleHeader))
+	return s, client
+}
+
+func TestWeatherHandler_StaleOnFailure(t *testing.T) {
+	s, client := newSwitchableWeatherServer(t)
+	client.respond(func() ([]byte, error) {
+		return nil, &weather.StatusError{Status: http.StatusBadGateway}
+	})
+
+	// Desligado, a falha da WeatherAPI continua sendo um erro
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+	assert.Equal(t, http.StatusInternalServerError, rr.Code)
+
+	t.Setenv("WEATHER_CACHE_STALE_TIMEOUT", "1s")
+	rr = httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100?units=c&meta=true", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "true", rr.Header().Get(staleHeader))
+
+	var response WeatherResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Equal(t, 25.0, *response.TempC)
+	assert.Equal(t, metaCacheStale, response.Meta.Cache)
+	assert.Equal(t, time.Unix(1760000000, 0).UTC(), *response.Meta.ObservedAt)
+}
+
+func TestWeatherHandler_StaleOnSlowUpstream(t *testing.T) {
+	s, client := newSwitchableWeatherServer(t)
+	release := make(chan struct{})
+	client.respond(func() ([]byte, error) {
+		<-release
+		return []byte(`{"current":{"last_updated_epoch":1760000900,"temp_c":30}}`), nil
+	})
+	t.Setenv("WEATHER_CACHE_STALE_TIMEOUT", "20ms")
+
+	start := time.Now()
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100?units=c", nil))
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, "true", rr.Header().Get(staleHeader))
+	assert.JSONEq(t, `{"temp_C":25}`, rr.Body.String())
+
+	// A chamada lenta termina em segundo plano e renova o cache, que as
+	// próximas requisições usam sem chamar a WeatherAPI
+	close(release)
+	t.Setenv("WEATHER_CACHE_TTL", "1m")
+	assert.Eventually(t, func() bool {
+		rr := httptest.NewRecorder()
+		s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100?units=c", nil))
+		return rr.Code == http.StatusOK && rr.Body.String() == `{"temp_C":30}`+"\n" && rr.Header().Get(staleHeader) == ""
+	}, time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"sync"
+
+	"github.com/weather-service/config"
+)
+
+// Modos de STARTUP_CHECK: "fail" (padrão) impede o serviço de subir com a
+// WeatherAPI mal configurada, "degraded" sobe assim mesmo e reporta o
+// problema no health check, e "off" pula a verificação
+const (
+	startupCheckFail     = "fail"
+	startupCheckDegraded = "degraded"
+	startupCheckOff      = "off"
+)
+
+// Localização usada na chamada de teste da WeatherAPI
+const startupCheckLocation = "São Paulo"
+
+// Problemas conhecidos do serviço, indexados pela dependência afetada
+type healthProblems struct {
+	mu       sync.RWMutex
+	problems map[string]string
+}
+
+var serviceHealth = &healthProblems{problems: map[string]string{}}
+
+func (h *healthProblems) report(dependency string, err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.problems[dependency] = err.Error()
+}
+
+func (h *healthProblems) resolve(dependency string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if _, ok := h.problems[dependency]; ok {
+		log.Printf("Dependency %s recovered", dependency)
+		delete(h.problems, dependency)
+	}
+}
+
+func (h *healthProblems) snapshot() map[string]string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if len(h.problems) == 0 {
+		return nil
+	}
+
+	problems := make(map[string]string, len(h.problems))
+	for dependency, problem := range h.problems {
+		problems[dependency] = problem
+	}
+	return problems
+}
+
+// Verifica a configuração obrigatória antes de subir o servidor, em vez de
+// descobrir a chave ausente ou inválida na primeira requisição
+func (s *Server) checkStartup() error {
+	mode := config.String("STARTUP_CHECK")
+	if mode == "" {
+		mode = startupCheckFail
+	}
+	if mode == startupCheckOff {
+		return nil
+	}
+
+	err := s.verifyWeatherAPIKey()
+	if err == nil {
+		log.Println("WeatherAPI key verified")
+		return nil
+	}
+
+	if mode == startupCheckDegraded {
+		log.Printf("WARNING: starting in degraded mode: %v", err)
+		serviceHealth.report("weather_api", err)
+		return nil
+	}
+	return fmt.Errorf("startup check failed: %w (set STARTUP_CHECK=degraded to start anyway)", err)
+}
+
+// Faz uma chamada real à WeatherAPI; o resultado conta na cota e fica no cache
+func (s *Server) verifyWeatherAPIKey() error {
+	keys, err := weatherAPIKeys()
+	if err != nil {
+		return err
+	}
+	if len(keys) == 0 {
+		return errors.New("WEATHER_API_KEY is not set")
+	}
+
+	var response WeatherAPIResponse
+	if err := s.callWeatherAPI(context.Background(), "current.json", url.Values{"q": {startupCheckLocation}}, &response); err != nil {
+		// Sem cota não dá para testar a chave, mas ela não está errada
+		if errors.Is(err, errQuotaExhausted) {
+			log.Println("WARNING: skipping WeatherAPI key check: quota exhausted")
+			return nil
+		}
+		return fmt.Errorf("weather api check failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Limpa os problemas registrados por outros testes
+func resetServiceHealth(t *testing.T) {
+	t.Helper()
+	serviceHealth = &healthProblems{problems: map[string]string{}}
+	t.Cleanup(func() { serviceHealth = &healthProblems{problems: map[string]string{}} })
+}
+
+func healthStatus(t *testing.T, s *Server) map[string]interface{} {
+	t.Helper()
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var body map[string]interface{}
+	assert.NoError(t, json.Unmarshal(rr.Body.Bytes(), &body))
+	return body
+}
+
+func TestCheckStartup(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		apiKey   string
+		upstream map[string]string
+		expected string
+	}{
+		{"valid key", "", "test-key", map[string]string{"/v1/current.json": `{"current":{"temp_c":25}}`}, ""},
+		{"missing key", "", "", nil, "startup check failed: WEATHER_API_KEY is not set (set STARTUP_CHECK=degraded to start anyway)"},
+		{"rejected key", "fail", "test-key", nil, "startup check failed: weather api check failed: weather API error: status 400 (set STARTUP_CHECK=degraded to start anyway)"},
+		{"check disabled", "off", "", nil, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resetServiceHealth(t)
+			s := newFakeUpstreamServer(t, tt.upstream)
+			t.Setenv("WEATHER_API_KEY", tt.apiKey)
+			t.Setenv("STARTUP_CHECK", tt.mode)
+
+			err := s.checkStartup()
+			if tt.expected == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tt.expected)
+			}
+			assert.Equal(t, "ok", healthStatus(t, s)["status"])
+		})
+	}
+}
+
+func TestCheckStartup_QuotaExhausted(t *testing.T) {
+	resetServiceHealth(t)
+	s := newFakeUpstreamServer(t, nil)
+	withQuota(t, newQuotaBudget(1, 0))
+	weatherAPIQuota.reserve()
+
+	assert.NoError(t, s.checkStartup())
+}
+
+func TestCheckStartup_DegradedMode(t *testing.T) {
+	resetServiceHealth(t)
+	upstream := map[string]string{}
+	s := newFakeUpstreamServer(t, upstream)
+	t.Setenv("STARTUP_CHECK", "degraded")
+
+	assert.NoError(t, s.checkStartup())
+	assert.Equal(t, map[string]interface{}{
+		"status":   "degraded",
+		"problems": map[string]interface{}{"weather_api": "weather api check failed: weather API error: status 400"},
+	}, healthStatus(t, s))
+
+	// A primeira chamada bem-sucedida à WeatherAPI encerra o modo degradado
+	upstream["/ws/01310100/json/"] = viaCEPSaoPaulo
+	upstream["/v1/current.json"] = `{"current":{"temp_c":25}}`
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, map[string]interface{}{"status": "ok"}, healthStatus(t, s))
+}
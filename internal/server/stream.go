@@ -0,0 +1,101 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+const defaultLiveRefreshInterval = time.Minute
+
+// Intervalo das atualizações enviadas pelo WebSocket e pelo stream SSE,
+// configurado em LIVE_REFRESH_INTERVAL (ex.: 30s, 5m)
+func liveRefreshInterval() time.Duration {
+	value := config.String("LIVE_REFRESH_INTERVAL")
+	if value == "" {
+		return defaultLiveRefreshInterval
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil || interval <= 0 {
+		log.Printf("WARNING: ignoring invalid LIVE_REFRESH_INTERVAL %q", value)
+		return defaultLiveRefreshInterval
+	}
+	return interval
+}
+
+func (s *Server) weatherStreamHandler(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeResponse(w, r, http.StatusInternalServerError, ErrorResponse{Message: "streaming not supported"})
+		return
+	}
+
+	units, ok := unitsFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	// Erros de CEP são respondidos normalmente antes de abrir o stream
+	cep := cepParam(r)
+	location, ok := s.resolveLocation(w, r, cep)
+	if !ok {
+		return
+	}
+
+	// O stream dura mais que o HTTP_WRITE_TIMEOUT do servidor
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		log.Printf("WARNING: failed to clear write deadline for stream: %v", err)
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+
+	interval := liveRefreshInterval()
+	log.Printf("Streaming weather for CEP %s every %s", cep, interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.sendWeatherEvent(r.Context(), w, location, units)
+		flusher.Flush()
+
+		select {
+		case <-r.Context().Done():
+			log.Printf("Weather stream for CEP %s closed by client", cep)
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Falhas na WeatherAPI viram um evento "error" e o stream continua, já que
+// costumam ser transitórias
+func (s *Server) sendWeatherEvent(ctx context.Context, w http.ResponseWriter, location string, units temperatureUnits) {
+	current, err := s.getCurrentWeather(ctx, location, false)
+	if err != nil {
+		log.Printf("ERROR: Failed to get temperature for location '%s': %v", location, err)
+		_, body := httpError(err)
+		writeEvent(w, "error", body)
+		return
+	}
+	writeEvent(w, "weather", newWeatherResponse(current.Current.TempC, units))
+}
+
+func writeEvent(w http.ResponseWriter, event string, data interface{}) {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("ERROR: Failed to encode %s event: %v", event, err)
+		return
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event, payload)
+}
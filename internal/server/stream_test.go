@@ -0,0 +1,107 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLiveRefreshInterval(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected time.Duration
+	}{
+		{"", defaultLiveRefreshInterval},
+		{"30s", 30 * time.Second},
+		{"invalid", defaultLiveRefreshInterval},
+		{"-5s", defaultLiveRefreshInterval},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("LIVE_REFRESH_INTERVAL", tt.value)
+		assert.Equal(t, tt.expected, liveRefreshInterval())
+	}
+}
+
+func TestWeatherStreamHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	t.Setenv("LIVE_REFRESH_INTERVAL", "50ms")
+
+	server := httptest.NewServer(s.router())
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, "GET", server.URL+"/weather/01310100/stream?units=c", nil)
+	assert.NoError(t, err)
+
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	// Leitura inicial e a primeira atualização periódica
+	scanner := bufio.NewScanner(resp.Body)
+	var events []string
+	for len(events) < 4 && scanner.Scan() {
+		if line := scanner.Text(); line != "" {
+			events = append(events, line)
+		}
+	}
+	assert.Equal(t, []string{
+		"event: weather", `data: {"temp_C":25}`,
+		"event: weather", `data: {"temp_C":25}`,
+	}, events)
+}
+
+// O stream não pode ser cortado pelo HTTP_WRITE_TIMEOUT do servidor
+func TestWeatherStreamHandler_OutlivesWriteTimeout(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	t.Setenv("LIVE_REFRESH_INTERVAL", "50ms")
+	t.Setenv("HTTP_WRITE_TIMEOUT", "100ms")
+
+	server := httptest.NewUnstartedServer(nil)
+	server.Config = newHTTPServer("", s.router())
+	server.Start()
+	defer server.Close()
+
+	resp, err := http.Get(server.URL + "/weather/01310100/stream")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+
+	scanner := bufio.NewScanner(resp.Body)
+	events := 0
+	for events < 5 && scanner.Scan() {
+		if strings.HasPrefix(scanner.Text(), "data: ") {
+			events++
+		}
+	}
+	assert.Equal(t, 5, events)
+}
+
+func TestWeatherStreamHandler_InvalidCEP(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	req, err := http.NewRequest("GET", "/weather/123/stream", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+	assert.True(t, strings.Contains(rr.Body.String(), "invalid zipcode"))
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/weather-service/config"
+	"github.com/weather-service/internal/telegram"
+)
+
+const (
+	defaultTelegramPollTimeout = 30 * time.Second
+	// Espera depois de uma falha no getUpdates, para não martelar a Bot API
+	telegramRetryDelay = 5 * time.Second
+
+	telegramHelp = "Envie um CEP (ex.: 01310-100) para receber a temperatura atual."
+)
+
+// Bot configurado em TELEGRAM_BOT_TOKEN, ou nil com o modo bot desligado
+func telegramBotFromEnv() *telegram.Client {
+	token := config.String("TELEGRAM_BOT_TOKEN")
+	if token == "" {
+		return nil
+	}
+	baseURL := config.String("TELEGRAM_API_URL")
+	if baseURL == "" {
+		baseURL = telegram.DefaultBaseURL
+	}
+	// O long polling segura a requisição por TELEGRAM_POLL_TIMEOUT
+	client := &http.Client{Timeout: telegramPollTimeout() + 10*time.Second}
+	return telegram.NewClient(strings.TrimSuffix(baseURL, "/"), token, client)
+}
+
+func telegramPollTimeout() time.Duration {
+	return config.Duration("TELEGRAM_POLL_TIMEOUT", defaultTelegramPollTimeout)
+}
+
+// Recebe as mensagens do bot por long polling e responde cada uma até ctx
+// terminar. Roda ao lado do servidor HTTP, com as mesmas consultas da API
+func (s *Server) runTelegramBot(ctx context.Context, bot *telegram.Client) {
+	log.Println("Telegram bot started")
+	var offset int64
+	for {
+		updates, err := bot.GetUpdates(ctx, offset, telegramPollTimeout())
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			log.Printf("WARNING: Failed to get Telegram updates: %v", err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(telegramRetryDelay):
+			}
+			continue
+		}
+
+		for _, update := range updates {
+			// Avançar o offset confirma a atualização, mesmo que a resposta
+			// falhe: uma mensagem problemática não trava as seguintes
+			offset = update.UpdateID + 1
+			if update.Message == nil || update.Message.Text == "" {
+				continue
+			}
+			reply := s.telegramReply(ctx, update.Message.Text)
+			if err := bot.SendMessage(ctx, update.Message.Chat.ID, reply); err != nil {
+				log.Printf("ERROR: Failed to send Telegram reply to chat %d: %v", update.Message.Chat.ID, err)
+			}
+		}
+	}
+}
+
+// Resposta a uma mensagem: a temperatura do CEP enviado ou a ajuda
+func (s *Server) telegramReply(ctx context.Context, text string) string {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "/") {
+		return telegramHelp
+	}
+
+	cep := strings.ReplaceAll(text, " ", "")
+	weather, err := s.lookupWeather(ctx, cep, defaultUnits)
+	switch {
+	case errors.Is(err, ErrInvalidCEP):
+		return "CEP inválido. " + telegramHelp
+	case errors.Is(err, ErrCEPNotFound):
+		return fmt.Sprintf("CEP %s não encontrado.", cep)
+	case err != nil:
+		log.Printf("ERROR: Telegram lookup for CEP %s failed: %v", cep, err)
+		return "Não foi possível consultar a temperatura agora. Tente de novo em instantes."
+	}
+	return fmt.Sprintf("%s (CEP %s)\n%.1f °C · %.1f °F · %.1f K", weather.city, weather.cep, *weather.TempC, *weather.TempF, *weather.TempK)
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTelegramReply(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, fakeWeatherClient{"São Paulo,SP": 25})
+	ctx := context.Background()
+
+	tests := []struct {
+		text     string
+		expected string
+	}{
+		{"01310-100", "São Paulo (CEP 01310-100)\n25.0 °C · 77.0 °F · 298.1 K"},
+		{" 01310 100 ", "São Paulo (CEP 01310-100)\n25.0 °C · 77.0 °F · 298.1 K"},
+		{"/start", telegramHelp},
+		{"oi", "CEP inválido. " + telegramHelp},
+		{"99999999", "CEP 99999999 não encontrado."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			assert.Equal(t, tt.expected, s.telegramReply(ctx, tt.text))
+		})
+	}
+}
+
+// O bot responde cada mensagem recebida e confirma as atualizações no offset
+func TestRunTelegramBot(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, fakeWeatherClient{"São Paulo,SP": 25})
+
+	var polls atomic.Int32
+	replies := make(chan string, 1)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseForm()
+		switch r.URL.Path {
+		case "/bottest-token/getUpdates":
+			if polls.Add(1) == 1 {
+				assert.Equal(t, "0", r.PostForm.Get("offset"))
+				w.Write([]byte(`{"ok":true,"result":[{"update_id":10,"message":{"message_id":1,"chat":{"id":7},"text":"01310100"}}]}`))
+				return
+			}
+			assert.Equal(t, "11", r.PostForm.Get("offset"))
+			w.Write([]byte(`{"ok":true,"result":[]}`))
+		case "/bottest-token/sendMessage":
+			assert.Equal(t, "7", r.PostForm.Get("chat_id"))
+			replies <- r.PostForm.Get("text")
+			w.Write([]byte(`{"ok":true,"result":{}}`))
+		}
+	}))
+	defer api.Close()
+	t.Setenv("TELEGRAM_BOT_TOKEN", "test-token")
+	t.Setenv("TELEGRAM_API_URL", api.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.runTelegramBot(ctx, telegramBotFromEnv())
+		close(done)
+	}()
+
+	select {
+	case reply := <-replies:
+		assert.Equal(t, "São Paulo (CEP 01310-100)\n25.0 °C · 77.0 °F · 298.1 K", reply)
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reply sent")
+	}
+	cancel()
+	<-done
+}
+
+func TestTelegramBotFromEnv(t *testing.T) {
+	t.Setenv("TELEGRAM_BOT_TOKEN", "")
+	assert.Nil(t, telegramBotFromEnv())
+}
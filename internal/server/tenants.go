@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/weather-service/config"
+	"gopkg.in/yaml.v3"
+)
+
+// Um time atendido pelo serviço: suas chaves de API dão acesso aos endpoints
+// e as chamadas que fazem à WeatherAPI usam as chaves e o orçamento do
+// próprio time, para que o consumo seja cobrado separadamente
+type tenant struct {
+	name           string
+	weatherAPIKeys []string
+	quota          *quotaBudget
+}
+
+// Formato de cada entrada do TENANTS_FILE
+type tenantConfig struct {
+	APIKeys        []string `yaml:"api_keys"`
+	WeatherAPIKeys []string `yaml:"weather_api_keys"`
+	DailyBudget    int      `yaml:"daily_budget"`
+	MonthlyBudget  int      `yaml:"monthly_budget"`
+}
+
+type tenantRegistry struct {
+	byAPIKey map[string]*tenant
+	byName   map[string]*tenant
+}
+
+type tenantContextKey struct{}
+
+// Lê os tenants do arquivo em TENANTS_FILE. Retorna nil quando não há
+// arquivo configurado, mantendo as chaves e a cota globais para todos
+func tenantRegistryFromEnv() (*tenantRegistry, error) {
+	path := config.String("TENANTS_FILE")
+	if path == "" {
+		return nil, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open TENANTS_FILE: %w", err)
+	}
+	defer file.Close()
+
+	var configs map[string]tenantConfig
+	decoder := yaml.NewDecoder(file)
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&configs); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("invalid TENANTS_FILE: %w", err)
+	}
+	return newTenantRegistry(configs)
+}
+
+func newTenantRegistry(configs map[string]tenantConfig) (*tenantRegistry, error) {
+	registry := &tenantRegistry{byAPIKey: map[string]*tenant{}, byName: map[string]*tenant{}}
+	for name, config := range configs {
+		if len(config.APIKeys) == 0 {
+			return nil, fmt.Errorf("tenant %q has no api_keys", name)
+		}
+		if len(config.WeatherAPIKeys) == 0 {
+			return nil, fmt.Errorf("tenant %q has no weather_api_keys", name)
+		}
+		if config.DailyBudget < 0 || config.MonthlyBudget < 0 {
+			return nil, fmt.Errorf("tenant %q has a negative budget", name)
+		}
+
+		t := &tenant{
+			name:           name,
+			weatherAPIKeys: config.WeatherAPIKeys,
+			quota:          newQuotaBudget(config.DailyBudget, config.MonthlyBudget),
+		}
+		registry.byName[name] = t
+		for _, key := range config.APIKeys {
+			if other, ok := registry.byAPIKey[key]; ok {
+				return nil, fmt.Errorf("api key of tenant %q is also used by tenant %q", name, other.name)
+			}
+			registry.byAPIKey[key] = t
+		}
+	}
+	return registry, nil
+}
+
+// As chaves dos tenants também são chaves de API aceitas pelo serviço
+func (r *tenantRegistry) validAPIKey(_ context.Context, key string) (bool, error) {
+	_, ok := r.byAPIKey[key]
+	return ok, nil
+}
+
+// Associa a requisição ao tenant dono da X-API-Key. Requisições com chaves
+// que não pertencem a nenhum tenant seguem com as credenciais globais
+func identifyTenant(registry *tenantRegistry) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if registry == nil {
+			return next
+		}
+
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if t, ok := registry.byAPIKey[r.Header.Get(apiKeyHeader)]; ok {
+				r = r.WithContext(context.WithValue(r.Context(), tenantContextKey{}, t))
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func tenantFromContext(ctx context.Context) *tenant {
+	t, _ := ctx.Value(tenantContextKey{}).(*tenant)
+	return t
+}
+
+// Chaves e orçamento da WeatherAPI para a chamada: os do tenant da
+// requisição, quando há um, ou os globais
+func upstreamCredentials(ctx context.Context) ([]string, *quotaBudget, error) {
+	if t := tenantFromContext(ctx); t != nil {
+		return t.weatherAPIKeys, t.quota, nil
+	}
+	keys, err := weatherAPIKeys()
+	return keys, weatherAPIQuota, err
+}
+
+// Nome do tenant para os logs das chamadas à WeatherAPI
+func tenantLabel(ctx context.Context) string {
+	if t := tenantFromContext(ctx); t != nil {
+		return t.name
+	}
+	return "-"
+}
+
+// Consumo da cota global ou, com ?tenant= ou ?subject=, do orçamento de um
+// tenant ou de um subject de JWT
+func quotaHandler(registry *tenantRegistry) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subject := r.URL.Query().Get("subject"); subject != "" {
+			writeResponse(w, r, http.StatusOK, subjectQuotas.status(subject))
+			return
+		}
+
+		name := r.URL.Query().Get("tenant")
+		if name == "" {
+			writeResponse(w, r, http.StatusOK, weatherAPIQuota.status())
+			return
+		}
+
+		var t *tenant
+		if registry != nil {
+			t = registry.byName[name]
+		}
+		if t == nil {
+			writeResponse(w, r, http.StatusNotFound, ErrorResponse{Message: "unknown tenant"})
+			return
+		}
+		writeResponse(w, r, http.StatusOK, t.quota.status())
+	}
+}
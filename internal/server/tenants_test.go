@@ -0,0 +1,157 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/internal/cep"
+	"github.com/weather-service/internal/weather"
+)
+
+const testTenantsFile = `
+team-a:
+  api_keys: [client-a]
+  weather_api_keys: [upstream-a]
+  daily_budget: 1
+team-b:
+  api_keys: [client-b1, client-b2]
+  weather_api_keys: [upstream-b]
+`
+
+func withTenantsFile(t *testing.T, content string) {
+	path := filepath.Join(t.TempDir(), "tenants.yaml")
+	assert.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	t.Setenv("TENANTS_FILE", path)
+}
+
+func TestTenantRegistryFromEnv(t *testing.T) {
+	withTenantsFile(t, testTenantsFile)
+
+	registry, err := tenantRegistryFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "team-b", registry.byAPIKey["client-b2"].name)
+	assert.Equal(t, []string{"upstream-a"}, registry.byName["team-a"].weatherAPIKeys)
+	assert.Equal(t, 1, registry.byName["team-a"].quota.status().Daily.Budget)
+}
+
+func TestTenantRegistryFromEnv_Invalid(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected string
+	}{
+		{"No api keys", "team-a:\n  weather_api_keys: [x]\n", `tenant "team-a" has no api_keys`},
+		{"No upstream keys", "team-a:\n  api_keys: [x]\n", `tenant "team-a" has no weather_api_keys`},
+		{"Negative budget", "team-a:\n  api_keys: [x]\n  weather_api_keys: [y]\n  daily_budget: -1\n", `tenant "team-a" has a negative budget`},
+		{"Shared api key", "team-a:\n  api_keys: [x]\n  weather_api_keys: [y]\nteam-b:\n  api_keys: [x]\n  weather_api_keys: [z]\n", "is also used by tenant"},
+		{"Unknown field", "team-a:\n  api_key: [x]\n", "field api_key not found"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			withTenantsFile(t, tt.content)
+			_, err := tenantRegistryFromEnv()
+			assert.ErrorContains(t, err, tt.expected)
+		})
+	}
+}
+
+// Cada tenant chama a WeatherAPI com a própria chave e consome o próprio orçamento
+func TestWeatherHandler_TenantCredentials(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	withFreshKeyPool(t)
+	withQuota(t, newQuotaBudget(0, 0))
+	withTenantsFile(t, testTenantsFile)
+
+	var usedKeys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/ws/01310100/json/" {
+			w.Write([]byte(viaCEPSaoPaulo))
+			return
+		}
+		usedKeys = append(usedKeys, r.URL.Query().Get("key"))
+		w.Write([]byte(`{"current":{"temp_c":25}}`))
+	}))
+	defer server.Close()
+	s.viaCEP = cep.NewViaCEP(server.URL+"/ws", nil)
+	s.weather = weather.NewClient(server.URL+"/v1", nil)
+
+	router := s.router()
+	request := func(apiKey string) int {
+		req, err := http.NewRequest("GET", "/weather/01310100", nil)
+		assert.NoError(t, err)
+		req.Header.Set("X-API-Key", apiKey)
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+
+		// Sem cache entre as chamadas, para que cada uma vá à WeatherAPI
+		s.weatherCache.Clear()
+		return rr.Code
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, request(""))
+	assert.Equal(t, http.StatusUnauthorized, request("unknown"))
+	assert.Equal(t, http.StatusOK, request("client-a"))
+	assert.Equal(t, http.StatusOK, request("client-b1"))
+	assert.Equal(t, http.StatusOK, request("client-b2"))
+	assert.Equal(t, []string{"upstream-a", "upstream-b", "upstream-b"}, usedKeys)
+
+	// O orçamento diário do team-a acabou; o do team-b e a cota global seguem intactos
+	assert.Equal(t, http.StatusServiceUnavailable, request("client-a"))
+	assert.Equal(t, http.StatusOK, request("client-b1"))
+	assert.Equal(t, 0, weatherAPIQuota.status().Daily.Used)
+}
+
+// Chaves de API_KEYS continuam válidas e usam as credenciais globais
+func TestWeatherHandler_NonTenantKeyUsesGlobalCredentials(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	withFreshKeyPool(t)
+	quota := newQuotaBudget(0, 0)
+	withQuota(t, quota)
+	withTenantsFile(t, testTenantsFile)
+	t.Setenv("API_KEYS", "global-client")
+
+	req, err := http.NewRequest("GET", "/weather/01310100", nil)
+	assert.NoError(t, err)
+	req.Header.Set("X-API-Key", "global-client")
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.Equal(t, 1, quota.status().Daily.Used)
+}
+
+func TestAdminQuotaHandler_Tenant(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	withTenantsFile(t, testTenantsFile)
+	t.Setenv("ADMIN_API_KEY", "admin-secret")
+	router := s.router()
+
+	request := func(path string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", path, nil)
+		assert.NoError(t, err)
+		req.Header.Set("X-API-Key", "admin-secret")
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := request("/admin/quota?tenant=team-a")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var status QuotaStatus
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&status))
+	assert.Equal(t, 1, status.Daily.Budget)
+	assert.Equal(t, 1, *status.Daily.Remaining)
+
+	rr = request("/admin/quota?tenant=team-z")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.JSONEq(t, `{"message":"unknown tenant"}`, rr.Body.String())
+}
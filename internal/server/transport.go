@@ -0,0 +1,68 @@
+package server
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+const (
+	defaultUpstreamMaxIdleConnsPerHost = 100
+	defaultUpstreamIdleConnTimeout     = 90 * time.Second
+	defaultUpstreamKeepAlive           = 30 * time.Second
+)
+
+var (
+	upstreamClientOnce sync.Once
+	sharedUpstream     *http.Client
+)
+
+// Cliente HTTP compartilhado pelas chamadas ao ViaCEP, à BrasilAPI e à
+// WeatherAPI. É criado na primeira chamada, depois de a configuração ser
+// carregada, e reaproveita as conexões entre requisições
+func upstreamClient() *http.Client {
+	upstreamClientOnce.Do(func() {
+		sharedUpstream = &http.Client{Transport: newUpstreamTransport()}
+	})
+	return sharedUpstream
+}
+
+// Repassa as requisições dos clientes dos provedores ao cliente compartilhado,
+// que só pode ser criado depois de a configuração ser carregada
+type sharedTransport struct{}
+
+func (sharedTransport) Do(req *http.Request) (*http.Response, error) {
+	return upstreamClient().Do(req)
+}
+
+// Transporte com o pool de conexões ajustável. O padrão do Go mantém só 2
+// conexões ociosas por host, e com muitas requisições por segundo as demais
+// são fechadas e reabertas (com novo handshake TLS) a cada chamada:
+//   - UPSTREAM_MAX_IDLE_CONNS_PER_HOST: conexões ociosas mantidas por host (padrão 100)
+//   - UPSTREAM_MAX_CONNS_PER_HOST: limite de conexões por host, 0 sem limite
+//   - UPSTREAM_IDLE_CONN_TIMEOUT: tempo até fechar uma conexão ociosa (padrão 90s)
+//   - UPSTREAM_KEEP_ALIVE: intervalo do keep-alive TCP (padrão 30s)
+//   - UPSTREAM_DISABLE_KEEP_ALIVES=true: uma conexão por requisição
+func newUpstreamTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: config.Duration("UPSTREAM_KEEP_ALIVE", defaultUpstreamKeepAlive),
+	}).DialContext
+
+	perHost := defaultUpstreamMaxIdleConnsPerHost
+	if value := config.Int("UPSTREAM_MAX_IDLE_CONNS_PER_HOST", 0); value > 0 {
+		perHost = value
+	}
+	transport.MaxIdleConnsPerHost = perHost
+	if transport.MaxIdleConns < perHost {
+		transport.MaxIdleConns = perHost
+	}
+	transport.MaxConnsPerHost = config.Int("UPSTREAM_MAX_CONNS_PER_HOST", 0)
+	transport.IdleConnTimeout = config.Duration("UPSTREAM_IDLE_CONN_TIMEOUT", defaultUpstreamIdleConnTimeout)
+	transport.DisableKeepAlives = config.Bool("UPSTREAM_DISABLE_KEEP_ALIVES", false)
+	return transport
+}
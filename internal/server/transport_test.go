@@ -0,0 +1,55 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httptrace"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewUpstreamTransport_Defaults(t *testing.T) {
+	transport := newUpstreamTransport()
+	assert.Equal(t, 100, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 0, transport.MaxConnsPerHost)
+	assert.Equal(t, 90*time.Second, transport.IdleConnTimeout)
+	assert.False(t, transport.DisableKeepAlives)
+}
+
+func TestNewUpstreamTransport_Env(t *testing.T) {
+	t.Setenv("UPSTREAM_MAX_IDLE_CONNS_PER_HOST", "256")
+	t.Setenv("UPSTREAM_MAX_CONNS_PER_HOST", "64")
+	t.Setenv("UPSTREAM_IDLE_CONN_TIMEOUT", "5m")
+	t.Setenv("UPSTREAM_DISABLE_KEEP_ALIVES", "true")
+
+	transport := newUpstreamTransport()
+	assert.Equal(t, 256, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 256, transport.MaxIdleConns)
+	assert.Equal(t, 64, transport.MaxConnsPerHost)
+	assert.Equal(t, 5*time.Minute, transport.IdleConnTimeout)
+	assert.True(t, transport.DisableKeepAlives)
+}
+
+// Chamadas seguidas ao mesmo host reaproveitam a conexão
+func TestNewUpstreamTransport_ReusesConnections(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	client := &http.Client{Transport: newUpstreamTransport()}
+	reused := 0
+	for i := 0; i < 3; i++ {
+		trace := &httptrace.ClientTrace{GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				reused++
+			}
+		}}
+		req, _ := http.NewRequest("GET", server.URL, nil)
+		req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+		resp, err := client.Do(req)
+		assert.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.Equal(t, 2, reused)
+}
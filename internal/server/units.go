@@ -0,0 +1,87 @@
+package server
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/weather-service/config"
+)
+
+// Escalas de temperatura incluídas na resposta
+type temperatureUnits struct {
+	C bool
+	F bool
+	K bool
+	R bool
+}
+
+var defaultUnits = temperatureUnits{C: true, F: true, K: true}
+
+// Interpreta o parâmetro units (ex.: "c", "c,f"); vazio mantém as escalas
+// padrão. Rankine só é incluído quando pedido explicitamente
+func parseUnits(value string) (temperatureUnits, error) {
+	if strings.TrimSpace(value) == "" {
+		return defaultUnits, nil
+	}
+
+	var units temperatureUnits
+	for _, unit := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(unit)) {
+		case "c":
+			units.C = true
+		case "f":
+			units.F = true
+		case "k":
+			units.K = true
+		case "r":
+			units.R = true
+		default:
+			return temperatureUnits{}, fmt.Errorf("unknown temperature unit %q", unit)
+		}
+	}
+
+	return units, nil
+}
+
+// Casas decimais configuradas em TEMP_PRECISION; sem a variável os valores
+// são retornados sem arredondamento
+func temperaturePrecision() (int, bool) {
+	value := config.String("TEMP_PRECISION")
+	if value == "" {
+		return 0, false
+	}
+
+	precision, err := strconv.Atoi(value)
+	if err != nil || precision < 0 {
+		log.Printf("WARNING: ignoring invalid TEMP_PRECISION %q", value)
+		return 0, false
+	}
+	return precision, true
+}
+
+// Arredonda para a precisão configurada (metade para longe do zero). As
+// conversões são feitas sobre o valor em Celsius original, e cada escala é
+// arredondada separadamente
+func roundTemperature(value float64) float64 {
+	precision, ok := temperaturePrecision()
+	if !ok {
+		return value
+	}
+
+	factor := math.Pow(10, float64(precision))
+	return math.Round(value*factor) / factor
+}
+
+// Texto de uma temperatura já arredondada por roundTemperature: com
+// TEMP_PRECISION, sempre com esse número de casas; sem, com todas as casas,
+// como no JSON
+func formatTemperature(value float64) string {
+	precision, ok := temperaturePrecision()
+	if !ok {
+		precision = -1
+	}
+	return strconv.FormatFloat(value, 'f', precision, 64)
+}
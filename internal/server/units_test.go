@@ -0,0 +1,59 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseUnits(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expected  temperatureUnits
+		expectErr bool
+	}{
+		{"Empty keeps default units", "", defaultUnits, false},
+		{"Single unit", "c", temperatureUnits{C: true}, false},
+		{"Comma separated", "c,k", temperatureUnits{C: true, K: true}, false},
+		{"Uppercase and spaces", " F , K ", temperatureUnits{F: true, K: true}, false},
+		{"Rankine", "k,r", temperatureUnits{K: true, R: true}, false},
+		{"Unknown unit", "c,x", temperatureUnits{}, true},
+		{"Empty item", "c,", temperatureUnits{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			units, err := parseUnits(tt.value)
+			if tt.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, units)
+		})
+	}
+}
+
+func TestRoundTemperature(t *testing.T) {
+	tests := []struct {
+		name      string
+		precision string
+		value     float64
+		expected  float64
+	}{
+		{"No precision configured", "", 80.60000000000001, 80.60000000000001},
+		{"One decimal", "1", 301.65, 301.7},
+		{"Zero decimals", "0", 298.5, 299},
+		{"Negative values round away from zero", "1", -3.25, -3.3},
+		{"Invalid precision is ignored", "abc", 25.123, 25.123},
+		{"Negative precision is ignored", "-1", 25.123, 25.123},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TEMP_PRECISION", tt.precision)
+			assert.Equal(t, tt.expected, roundTemperature(tt.value))
+		})
+	}
+}
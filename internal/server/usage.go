@@ -0,0 +1,212 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/weather-service/config"
+)
+
+const (
+	defaultStatsWindow = 24 * time.Hour
+	statsTopSize       = 10
+)
+
+// Resultado do cache da WeatherAPI em uma requisição
+const (
+	usageCacheHit  = "hit"
+	usageCacheMiss = "miss"
+)
+
+// Requisição atendida pelos endpoints de dados, gravada no banco do registro
+// das consultas para as estatísticas de /stats
+type requestRecord struct {
+	Status int
+	CEP    string
+	City   string
+	UF     string
+	// Vazio quando a requisição não chamou a WeatherAPI
+	Cache string
+	// Subject do JWT que autenticou a requisição, ou vazio
+	Subject string
+	At      time.Time
+}
+
+// Totais das requisições desde um instante
+type usageSummary struct {
+	Total     int
+	CacheHits int
+	// Requisições que chamaram a WeatherAPI, com ou sem cache
+	CacheLookups int
+	TopCEPs      []CEPCount
+	TopCities    []CityCount
+	TopSubjects  []SubjectCount
+	Errors       []ErrorCount
+}
+
+type StatsResponse struct {
+	Window        string    `json:"window" xml:"window"`
+	Since         time.Time `json:"since" xml:"since"`
+	TotalRequests int       `json:"total_requests" xml:"total_requests"`
+	// Nulo quando nenhuma requisição da janela chamou a WeatherAPI
+	CacheHitRatio *float64    `json:"cache_hit_ratio" xml:"cache_hit_ratio,omitempty"`
+	TopCEPs       []CEPCount  `json:"top_ceps" xml:"top_cep"`
+	TopCities     []CityCount `json:"top_cities" xml:"top_city"`
+	// Subjects dos JWTs que mais fizeram requisições
+	TopSubjects []SubjectCount `json:"top_subjects" xml:"top_subject"`
+	Errors      []ErrorCount   `json:"errors" xml:"error"`
+}
+
+type CEPCount struct {
+	CEP      string `json:"cep" xml:"cep"`
+	Requests int    `json:"requests" xml:"requests"`
+}
+
+type CityCount struct {
+	City     string `json:"city" xml:"city"`
+	UF       string `json:"uf" xml:"uf"`
+	Requests int    `json:"requests" xml:"requests"`
+}
+
+type SubjectCount struct {
+	Subject  string `json:"subject" xml:"subject"`
+	Requests int    `json:"requests" xml:"requests"`
+}
+
+type ErrorCount struct {
+	Status   int `json:"status" xml:"status"`
+	Requests int `json:"requests" xml:"requests"`
+}
+
+type usageContextKey struct{}
+
+// Dados da requisição preenchidos pelos handlers enquanto ela é atendida
+type usageEvent struct {
+	mu     sync.Mutex
+	record requestRecord
+}
+
+// Grava cada requisição no registro das consultas, quando ligado. Os handlers
+// informam o endereço resolvido e o uso do cache pelo contexto
+func (s *Server) recordUsage(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if s.lookups == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		event := &usageEvent{}
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(context.WithValue(r.Context(), usageContextKey{}, event)))
+
+		event.mu.Lock()
+		record := event.record
+		event.mu.Unlock()
+
+		record.Status, record.At = ww.Status(), time.Now().UTC()
+		if record.Status == 0 {
+			record.Status = http.StatusOK
+		}
+		if record.CEP == "" {
+			if cep := strings.ReplaceAll(cepParam(r), "-", ""); isValidCEP(cep) {
+				record.CEP = cep
+			}
+		}
+
+		// O cliente pode já ter desconectado; a gravação não depende dele
+		if err := s.lookups.recordRequest(context.WithoutCancel(r.Context()), record); err != nil {
+			log.Printf("ERROR: Failed to record request %s: %v", chi.RouteContext(r.Context()).RoutePattern(), err)
+		}
+	})
+}
+
+func usageFromContext(ctx context.Context) *usageEvent {
+	event, _ := ctx.Value(usageContextKey{}).(*usageEvent)
+	return event
+}
+
+// Anota o endereço resolvido para o CEP da requisição
+func noteUsageAddress(ctx context.Context, address *ViaCEPResponse) {
+	event := usageFromContext(ctx)
+	if event == nil {
+		return
+	}
+
+	event.mu.Lock()
+	defer event.mu.Unlock()
+	event.record.CEP = strings.ReplaceAll(address.Cep, "-", "")
+	event.record.City, event.record.UF = address.Localidade, address.UF
+}
+
+// Anota o subject do JWT que autenticou a requisição
+func noteUsageSubject(ctx context.Context, subject string) {
+	event := usageFromContext(ctx)
+	if event == nil {
+		return
+	}
+
+	event.mu.Lock()
+	defer event.mu.Unlock()
+	event.record.Subject = subject
+}
+
+// Anota se a chamada à WeatherAPI foi atendida pelo cache. Com várias
+// chamadas na mesma requisição, basta uma ida à WeatherAPI para contar como miss
+func noteUsageCache(ctx context.Context, hit bool) {
+	event := usageFromContext(ctx)
+	if event == nil {
+		return
+	}
+
+	event.mu.Lock()
+	defer event.mu.Unlock()
+	if !hit {
+		event.record.Cache = usageCacheMiss
+	} else if event.record.Cache == "" {
+		event.record.Cache = usageCacheHit
+	}
+}
+
+// Janela das estatísticas de /stats (STATS_WINDOW)
+func statsWindow() time.Duration {
+	return config.Duration("STATS_WINDOW", defaultStatsWindow)
+}
+
+// GET /stats: uso do serviço na janela STATS_WINDOW. Só existe com o registro
+// das consultas ligado, que guarda as requisições entre reinícios
+func (s *Server) statsHandler(w http.ResponseWriter, r *http.Request) {
+	if s.lookups == nil {
+		notFoundHandler(w, r)
+		return
+	}
+
+	window := statsWindow()
+	since := time.Now().UTC().Add(-window)
+	summary, err := s.lookups.usage(r.Context(), since, statsTopSize)
+	if err != nil {
+		log.Printf("ERROR: Failed to read usage stats: %v", err)
+		writeResponse(w, r, http.StatusInternalServerError, ErrorResponse{Message: "internal server error"})
+		return
+	}
+
+	response := StatsResponse{
+		Window:        window.String(),
+		Since:         since,
+		TotalRequests: summary.Total,
+		TopCEPs:       summary.TopCEPs,
+		TopCities:     summary.TopCities,
+		TopSubjects:   summary.TopSubjects,
+		Errors:        summary.Errors,
+	}
+	if summary.CacheLookups > 0 {
+		ratio := float64(summary.CacheHits) / float64(summary.CacheLookups)
+		response.CacheHitRatio = &ratio
+	}
+	writeResponse(w, r, http.StatusOK, response)
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStatsHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	t.Setenv("WEATHER_CACHE_TTL", "1m")
+	withSQLiteLookups(t, s)
+
+	router := s.router()
+	request := func(path string) *httptest.ResponseRecorder {
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		return rr
+	}
+	assert.Equal(t, http.StatusOK, request("/weather/01310100").Code)
+	assert.Equal(t, http.StatusOK, request("/v1/weather/01310-100").Code)
+	assert.Equal(t, http.StatusUnprocessableEntity, request("/weather/123").Code)
+
+	rr := request("/stats")
+	assert.Equal(t, http.StatusOK, rr.Code)
+	var response StatsResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+	assert.Equal(t, "24h0m0s", response.Window)
+	assert.Equal(t, 3, response.TotalRequests)
+	// A primeira consulta foi à WeatherAPI; a segunda veio do cache
+	assert.Equal(t, 0.5, *response.CacheHitRatio)
+	assert.Equal(t, []CEPCount{{CEP: "01310100", Requests: 2}}, response.TopCEPs)
+	assert.Equal(t, []CityCount{{City: "São Paulo", UF: "SP", Requests: 2}}, response.TopCities)
+	assert.Equal(t, []ErrorCount{{Status: http.StatusUnprocessableEntity, Requests: 1}}, response.Errors)
+
+	// A própria consulta a /stats também conta
+	assert.NoError(t, json.NewDecoder(request("/stats").Body).Decode(&response))
+	assert.Equal(t, 4, response.TotalRequests)
+}
+
+// Requisições com JWT são registradas com o subject
+func TestStatsHandler_TopSubjects(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{})
+	withSQLiteLookups(t, s)
+	t.Setenv("JWT_HS256_SECRET", "segredo")
+
+	router := s.router()
+	request := func(subject, path string) *httptest.ResponseRecorder {
+		claims := jwt.MapClaims{"sub": subject, "exp": time.Now().Add(time.Hour).Unix()}
+		req := httptest.NewRequest("GET", path, nil)
+		req.Header.Set("Authorization", "Bearer "+signedToken(t, jwt.SigningMethodHS256, []byte("segredo"), "", claims))
+		rr := httptest.NewRecorder()
+		router.ServeHTTP(rr, req)
+		return rr
+	}
+	request("user-1", "/weather/123")
+	request("user-1", "/weather/456")
+	request("user-2", "/weather/123")
+
+	var response StatsResponse
+	assert.NoError(t, json.NewDecoder(request("user-2", "/stats").Body).Decode(&response))
+	assert.Equal(t, []SubjectCount{{Subject: "user-1", Requests: 2}, {Subject: "user-2", Requests: 1}}, response.TopSubjects)
+}
+
+func TestStatsHandler_WithoutLookups(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/stats", nil))
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	assert.JSONEq(t, `{"message":"not found"}`, rr.Body.String())
+}
+
+// As requisições ficam no banco e continuam nas estatísticas depois de reabri-lo
+func TestSQLLookupStore_UsageSurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "lookups.db")
+	store, err := openSQLiteLookupStore(path)
+	assert.NoError(t, err)
+
+	now := time.Now().UTC()
+	for _, request := range []requestRecord{
+		{Status: 200, CEP: "01310100", City: "São Paulo", UF: "SP", Cache: usageCacheMiss, At: now.Add(-time.Minute)},
+		{Status: 200, CEP: "20040002", City: "Rio de Janeiro", UF: "RJ", Cache: usageCacheHit, At: now.Add(-time.Minute)},
+		{Status: 200, CEP: "20040002", City: "Rio de Janeiro", UF: "RJ", Cache: usageCacheHit, At: now.Add(-time.Minute)},
+		{Status: 503, CEP: "01310100", At: now.Add(-time.Minute)},
+		{Status: 401, At: now.Add(-time.Minute)},
+		// Fora da janela
+		{Status: 500, CEP: "01310100", City: "São Paulo", UF: "SP", At: now.Add(-2 * time.Hour)},
+	} {
+		assert.NoError(t, store.recordRequest(context.Background(), request))
+	}
+	assert.NoError(t, store.Close())
+
+	store, err = openSQLiteLookupStore(path)
+	assert.NoError(t, err)
+	defer store.Close()
+
+	summary, err := store.usage(context.Background(), now.Add(-time.Hour), 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, summary.Total)
+	assert.Equal(t, 2, summary.CacheHits)
+	assert.Equal(t, 3, summary.CacheLookups)
+	assert.Equal(t, []CEPCount{{CEP: "01310100", Requests: 2}}, summary.TopCEPs)
+	assert.Equal(t, []CityCount{{City: "Rio de Janeiro", UF: "RJ", Requests: 2}}, summary.TopCities)
+	assert.Equal(t, []ErrorCount{{Status: 401, Requests: 1}, {Status: 503, Requests: 1}}, summary.Errors)
+}
@@ -0,0 +1,239 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+const (
+	defaultVaultAuthPath        = "kubernetes"
+	defaultVaultK8sTokenFile    = "/var/run/secrets/kubernetes.io/serviceaccount/token"
+	defaultVaultSecretPath      = "secret/data/weather-service"
+	defaultVaultRefreshInterval = 5 * time.Minute
+)
+
+// Busca segredos (a chave da WeatherAPI e futuras chaves de provedores) no
+// Vault. Cada campo do segredo com o nome de uma configuração conhecida
+// (ex.: WEATHER_API_KEY ou weather_api_key) vira a variável correspondente,
+// a menos que ela já esteja definida no ambiente
+type vaultClient struct {
+	addr            string
+	role            string
+	authPath        string
+	k8sTokenFile    string
+	secretPath      string
+	refreshInterval time.Duration
+	client          *http.Client
+
+	mu        sync.Mutex
+	token     string
+	ttl       time.Duration
+	renewable bool
+	fromVault map[string]string
+}
+
+// Ligado por VAULT_ADDR. Autentica com VAULT_TOKEN ou, com VAULT_ROLE, pelo
+// service account do Kubernetes; retorna nil sem VAULT_ADDR
+func vaultClientFromEnv() *vaultClient {
+	addr := config.String("VAULT_ADDR")
+	if addr == "" {
+		return nil
+	}
+
+	v := &vaultClient{
+		addr:            strings.TrimSuffix(addr, "/"),
+		role:            config.String("VAULT_ROLE"),
+		authPath:        defaultVaultAuthPath,
+		k8sTokenFile:    defaultVaultK8sTokenFile,
+		secretPath:      defaultVaultSecretPath,
+		refreshInterval: config.Duration("VAULT_REFRESH_INTERVAL", defaultVaultRefreshInterval),
+		client:          &http.Client{Timeout: 10 * time.Second},
+		token:           config.String("VAULT_TOKEN"),
+		fromVault:       map[string]string{},
+	}
+	if value := config.String("VAULT_AUTH_PATH"); value != "" {
+		v.authPath = strings.Trim(value, "/")
+	}
+	if value := config.String("VAULT_K8S_TOKEN_FILE"); value != "" {
+		v.k8sTokenFile = value
+	}
+	if value := config.String("VAULT_SECRET_PATH"); value != "" {
+		v.secretPath = strings.Trim(value, "/")
+	}
+	return v
+}
+
+// Autentica e aplica os segredos na inicialização
+func (v *vaultClient) load() error {
+	if v.role == "" && v.token == "" {
+		return errors.New("VAULT_ADDR requires VAULT_ROLE or VAULT_TOKEN")
+	}
+	if v.role != "" {
+		if err := v.login(); err != nil {
+			return err
+		}
+	}
+	return v.fetchSecrets()
+}
+
+// Login pelo método kubernetes com o token do service account do pod
+func (v *vaultClient) login() error {
+	jwt, err := os.ReadFile(v.k8sTokenFile)
+	if err != nil {
+		return fmt.Errorf("failed to read Kubernetes service account token: %w", err)
+	}
+
+	body, _ := json.Marshal(map[string]string{"role": v.role, "jwt": strings.TrimSpace(string(jwt))})
+	return v.authenticate("POST", "/v1/auth/"+v.authPath+"/login", body)
+}
+
+// Renova o token antes de expirar
+func (v *vaultClient) renew() error {
+	return v.authenticate("POST", "/v1/auth/token/renew-self", nil)
+}
+
+func (v *vaultClient) authenticate(method, path string, body []byte) error {
+	var response struct {
+		Auth struct {
+			ClientToken   string `json:"client_token"`
+			LeaseDuration int    `json:"lease_duration"`
+			Renewable     bool   `json:"renewable"`
+		} `json:"auth"`
+	}
+	if err := v.do(method, path, body, &response); err != nil {
+		return err
+	}
+	if response.Auth.ClientToken == "" {
+		return fmt.Errorf("vault %s returned no token", path)
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.token = response.Auth.ClientToken
+	v.ttl = time.Duration(response.Auth.LeaseDuration) * time.Second
+	v.renewable = response.Auth.Renewable
+	return nil
+}
+
+// Lê o segredo (KV v1 ou v2) e exporta os campos para o ambiente
+func (v *vaultClient) fetchSecrets() error {
+	var response struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	if err := v.do("GET", "/v1/"+v.secretPath, nil, &response); err != nil {
+		return err
+	}
+
+	// No KV v2 os campos ficam em data.data, ao lado de data.metadata
+	fields := response.Data
+	if nested, ok := fields["data"].(map[string]interface{}); ok {
+		if _, ok := fields["metadata"]; ok {
+			fields = nested
+		}
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	for field, raw := range fields {
+		name := strings.ToUpper(field)
+		if _, known := config.Lookup(name); !known {
+			log.Printf("WARNING: ignoring unknown vault secret field %q", field)
+			continue
+		}
+		value := fmt.Sprint(raw)
+
+		// Flags e variáveis de ambiente têm precedência sobre o Vault, que
+		// tem precedência sobre o arquivo de configuração
+		previous, ours := v.fromVault[name]
+		if ours && previous == value {
+			continue
+		}
+		if ours {
+			log.Printf("Secret %s rotated in vault", name)
+		}
+		config.Set(config.SourceVault, name, value)
+		v.fromVault[name] = value
+	}
+	return nil
+}
+
+func (v *vaultClient) do(method, path string, body []byte, out interface{}) error {
+	req, err := http.NewRequest(method, v.addr+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	v.mu.Lock()
+	if v.token != "" {
+		req.Header.Set("X-Vault-Token", v.token)
+	}
+	v.mu.Unlock()
+
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach vault: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("vault %s returned status %d", path, resp.StatusCode)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode vault response: %w", err)
+	}
+	return nil
+}
+
+// Tempo até a próxima renovação: metade do TTL do token, limitado ao
+// intervalo de releitura dos segredos
+func (v *vaultClient) nextRefresh() time.Duration {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if v.role != "" && v.ttl > 0 && v.ttl/2 < v.refreshInterval {
+		return v.ttl / 2
+	}
+	return v.refreshInterval
+}
+
+// Renova o token (ou faz login de novo se a renovação falhar) e relê os
+// segredos, para acompanhar rotações
+func (v *vaultClient) refresh() {
+	if v.role != "" {
+		v.mu.Lock()
+		renewable := v.renewable
+		v.mu.Unlock()
+
+		err := errors.New("token not renewable")
+		if renewable {
+			err = v.renew()
+		}
+		if err != nil {
+			log.Printf("WARNING: vault token renewal failed, logging in again: %v", err)
+			if err := v.login(); err != nil {
+				log.Printf("ERROR: vault login failed: %v", err)
+				return
+			}
+		}
+	}
+
+	if err := v.fetchSecrets(); err != nil {
+		log.Printf("ERROR: failed to refresh vault secrets: %v", err)
+	}
+}
+
+func (v *vaultClient) keepFresh() {
+	for {
+		time.Sleep(v.nextRefresh())
+		v.refresh()
+	}
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/config"
+)
+
+// Vault fake com login kubernetes, renovação de token e um segredo KV v2
+type fakeVault struct {
+	mu         sync.Mutex
+	secret     map[string]interface{}
+	logins     int
+	renewals   int
+	failRenew  bool
+	validToken string
+}
+
+func newFakeVault(t *testing.T, secret map[string]interface{}) (*fakeVault, *httptest.Server) {
+	t.Helper()
+	fake := &fakeVault{secret: secret}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fake.mu.Lock()
+		defer fake.mu.Unlock()
+
+		switch r.URL.Path {
+		case "/v1/auth/kubernetes/login":
+			var body map[string]string
+			json.NewDecoder(r.Body).Decode(&body)
+			if body["role"] != "weather-service" || body["jwt"] != "pod-jwt" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fake.logins++
+			fake.validToken = fmt.Sprintf("token-%d", fake.logins)
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"auth": map[string]interface{}{"client_token": fake.validToken, "lease_duration": 60, "renewable": true},
+			})
+		case "/v1/auth/token/renew-self":
+			if fake.failRenew || r.Header.Get("X-Vault-Token") != fake.validToken {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			fake.renewals++
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"auth": map[string]interface{}{"client_token": fake.validToken, "lease_duration": 60, "renewable": true},
+			})
+		case "/v1/secret/data/weather-service":
+			if r.Header.Get("X-Vault-Token") != fake.validToken {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{
+				"data": map[string]interface{}{"data": fake.secret, "metadata": map[string]interface{}{"version": 1}},
+			})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return fake, server
+}
+
+func withVaultEnv(t *testing.T, addr string) {
+	t.Helper()
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	assert.NoError(t, os.WriteFile(tokenFile, []byte("pod-jwt\n"), 0o600))
+
+	t.Setenv("VAULT_ADDR", addr)
+	t.Setenv("VAULT_ROLE", "weather-service")
+	t.Setenv("VAULT_K8S_TOKEN_FILE", tokenFile)
+	t.Setenv("WEATHER_API_KEY", "")
+	os.Unsetenv("WEATHER_API_KEY")
+	clearConfigLayer(t, config.SourceVault)
+}
+
+func TestVaultClient_Load(t *testing.T) {
+	_, server := newFakeVault(t, map[string]interface{}{"weather_api_key": "vault-key", "unrelated": "x"})
+	withVaultEnv(t, server.URL)
+
+	vault := vaultClientFromEnv()
+	assert.NoError(t, vault.load())
+	assert.Equal(t, "vault-key", config.String("WEATHER_API_KEY"))
+	assert.Equal(t, 30*time.Second, vault.nextRefresh())
+}
+
+func TestVaultClient_EnvironmentTakesPrecedence(t *testing.T) {
+	_, server := newFakeVault(t, map[string]interface{}{"WEATHER_API_KEY": "vault-key"})
+	withVaultEnv(t, server.URL)
+	t.Setenv("WEATHER_API_KEY", "env-key")
+
+	assert.NoError(t, vaultClientFromEnv().load())
+	assert.Equal(t, "env-key", config.String("WEATHER_API_KEY"))
+}
+
+func TestVaultClient_RefreshRenewsAndRotates(t *testing.T) {
+	fake, server := newFakeVault(t, map[string]interface{}{"weather_api_key": "vault-key"})
+	withVaultEnv(t, server.URL)
+
+	vault := vaultClientFromEnv()
+	assert.NoError(t, vault.load())
+
+	fake.mu.Lock()
+	fake.secret = map[string]interface{}{"weather_api_key": "rotated-key"}
+	fake.mu.Unlock()
+
+	vault.refresh()
+	assert.Equal(t, 1, fake.renewals)
+	assert.Equal(t, 1, fake.logins)
+	assert.Equal(t, "rotated-key", config.String("WEATHER_API_KEY"))
+
+	// Renovação recusada: novo login
+	fake.mu.Lock()
+	fake.failRenew = true
+	fake.mu.Unlock()
+
+	vault.refresh()
+	assert.Equal(t, 2, fake.logins)
+	assert.Equal(t, "rotated-key", config.String("WEATHER_API_KEY"))
+}
+
+func TestVaultClient_Errors(t *testing.T) {
+	_, server := newFakeVault(t, nil)
+
+	withVaultEnv(t, server.URL)
+	t.Setenv("VAULT_ROLE", "")
+	assert.EqualError(t, vaultClientFromEnv().load(), "VAULT_ADDR requires VAULT_ROLE or VAULT_TOKEN")
+
+	t.Setenv("VAULT_ROLE", "other-role")
+	assert.EqualError(t, vaultClientFromEnv().load(), "vault /v1/auth/kubernetes/login returned status 403")
+
+	t.Setenv("VAULT_ADDR", "")
+	assert.Nil(t, vaultClientFromEnv())
+}
+
+func TestCLI_LoadsSecretsFromVault(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	_, server := newFakeVault(t, map[string]interface{}{"weather_api_key": "vault-key"})
+	withVaultEnv(t, server.URL)
+	t.Cleanup(func() { secretsVault = nil })
+
+	out, err := runCLI(t, s, "lookup", "01310100", "--units", "c")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"temp_C":25}`, out)
+	assert.Equal(t, "vault-key", config.String("WEATHER_API_KEY"))
+}
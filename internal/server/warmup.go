@@ -0,0 +1,81 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/weather-service/config"
+)
+
+// Consulta os CEPs de CACHE_WARMUP_CEPS e CACHE_WARMUP_FILE para que as
+// primeiras requisições depois do deploy já encontrem o endereço e a
+// temperatura em cache. Os CEPs são consultados com o mesmo paralelismo dos
+// lotes (BATCH_CONCURRENCY). Até terminar, /readyz responde 503
+func (s *Server) warmCache(ctx context.Context) {
+	defer s.cacheWarm.Store(true)
+
+	ceps, err := warmupCEPs()
+	if err != nil {
+		log.Printf("ERROR: %v", err)
+	}
+	if len(ceps) == 0 {
+		log.Println("Cache warm-up finished")
+		return
+	}
+	if weatherCacheTTLFor("current.json") == 0 {
+		log.Println("WARNING: WEATHER_CACHE_TTL_CURRENT and WEATHER_CACHE_TTL not set, warm-up only fills the address cache")
+	}
+
+	start := time.Now()
+	failed := 0
+	for _, result := range s.runBatch(ctx, ceps, defaultUnits, batchConcurrency()) {
+		if result.Error != "" {
+			log.Printf("WARNING: failed to warm cache for CEP %s: %s", result.CEP, result.Error)
+			failed++
+		}
+	}
+	log.Printf("Cache warm-up of %d CEPs finished in %s (%d failed)", len(ceps), time.Since(start).Round(time.Millisecond), failed)
+}
+
+// CEPs de CACHE_WARMUP_CEPS (separados por vírgula) seguidos dos de
+// CACHE_WARMUP_FILE (um por linha; o que vem depois de # é comentário, para
+// identificar a filial, por exemplo), sem repetições. Com o arquivo ilegível,
+// retorna os CEPs da variável e o erro
+func warmupCEPs() ([]string, error) {
+	var ceps []string
+	seen := map[string]bool{}
+	add := func(code string) {
+		if key := strings.ReplaceAll(cleanCEP(code), "-", ""); key != "" && !seen[key] {
+			seen[key] = true
+			ceps = append(ceps, code)
+		}
+	}
+	for _, code := range config.List("CACHE_WARMUP_CEPS", nil) {
+		add(code)
+	}
+
+	path := config.String("CACHE_WARMUP_FILE")
+	if path == "" {
+		return ceps, nil
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return ceps, fmt.Errorf("failed to open CACHE_WARMUP_FILE: %w", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "#")
+		add(strings.TrimSpace(line))
+	}
+	if err := scanner.Err(); err != nil {
+		return ceps, fmt.Errorf("failed to read CACHE_WARMUP_FILE: %w", err)
+	}
+	return ceps, nil
+}
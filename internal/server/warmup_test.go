@@ -0,0 +1,56 @@
+package server
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWarmupCEPs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "filiais.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("# Filiais\n01310100  # Paulista\n\n20040-002 # Centro RJ\n30130-010\n"), 0o600))
+	t.Setenv("CACHE_WARMUP_CEPS", "01310-100, 70040010")
+	t.Setenv("CACHE_WARMUP_FILE", path)
+
+	ceps, err := warmupCEPs()
+	assert.NoError(t, err)
+	// 01310100 do arquivo repete o 01310-100 da variável
+	assert.Equal(t, []string{"01310-100", "70040010", "20040-002", "30130-010"}, ceps)
+
+	t.Setenv("CACHE_WARMUP_FILE", filepath.Join(t.TempDir(), "missing.txt"))
+	ceps, err = warmupCEPs()
+	assert.Error(t, err)
+	assert.Equal(t, []string{"01310-100", "70040010"}, ceps)
+}
+
+func TestWarmCache_File(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	s.cacheWarm.Store(false)
+	path := filepath.Join(t.TempDir(), "filiais.txt")
+	assert.NoError(t, os.WriteFile(path, []byte("01310100 # Paulista\n99999999\n"), 0o600))
+	t.Setenv("CACHE_WARMUP_FILE", path)
+	t.Setenv("BATCH_CONCURRENCY", "2")
+
+	s.warmCache(context.Background())
+
+	assert.True(t, s.cacheWarm.Load())
+	_, cached := s.weatherCache.Get("current.json?aqi=no&q=S%C3%A3o+Paulo%2CSP")
+	assert.True(t, cached)
+}
+
+// Um arquivo ilegível não impede a instância de ficar pronta
+func TestWarmCache_MissingFile(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	s.cacheWarm.Store(false)
+	t.Setenv("CACHE_WARMUP_FILE", filepath.Join(t.TempDir(), "missing.txt"))
+
+	s.warmCache(context.Background())
+
+	assert.True(t, s.cacheWarm.Load())
+}
@@ -0,0 +1,781 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/weather-service/config"
+	"github.com/weather-service/internal/cep"
+	"github.com/weather-service/internal/weather"
+	"golang.org/x/sync/singleflight"
+)
+
+type WeatherResponse struct {
+	TempC *float64 `json:"temp_C,omitempty" xml:"temp_C,omitempty"`
+	TempF *float64 `json:"temp_F,omitempty" xml:"temp_F,omitempty"`
+	TempK *float64 `json:"temp_K,omitempty" xml:"temp_K,omitempty"`
+	TempR *float64 `json:"temp_R,omitempty" xml:"temp_R,omitempty"`
+
+	FeelsLikeC *float64 `json:"feels_like_C,omitempty" xml:"feels_like_C,omitempty"`
+	FeelsLikeF *float64 `json:"feels_like_F,omitempty" xml:"feels_like_F,omitempty"`
+	FeelsLikeK *float64 `json:"feels_like_K,omitempty" xml:"feels_like_K,omitempty"`
+	FeelsLikeR *float64 `json:"feels_like_R,omitempty" xml:"feels_like_R,omitempty"`
+
+	Location   *Address    `json:"location,omitempty" xml:"location,omitempty"`
+	AirQuality *AirQuality `json:"air_quality,omitempty" xml:"air_quality,omitempty"`
+	Conditions *Conditions `json:"conditions,omitempty" xml:"conditions,omitempty"`
+	// Código IBGE do município, com extended=true quando o provedor de CEP informa
+	IBGE string `json:"ibge,omitempty" xml:"ibge,omitempty"`
+	// Origem e idade dos dados, com meta=true
+	Meta *ResponseMeta `json:"meta,omitempty" xml:"meta,omitempty"`
+
+	// Usados apenas na saída CSV e na tabela do CLI, que sempre identificam o
+	// CEP e a cidade
+	cep  string
+	city string
+}
+
+type Address struct {
+	Cep        string `json:"cep" xml:"cep"`
+	Logradouro string `json:"logradouro" xml:"logradouro"`
+	Bairro     string `json:"bairro" xml:"bairro"`
+	Localidade string `json:"localidade" xml:"localidade"`
+	UF         string `json:"uf" xml:"uf"`
+	// Código ISO do país, só nas consultas com ?country= de fora do Brasil
+	Country string `json:"country,omitempty" xml:"country,omitempty"`
+	// "approximate" quando a cidade veio da tabela de CEPs embutida
+	Source string `json:"source,omitempty" xml:"source,omitempty"`
+}
+
+type Conditions struct {
+	Humidity      int     `json:"humidity" xml:"humidity"`
+	WindKph       float64 `json:"wind_kph" xml:"wind_kph"`
+	WindDir       string  `json:"wind_dir" xml:"wind_dir"`
+	WindDegree    int     `json:"wind_degree" xml:"wind_degree"`
+	PressureMb    float64 `json:"pressure_mb" xml:"pressure_mb"`
+	Cloud         int     `json:"cloud" xml:"cloud"`
+	ConditionText string  `json:"condition_text" xml:"condition_text"`
+	ConditionCode int     `json:"condition_code" xml:"condition_code"`
+}
+
+type AirQuality struct {
+	PM25       float64 `json:"pm2_5" xml:"pm2_5"`
+	PM10       float64 `json:"pm10" xml:"pm10"`
+	USEPAIndex int     `json:"us_epa_index" xml:"us_epa_index"`
+}
+
+type ErrorResponse struct {
+	Message string `json:"message" xml:"message"`
+}
+
+// Endereço resolvido pelos provedores de CEP, nos nomes de campo do ViaCEP
+type ViaCEPResponse struct {
+	Cep         string `json:"cep"`
+	Logradouro  string `json:"logradouro"`
+	Complemento string `json:"complemento"`
+	Bairro      string `json:"bairro"`
+	Localidade  string `json:"localidade"`
+	UF          string `json:"uf"`
+	Ibge        string `json:"ibge,omitempty"`
+
+	// Endereço da tabela embutida, com precisão de cidade, em vez do ViaCEP
+	approximate bool
+	// De onde o endereço veio, para o bloco meta (viacep, brasilapi, address_cache, embedded)
+	provider string
+}
+
+func viaCEPResponse(address *cep.Address) *ViaCEPResponse {
+	return &ViaCEPResponse{
+		Cep:         address.CEP,
+		Logradouro:  address.Street,
+		Complemento: address.Complement,
+		Bairro:      address.Neighborhood,
+		Localidade:  address.City,
+		UF:          address.UF,
+		Ibge:        address.IBGE,
+	}
+}
+
+// Tempo máximo de uma consulta ao ViaCEP (VIACEP_TIMEOUT)
+const defaultViaCEPTimeout = 5 * time.Second
+
+// Chamada à WeatherAPI com uma chave (weather.Client). Status diferentes de
+// 200 voltam como *weather.StatusError
+type weatherAPIClient interface {
+	Fetch(ctx context.Context, endpoint string, params url.Values, key string) ([]byte, error)
+}
+
+type WeatherAPIResponse struct {
+	Location struct {
+		Name    string  `json:"name"`
+		Region  string  `json:"region"`
+		Country string  `json:"country"`
+		Lat     float64 `json:"lat"`
+		Lon     float64 `json:"lon"`
+	} `json:"location"`
+	Current struct {
+		LastUpdatedEpoch int64   `json:"last_updated_epoch"`
+		TempC            float64 `json:"temp_c"`
+		FeelsLikeC       float64 `json:"feelslike_c"`
+		Humidity         int     `json:"humidity"`
+		WindKph          float64 `json:"wind_kph"`
+		WindDir          string  `json:"wind_dir"`
+		WindDegree       int     `json:"wind_degree"`
+		PressureMb       float64 `json:"pressure_mb"`
+		Cloud            int     `json:"cloud"`
+		Condition        struct {
+			Text string `json:"text"`
+			Code int    `json:"code"`
+		} `json:"condition"`
+		AirQuality *struct {
+			PM25       float64 `json:"pm2_5"`
+			PM10       float64 `json:"pm10"`
+			USEPAIndex int     `json:"us-epa-index"`
+		} `json:"air_quality,omitempty"`
+	} `json:"current"`
+}
+
+// Em modo degradado o serviço continua respondendo 200, mas lista os problemas
+// Com ?deep=true também verifica se a ViaCEP e a WeatherAPI estão acessíveis
+func (s *Server) healthHandler(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+
+	response := map[string]interface{}{"status": "ok"}
+	if problems := serviceHealth.snapshot(); problems != nil {
+		response["status"] = "degraded"
+		response["problems"] = problems
+	}
+	if queryFlag(r, "deep") {
+		dependencies := s.deepHealth.check()
+		for _, dependency := range dependencies {
+			if dependency.Status != "ok" {
+				response["status"] = "degraded"
+			}
+		}
+		response["dependencies"] = dependencies
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) weatherHandler(w http.ResponseWriter, r *http.Request) {
+	r = r.WithContext(startReading(r.Context()))
+	units, ok := unitsFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if country, ok := internationalCountry(r); ok {
+		s.internationalWeatherHandler(w, r, country, units)
+		return
+	}
+
+	cep := cepParam(r)
+	address, ok := s.resolveAddress(w, r, cep)
+	if !ok {
+		return
+	}
+
+	response, tempC, ok := s.currentWeatherResponse(w, r, address.location(), units)
+	if !ok {
+		return
+	}
+	response.cep = address.Cep
+	response.city = address.Localidade
+	if queryIncludes(r, "location") {
+		response.Location = address.address()
+	}
+	if queryFlag(r, "extended") {
+		response.IBGE = address.Ibge
+	}
+	response.noteLocationProvider(address.provider)
+
+	log.Printf("Successfully processed CEP %s: %.1f°C, %.1f°F, %.1f°K", cep, tempC, weather.CelsiusToFahrenheit(tempC), weather.CelsiusToKelvin(tempC))
+	s.recordLookup(r.Context(), address, tempC)
+	s.publishReading(r.Context(), address, tempC)
+
+	// Retornar resposta
+	writeResponse(w, r, http.StatusOK, response)
+}
+
+// Busca o clima atual de location e monta a resposta de /weather com os
+// parâmetros da requisição (feels_like, aqi, extended). Quando a consulta
+// falha ou o cliente já tem a versão atual (304), escreve a resposta e
+// retorna ok false
+func (s *Server) currentWeatherResponse(w http.ResponseWriter, r *http.Request, location string, units temperatureUnits) (*WeatherResponse, float64, bool) {
+	// Buscar clima atual pela localização
+	withAQI := queryFlag(r, "aqi")
+	current, err := s.getCurrentWeather(r.Context(), location, withAQI)
+	if err != nil {
+		log.Printf("ERROR: Failed to get temperature for location '%s': %v", location, err)
+		status, body := httpError(err)
+		writeResponse(w, r, status, body)
+		return nil, 0, false
+	}
+
+	// Enquanto a WeatherAPI não publicar uma nova observação, o cliente pode
+	// reaproveitar a resposta que já tem
+	etag := weatherETag(r, location, current.Current.LastUpdatedEpoch)
+	w.Header().Set("ETag", etag)
+	writeStaleHeader(w, r)
+	if etagMatches(r.Header.Get("If-None-Match"), etag) {
+		w.WriteHeader(http.StatusNotModified)
+		return nil, 0, false
+	}
+
+	// Converter temperaturas
+	tempC := current.Current.TempC
+	response := newWeatherResponse(tempC, units)
+
+	if queryFlag(r, "feels_like") {
+		feelsLike := newWeatherResponse(current.Current.FeelsLikeC, units)
+		response.FeelsLikeC = feelsLike.TempC
+		response.FeelsLikeF = feelsLike.TempF
+		response.FeelsLikeK = feelsLike.TempK
+		response.FeelsLikeR = feelsLike.TempR
+	}
+
+	if withAQI && current.Current.AirQuality != nil {
+		aq := current.Current.AirQuality
+		response.AirQuality = &AirQuality{PM25: aq.PM25, PM10: aq.PM10, USEPAIndex: aq.USEPAIndex}
+	}
+
+	if queryFlag(r, "extended") {
+		response.Conditions = current.conditions()
+	}
+	response.Meta = responseMeta(r, current)
+	return &response, tempC, true
+}
+
+// Lê o parâmetro units, respondendo 422 quando alguma escala é desconhecida
+func unitsFromRequest(w http.ResponseWriter, r *http.Request) (temperatureUnits, bool) {
+	units, err := parseUnits(r.URL.Query().Get("units"))
+	if err != nil {
+		log.Printf("Invalid units: %v", err)
+		writeResponse(w, r, http.StatusUnprocessableEntity, ErrorResponse{Message: "invalid units"})
+		return temperatureUnits{}, false
+	}
+	return units, true
+}
+
+// Extrai o CEP do parâmetro {cep} da rota, sem pontos, espaços nem escapes
+func cepParam(r *http.Request) string {
+	return cep.Clean(chi.URLParam(r, "cep"))
+}
+
+// Valida o CEP e resolve a localização, escrevendo a resposta de erro quando
+// algum passo falha
+func (s *Server) resolveLocation(w http.ResponseWriter, r *http.Request, cep string) (string, bool) {
+	address, ok := s.resolveAddress(w, r, cep)
+	if !ok {
+		return "", false
+	}
+	return address.location(), true
+}
+
+// Como resolveLocation, mas devolve o endereço completo do ViaCEP
+func (s *Server) resolveAddress(w http.ResponseWriter, r *http.Request, cep string) (*ViaCEPResponse, bool) {
+	log.Printf("Received request for CEP: %s", cep)
+
+	// Validar formato do CEP (8 dígitos)
+	if !isValidCEP(cep) {
+		log.Printf("Invalid CEP format: %s", cep)
+		status, body := httpError(ErrInvalidCEP)
+		writeResponse(w, r, status, body)
+		return nil, false
+	}
+
+	// Buscar localização pelo CEP
+	address, err := s.getAddressByCEP(r.Context(), cep)
+	if err != nil {
+		if errors.Is(err, ErrCEPNotFound) {
+			log.Printf("CEP not found: %s", cep)
+		} else {
+			log.Printf("ERROR: Failed to get location for CEP %s: %v", cep, err)
+		}
+		status, body := httpError(err)
+		writeResponse(w, r, status, body)
+		return nil, false
+	}
+
+	log.Printf("Found location for CEP %s: %s", cep, address.location())
+	if address.approximate {
+		w.Header().Set("X-Location-Source", approximateSource)
+	}
+	noteUsageAddress(r.Context(), address)
+	return address, true
+}
+
+// Valida o CEP e busca o endereço fora de um handler HTTP. Os erros trazem
+// as mesmas mensagens usadas pela API REST e continuam comparáveis com
+// errors.Is (ErrInvalidCEP, ErrCEPNotFound, ErrUpstreamUnavailable)
+func (s *Server) lookupAddress(ctx context.Context, cep string) (*ViaCEPResponse, error) {
+	cep = cleanCEP(cep)
+	if !isValidCEP(cep) {
+		return nil, newPublicError(ErrInvalidCEP)
+	}
+
+	address, err := s.getAddressByCEP(ctx, cep)
+	if err != nil {
+		if !errors.Is(err, ErrCEPNotFound) {
+			log.Printf("ERROR: Failed to get location for CEP %s: %v", cep, err)
+		}
+		return nil, newPublicError(err)
+	}
+	return address, nil
+}
+
+// Fluxo completo CEP → temperatura atual fora de um handler HTTP
+func (s *Server) lookupWeather(ctx context.Context, cep string, units temperatureUnits) (*WeatherResponse, error) {
+	ctx = startReading(ctx)
+	address, err := s.lookupAddress(ctx, cep)
+	if err != nil {
+		return nil, err
+	}
+	noteUsageAddress(ctx, address)
+
+	current, err := s.getCurrentWeather(ctx, address.location(), false)
+	if err != nil {
+		log.Printf("ERROR: Failed to get temperature for location '%s': %v", address.location(), err)
+		return nil, newPublicError(err)
+	}
+
+	s.recordLookup(ctx, address, current.Current.TempC)
+	s.publishReading(ctx, address, current.Current.TempC)
+	response := newWeatherResponse(current.Current.TempC, units)
+	response.cep, response.city = address.Cep, address.Localidade
+	return &response, nil
+}
+
+// Interpreta um parâmetro de query booleano (true, 1, ...); ausente ou inválido é false
+func queryFlag(r *http.Request, name string) bool {
+	enabled, _ := strconv.ParseBool(r.URL.Query().Get(name))
+	return enabled
+}
+
+// Verifica se o parâmetro include (lista separada por vírgula) contém o valor
+func queryIncludes(r *http.Request, value string) bool {
+	for _, item := range strings.Split(r.URL.Query().Get("include"), ",") {
+		if strings.TrimSpace(item) == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Atalho para cep.Clean nas funções em que "cep" é o nome do parâmetro
+func cleanCEP(code string) string {
+	return cep.Clean(code)
+}
+
+// Atalho para cep.Valid nas funções em que "cep" é o nome do parâmetro
+func isValidCEP(code string) bool {
+	return cep.Valid(code)
+}
+
+func (s *Server) getAddressByCEP(ctx context.Context, cep string) (*ViaCEPResponse, error) {
+	// Remove hífens do CEP
+	cep = strings.ReplaceAll(cep, "-", "")
+
+	if strictCEP() && !allocatedCEP(cep) {
+		log.Printf("CEP %s is outside the ranges of every state", cep)
+		return nil, ErrCEPPrefixNotAllocated
+	}
+
+	if offlineCEP() {
+		address, ok := offlineAddress(cep)
+		if !ok {
+			log.Printf("CEP %s is not in the offline dataset", cep)
+			return nil, ErrCEPNotFound
+		}
+		return address, nil
+	}
+
+	// Consultas simultâneas ao mesmo CEP viram uma só chamada ao ViaCEP. Quem
+	// desiste (ctx) deixa de esperar, sem cancelar a consulta dos demais
+	var result singleflight.Result
+	select {
+	case result = <-s.viaCEPFlight.DoChan(cep, func() (interface{}, error) { return s.fetchAddress(cep) }):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if result.Err == nil {
+		// Cópia, para que quem recebe o endereço compartilhado possa alterá-lo
+		copied := *result.Val.(*ViaCEPResponse)
+		return &copied, nil
+	}
+	if errors.Is(result.Err, ErrCEPNotFound) {
+		return nil, result.Err
+	}
+
+	// Com o ViaCEP fora do ar, a cidade da tabela embutida é melhor que um 500.
+	// Um CEP que o ViaCEP disse não existir continua sendo 404
+	err := &UpstreamError{Provider: cepProvidersName, Err: result.Err}
+	if config.Bool("CEP_FALLBACK", true) {
+		if approximate, ok := offlineAddress(cep); ok {
+			log.Printf("WARNING: ViaCEP unavailable for CEP %s (%v), using approximate location %s", cep, result.Err, approximate.location())
+			return approximate, nil
+		}
+	}
+	return nil, err
+}
+
+// Consulta os provedores de CEP, usando o cache de endereços quando
+// configurado. CEPs inexistentes ficam em cache por CEP_NOT_FOUND_TTL
+func (s *Server) fetchAddress(cep string) (*ViaCEPResponse, error) {
+	if s.knownNotFoundCEP(cep) {
+		log.Printf("CEP %s recently not found, skipping CEP providers", cep)
+		return nil, ErrCEPNotFound
+	}
+
+	var cached *cachedAddress
+	if s.addresses != nil {
+		if entry, ok := s.addresses.get(cep); ok {
+			if entry.fresh(time.Now()) {
+				addressCacheCounters.Hit()
+				entry.Address.provider = locationProviderAddressCache
+				return &entry.Address, nil
+			}
+			cached = &entry
+		}
+		addressCacheCounters.Miss()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), config.Duration("VIACEP_TIMEOUT", defaultViaCEPTimeout))
+	defer cancel()
+	address, err := s.queryCEPProviders(ctx, cep)
+	if errors.Is(err, errCircuitOpen) && cached != nil {
+		log.Printf("WARNING: CEP provider circuit breaker open, serving cached address for CEP %s", cep)
+		addressCacheCounters.Stale()
+		cached.Address.provider = locationProviderAddressCache
+		return &cached.Address, nil
+	}
+	if errors.Is(err, ErrCEPNotFound) {
+		s.rememberNotFoundCEP(cep)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.addresses != nil {
+		if err := s.addresses.set(cep, address); err != nil {
+			log.Printf("ERROR: Failed to cache address for CEP %s: %v", cep, err)
+		}
+	}
+	return address, nil
+}
+
+func (s *Server) queryViaCEP(ctx context.Context, code string) (*ViaCEPResponse, error) {
+	return queryCEPProvider(ctx, viaCEPProvider, s.viaCEP, code)
+}
+
+// Consulta um provedor de CEP passando pelo circuit breaker dele. Um CEP
+// inexistente é uma resposta válida e não conta como falha do provedor
+func queryCEPProvider(ctx context.Context, p *provider, client cepClient, code string) (*ViaCEPResponse, error) {
+	if !p.breaker.allow() {
+		return nil, errCircuitOpen
+	}
+
+	start := time.Now()
+	address, err := client.Lookup(ctx, code)
+	p.recordCall(ctx, err == nil || errors.Is(err, cep.ErrNotFound), time.Since(start))
+	if err != nil {
+		return nil, err
+	}
+	response := viaCEPResponse(address)
+	response.provider = p.name
+	return response, nil
+}
+
+// Consulta de CEP de um provedor (cep.ViaCEP, cep.BrasilAPI)
+type cepClient interface {
+	Lookup(ctx context.Context, cep string) (*cep.Address, error)
+}
+
+func (v ViaCEPResponse) address() *Address {
+	address := &Address{
+		Cep:        v.Cep,
+		Logradouro: v.Logradouro,
+		Bairro:     v.Bairro,
+		Localidade: v.Localidade,
+		UF:         v.UF,
+	}
+	if v.approximate {
+		address.Source = approximateSource
+	}
+	return address
+}
+
+// Cidade e estado no formato usado para consultar a WeatherAPI
+func (v ViaCEPResponse) location() string {
+	return fmt.Sprintf("%s,%s", v.Localidade, v.UF)
+}
+
+func (s *Server) getCurrentWeather(ctx context.Context, location string, withAQI bool) (*WeatherAPIResponse, error) {
+	log.Printf("Fetching weather for location: %s", location)
+
+	aqi := "no"
+	if withAQI {
+		aqi = "yes"
+	}
+
+	var weatherAPI WeatherAPIResponse
+	params := url.Values{"q": {location}, "aqi": {aqi}}
+	if err := s.callWeatherAPI(ctx, "current.json", params, &weatherAPI); err != nil {
+		return nil, err
+	}
+
+	log.Printf("Successfully fetched temperature for %s: %.1f°C", location, weatherAPI.Current.TempC)
+	return &weatherAPI, nil
+}
+
+func (w WeatherAPIResponse) conditions() *Conditions {
+	c := w.Current
+	return &Conditions{
+		Humidity:      c.Humidity,
+		WindKph:       c.WindKph,
+		WindDir:       c.WindDir,
+		WindDegree:    c.WindDegree,
+		PressureMb:    c.PressureMb,
+		Cloud:         c.Cloud,
+		ConditionText: c.Condition.Text,
+		ConditionCode: c.Condition.Code,
+	}
+}
+
+// Faz a chamada a um endpoint da WeatherAPI e decodifica a resposta em out.
+// Respostas em cache dentro do TTL do endpoint dispensam a chamada; com a
+// cota esgotada, uma resposta em cache é usada mesmo que antiga. Com
+// WEATHER_CACHE_STALE_TIMEOUT, a resposta vencida também é usada quando a
+// WeatherAPI falha ou demora, e a chamada lenta renova o cache em segundo
+// plano. Requisições de um tenant usam as chaves e o orçamento dele; o cache
+// é compartilhado
+func (s *Server) callWeatherAPI(ctx context.Context, endpoint string, params url.Values, out interface{}) error {
+	keys, quota, err := upstreamCredentials(ctx)
+	if err != nil {
+		log.Printf("ERROR: %v", err)
+		return errWeatherAPIKeyMissing
+	}
+	if len(keys) == 0 {
+		log.Println("ERROR: WEATHER_API_KEY not set")
+		return errWeatherAPIKeyMissing
+	}
+
+	cacheKey := endpoint + "?" + params.Encode()
+	cached, hasCached := s.weatherCache.Get(cacheKey)
+	if hasCached && !cacheRefresh(ctx) && s.weatherCache.Fresh(cached, weatherCacheTTLFor(endpoint)) {
+		s.weatherCache.Hit()
+		noteUsageCache(ctx, true)
+		noteReadingSource(ctx, true)
+		return weatherAPIFailure(decodeWeatherAPIBody(cached.Body, out))
+	}
+
+	if !cacheRefresh(ctx) {
+		s.weatherCache.Miss()
+	}
+
+	// Chamadas simultâneas idênticas do mesmo tenant e subject viram uma só,
+	// já que cada um tem seu orçamento. A chamada compartilhada não é
+	// cancelada quando o cliente que a iniciou desiste
+	flightKey := tenantLabel(ctx) + "\x00" + requestSubject(ctx) + "\x00" + cacheKey
+	shared := context.WithoutCancel(ctx)
+	flight := s.weatherFlight.DoChan(flightKey, func() (interface{}, error) {
+		return s.fetchWeatherAPIResult(shared, endpoint, params, keys, quota)
+	})
+
+	// Com uma resposta vencida em mãos, a espera pela WeatherAPI é limitada
+	var staleAfter <-chan time.Time
+	staleTimeout := weatherCacheStaleTimeout()
+	serveStale := hasCached && !cacheRefresh(ctx) && staleTimeout > 0
+	if serveStale {
+		timer := time.NewTimer(staleTimeout)
+		defer timer.Stop()
+		staleAfter = timer.C
+	}
+
+	var call singleflight.Result
+	select {
+	case call = <-flight:
+	case <-staleAfter:
+		log.Printf("WARNING: Weather API slower than %s, serving stale cached %s (q=%s)", staleTimeout, endpoint, params.Get("q"))
+		go s.refreshStaleWeather(cacheKey, flight)
+		return s.serveStaleWeather(ctx, cached.Body, out)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+	if call.Err != nil {
+		if serveStale {
+			log.Printf("WARNING: Weather API failed (%v), serving stale cached %s (q=%s)", call.Err, endpoint, params.Get("q"))
+			return s.serveStaleWeather(ctx, cached.Body, out)
+		}
+		return weatherAPIFailure(call.Err)
+	}
+
+	result := call.Val.(weatherAPIResult)
+	if result.cached {
+		return s.serveStaleWeather(ctx, result.body, out)
+	}
+	noteUsageCache(ctx, false)
+	noteReadingSource(ctx, false)
+	if err := decodeWeatherAPIBody(result.body, out); err != nil {
+		return weatherAPIFailure(err)
+	}
+	s.weatherCache.Set(cacheKey, result.body)
+	serviceHealth.resolve("weather_api")
+	return nil
+}
+
+// Sem chave para o tenant (ou WEATHER_API_KEY ausente)
+var errWeatherAPIKeyMissing = errors.New("weather API key not configured")
+
+func weatherAPIFailure(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &UpstreamError{Provider: weatherAPIProvider.name, Err: err}
+}
+
+// Corpo obtido para uma chamada; cached indica uma resposta antiga do cache,
+// usada com o circuit breaker aberto ou a cota esgotada
+type weatherAPIResult struct {
+	body   []byte
+	cached bool
+}
+
+func (s *Server) fetchWeatherAPIResult(ctx context.Context, endpoint string, params url.Values, keys []string, quota *quotaBudget) (weatherAPIResult, error) {
+	cached, hasCached := s.weatherCache.Get(endpoint + "?" + params.Encode())
+
+	// Com o provedor fora do ar, uma resposta antiga é melhor que nenhuma
+	if !weatherAPIProvider.breaker.allow() {
+		if hasCached {
+			log.Printf("WARNING: Weather API circuit breaker open, serving cached %s (q=%s)", endpoint, params.Get("q"))
+			return weatherAPIResult{body: cached.Body, cached: true}, nil
+		}
+		return weatherAPIResult{}, errCircuitOpen
+	}
+
+	tenant, subject := tenantLabel(ctx), subjectLabel(ctx)
+	if !reserveQuotas(quota, subjectQuota(ctx)) {
+		if hasCached {
+			log.Printf("WARNING: Weather API quota exhausted for tenant %s subject %s, serving cached %s (q=%s)", tenant, subject, endpoint, params.Get("q"))
+			return weatherAPIResult{body: cached.Body, cached: true}, nil
+		}
+		log.Printf("ERROR: Weather API quota exhausted for tenant %s subject %s, no cached %s for q=%s", tenant, subject, endpoint, params.Get("q"))
+		return weatherAPIResult{}, errQuotaExhausted
+	}
+
+	log.Printf("Calling weather API %s for tenant %s subject %s (q=%s)", endpoint, tenant, subject, params.Get("q"))
+
+	body, err := s.fetchWeatherAPI(ctx, endpoint, params, keys)
+	if err != nil {
+		return weatherAPIResult{}, err
+	}
+	return weatherAPIResult{body: body}, nil
+}
+
+// Tenta as chaves em rodízio. Uma chave recusada pela WeatherAPI (inválida,
+// desativada ou sem cota no plano) fica de fora por WEATHER_API_KEY_COOLDOWN
+// e a requisição segue com a próxima
+func (s *Server) fetchWeatherAPI(ctx context.Context, endpoint string, params url.Values, keys []string) ([]byte, error) {
+	var lastErr error
+	for _, key := range weatherAPIKeyPool.candidates(keys) {
+		start := time.Now()
+		body, status, err := s.fetchWeatherAPIWithKey(ctx, endpoint, params, key)
+		serverFailure := err != nil && (status == 0 || status >= http.StatusInternalServerError)
+		weatherAPIProvider.record(!serverFailure, time.Since(start))
+		if err == nil {
+			return body, nil
+		}
+		if serverFailure {
+			return nil, err
+		}
+		if status != http.StatusUnauthorized && status != http.StatusForbidden {
+			return nil, err
+		}
+
+		log.Printf("WARNING: Weather API rejected key %s (status %d), trying the next key", maskKey(key), status)
+		weatherAPIKeyPool.disable(key)
+		lastErr = err
+	}
+	return nil, lastErr
+}
+
+// Chama a WeatherAPI com uma chave e retorna o status HTTP junto com o erro,
+// 0 quando a WeatherAPI nem respondeu
+func (s *Server) fetchWeatherAPIWithKey(ctx context.Context, endpoint string, params url.Values, key string) ([]byte, int, error) {
+	body, err := s.weather.Fetch(ctx, endpoint, params, key)
+	var statusErr *weather.StatusError
+	if errors.As(err, &statusErr) {
+		log.Printf("ERROR: Weather API returned status %d for %s (q=%s)", statusErr.Status, endpoint, params.Get("q"))
+		if statusErr.Details != nil {
+			log.Printf("Weather API error details: %+v", statusErr.Details)
+		}
+		return nil, statusErr.Status, err
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to fetch weather data: %v", err)
+		return nil, 0, err
+	}
+	return body, http.StatusOK, nil
+}
+
+func decodeWeatherAPIBody(body []byte, out interface{}) error {
+	if err := json.Unmarshal(body, out); err != nil {
+		log.Printf("ERROR: Failed to decode weather API response: %v", err)
+		return fmt.Errorf("failed to parse weather data: %v", err)
+	}
+	return nil
+}
+
+func (wr WeatherResponse) csvHeader() []string {
+	return []string{"cep", "city", "temp_C", "temp_F", "temp_K"}
+}
+
+func (wr WeatherResponse) csvRecords() [][]string {
+	return [][]string{{wr.cep, wr.city, formatCSVFloat(wr.TempC), formatCSVFloat(wr.TempF), formatCSVFloat(wr.TempK)}}
+}
+
+func (e ErrorResponse) csvHeader() []string {
+	return []string{"message"}
+}
+
+func (e ErrorResponse) csvRecords() [][]string {
+	return [][]string{{e.Message}}
+}
+
+// Escalas não selecionadas ficam como célula vazia
+func formatCSVFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
+
+// Monta a resposta apenas com as escalas selecionadas, já arredondadas
+func newWeatherResponse(celsius float64, units temperatureUnits) WeatherResponse {
+	var response WeatherResponse
+	if units.C {
+		rounded := roundTemperature(celsius)
+		response.TempC = &rounded
+	}
+	if units.F {
+		fahrenheit := roundTemperature(weather.CelsiusToFahrenheit(celsius))
+		response.TempF = &fahrenheit
+	}
+	if units.K {
+		kelvin := roundTemperature(weather.CelsiusToKelvin(celsius))
+		response.TempK = &kelvin
+	}
+	if units.R {
+		rankine := roundTemperature(weather.CelsiusToRankine(celsius))
+		response.TempR = &rankine
+	}
+	return response
+}
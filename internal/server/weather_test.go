@@ -0,0 +1,408 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/internal/cep"
+	"github.com/weather-service/internal/weather"
+)
+
+const viaCEPSaoPaulo = `{"cep":"01310-100","logradouro":"Avenida Paulista","bairro":"Bela Vista","localidade":"São Paulo","uf":"SP"}`
+
+// Server com os provedores apontando para um servidor fake, que responde
+// pelo ViaCEP, pela WeatherAPI e pelos demais com as respostas indexadas pelo
+// path da requisição
+func newFakeUpstreamServer(t testing.TB, responses map[string]string) *Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, ok := responses[r.URL.Path]
+		if !ok {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":{"code":1006,"message":"No matching location found."}}`))
+			return
+		}
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+
+	t.Setenv("WEATHER_API_KEY", "test-key")
+	resetProviders()
+	t.Cleanup(resetProviders)
+
+	return New(Clients{
+		viaCEP:     cep.NewViaCEP(server.URL+"/ws", nil),
+		brasilAPI:  cep.NewBrasilAPI(server.URL+"/brasilapi", nil),
+		weather:    weather.NewClient(server.URL+"/v1", nil),
+		ibge:       cep.NewIBGE(server.URL+"/ibge", nil),
+		zippopotam: cep.NewZippopotam(server.URL+"/zippopotam", nil),
+	})
+}
+
+func TestWeatherHandler_InvalidCEP(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	tests := []struct {
+		name           string
+		cep            string
+		expectedStatus int
+		expectedMsg    string
+	}{
+		{"CEP with letters", "0131010a", http.StatusUnprocessableEntity, "invalid zipcode"},
+		{"CEP too short", "0131010", http.StatusUnprocessableEntity, "invalid zipcode"},
+		{"CEP too long", "013101000", http.StatusUnprocessableEntity, "invalid zipcode"},
+		{"Empty CEP", "", http.StatusUnprocessableEntity, "invalid zipcode"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/weather/"+tt.cep, nil)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+
+			var response ErrorResponse
+			err = json.NewDecoder(rr.Body).Decode(&response)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedMsg, response.Message)
+		})
+	}
+}
+
+// Pontos, espaços e escapes são removidos antes da validação
+func TestWeatherHandler_LenientCEP(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, fakeWeatherClient{"São Paulo,SP": 25})
+
+	for _, path := range []string{"/weather/01310.100", "/weather/01310%20100", "/weather/01.310-100", "/weather/01310%2E100", "/v1/weather/01310.100"} {
+		t.Run(path, func(t *testing.T) {
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+			assert.Equal(t, http.StatusOK, rr.Code, rr.Body.String())
+		})
+	}
+
+	// Nas consultas fora da rota também
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/compare?cep1=01310.100&cep2=01310%20100", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// O hífen pode estar em qualquer posição, como sempre foi
+	rr = httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/0131-0100", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+}
+
+// Provedor de CEP em memória: CEPs fora do mapa não existem
+type fakeCEPClient map[string]*cep.Address
+
+func (f fakeCEPClient) Lookup(ctx context.Context, code string) (*cep.Address, error) {
+	if address, ok := f[code]; ok {
+		return address, nil
+	}
+	return nil, cep.ErrNotFound
+}
+
+// WeatherAPI em memória, com a temperatura de cada localização (parâmetro q)
+type fakeWeatherClient map[string]float64
+
+func (f fakeWeatherClient) Fetch(ctx context.Context, endpoint string, params url.Values, key string) ([]byte, error) {
+	tempC, ok := f[params.Get("q")]
+	if !ok {
+		return nil, &weather.StatusError{Status: http.StatusBadRequest}
+	}
+	return []byte(fmt.Sprintf(`{"current":{"temp_c":%g}}`, tempC)), nil
+}
+
+// Server com os provedores em memória, sem nenhuma chamada de rede
+func newFakeClientsServer(t testing.TB, addresses fakeCEPClient, temperatures fakeWeatherClient) *Server {
+	t.Helper()
+	s := newFakeUpstreamServer(t, nil)
+	s.viaCEP, s.brasilAPI, s.weather = addresses, addresses, temperatures
+	return s
+}
+
+func TestWeatherHandler_FakeClients(t *testing.T) {
+	s := newFakeClientsServer(t,
+		fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}},
+		fakeWeatherClient{"São Paulo,SP": 25},
+	)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"temp_C":25,"temp_F":77,"temp_K":298.15}`, rr.Body.String())
+}
+
+func TestWeatherHandler_CEPNotFound(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{}, fakeWeatherClient{})
+
+	// CEP válido no formato mas que não existe
+	req, err := http.NewRequest("GET", "/weather/99999999", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+
+	var response ErrorResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, "can not find zipcode", response.Message)
+}
+
+func TestHealthHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	req, err := http.NewRequest("GET", "/", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response map[string]string
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, "ok", response["status"])
+}
+
+// Teste de integração - requer WEATHER_API_KEY configurada
+func TestWeatherHandler_ValidCEP_Integration(t *testing.T) {
+	// Este teste só roda se a variável de ambiente estiver configurada
+	// Usa um CEP válido de São Paulo
+	t.Skip("Integration test - requires WEATHER_API_KEY")
+	s := New(DefaultClients())
+
+	req, err := http.NewRequest("GET", "/weather/01310100", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response WeatherResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	assert.NoError(t, err)
+
+	// Verifica se as temperaturas foram calculadas corretamente
+	expectedF := *response.TempC*1.8 + 32
+	expectedK := *response.TempC + 273.15
+
+	assert.Equal(t, expectedF, *response.TempF)
+	assert.Equal(t, expectedK, *response.TempK)
+}
+
+func TestWeatherHandler_AirQuality(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25,"air_quality":{"pm2_5":12.5,"pm10":20.1,"us-epa-index":1}}}`,
+	})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected *AirQuality
+	}{
+		{"Without aqi flag", "", nil},
+		{"With aqi flag", "?aqi=true", &AirQuality{PM25: 12.5, PM10: 20.1, USEPAIndex: 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/weather/01310100"+tt.query, nil)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+
+			var response WeatherResponse
+			err = json.NewDecoder(rr.Body).Decode(&response)
+			assert.NoError(t, err)
+			assert.Equal(t, 25.0, *response.TempC)
+			assert.Equal(t, tt.expected, response.AirQuality)
+		})
+	}
+}
+
+func TestWeatherHandler_Extended(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25,"humidity":62,"wind_kph":11.2,"wind_dir":"SE","wind_degree":140,"pressure_mb":1015,"cloud":25,"condition":{"text":"Partly cloudy","code":1003}}}`,
+	})
+
+	req, err := http.NewRequest("GET", "/weather/01310100?extended=true", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	var response WeatherResponse
+	err = json.NewDecoder(rr.Body).Decode(&response)
+	assert.NoError(t, err)
+	assert.Equal(t, &Conditions{
+		Humidity:      62,
+		WindKph:       11.2,
+		WindDir:       "SE",
+		WindDegree:    140,
+		PressureMb:    1015,
+		Cloud:         25,
+		ConditionText: "Partly cloudy",
+		ConditionCode: 1003,
+	}, response.Conditions)
+}
+
+func TestWeatherHandler_FeelsLike(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25,"feelslike_c":27}}`,
+	})
+
+	tests := []struct {
+		name     string
+		query    string
+		expected string
+	}{
+		{"Without feels_like flag", "", `{"temp_C":25,"temp_F":77,"temp_K":298.15}`},
+		{"With feels_like flag", "?feels_like=true", `{"temp_C":25,"temp_F":77,"temp_K":298.15,"feels_like_C":27,"feels_like_F":80.6,"feels_like_K":300.15}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/weather/01310100"+tt.query, nil)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, req)
+
+			assert.Equal(t, http.StatusOK, rr.Code)
+			assert.JSONEq(t, tt.expected, rr.Body.String())
+		})
+	}
+}
+
+func TestWeatherHandler_Units(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":0,"feelslike_c":-2}}`,
+	})
+
+	tests := []struct {
+		name           string
+		query          string
+		expectedStatus int
+		expected       string
+	}{
+		{"Default returns all units", "", http.StatusOK, `{"temp_C":0,"temp_F":32,"temp_K":273.15}`},
+		{"Only celsius", "?units=c", http.StatusOK, `{"temp_C":0}`},
+		{"Fahrenheit and kelvin", "?units=f,k", http.StatusOK, `{"temp_F":32,"temp_K":273.15}`},
+		{"Rankine", "?units=r", http.StatusOK, `{"temp_R":491.67}`},
+		{"Units apply to feels like", "?units=c&feels_like=true", http.StatusOK, `{"temp_C":0,"feels_like_C":-2}`},
+		{"Unknown unit", "?units=x", http.StatusUnprocessableEntity, `{"message":"invalid units"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest("GET", "/weather/01310100"+tt.query, nil)
+			assert.NoError(t, err)
+
+			rr := httptest.NewRecorder()
+			s.router().ServeHTTP(rr, req)
+
+			assert.Equal(t, tt.expectedStatus, rr.Code)
+			assert.JSONEq(t, tt.expected, rr.Body.String())
+		})
+	}
+}
+
+func TestWeatherHandler_Precision(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":28.47,"feelslike_c":31.04}}`,
+	})
+	t.Setenv("TEMP_PRECISION", "1")
+
+	req, err := http.NewRequest("GET", "/weather/01310100?feels_like=true", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"temp_C":28.5,"temp_F":83.2,"temp_K":301.6,"feels_like_C":31,"feels_like_F":87.9,"feels_like_K":304.2}`, rr.Body.String())
+}
+
+func TestWeatherHandler_IncludeLocation(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+
+	req, err := http.NewRequest("GET", "/weather/01310100?include=location&units=c", nil)
+	assert.NoError(t, err)
+
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+
+	assert.Equal(t, http.StatusOK, rr.Code)
+	assert.JSONEq(t, `{"temp_C":25,"location":{"cep":"01310-100","logradouro":"Avenida Paulista","bairro":"Bela Vista","localidade":"São Paulo","uf":"SP"}}`, rr.Body.String())
+}
+
+// Requisições simultâneas para o mesmo CEP fazem uma chamada a cada provedor
+func TestWeatherHandler_ConcurrentLookupsShareUpstreamCalls(t *testing.T) {
+	s := newFakeUpstreamServer(t, nil)
+	t.Setenv("WEATHER_API_KEY", "test-key")
+
+	var viaCEPCalls, weatherAPICalls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Lento o bastante para que todas as requisições cheguem durante a chamada
+		time.Sleep(200 * time.Millisecond)
+		if r.URL.Path == "/ws/01310100/json/" {
+			viaCEPCalls.Add(1)
+			w.Write([]byte(viaCEPSaoPaulo))
+			return
+		}
+		weatherAPICalls.Add(1)
+		w.Write([]byte(`{"current":{"temp_c":25}}`))
+	}))
+	defer server.Close()
+	s.viaCEP = cep.NewViaCEP(server.URL+"/ws", nil)
+	s.weather = weather.NewClient(server.URL+"/v1", nil)
+
+	router := s.router()
+	var wg sync.WaitGroup
+	codes := make([]int, 100)
+	for i := range codes {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			rr := httptest.NewRecorder()
+			router.ServeHTTP(rr, httptest.NewRequest("GET", "/weather/01310100", nil))
+			codes[i] = rr.Code
+		}(i)
+	}
+	wg.Wait()
+
+	for _, code := range codes {
+		assert.Equal(t, http.StatusOK, code)
+	}
+	assert.Equal(t, int32(1), viaCEPCalls.Load())
+	assert.Equal(t, int32(1), weatherAPICalls.Load())
+}
@@ -0,0 +1,607 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/mail"
+	"net/netip"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/weather-service/config"
+)
+
+const (
+	defaultWebhookPollInterval     = 5 * time.Minute
+	defaultWebhookTimeout          = 10 * time.Second
+	defaultWebhookMaxSubscriptions = 1000
+
+	webhookSignatureHeader = "X-Webhook-Signature"
+)
+
+// Escalas avaliadas pelas inscrições, nos nomes de campo de WeatherResponse
+var subscriptionMetrics = map[string]func(WeatherResponse) *float64{
+	"temp_C": func(w WeatherResponse) *float64 { return w.TempC },
+	"temp_F": func(w WeatherResponse) *float64 { return w.TempF },
+	"temp_K": func(w WeatherResponse) *float64 { return w.TempK },
+	"temp_R": func(w WeatherResponse) *float64 { return w.TempR },
+}
+
+var subscriptionOperators = map[string]func(value, threshold float64) bool{
+	">":  func(value, threshold float64) bool { return value > threshold },
+	">=": func(value, threshold float64) bool { return value >= threshold },
+	"<":  func(value, threshold float64) bool { return value < threshold },
+	"<=": func(value, threshold float64) bool { return value <= threshold },
+}
+
+// Situação de uma regra: ainda não verificada, condição falsa ou condição
+// verdadeira na última verificação
+const (
+	alertStatusPending = "pending"
+	alertStatusOK      = "ok"
+	alertStatusFiring  = "firing"
+)
+
+// Canais de entrega de uma regra. webhook envia o WebhookEvent em JSON;
+// slack, uma mensagem formatada para um incoming webhook do Slack; email, um
+// e-mail pelo servidor SMTP configurado
+const (
+	alertChannelWebhook = "webhook"
+	alertChannelSlack   = "slack"
+	alertChannelEmail   = "email"
+)
+
+// Inscrição (regra de alerta) em um limite de temperatura: quando a condição
+// passa a valer para o CEP, o serviço avisa CallbackURL (ou Email, no canal
+// email) no formato do canal. O aviso só sai na transição para firing, não a
+// cada verificação. Owner (veja ruleOwner) não sai nas respostas
+type Subscription struct {
+	ID          string     `json:"id"`
+	CEP         string     `json:"cep"`
+	Metric      string     `json:"metric"`
+	Operator    string     `json:"operator"`
+	Threshold   float64    `json:"threshold"`
+	Channel     string     `json:"channel"`
+	CallbackURL string     `json:"callback_url,omitempty"`
+	Email       string     `json:"email,omitempty"`
+	Status      string     `json:"status"`
+	CreatedAt   time.Time  `json:"created_at"`
+	LastFiredAt *time.Time `json:"last_fired_at,omitempty"`
+	Owner       string     `json:"-"`
+}
+
+// Channel vazio é webhook
+type SubscriptionRequest struct {
+	CEP         string   `json:"cep"`
+	Metric      string   `json:"metric"`
+	Operator    string   `json:"operator"`
+	Threshold   *float64 `json:"threshold"`
+	Channel     string   `json:"channel"`
+	CallbackURL string   `json:"callback_url"`
+	Email       string   `json:"email"`
+}
+
+type SubscriptionsResponse struct {
+	Subscriptions []Subscription `json:"subscriptions"`
+}
+
+// Corpo do POST enviado ao callback
+type WebhookEvent struct {
+	SubscriptionID string    `json:"subscription_id"`
+	CEP            string    `json:"cep"`
+	City           string    `json:"city"`
+	Metric         string    `json:"metric"`
+	Operator       string    `json:"operator"`
+	Threshold      float64   `json:"threshold"`
+	Value          float64   `json:"value"`
+	FiredAt        time.Time `json:"fired_at"`
+}
+
+func (s Subscription) matches(weather WeatherResponse) (float64, bool) {
+	value := subscriptionMetrics[s.Metric](weather)
+	if value == nil {
+		return 0, false
+	}
+	return *value, subscriptionOperators[s.Operator](*value, s.Threshold)
+}
+
+// Onde ficam as inscrições. create recusa com errSubscriptionLimit quando já
+// há WEBHOOK_MAX_SUBSCRIPTIONS inscrições
+type subscriptionStore interface {
+	create(ctx context.Context, subscription Subscription) error
+	// Da mais antiga para a mais recente
+	list(ctx context.Context) ([]Subscription, error)
+	get(ctx context.Context, id string) (Subscription, bool, error)
+	delete(ctx context.Context, id string) (bool, error)
+	// Grava o resultado da última verificação de uma inscrição. firedAt
+	// zerado mantém o último disparo registrado
+	setStatus(ctx context.Context, id, status string, firedAt time.Time) error
+}
+
+type memorySubscriptionStore struct {
+	mu            sync.Mutex
+	subscriptions map[string]Subscription
+}
+
+func newMemorySubscriptionStore() *memorySubscriptionStore {
+	return &memorySubscriptionStore{subscriptions: make(map[string]Subscription)}
+}
+
+func (s *memorySubscriptionStore) create(ctx context.Context, subscription Subscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.subscriptions) >= webhookMaxSubscriptions() {
+		return errSubscriptionLimit
+	}
+	s.subscriptions[subscription.ID] = subscription
+	return nil
+}
+
+func (s *memorySubscriptionStore) list(ctx context.Context) ([]Subscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscriptions := make([]Subscription, 0, len(s.subscriptions))
+	for _, subscription := range s.subscriptions {
+		subscriptions = append(subscriptions, subscription)
+	}
+	sort.Slice(subscriptions, func(i, j int) bool {
+		return subscriptions[i].CreatedAt.Before(subscriptions[j].CreatedAt)
+	})
+	return subscriptions, nil
+}
+
+func (s *memorySubscriptionStore) get(ctx context.Context, id string) (Subscription, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subscription, ok := s.subscriptions[id]
+	return subscription, ok, nil
+}
+
+func (s *memorySubscriptionStore) delete(ctx context.Context, id string) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.subscriptions[id]
+	delete(s.subscriptions, id)
+	return ok, nil
+}
+
+func (s *memorySubscriptionStore) setStatus(ctx context.Context, id, status string, firedAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if subscription, ok := s.subscriptions[id]; ok {
+		subscription.Status = status
+		if !firedAt.IsZero() {
+			subscription.LastFiredAt = &firedAt
+		}
+		s.subscriptions[id] = subscription
+	}
+	return nil
+}
+
+var errSubscriptionLimit = errors.New("subscription limit reached")
+
+func webhookMaxSubscriptions() int {
+	if max := config.Int("WEBHOOK_MAX_SUBSCRIPTIONS", 0); max > 0 {
+		return max
+	}
+	return defaultWebhookMaxSubscriptions
+}
+
+// Identificador aleatório de 128 bits em hexadecimal
+func newSubscriptionID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(fmt.Sprintf("crypto/rand failed: %v", err))
+	}
+	return hex.EncodeToString(id)
+}
+
+// Valida o corpo de POST /alert-rules. A mensagem do erro é a resposta 422
+func (req SubscriptionRequest) validate() error {
+	if !isValidCEP(req.CEP) {
+		return ErrInvalidCEP
+	}
+	if _, ok := subscriptionMetrics[req.Metric]; !ok {
+		return errors.New("invalid metric")
+	}
+	if _, ok := subscriptionOperators[req.Operator]; !ok {
+		return errors.New("invalid operator")
+	}
+	if req.Threshold == nil {
+		return errors.New("invalid threshold")
+	}
+	switch req.Channel {
+	case "", alertChannelWebhook, alertChannelSlack:
+	case alertChannelEmail:
+		if !emailConfigured() {
+			return errors.New("email channel not configured")
+		}
+		address, err := mail.ParseAddress(req.Email)
+		if err != nil {
+			return errors.New("invalid email")
+		}
+		if !allowedEmailRecipient(address.Address) {
+			return errors.New("email domain not allowed")
+		}
+		return nil
+	default:
+		return errors.New("invalid channel")
+	}
+	callback, err := url.Parse(req.CallbackURL)
+	if err != nil || (callback.Scheme != "http" && callback.Scheme != "https") || callback.Hostname() == "" {
+		return errors.New("invalid callback_url")
+	}
+	// Endereços internos escritos direto na URL são recusados já aqui; os
+	// nomes são checados de novo na entrega, depois de resolvidos
+	if !config.Bool("WEBHOOK_ALLOW_PRIVATE", false) && internalHost(callback.Hostname()) {
+		return errors.New("invalid callback_url")
+	}
+	// Os incoming webhooks do Slack são sempre HTTPS
+	if req.Channel == alertChannelSlack && callback.Scheme != "https" {
+		return errors.New("invalid callback_url")
+	}
+	return nil
+}
+
+// Hosts que apontam para a própria máquina, para a rede interna ou para o
+// metadata server do provedor de nuvem
+func internalHost(host string) bool {
+	if addr, err := netip.ParseAddr(host); err == nil {
+		return !publicAddress(addr)
+	}
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	return host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		host == "metadata" || strings.HasSuffix(host, ".internal")
+}
+
+// Dono das regras criadas pela requisição: o tenant, o subject do JWT ou a
+// chave de API conferida, da qual só o hash é gravado. Sem autenticação
+// configurada o dono é vazio, e as regras sem dono são vistas por todos os
+// clientes anônimos
+func ruleOwner(ctx context.Context) string {
+	if t := tenantFromContext(ctx); t != nil {
+		return "tenant:" + t.name
+	}
+	if subject := requestSubject(ctx); subject != "" {
+		return "sub:" + subject
+	}
+	if key := authenticatedAPIKey(ctx); key != "" {
+		sum := sha256.Sum256([]byte(key))
+		return "key:" + hex.EncodeToString(sum[:8])
+	}
+	return ""
+}
+
+// Regra do dono da requisição; as de outros donos ficam invisíveis, como se
+// não existissem
+func (s *Server) ownSubscription(ctx context.Context, id string) (Subscription, bool, error) {
+	subscription, ok, err := s.subscriptions.get(ctx, id)
+	if err != nil || !ok || subscription.Owner != ruleOwner(ctx) {
+		return Subscription{}, false, err
+	}
+	return subscription, true, nil
+}
+
+// Rotas de /alert-rules, também montadas em /subscriptions, o nome original
+func (s *Server) subscriptionRoutes(r chi.Router) {
+	r.With(limitBody(maxBodySize())).Post("/", s.createSubscriptionHandler)
+	r.Get("/", s.listSubscriptionsHandler)
+	r.Get("/{id}", s.getSubscriptionHandler)
+	r.Delete("/{id}", s.deleteSubscriptionHandler)
+}
+
+// POST /alert-rules: cria uma inscrição. O CEP precisa existir
+func (s *Server) createSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	var request SubscriptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		if isBodyTooLarge(err) {
+			writeResponse(w, r, http.StatusRequestEntityTooLarge, ErrorResponse{Message: "request body too large"})
+			return
+		}
+		writeResponse(w, r, http.StatusBadRequest, ErrorResponse{Message: "invalid request body"})
+		return
+	}
+	request.CEP = cleanCEP(request.CEP)
+	if err := request.validate(); err != nil {
+		writeResponse(w, r, http.StatusUnprocessableEntity, ErrorResponse{Message: err.Error()})
+		return
+	}
+	// E-mail só para clientes identificados, para o serviço não virar relay
+	// de spam com a autenticação desligada
+	if request.Channel == alertChannelEmail && ruleOwner(r.Context()) == "" {
+		writeResponse(w, r, http.StatusForbidden, ErrorResponse{Message: "email channel requires authentication"})
+		return
+	}
+
+	if _, err := s.lookupAddress(r.Context(), request.CEP); err != nil {
+		status, body := httpError(err)
+		writeResponse(w, r, status, body)
+		return
+	}
+
+	// Cada canal guarda só o próprio destino
+	channel := request.Channel
+	switch channel {
+	case "":
+		channel = alertChannelWebhook
+		request.Email = ""
+	case alertChannelEmail:
+		request.CallbackURL = ""
+	default:
+		request.Email = ""
+	}
+	subscription := Subscription{
+		ID:          newSubscriptionID(),
+		CEP:         strings.ReplaceAll(request.CEP, "-", ""),
+		Metric:      request.Metric,
+		Operator:    request.Operator,
+		Threshold:   *request.Threshold,
+		Channel:     channel,
+		CallbackURL: request.CallbackURL,
+		Email:       request.Email,
+		Status:      alertStatusPending,
+		Owner:       ruleOwner(r.Context()),
+		// Em segundos, a precisão com que o SQLite guarda o horário
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if err := s.subscriptions.create(r.Context(), subscription); err != nil {
+		if errors.Is(err, errSubscriptionLimit) {
+			writeResponse(w, r, http.StatusUnprocessableEntity, ErrorResponse{Message: err.Error()})
+			return
+		}
+		log.Printf("ERROR: Failed to create subscription for CEP %s: %v", subscription.CEP, err)
+		writeResponse(w, r, http.StatusInternalServerError, ErrorResponse{Message: "internal server error"})
+		return
+	}
+
+	log.Printf("Created subscription %s: CEP %s %s %s %g via %s", subscription.ID, subscription.CEP, subscription.Metric, subscription.Operator, subscription.Threshold, subscription.Channel)
+	w.Header().Set("Location", strings.TrimSuffix(r.URL.Path, "/")+"/"+subscription.ID)
+	writeResponse(w, r, http.StatusCreated, subscription)
+}
+
+// Lista só as regras do dono da requisição
+func (s *Server) listSubscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	all, err := s.subscriptions.list(r.Context())
+	if err != nil {
+		log.Printf("ERROR: Failed to list subscriptions: %v", err)
+		writeResponse(w, r, http.StatusInternalServerError, ErrorResponse{Message: "internal server error"})
+		return
+	}
+	owner := ruleOwner(r.Context())
+	list := []Subscription{}
+	for _, subscription := range all {
+		if subscription.Owner == owner {
+			list = append(list, subscription)
+		}
+	}
+	writeResponse(w, r, http.StatusOK, SubscriptionsResponse{Subscriptions: list})
+}
+
+func (s *Server) getSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	subscription, ok, err := s.ownSubscription(r.Context(), chi.URLParam(r, "id"))
+	if err != nil {
+		log.Printf("ERROR: Failed to read subscription: %v", err)
+		writeResponse(w, r, http.StatusInternalServerError, ErrorResponse{Message: "internal server error"})
+		return
+	}
+	if !ok {
+		notFoundHandler(w, r)
+		return
+	}
+	writeResponse(w, r, http.StatusOK, subscription)
+}
+
+func (s *Server) deleteSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	_, deleted, err := s.ownSubscription(r.Context(), id)
+	if err == nil && deleted {
+		deleted, err = s.subscriptions.delete(r.Context(), id)
+	}
+	if err != nil {
+		log.Printf("ERROR: Failed to delete subscription %s: %v", id, err)
+		writeResponse(w, r, http.StatusInternalServerError, ErrorResponse{Message: "internal server error"})
+		return
+	}
+	if !deleted {
+		notFoundHandler(w, r)
+		return
+	}
+	log.Printf("Deleted subscription %s", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// Verifica as inscrições a cada WEBHOOK_POLL_INTERVAL até ctx terminar
+func (s *Server) runSubscriptionPoller(ctx context.Context) {
+	ticker := time.NewTicker(config.Duration("WEBHOOK_POLL_INTERVAL", defaultWebhookPollInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.pollSubscriptions(ctx)
+		}
+	}
+}
+
+// Consulta a temperatura de cada CEP com inscrições uma única vez e dispara
+// os webhooks das condições que passaram a valer. Uma entrega que falha
+// deixa a inscrição como estava, para ser tentada na próxima verificação
+func (s *Server) pollSubscriptions(ctx context.Context) {
+	list, err := s.subscriptions.list(ctx)
+	if err != nil {
+		log.Printf("ERROR: Failed to list subscriptions: %v", err)
+		return
+	}
+
+	byCEP := make(map[string][]Subscription)
+	for _, subscription := range list {
+		byCEP[subscription.CEP] = append(byCEP[subscription.CEP], subscription)
+	}
+
+	for cep, group := range byCEP {
+		weather, err := s.lookupWeather(ctx, cep, graphqlUnits)
+		if err != nil {
+			log.Printf("WARNING: Failed to check subscriptions for CEP %s: %v", cep, err)
+			continue
+		}
+
+		for _, subscription := range group {
+			value, met := subscription.matches(*weather)
+			status, firedAt := alertStatusOK, time.Time{}
+			if met {
+				status = alertStatusFiring
+			}
+			if status == subscription.Status {
+				continue
+			}
+			if met {
+				firedAt = time.Now().UTC().Truncate(time.Second)
+				event := WebhookEvent{
+					SubscriptionID: subscription.ID,
+					CEP:            subscription.CEP,
+					City:           weather.city,
+					Metric:         subscription.Metric,
+					Operator:       subscription.Operator,
+					Threshold:      subscription.Threshold,
+					Value:          value,
+					FiredAt:        firedAt,
+				}
+				if err := notifyAlert(ctx, subscription, event, *weather); err != nil {
+					log.Printf("ERROR: Failed to deliver %s alert for subscription %s: %v", subscription.Channel, subscription.ID, err)
+					continue
+				}
+				log.Printf("Delivered %s alert for subscription %s: %s = %g", subscription.Channel, subscription.ID, subscription.Metric, value)
+				s.publishAlert(ctx, event)
+			}
+			if err := s.subscriptions.setStatus(ctx, subscription.ID, status, firedAt); err != nil {
+				log.Printf("ERROR: Failed to update subscription %s: %v", subscription.ID, err)
+			}
+		}
+	}
+}
+
+// Entrega o disparo de uma regra pelo canal dela
+func notifyAlert(ctx context.Context, subscription Subscription, event WebhookEvent, weather WeatherResponse) error {
+	switch subscription.Channel {
+	case alertChannelSlack:
+		return deliverSlack(ctx, subscription.CallbackURL, slackAlertMessage(event, weather))
+	case alertChannelEmail:
+		return deliverEmail(ctx, subscription.Email, event, weather)
+	}
+	return deliverWebhook(ctx, subscription.CallbackURL, event)
+}
+
+// Cliente das entregas. Não usa o transporte dos provedores: os callbacks
+// são de terceiros e têm outro perfil de conexão. Os endereços são checados
+// na conexão, depois da resolução do DNS, e redirecionamentos não são
+// seguidos: a resposta 3xx conta como falha da entrega
+var webhookClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: defaultWebhookTimeout,
+			Control: checkWebhookAddress,
+		}).DialContext,
+		TLSHandshakeTimeout: defaultWebhookTimeout,
+		MaxIdleConnsPerHost: 2,
+		IdleConnTimeout:     90 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	},
+}
+
+var errWebhookAddress = errors.New("callback address is not public")
+
+// Recusa a conexão com endereços que não são públicos (loopback, redes
+// privadas, link-local como o metadata server 169.254.169.254), a menos que
+// WEBHOOK_ALLOW_PRIVATE libere callbacks na rede interna
+func checkWebhookAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return err
+	}
+	if !publicAddress(addr) && !config.Bool("WEBHOOK_ALLOW_PRIVATE", false) {
+		return fmt.Errorf("%w: %s", errWebhookAddress, addr)
+	}
+	return nil
+}
+
+// Faixa compartilhada do CGNAT (RFC 6598), que netip não classifica
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+func publicAddress(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsGlobalUnicast() && !addr.IsPrivate() && !sharedAddressSpace.Contains(addr)
+}
+
+// POST do evento em JSON. Com WEBHOOK_SECRET, o corpo vai assinado em
+// X-Webhook-Signature (sha256=<HMAC-SHA256 em hex>)
+func deliverWebhook(ctx context.Context, callbackURL string, event WebhookEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	header := http.Header{}
+	if secret := config.String("WEBHOOK_SECRET"); secret != "" {
+		header.Set(webhookSignatureHeader, webhookSignature([]byte(secret), body))
+	}
+	return postAlert(ctx, callbackURL, body, header)
+}
+
+// POST de um corpo JSON a um destino de alerta, limitado a WEBHOOK_TIMEOUT.
+// Qualquer status fora de 2xx é uma falha
+func postAlert(ctx context.Context, target string, body []byte, header http.Header) error {
+	ctx, cancel := context.WithTimeout(ctx, config.Duration("WEBHOOK_TIMEOUT", defaultWebhookTimeout))
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "weather-service-webhooks/"+version)
+
+	resp, err := webhookClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("callback returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func webhookSignature(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,290 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func requestSubscriptions(t *testing.T, s *Server, method, path, body string) *httptest.ResponseRecorder {
+	return requestSubscriptionsAs(t, s, "", method, path, body)
+}
+
+// Requisição com a X-API-Key informada; vazia, sem o cabeçalho
+func requestSubscriptionsAs(t *testing.T, s *Server, key, method, path, body string) *httptest.ResponseRecorder {
+	t.Helper()
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if key != "" {
+		req.Header.Set(apiKeyHeader, key)
+	}
+	rr := httptest.NewRecorder()
+	s.router().ServeHTTP(rr, req)
+	return rr
+}
+
+// Callback que guarda os eventos recebidos e responde com status. Roda em
+// 127.0.0.1, então libera callbacks na rede interna
+type webhookReceiver struct {
+	*httptest.Server
+	mu         sync.Mutex
+	events     []WebhookEvent
+	signatures []string
+	status     int
+}
+
+func newWebhookReceiver(t *testing.T) *webhookReceiver {
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE", "true")
+	receiver := &webhookReceiver{status: http.StatusOK}
+	receiver.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receiver.mu.Lock()
+		defer receiver.mu.Unlock()
+		var event WebhookEvent
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&event))
+		receiver.events = append(receiver.events, event)
+		receiver.signatures = append(receiver.signatures, r.Header.Get(webhookSignatureHeader))
+		w.WriteHeader(receiver.status)
+	}))
+	t.Cleanup(receiver.Close)
+	return receiver
+}
+
+func (r *webhookReceiver) received() []WebhookEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]WebhookEvent(nil), r.events...)
+}
+
+func TestSubscriptions_CRUD(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, nil)
+
+	rr := requestSubscriptions(t, s, "POST", "/alert-rules", `{"cep":"01310-100","metric":"temp_C","operator":">","threshold":35,"callback_url":"https://example.com/hook"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	var created Subscription
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&created))
+	assert.Len(t, created.ID, 32)
+	assert.Equal(t, "01310100", created.CEP)
+	assert.Equal(t, 35.0, created.Threshold)
+	assert.Equal(t, alertStatusPending, created.Status)
+	assert.Equal(t, alertChannelWebhook, created.Channel)
+	assert.Equal(t, "/alert-rules/"+created.ID, rr.Header().Get("Location"))
+
+	rr = requestSubscriptions(t, s, "GET", "/alert-rules", "")
+	var list SubscriptionsResponse
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&list))
+	assert.Equal(t, []string{created.ID}, []string{list.Subscriptions[0].ID})
+
+	rr = requestSubscriptions(t, s, "GET", "/alert-rules/"+created.ID, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	// /subscriptions, o nome original, chega às mesmas regras
+	rr = requestSubscriptions(t, s, "GET", "/subscriptions/"+created.ID, "")
+	assert.Equal(t, http.StatusOK, rr.Code)
+
+	rr = requestSubscriptions(t, s, "DELETE", "/alert-rules/"+created.ID, "")
+	assert.Equal(t, http.StatusNoContent, rr.Code)
+	rr = requestSubscriptions(t, s, "DELETE", "/subscriptions/"+created.ID, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+	rr = requestSubscriptions(t, s, "GET", "/subscriptions/"+created.ID, "")
+	assert.Equal(t, http.StatusNotFound, rr.Code)
+}
+
+func TestSubscriptions_Invalid(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, nil)
+
+	tests := []struct {
+		name     string
+		body     string
+		status   int
+		expected string
+	}{
+		{"malformed body", `{`, http.StatusBadRequest, "invalid request body"},
+		{"invalid CEP", `{"cep":"123","metric":"temp_C","operator":">","threshold":35,"callback_url":"https://example.com"}`, http.StatusUnprocessableEntity, "invalid zipcode"},
+		{"unknown CEP", `{"cep":"99999999","metric":"temp_C","operator":">","threshold":35,"callback_url":"https://example.com"}`, http.StatusNotFound, "can not find zipcode"},
+		{"invalid metric", `{"cep":"01310100","metric":"humidity","operator":">","threshold":35,"callback_url":"https://example.com"}`, http.StatusUnprocessableEntity, "invalid metric"},
+		{"invalid operator", `{"cep":"01310100","metric":"temp_C","operator":"==","threshold":35,"callback_url":"https://example.com"}`, http.StatusUnprocessableEntity, "invalid operator"},
+		{"missing threshold", `{"cep":"01310100","metric":"temp_C","operator":">","callback_url":"https://example.com"}`, http.StatusUnprocessableEntity, "invalid threshold"},
+		{"invalid callback", `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"callback_url":"ftp://example.com"}`, http.StatusUnprocessableEntity, "invalid callback_url"},
+		{"invalid channel", `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"channel":"sms","callback_url":"https://example.com"}`, http.StatusUnprocessableEntity, "invalid channel"},
+		{"loopback callback", `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"callback_url":"http://127.0.0.1:8080/hook"}`, http.StatusUnprocessableEntity, "invalid callback_url"},
+		{"localhost callback", `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"callback_url":"http://localhost/hook"}`, http.StatusUnprocessableEntity, "invalid callback_url"},
+		{"metadata server", `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"callback_url":"http://169.254.169.254/computeMetadata/v1/"}`, http.StatusUnprocessableEntity, "invalid callback_url"},
+		{"metadata host", `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"callback_url":"http://metadata.google.internal/"}`, http.StatusUnprocessableEntity, "invalid callback_url"},
+		{"private network", `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"callback_url":"https://[fd00::1]/hook"}`, http.StatusUnprocessableEntity, "invalid callback_url"},
+		{"plain HTTP Slack webhook", `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"channel":"slack","callback_url":"http://hooks.slack.com/services/T0/B0/x"}`, http.StatusUnprocessableEntity, "invalid callback_url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rr := requestSubscriptions(t, s, "POST", "/subscriptions", tt.body)
+			assert.Equal(t, tt.status, rr.Code)
+			assert.JSONEq(t, `{"message":"`+tt.expected+`"}`, rr.Body.String())
+		})
+	}
+
+	t.Run("limit", func(t *testing.T) {
+		t.Setenv("WEBHOOK_MAX_SUBSCRIPTIONS", "1")
+		body := `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"callback_url":"https://example.com"}`
+		assert.Equal(t, http.StatusCreated, requestSubscriptions(t, s, "POST", "/subscriptions", body).Code)
+		rr := requestSubscriptions(t, s, "POST", "/subscriptions", body)
+		assert.Equal(t, http.StatusUnprocessableEntity, rr.Code)
+		assert.JSONEq(t, `{"message":"subscription limit reached"}`, rr.Body.String())
+	})
+}
+
+// Cada cliente autenticado vê e apaga só as próprias regras
+func TestSubscriptions_Owner(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, nil)
+	t.Setenv("API_KEYS", "key-a,key-b")
+	request := func(key, method, path, body string) *httptest.ResponseRecorder {
+		return requestSubscriptionsAs(t, s, key, method, path, body)
+	}
+
+	rr := request("key-a", "POST", "/alert-rules", `{"cep":"01310100","metric":"temp_C","operator":">","threshold":35,"callback_url":"https://example.com/hook"}`)
+	assert.Equal(t, http.StatusCreated, rr.Code)
+	assert.NotContains(t, rr.Body.String(), "owner")
+	var created Subscription
+	assert.NoError(t, json.NewDecoder(rr.Body).Decode(&created))
+
+	rr = request("key-b", "GET", "/alert-rules", "")
+	assert.JSONEq(t, `{"subscriptions":[]}`, rr.Body.String())
+	assert.Equal(t, http.StatusNotFound, request("key-b", "GET", "/alert-rules/"+created.ID, "").Code)
+	assert.Equal(t, http.StatusNotFound, request("key-b", "DELETE", "/alert-rules/"+created.ID, "").Code)
+
+	rr = request("key-a", "GET", "/alert-rules", "")
+	assert.Contains(t, rr.Body.String(), created.ID)
+	assert.Equal(t, http.StatusNoContent, request("key-a", "DELETE", "/alert-rules/"+created.ID, "").Code)
+}
+
+func TestRuleOwner(t *testing.T) {
+	ctx := context.Background()
+	assert.Empty(t, ruleOwner(ctx))
+	keyCtx := context.WithValue(ctx, apiKeyContextKey{}, "key-a")
+	assert.Regexp(t, `^key:[0-9a-f]{16}$`, ruleOwner(keyCtx))
+	assert.NotContains(t, ruleOwner(keyCtx), "key-a")
+	assert.Equal(t, "sub:user-1", ruleOwner(context.WithValue(keyCtx, subjectContextKey{}, "user-1")))
+	assert.Equal(t, "tenant:acme", ruleOwner(context.WithValue(keyCtx, tenantContextKey{}, &tenant{name: "acme"})))
+}
+
+// O webhook dispara quando a condição passa a valer, não a cada verificação,
+// e volta a disparar depois que a condição deixa de valer
+func TestPollSubscriptions(t *testing.T) {
+	temperatures := fakeWeatherClient{"São Paulo,SP": 36}
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, temperatures)
+	t.Setenv("WEBHOOK_SECRET", "webhook-secret")
+	receiver := newWebhookReceiver(t)
+
+	ctx := context.Background()
+	hot := Subscription{ID: "hot", CEP: "01310100", Metric: "temp_C", Operator: ">", Threshold: 35, CallbackURL: receiver.URL}
+	cold := Subscription{ID: "cold", CEP: "01310100", Metric: "temp_F", Operator: "<", Threshold: 50, CallbackURL: receiver.URL}
+	assert.NoError(t, s.subscriptions.create(ctx, hot))
+	assert.NoError(t, s.subscriptions.create(ctx, cold))
+
+	s.pollSubscriptions(ctx)
+	events := receiver.received()
+	stored, _, _ := s.subscriptions.get(ctx, "cold")
+	assert.Equal(t, alertStatusOK, stored.Status)
+	assert.Nil(t, stored.LastFiredAt)
+	if assert.Len(t, events, 1) {
+		assert.Equal(t, "hot", events[0].SubscriptionID)
+		assert.Equal(t, 36.0, events[0].Value)
+		assert.Equal(t, "São Paulo", events[0].City)
+		body, _ := json.Marshal(events[0])
+		assert.Equal(t, webhookSignature([]byte("webhook-secret"), body), receiver.signatures[0])
+		stored, _, _ := s.subscriptions.get(ctx, "hot")
+		assert.Equal(t, alertStatusFiring, stored.Status)
+		assert.Equal(t, &events[0].FiredAt, stored.LastFiredAt)
+	}
+
+	s.pollSubscriptions(ctx)
+	assert.Len(t, receiver.received(), 1, "still above the threshold: no new webhook")
+
+	temperatures["São Paulo,SP"] = 25
+	s.weatherCache.Clear()
+	s.pollSubscriptions(ctx)
+	assert.Len(t, receiver.received(), 1)
+
+	temperatures["São Paulo,SP"] = 37
+	s.weatherCache.Clear()
+	s.pollSubscriptions(ctx)
+	assert.Len(t, receiver.received(), 2, "crossed the threshold again")
+}
+
+// Uma entrega recusada é tentada de novo na próxima verificação
+func TestPollSubscriptions_FailedDelivery(t *testing.T) {
+	s := newFakeClientsServer(t, fakeCEPClient{"01310100": {CEP: "01310-100", City: "São Paulo", UF: "SP"}}, fakeWeatherClient{"São Paulo,SP": 36})
+	receiver := newWebhookReceiver(t)
+	receiver.status = http.StatusInternalServerError
+
+	ctx := context.Background()
+	assert.NoError(t, s.subscriptions.create(ctx, Subscription{ID: "hot", CEP: "01310100", Metric: "temp_C", Operator: ">=", Threshold: 36, CallbackURL: receiver.URL}))
+
+	s.pollSubscriptions(ctx)
+	receiver.mu.Lock()
+	receiver.status = http.StatusNoContent
+	receiver.mu.Unlock()
+	s.pollSubscriptions(ctx)
+	s.pollSubscriptions(ctx)
+	assert.Len(t, receiver.received(), 2)
+}
+
+// Um nome que resolve para a rede interna passa pela validação, mas a
+// conexão é recusada depois da resolução
+func TestDeliverWebhook_PrivateAddress(t *testing.T) {
+	called := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	defer server.Close()
+
+	target := strings.Replace(server.URL, "127.0.0.1", "localhost", 1)
+	err := deliverWebhook(context.Background(), target, WebhookEvent{SubscriptionID: "hot"})
+	assert.ErrorIs(t, err, errWebhookAddress)
+	assert.False(t, called)
+
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE", "true")
+	assert.NoError(t, deliverWebhook(context.Background(), target, WebhookEvent{SubscriptionID: "hot"}))
+	assert.True(t, called)
+}
+
+// Um redirecionamento do callback não é seguido e conta como falha
+func TestDeliverWebhook_Redirect(t *testing.T) {
+	t.Setenv("WEBHOOK_ALLOW_PRIVATE", "true")
+	redirected := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { redirected = true }))
+	defer target.Close()
+	server := httptest.NewServer(http.RedirectHandler(target.URL, http.StatusTemporaryRedirect))
+	defer server.Close()
+
+	err := deliverWebhook(context.Background(), server.URL, WebhookEvent{SubscriptionID: "hot"})
+	assert.EqualError(t, err, "callback returned status 307")
+	assert.False(t, redirected)
+}
+
+func TestPublicAddress(t *testing.T) {
+	for address, public := range map[string]bool{
+		"8.8.8.8":          true,
+		"2001:4860::8888":  true,
+		"127.0.0.1":        false,
+		"10.1.2.3":         false,
+		"172.16.0.1":       false,
+		"192.168.0.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.1":       false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"fe80::1":          false,
+		"::ffff:127.0.0.1": false,
+	} {
+		assert.Equal(t, public, publicAddress(netip.MustParseAddr(address)), address)
+	}
+}
+
+func TestWebhookSignature(t *testing.T) {
+	// echo -n '{"a":1}' | openssl dgst -sha256 -hmac secret
+	assert.Equal(t, "sha256=aa9e2e3575f5d7098b6caccd790888c36d5fdb63342a73bada2d6a51747a8494", webhookSignature([]byte("secret"), []byte(`{"a":1}`)))
+}
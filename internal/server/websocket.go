@@ -0,0 +1,164 @@
+package server
+
+import (
+	"context"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// Limita quantos CEPs uma conexão pode acompanhar, protegendo a cota da WeatherAPI
+const maxWebSocketSubscriptions = 20
+
+type WebSocketMessage struct {
+	Action string   `json:"action"`
+	CEPs   []string `json:"ceps"`
+}
+
+type WeatherUpdate struct {
+	Cep string `json:"cep,omitempty"`
+	WeatherResponse
+	Message string `json:"message,omitempty"`
+}
+
+// A API é pública e sem cookies, então conexões de qualquer origem são aceitas
+var upgrader = websocket.Upgrader{
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+type wsSession struct {
+	server *Server
+	ctx    context.Context
+	conn   *websocket.Conn
+	units  temperatureUnits
+
+	mu   sync.Mutex
+	ceps map[string]bool
+
+	writeMu sync.Mutex
+}
+
+func (s *Server) wsHandler(w http.ResponseWriter, r *http.Request) {
+	units, ok := unitsFromRequest(w, r)
+	if !ok {
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("ERROR: WebSocket upgrade failed: %v", err)
+		return
+	}
+	defer conn.Close()
+
+	interval := liveRefreshInterval()
+	log.Printf("WebSocket client connected from %s (refresh every %s)", r.RemoteAddr, interval)
+
+	session := &wsSession{server: s, ctx: r.Context(), conn: conn, units: units, ceps: map[string]bool{}}
+	done := make(chan struct{})
+	go session.readLoop(done)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			log.Printf("WebSocket client disconnected from %s", r.RemoteAddr)
+			return
+		case <-ticker.C:
+			session.push(session.subscriptions())
+		}
+	}
+}
+
+func (s *wsSession) readLoop(done chan struct{}) {
+	defer close(done)
+
+	for {
+		var msg WebSocketMessage
+		if err := s.conn.ReadJSON(&msg); err != nil {
+			return
+		}
+
+		switch msg.Action {
+		case "subscribe":
+			added, ok := s.subscribe(msg.CEPs)
+			if !ok {
+				s.send(WeatherUpdate{Message: "too many subscriptions"})
+			}
+			// Novas inscrições recebem a leitura atual sem esperar o próximo ciclo
+			s.push(added)
+		case "unsubscribe":
+			s.unsubscribe(msg.CEPs)
+		default:
+			s.send(WeatherUpdate{Message: "unknown action"})
+		}
+	}
+}
+
+// Adiciona os CEPs até o limite por conexão; ok é false quando algum foi recusado
+func (s *wsSession) subscribe(ceps []string) ([]string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var added []string
+	for _, cep := range ceps {
+		cep = strings.ReplaceAll(strings.TrimSpace(cep), "-", "")
+		if s.ceps[cep] {
+			continue
+		}
+		if len(s.ceps) >= maxWebSocketSubscriptions {
+			return added, false
+		}
+		s.ceps[cep] = true
+		added = append(added, cep)
+	}
+	return added, true
+}
+
+func (s *wsSession) unsubscribe(ceps []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, cep := range ceps {
+		delete(s.ceps, strings.ReplaceAll(strings.TrimSpace(cep), "-", ""))
+	}
+}
+
+func (s *wsSession) subscriptions() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ceps := make([]string, 0, len(s.ceps))
+	for cep := range s.ceps {
+		ceps = append(ceps, cep)
+	}
+	return ceps
+}
+
+func (s *wsSession) push(ceps []string) {
+	for _, cep := range ceps {
+		weather, err := s.server.lookupWeather(s.ctx, cep, s.units)
+		if err != nil {
+			s.send(WeatherUpdate{Cep: cep, Message: err.Error()})
+			continue
+		}
+		s.send(WeatherUpdate{Cep: cep, WeatherResponse: *weather})
+	}
+}
+
+// Escritas concorrentes não são permitidas pelo gorilla/websocket
+func (s *wsSession) send(update WeatherUpdate) {
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+
+	s.conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+	if err := s.conn.WriteJSON(update); err != nil {
+		log.Printf("ERROR: Failed to write WebSocket update: %v", err)
+	}
+}
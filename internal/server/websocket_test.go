@@ -0,0 +1,56 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWsHandler(t *testing.T) {
+	s := newFakeUpstreamServer(t, map[string]string{
+		"/ws/01310100/json/": viaCEPSaoPaulo,
+		"/ws/99999999/json/": `{"erro": "true"}`,
+		"/v1/current.json":   `{"current":{"temp_c":25}}`,
+	})
+	t.Setenv("LIVE_REFRESH_INTERVAL", "50ms")
+
+	server := httptest.NewServer(http.HandlerFunc(s.wsHandler))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?units=c", nil)
+	assert.NoError(t, err)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+
+	err = conn.WriteJSON(WebSocketMessage{Action: "subscribe", CEPs: []string{"01310-100"}})
+	assert.NoError(t, err)
+
+	// Leitura imediata da inscrição e depois a atualização periódica
+	for i := 0; i < 2; i++ {
+		var update WeatherUpdate
+		err = conn.ReadJSON(&update)
+		assert.NoError(t, err)
+		assert.Equal(t, "01310100", update.Cep)
+		assert.Equal(t, 25.0, *update.TempC)
+		assert.Nil(t, update.TempF)
+	}
+
+	err = conn.WriteJSON(WebSocketMessage{Action: "unsubscribe", CEPs: []string{"01310100"}})
+	assert.NoError(t, err)
+	err = conn.WriteJSON(WebSocketMessage{Action: "subscribe", CEPs: []string{"99999999"}})
+	assert.NoError(t, err)
+
+	// Descarta atualizações do CEP antigo que estavam em trânsito
+	var update WeatherUpdate
+	for update.Cep != "99999999" {
+		update = WeatherUpdate{}
+		err = conn.ReadJSON(&update)
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "can not find zipcode", update.Message)
+}
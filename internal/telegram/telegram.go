@@ -0,0 +1,125 @@
+// Package telegram implementa o mínimo da Bot API do Telegram usado pelo
+// serviço: receber mensagens por long polling (getUpdates) e responder
+// (sendMessage)
+package telegram
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const DefaultBaseURL = "https://api.telegram.org"
+
+// Executa as requisições do cliente. *http.Client atende a interface; nos
+// testes, qualquer implementação que não acesse a rede
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Cliente de um bot
+type Client struct {
+	baseURL string
+	token   string
+	client  Doer
+}
+
+// Cliente do bot com token em baseURL (DefaultBaseURL em produção). Sem
+// client, usa o http.DefaultClient. O long polling deixa a requisição aberta
+// pelo timeout de GetUpdates, então o client não pode ter timeout menor
+func NewClient(baseURL, token string, client Doer) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{baseURL: baseURL, token: token, client: client}
+}
+
+type Update struct {
+	UpdateID int64    `json:"update_id"`
+	Message  *Message `json:"message"`
+}
+
+type Message struct {
+	MessageID int64  `json:"message_id"`
+	Chat      Chat   `json:"chat"`
+	Text      string `json:"text"`
+}
+
+type Chat struct {
+	ID int64 `json:"id"`
+}
+
+// A Bot API respondeu com "ok": false
+type APIError struct {
+	Code        int
+	Description string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("telegram API error %d: %s", e.Code, e.Description)
+}
+
+// Envelope de todas as respostas da Bot API
+type response struct {
+	OK          bool            `json:"ok"`
+	Result      json.RawMessage `json:"result"`
+	ErrorCode   int             `json:"error_code"`
+	Description string          `json:"description"`
+}
+
+// Atualizações a partir de offset (o update_id seguinte ao último tratado,
+// que confirma os anteriores). Espera até timeout por uma mensagem nova
+func (c *Client) GetUpdates(ctx context.Context, offset int64, timeout time.Duration) ([]Update, error) {
+	params := url.Values{
+		"offset":          {strconv.FormatInt(offset, 10)},
+		"timeout":         {strconv.Itoa(int(timeout.Seconds()))},
+		"allowed_updates": {`["message"]`},
+	}
+	var updates []Update
+	err := c.call(ctx, "getUpdates", params, &updates)
+	return updates, err
+}
+
+// Envia text ao chat, sem formatação
+func (c *Client) SendMessage(ctx context.Context, chatID int64, text string) error {
+	params := url.Values{
+		"chat_id": {strconv.FormatInt(chatID, 10)},
+		"text":    {text},
+	}
+	return c.call(ctx, "sendMessage", params, nil)
+}
+
+func (c *Client) call(ctx context.Context, method string, params url.Values, result interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.baseURL+"/bot"+c.token+"/"+method, bytes.NewBufferString(params.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		// O erro de *url.Error traz a URL, e com ela o token
+		if urlErr, ok := err.(*url.Error); ok {
+			err = urlErr.Err
+		}
+		return fmt.Errorf("failed to call telegram %s: %w", method, err)
+	}
+	defer resp.Body.Close()
+
+	var body response
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("invalid telegram %s response (status %d): %w", method, resp.StatusCode, err)
+	}
+	if !body.OK {
+		return &APIError{Code: body.ErrorCode, Description: body.Description}
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(body.Result, result)
+}
@@ -0,0 +1,62 @@
+package telegram
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClient_GetUpdates(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bot123:abc/getUpdates", r.URL.Path)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "42", r.PostForm.Get("offset"))
+		assert.Equal(t, "30", r.PostForm.Get("timeout"))
+		w.Write([]byte(`{"ok":true,"result":[{"update_id":42,"message":{"message_id":1,"chat":{"id":7},"text":"01310100"}},{"update_id":43}]}`))
+	}))
+	defer server.Close()
+
+	updates, err := NewClient(server.URL, "123:abc", nil).GetUpdates(context.Background(), 42, 30*time.Second)
+	assert.NoError(t, err)
+	assert.Equal(t, []Update{
+		{UpdateID: 42, Message: &Message{MessageID: 1, Chat: Chat{ID: 7}, Text: "01310100"}},
+		{UpdateID: 43},
+	}, updates)
+}
+
+func TestClient_SendMessage(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/bot123:abc/sendMessage", r.URL.Path)
+		assert.NoError(t, r.ParseForm())
+		assert.Equal(t, "7", r.PostForm.Get("chat_id"))
+		assert.Equal(t, "São Paulo: 25 °C", r.PostForm.Get("text"))
+		w.Write([]byte(`{"ok":true,"result":{"message_id":2,"chat":{"id":7}}}`))
+	}))
+	defer server.Close()
+
+	assert.NoError(t, NewClient(server.URL, "123:abc", nil).SendMessage(context.Background(), 7, "São Paulo: 25 °C"))
+}
+
+func TestClient_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"ok":false,"error_code":401,"description":"Unauthorized"}`))
+	}))
+
+	err := NewClient(server.URL, "123:abc", nil).SendMessage(context.Background(), 7, "oi")
+	var apiErr *APIError
+	assert.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, &APIError{Code: 401, Description: "Unauthorized"}, apiErr)
+
+	// Falhas de conexão não expõem o token, que faz parte da URL
+	server.Close()
+	err = NewClient(server.URL, "123:abc", nil).SendMessage(context.Background(), 7, "oi")
+	assert.Error(t, err)
+	assert.False(t, strings.Contains(err.Error(), "123:abc"), err.Error())
+}
@@ -0,0 +1,85 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+const DefaultBaseURL = "https://api.weatherapi.com/v1"
+
+// Executa as requisições do cliente. *http.Client atende a interface; nos
+// testes, qualquer implementação que não acesse a rede
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Cliente da WeatherAPI
+type Client struct {
+	baseURL string
+	client  Doer
+}
+
+// Cliente da WeatherAPI em baseURL (DefaultBaseURL em produção). Sem client,
+// usa o http.DefaultClient
+func NewClient(baseURL string, client Doer) *Client {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &Client{baseURL: baseURL, client: client}
+}
+
+// URL de um endpoint (current.json, forecast.json...), sem parâmetros
+func (c *Client) URL(endpoint string) string {
+	return c.baseURL + "/" + endpoint
+}
+
+// A WeatherAPI respondeu com um status diferente de 200. Details traz o
+// corpo de erro da WeatherAPI, quando ela o envia em JSON
+type StatusError struct {
+	Status  int
+	Details map[string]interface{}
+}
+
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("weather API error: status %d", e.Status)
+}
+
+// Chama o endpoint com a chave e os parâmetros e retorna o corpo da resposta
+// sem decodificar, para que quem chama possa guardá-lo em cache. Respostas
+// diferentes de 200 viram *StatusError
+func (c *Client) Fetch(ctx context.Context, endpoint string, params url.Values, key string) ([]byte, error) {
+	// Encode dos parâmetros evita problemas com caracteres especiais na localização
+	query := url.Values{"key": {key}}
+	for name, values := range params {
+		query[name] = values
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", c.URL(endpoint)+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to weather API: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		statusErr := &StatusError{Status: resp.StatusCode}
+		var details map[string]interface{}
+		if err := json.NewDecoder(resp.Body).Decode(&details); err == nil {
+			statusErr.Details = details
+		}
+		return nil, statusErr
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read weather data: %v", err)
+	}
+	return body, nil
+}
@@ -0,0 +1,84 @@
+package weather
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/weather-service/internal/recorder"
+)
+
+// Cassete de testdata/cassettes. Com RECORD_CASSETTES=true, os cassetes
+// regraváveis são refeitos contra a WeatherAPI real (com a chave de
+// WEATHER_API_KEY) ao fim do teste
+func cassette(t *testing.T, name string, recordable bool) *recorder.Recorder {
+	mode := recorder.ModeFromEnv()
+	if !recordable {
+		mode = recorder.Replay
+	}
+	rec, err := recorder.New(filepath.Join("testdata", "cassettes", name+".yaml"), mode, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := rec.Stop(); err != nil {
+			t.Error(err)
+		}
+	})
+	return rec
+}
+
+// Respostas reais da WeatherAPI: o campo de que o serviço depende no sucesso
+// e o corpo de erro de cada falha que ele trata
+func TestClient_Contract(t *testing.T) {
+	saoPaulo := url.Values{"q": {"São Paulo,SP"}, "aqi": {"no"}}
+
+	t.Run("weatherapi_current", func(t *testing.T) {
+		client := NewClient(DefaultBaseURL, cassette(t, "weatherapi_current", true))
+
+		body, err := client.Fetch(context.Background(), "current.json", saoPaulo, os.Getenv("WEATHER_API_KEY"))
+		assert.NoError(t, err)
+
+		var response struct {
+			Current struct {
+				TempC *float64 `json:"temp_c"`
+			} `json:"current"`
+		}
+		assert.NoError(t, json.Unmarshal(body, &response))
+		assert.NotNil(t, response.Current.TempC, "current.temp_c is missing")
+	})
+
+	errorTests := []struct {
+		cassette   string
+		recordable bool
+		params     url.Values
+		key        string
+		status     int
+		code       float64
+	}{
+		{"weatherapi_no_location", true, url.Values{"q": {"Cidade Inexistente,XX"}, "aqi": {"no"}}, os.Getenv("WEATHER_API_KEY"), http.StatusBadRequest, 1006},
+		{"weatherapi_invalid_key", true, saoPaulo, "invalid-key", http.StatusUnauthorized, 2006},
+		{"weatherapi_quota_exceeded", false, saoPaulo, "", http.StatusForbidden, 2007},
+	}
+	for _, tt := range errorTests {
+		t.Run(tt.cassette, func(t *testing.T) {
+			client := NewClient(DefaultBaseURL, cassette(t, tt.cassette, tt.recordable))
+
+			_, err := client.Fetch(context.Background(), "current.json", tt.params, tt.key)
+			var statusErr *StatusError
+			if !assert.True(t, errors.As(err, &statusErr), "unexpected error: %v", err) {
+				return
+			}
+			assert.Equal(t, tt.status, statusErr.Status)
+			details, _ := statusErr.Details["error"].(map[string]interface{})
+			assert.Equal(t, tt.code, details["code"])
+			assert.NotEmpty(t, details["message"])
+		})
+	}
+}
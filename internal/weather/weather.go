@@ -0,0 +1,20 @@
+// Package weather consulta a WeatherAPI e converte temperaturas entre as
+// escalas usadas pelo serviço.
+//
+// O cliente só faz a chamada HTTP com uma chave; rodízio de chaves, cota,
+// cache e circuit breaker ficam com quem o usa
+package weather
+
+// Conversões a partir de Celsius, sem arredondamento
+
+func CelsiusToFahrenheit(celsius float64) float64 {
+	return celsius*1.8 + 32
+}
+
+func CelsiusToKelvin(celsius float64) float64 {
+	return celsius + 273.15
+}
+
+func CelsiusToRankine(celsius float64) float64 {
+	return celsius*1.8 + 491.67
+}
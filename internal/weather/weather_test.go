@@ -0,0 +1,96 @@
+package weather
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCelsiusToFahrenheit(t *testing.T) {
+	tests := []struct {
+		celsius  float64
+		expected float64
+	}{
+		{0, 32},
+		{100, 212},
+		{-40, -40},
+		{25, 77},
+	}
+
+	for _, tt := range tests {
+		result := CelsiusToFahrenheit(tt.celsius)
+		assert.Equal(t, tt.expected, result)
+	}
+}
+
+func TestCelsiusToKelvin(t *testing.T) {
+	tests := []struct {
+		celsius  float64
+		expected float64
+	}{
+		{0, 273.15},
+		{-273.15, 0},
+		{25, 298.15},
+		{100, 373.15},
+	}
+
+	for _, tt := range tests {
+		result := CelsiusToKelvin(tt.celsius)
+		assert.Equal(t, tt.expected, result)
+	}
+}
+
+func TestCelsiusToRankine(t *testing.T) {
+	tests := []struct {
+		celsius  float64
+		expected float64
+	}{
+		{-273.15, 0},
+		{0, 491.67},
+		{100, 671.67},
+	}
+
+	for _, tt := range tests {
+		result := CelsiusToRankine(tt.celsius)
+		assert.InDelta(t, tt.expected, result, 1e-9)
+	}
+}
+
+func TestClient_Fetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("key") != "good-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":{"code":2006,"message":"API key is invalid."}}`))
+			return
+		}
+		assert.Equal(t, "/v1/current.json", r.URL.Path)
+		assert.Equal(t, "São Paulo,SP", r.URL.Query().Get("q"))
+		w.Write([]byte(`{"current":{"temp_c":25}}`))
+	}))
+	defer server.Close()
+
+	client := NewClient(server.URL+"/v1", server.Client())
+	params := url.Values{"q": {"São Paulo,SP"}}
+
+	body, err := client.Fetch(context.Background(), "current.json", params, "good-key")
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"current":{"temp_c":25}}`, string(body))
+
+	_, err = client.Fetch(context.Background(), "current.json", params, "bad-key")
+	var statusErr *StatusError
+	assert.True(t, errors.As(err, &statusErr))
+	assert.Equal(t, http.StatusUnauthorized, statusErr.Status)
+	assert.NotNil(t, statusErr.Details["error"])
+	assert.EqualError(t, err, "weather API error: status 401")
+}
+
+func TestClient_FetchUnreachable(t *testing.T) {
+	client := NewClient("http://127.0.0.1:1/v1", nil)
+	_, err := client.Fetch(context.Background(), "current.json", url.Values{}, "key")
+	assert.ErrorContains(t, err, "failed to connect to weather API")
+}
@@ -0,0 +1,217 @@
+// Package weathercep resolve um CEP na temperatura atual da cidade, o mesmo
+// fluxo de GET /weather/{cep}, para programas Go que queiram embuti-lo em vez
+// de chamar a API HTTP.
+//
+// O Service consulta o ViaCEP (e a BrasilAPI quando o ViaCEP falha) e a
+// WeatherAPI com uma única chave. Circuit breaker, cota, rodízio de chaves,
+// registro das consultas e as demais políticas do servidor ficam de fora:
+// quem embute o pacote decide as suas
+//
+//	service, err := weathercep.New(weathercep.Config{WeatherAPIKey: key})
+//	if err != nil {
+//		return err
+//	}
+//	current, err := service.Weather(ctx, "01310-100")
+//	if errors.Is(err, weathercep.ErrNotFound) {
+//		...
+//	}
+//	fmt.Printf("%s: %.1f°C\n", current.Address.City, current.Celsius)
+package weathercep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/weather-service/internal/cache"
+	"github.com/weather-service/internal/cep"
+	"github.com/weather-service/internal/weather"
+)
+
+var (
+	// O CEP não tem 8 dígitos (com ou sem hífen)
+	ErrInvalidCEP = errors.New("invalid zipcode")
+	// Os provedores de CEP responderam que o CEP não existe
+	ErrNotFound = errors.New("can not find zipcode")
+)
+
+// A WeatherAPI respondeu com um status diferente de 200: chave inválida (401),
+// cota esgotada (403), localização desconhecida (400)...
+type WeatherAPIError struct {
+	Status int
+	// Mensagem de erro da WeatherAPI, quando ela a envia
+	Message string
+}
+
+func (e *WeatherAPIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("weather API error: status %d", e.Status)
+	}
+	return fmt.Sprintf("weather API error: status %d: %s", e.Status, e.Message)
+}
+
+// Executa as requisições aos provedores. *http.Client atende a interface
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type Config struct {
+	// Chave da WeatherAPI (obrigatória)
+	WeatherAPIKey string
+	// Cliente HTTP dos provedores; sem ele, http.DefaultClient
+	HTTPClient Doer
+	// Endereços dos provedores; vazios, os de produção
+	ViaCEPURL     string
+	BrasilAPIURL  string
+	WeatherAPIURL string
+	// Por quanto tempo a temperatura de uma cidade é reaproveitada; zero
+	// desliga o cache
+	CacheTTL time.Duration
+}
+
+type Address struct {
+	CEP          string `json:"cep"`
+	Street       string `json:"street"`
+	Neighborhood string `json:"neighborhood"`
+	City         string `json:"city"`
+	UF           string `json:"uf"`
+}
+
+// Temperatura atual da cidade de um CEP, sem arredondamento
+type Weather struct {
+	Address    Address `json:"address"`
+	Celsius    float64 `json:"temp_C"`
+	Fahrenheit float64 `json:"temp_F"`
+	Kelvin     float64 `json:"temp_K"`
+}
+
+// Consulta os provedores. É seguro para uso concorrente
+type Service struct {
+	key       string
+	viaCEP    *cep.ViaCEP
+	brasilAPI *cep.BrasilAPI
+	weather   *weather.Client
+	cache     *cache.Store
+	cacheTTL  time.Duration
+}
+
+func New(config Config) (*Service, error) {
+	if config.WeatherAPIKey == "" {
+		return nil, errors.New("weathercep: WeatherAPIKey is required")
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+
+	return &Service{
+		key:       config.WeatherAPIKey,
+		viaCEP:    cep.NewViaCEP(orDefault(config.ViaCEPURL, cep.DefaultViaCEPURL), config.HTTPClient),
+		brasilAPI: cep.NewBrasilAPI(orDefault(config.BrasilAPIURL, cep.DefaultBrasilAPIURL), config.HTTPClient),
+		weather:   weather.NewClient(orDefault(config.WeatherAPIURL, weather.DefaultBaseURL), config.HTTPClient),
+		cache:     cache.New(),
+		cacheTTL:  config.CacheTTL,
+	}, nil
+}
+
+func orDefault(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// Endereço de um CEP (01310100, 01310-100 ou com pontos e espaços, como
+// 01.310-100). Retorna ErrInvalidCEP, ErrNotFound ou o erro do provedor
+// quando os dois estão fora do ar
+func (s *Service) Address(ctx context.Context, code string) (*Address, error) {
+	code = cep.Clean(code)
+	if !cep.Valid(code) {
+		return nil, ErrInvalidCEP
+	}
+	code = cep.Normalize(code)
+
+	address, err := s.viaCEP.Lookup(ctx, code)
+	if err != nil && !errors.Is(err, cep.ErrNotFound) && ctx.Err() == nil {
+		address, err = s.brasilAPI.Lookup(ctx, code)
+	}
+	if errors.Is(err, cep.ErrNotFound) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("weathercep: looking up CEP %s: %w", code, err)
+	}
+
+	return &Address{
+		CEP:          address.CEP,
+		Street:       address.Street,
+		Neighborhood: address.Neighborhood,
+		City:         address.City,
+		UF:           address.UF,
+	}, nil
+}
+
+// Temperatura atual da cidade de um CEP, com os erros de Address. Respostas
+// de erro da WeatherAPI voltam como *WeatherAPIError dentro do erro retornado
+func (s *Service) Weather(ctx context.Context, code string) (*Weather, error) {
+	address, err := s.Address(ctx, code)
+	if err != nil {
+		return nil, err
+	}
+
+	celsius, err := s.currentCelsius(ctx, address.City+","+address.UF)
+	if err != nil {
+		return nil, fmt.Errorf("weathercep: fetching weather for %s/%s: %w", address.City, address.UF, err)
+	}
+
+	return &Weather{
+		Address:    *address,
+		Celsius:    celsius,
+		Fahrenheit: weather.CelsiusToFahrenheit(celsius),
+		Kelvin:     weather.CelsiusToKelvin(celsius),
+	}, nil
+}
+
+func (s *Service) currentCelsius(ctx context.Context, location string) (float64, error) {
+	body, cached := []byte(nil), false
+	if s.cacheTTL > 0 {
+		if entry, ok := s.cache.Get(location); ok && s.cache.Fresh(entry, s.cacheTTL) {
+			body, cached = entry.Body, true
+		}
+	}
+	if !cached {
+		var err error
+		body, err = s.weather.Fetch(ctx, "current.json", url.Values{"q": {location}, "aqi": {"no"}}, s.key)
+		var statusErr *weather.StatusError
+		if errors.As(err, &statusErr) {
+			apiErr := &WeatherAPIError{Status: statusErr.Status}
+			if details, ok := statusErr.Details["error"].(map[string]interface{}); ok {
+				apiErr.Message, _ = details["message"].(string)
+			}
+			return 0, apiErr
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var response struct {
+		Current struct {
+			TempC *float64 `json:"temp_c"`
+		} `json:"current"`
+	}
+	if err := json.Unmarshal(body, &response); err != nil {
+		return 0, err
+	}
+	if response.Current.TempC == nil {
+		return 0, errors.New("response without current.temp_c")
+	}
+
+	if s.cacheTTL > 0 && !cached {
+		s.cache.Set(location, body)
+	}
+	return *response.Current.TempC, nil
+}
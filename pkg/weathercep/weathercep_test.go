@@ -0,0 +1,114 @@
+package weathercep
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Servidor respondendo pelo ViaCEP, pela BrasilAPI e pela WeatherAPI
+func newTestService(t *testing.T, handler http.HandlerFunc, ttl time.Duration) *Service {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	service, err := New(Config{
+		WeatherAPIKey: "test-key",
+		HTTPClient:    server.Client(),
+		ViaCEPURL:     server.URL + "/ws",
+		BrasilAPIURL:  server.URL + "/brasilapi",
+		WeatherAPIURL: server.URL + "/v1",
+		CacheTTL:      ttl,
+	})
+	assert.NoError(t, err)
+	return service
+}
+
+func upstreams(weatherCalls *atomic.Int32) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ws/01310100/json/":
+			w.Write([]byte(`{"cep":"01310-100","logradouro":"Avenida Paulista","bairro":"Bela Vista","localidade":"São Paulo","uf":"SP"}`))
+		case "/ws/99999999/json/":
+			w.Write([]byte(`{"erro":"true"}`))
+		case "/ws/20040020/json/":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/brasilapi/cep/v2/20040020":
+			w.Write([]byte(`{"cep":"20040020","state":"RJ","city":"Rio de Janeiro","neighborhood":"Centro","street":"Avenida Rio Branco"}`))
+		case "/ws/69900000/json/":
+			w.Write([]byte(`{"cep":"69900-000","localidade":"Cidade Inexistente","uf":"XX"}`))
+		case "/v1/current.json":
+			weatherCalls.Add(1)
+			if r.URL.Query().Get("key") != "test-key" || r.URL.Query().Get("q") == "Cidade Inexistente,XX" {
+				w.WriteHeader(http.StatusBadRequest)
+				w.Write([]byte(`{"error":{"code":1006,"message":"No matching location found."}}`))
+				return
+			}
+			w.Write([]byte(`{"current":{"temp_c":25}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}
+}
+
+func TestService_Weather(t *testing.T) {
+	var calls atomic.Int32
+	service := newTestService(t, upstreams(&calls), 0)
+
+	current, err := service.Weather(context.Background(), "01310-100")
+	assert.NoError(t, err)
+	assert.Equal(t, &Weather{
+		Address:    Address{CEP: "01310-100", Street: "Avenida Paulista", Neighborhood: "Bela Vista", City: "São Paulo", UF: "SP"},
+		Celsius:    25,
+		Fahrenheit: 77,
+		Kelvin:     298.15,
+	}, current)
+}
+
+func TestService_Errors(t *testing.T) {
+	var calls atomic.Int32
+	service := newTestService(t, upstreams(&calls), 0)
+
+	_, err := service.Weather(context.Background(), "0131010a")
+	assert.True(t, errors.Is(err, ErrInvalidCEP))
+
+	_, err = service.Weather(context.Background(), "99999999")
+	assert.True(t, errors.Is(err, ErrNotFound))
+
+	_, err = service.Weather(context.Background(), "69900000")
+	var apiErr *WeatherAPIError
+	assert.True(t, errors.As(err, &apiErr), "unexpected error: %v", err)
+	assert.Equal(t, &WeatherAPIError{Status: http.StatusBadRequest, Message: "No matching location found."}, apiErr)
+}
+
+// Com o ViaCEP fora do ar, o endereço vem da BrasilAPI
+func TestService_BrasilAPIFallback(t *testing.T) {
+	var calls atomic.Int32
+	service := newTestService(t, upstreams(&calls), 0)
+
+	address, err := service.Address(context.Background(), "20040020")
+	assert.NoError(t, err)
+	assert.Equal(t, "Rio de Janeiro", address.City)
+	assert.Equal(t, "20040-020", address.CEP)
+}
+
+func TestService_Cache(t *testing.T) {
+	var calls atomic.Int32
+	service := newTestService(t, upstreams(&calls), time.Minute)
+
+	for i := 0; i < 3; i++ {
+		_, err := service.Weather(context.Background(), "01310100")
+		assert.NoError(t, err)
+	}
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestNew_RequiresKey(t *testing.T) {
+	_, err := New(Config{})
+	assert.EqualError(t, err, "weathercep: WeatherAPIKey is required")
+}
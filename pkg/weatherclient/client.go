@@ -0,0 +1,347 @@
+// Package weatherclient é o cliente Go tipado da API HTTP do serviço, com os
+// tipos de openapi.json, context em todas as chamadas e novas tentativas
+// para falhas temporárias.
+//
+//	client := weatherclient.New(weatherclient.Config{
+//		BaseURL: "https://weather.example.com",
+//		APIKey:  os.Getenv("WEATHER_SERVICE_KEY"),
+//	})
+//	current, err := client.Weather(ctx, "01310-100", nil)
+//	if errors.Is(err, weatherclient.ErrNotFound) {
+//		...
+//	}
+//
+// Para embutir o fluxo CEP → temperatura sem passar pela API, veja o pacote
+// weathercep
+package weatherclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultMaxRetries   = 2
+	defaultRetryBackoff = 200 * time.Millisecond
+	// Retry-After maior que isso não é esperado: a chamada falha com o 429/503
+	maxRetryAfter = 30 * time.Second
+)
+
+// Erros comparáveis com errors.Is, pelo status da resposta
+var (
+	ErrInvalidCEP  = errors.New("invalid zipcode")
+	ErrNotFound    = errors.New("can not find zipcode")
+	ErrUnavailable = errors.New("service unavailable")
+)
+
+// A API respondeu com um status de erro. Message é a mensagem de
+// ErrorResponse, quando a API a envia
+type APIError struct {
+	Status  int
+	Message string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("weather service: status %d", e.Status)
+	}
+	return fmt.Sprintf("weather service: status %d: %s", e.Status, e.Message)
+}
+
+// 422 é ErrInvalidCEP, 404 é ErrNotFound e 503 é ErrUnavailable
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrInvalidCEP:
+		return e.Status == http.StatusUnprocessableEntity
+	case ErrNotFound:
+		return e.Status == http.StatusNotFound
+	case ErrUnavailable:
+		return e.Status == http.StatusServiceUnavailable
+	}
+	return false
+}
+
+// Executa as requisições. *http.Client atende a interface
+type Doer interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+type Config struct {
+	// Endereço do serviço, sem barra no fim (ex.: https://weather.example.com)
+	BaseURL string
+	// Enviada em X-API-Key, quando o serviço exige chaves
+	APIKey string
+	// Enviado como Bearer, quando o serviço exige JWT
+	Token string
+	// Sem ele, http.DefaultClient
+	HTTPClient Doer
+	// Novas tentativas depois da primeira chamada (padrão 2; negativo
+	// desliga) para erros de rede, 429, 502, 503 e 504
+	MaxRetries int
+	// Espera antes da primeira nova tentativa, dobrada a cada tentativa
+	// (padrão 200ms). Um Retry-After da resposta tem precedência
+	RetryBackoff time.Duration
+}
+
+type Client struct {
+	baseURL    string
+	apiKey     string
+	token      string
+	client     Doer
+	maxRetries int
+	backoff    time.Duration
+}
+
+func New(config Config) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(config.BaseURL, "/"),
+		apiKey:     config.APIKey,
+		token:      config.Token,
+		client:     config.HTTPClient,
+		maxRetries: config.MaxRetries,
+		backoff:    config.RetryBackoff,
+	}
+	if c.client == nil {
+		c.client = http.DefaultClient
+	}
+	if c.maxRetries == 0 {
+		c.maxRetries = defaultMaxRetries
+	} else if c.maxRetries < 0 {
+		c.maxRetries = 0
+	}
+	if c.backoff <= 0 {
+		c.backoff = defaultRetryBackoff
+	}
+	return c
+}
+
+// Parâmetros opcionais de Weather
+type WeatherOptions struct {
+	// Escalas separadas por vírgula: c, f, k, r (padrão do serviço: c,f,k)
+	Units     string
+	FeelsLike bool
+	AQI       bool
+	Extended  bool
+	// Inclui o endereço resolvido (location)
+	Location bool
+	// Código ISO do país; fora do Brasil, cep é o código postal do país
+	Country string
+	// Inclui o bloco meta (provedores, cache, horário da observação e duração)
+	Meta bool
+}
+
+// GET /weather/{cep}
+func (c *Client) Weather(ctx context.Context, cep string, options *WeatherOptions) (*WeatherResponse, error) {
+	query := url.Values{}
+	if options != nil {
+		setQuery(query, "units", options.Units)
+		setFlag(query, "feels_like", options.FeelsLike)
+		setFlag(query, "aqi", options.AQI)
+		setFlag(query, "extended", options.Extended)
+		if options.Location {
+			query.Set("include", "location")
+		}
+		setQuery(query, "country", options.Country)
+		setFlag(query, "meta", options.Meta)
+	}
+
+	var response WeatherResponse
+	if err := c.do(ctx, http.MethodGet, "/weather/"+url.PathEscape(cep), query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// POST /weather/batch. Erros de cada CEP vêm em BatchResult.Error
+func (c *Client) Batch(ctx context.Context, ceps []string, units string) (*BatchResponse, error) {
+	body, err := json.Marshal(BatchRequest{CEPs: ceps})
+	if err != nil {
+		return nil, err
+	}
+	query := url.Values{}
+	setQuery(query, "units", units)
+
+	var response BatchResponse
+	if err := c.do(ctx, http.MethodPost, "/weather/batch", query, body, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GET /history/{cep}?date=YYYY-MM-DD: temperaturas do dia
+func (c *Client) History(ctx context.Context, cep, date, units string) (*DailyTemperatures, error) {
+	query := url.Values{"date": {date}}
+	setQuery(query, "units", units)
+
+	var response DailyTemperatures
+	if err := c.do(ctx, http.MethodGet, "/history/"+url.PathEscape(cep), query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// Parâmetros opcionais de Readings. From e To no formato YYYY-MM-DD
+type ReadingsOptions struct {
+	Units string
+	From  string
+	To    string
+	Page  int
+	Limit int
+}
+
+// GET /history/{cep} sem date: leituras registradas, quando o serviço tem o
+// registro das consultas ligado
+func (c *Client) Readings(ctx context.Context, cep string, options *ReadingsOptions) (*ReadingsResponse, error) {
+	query := url.Values{}
+	if options != nil {
+		setQuery(query, "units", options.Units)
+		setQuery(query, "from", options.From)
+		setQuery(query, "to", options.To)
+		if options.Page > 0 {
+			query.Set("page", strconv.Itoa(options.Page))
+		}
+		if options.Limit > 0 {
+			query.Set("limit", strconv.Itoa(options.Limit))
+		}
+	}
+
+	var response ReadingsResponse
+	if err := c.do(ctx, http.MethodGet, "/history/"+url.PathEscape(cep), query, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GET /astronomy/{cep}
+func (c *Client) Astronomy(ctx context.Context, cep string) (*AstronomyResponse, error) {
+	var response AstronomyResponse
+	if err := c.do(ctx, http.MethodGet, "/astronomy/"+url.PathEscape(cep), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// GET /alerts/{cep}
+func (c *Client) Alerts(ctx context.Context, cep string) (*AlertsResponse, error) {
+	var response AlertsResponse
+	if err := c.do(ctx, http.MethodGet, "/alerts/"+url.PathEscape(cep), nil, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+func setQuery(query url.Values, name, value string) {
+	if value != "" {
+		query.Set(name, value)
+	}
+}
+
+func setFlag(query url.Values, name string, enabled bool) {
+	if enabled {
+		query.Set(name, "true")
+	}
+}
+
+// Faz a chamada, tentando de novo as falhas temporárias, e decodifica a
+// resposta 200 em out
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body []byte, out interface{}) error {
+	target := c.baseURL + path
+	if len(query) > 0 {
+		target += "?" + query.Encode()
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(ctx, method, target, body)
+		if err == nil && resp.StatusCode == http.StatusOK {
+			defer resp.Body.Close()
+			return json.NewDecoder(resp.Body).Decode(out)
+		}
+
+		var wait time.Duration
+		if err == nil {
+			err = responseError(resp)
+			wait = retryAfter(resp)
+			resp.Body.Close()
+		}
+		if attempt >= c.maxRetries || !retryable(ctx, err) || wait > maxRetryAfter {
+			return err
+		}
+		if wait == 0 {
+			wait = c.backoff << attempt
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+	}
+}
+
+func (c *Client) send(ctx context.Context, method, target string, body []byte) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.apiKey != "" {
+		req.Header.Set("X-API-Key", c.apiKey)
+	}
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+	return c.client.Do(req)
+}
+
+func responseError(resp *http.Response) error {
+	apiErr := &APIError{Status: resp.StatusCode}
+	var body ErrorResponse
+	if json.NewDecoder(resp.Body).Decode(&body) == nil {
+		apiErr.Message = body.Message
+	}
+	return apiErr
+}
+
+// Erros de rede e respostas que indicam sobrecarga ou indisponibilidade
+// passageira. Um context cancelado ou vencido nunca é tentado de novo
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	switch apiErr.Status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// Retry-After em segundos; zero quando ausente ou em outro formato
+func retryAfter(resp *http.Response) time.Duration {
+	seconds, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
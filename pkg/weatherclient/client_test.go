@@ -0,0 +1,179 @@
+package weatherclient
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestClient(t *testing.T, handler http.HandlerFunc) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	return New(Config{BaseURL: server.URL + "/", APIKey: "key-1", HTTPClient: server.Client(), RetryBackoff: time.Millisecond})
+}
+
+func TestClient_Weather(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/weather/01310-100", r.URL.Path)
+		assert.Equal(t, "c,f", r.URL.Query().Get("units"))
+		assert.Equal(t, "true", r.URL.Query().Get("feels_like"))
+		assert.Equal(t, "location", r.URL.Query().Get("include"))
+		assert.Equal(t, "key-1", r.Header.Get("X-API-Key"))
+		w.Write([]byte(`{"temp_C":25,"temp_F":77,"feels_like_C":26,"location":{"cep":"01310-100","localidade":"São Paulo","uf":"SP"}}`))
+	})
+
+	response, err := client.Weather(context.Background(), "01310-100", &WeatherOptions{Units: "c,f", FeelsLike: true, Location: true})
+	assert.NoError(t, err)
+	assert.Equal(t, 25.0, *response.TempC)
+	assert.Equal(t, 77.0, *response.TempF)
+	assert.Nil(t, response.TempK)
+	assert.Equal(t, 26.0, *response.FeelsLikeC)
+	assert.Equal(t, "São Paulo", response.Location.Localidade)
+}
+
+func TestClient_WeatherCountry(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/weather/90210", r.URL.Path)
+		assert.Equal(t, "US", r.URL.Query().Get("country"))
+		w.Write([]byte(`{"temp_C":20,"location":{"cep":"90210","localidade":"Beverly Hills","uf":"CA","country":"US"}}`))
+	})
+
+	response, err := client.Weather(context.Background(), "90210", &WeatherOptions{Country: "US", Location: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "US", response.Location.Country)
+}
+
+func TestClient_WeatherMeta(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "true", r.URL.Query().Get("meta"))
+		w.Write([]byte(`{"temp_C":25,"meta":{"provider":"weather_api","location_provider":"viacep","cache":"hit","observed_at":"2026-10-17T12:00:00Z","duration_ms":1.5}}`))
+	})
+
+	response, err := client.Weather(context.Background(), "01310-100", &WeatherOptions{Meta: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "viacep", response.Meta.LocationProvider)
+	assert.Equal(t, "hit", response.Meta.Cache)
+	assert.Equal(t, time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC), *response.Meta.ObservedAt)
+}
+
+func TestClient_Batch(t *testing.T) {
+	client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		var request BatchRequest
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		assert.Equal(t, []string{"01310100", "0131010a"}, request.CEPs)
+		w.Write([]byte(`{"results":[{"cep":"01310100","weather":{"temp_C":25}},{"cep":"0131010a","error":"invalid zipcode"}]}`))
+	})
+
+	response, err := client.Batch(context.Background(), []string{"01310100", "0131010a"}, "")
+	assert.NoError(t, err)
+	assert.Len(t, response.Results, 2)
+	assert.Equal(t, 25.0, *response.Results[0].Weather.TempC)
+	assert.Equal(t, "invalid zipcode", response.Results[1].Error)
+}
+
+func TestClient_Errors(t *testing.T) {
+	tests := []struct {
+		status  int
+		message string
+		target  error
+	}{
+		{http.StatusUnprocessableEntity, "invalid zipcode", ErrInvalidCEP},
+		{http.StatusNotFound, "can not find zipcode", ErrNotFound},
+		{http.StatusServiceUnavailable, "weather api unavailable", ErrUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.message, func(t *testing.T) {
+			client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				json.NewEncoder(w).Encode(ErrorResponse{Message: tt.message})
+			})
+
+			_, err := client.Weather(context.Background(), "01310100", nil)
+			assert.True(t, errors.Is(err, tt.target), "unexpected error: %v", err)
+			var apiErr *APIError
+			assert.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, &APIError{Status: tt.status, Message: tt.message}, apiErr)
+		})
+	}
+}
+
+func TestClient_Retries(t *testing.T) {
+	t.Run("temporary failures", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) < 3 {
+				w.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			w.Write([]byte(`{"temp_C":25}`))
+		})
+
+		response, err := client.Weather(context.Background(), "01310100", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, 25.0, *response.TempC)
+		assert.EqualValues(t, 3, calls.Load())
+	})
+
+	t.Run("gives up after MaxRetries", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		})
+
+		_, err := client.Weather(context.Background(), "01310100", nil)
+		assert.True(t, errors.Is(err, ErrUnavailable))
+		assert.EqualValues(t, 1+defaultMaxRetries, calls.Load())
+	})
+
+	t.Run("client errors are not retried", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			calls.Add(1)
+			w.WriteHeader(http.StatusNotFound)
+		})
+
+		_, err := client.Weather(context.Background(), "99999999", nil)
+		assert.True(t, errors.Is(err, ErrNotFound))
+		assert.EqualValues(t, 1, calls.Load())
+	})
+
+	t.Run("Retry-After", func(t *testing.T) {
+		var calls atomic.Int32
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.Header().Set("Retry-After", "1")
+				w.WriteHeader(http.StatusTooManyRequests)
+				return
+			}
+			w.Write([]byte(`{"temp_C":25}`))
+		})
+
+		start := time.Now()
+		_, err := client.Weather(context.Background(), "01310100", nil)
+		assert.NoError(t, err)
+		assert.GreaterOrEqual(t, time.Since(start), time.Second)
+	})
+
+	t.Run("context canceled while waiting", func(t *testing.T) {
+		client := newTestClient(t, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "10")
+			w.WriteHeader(http.StatusTooManyRequests)
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+		_, err := client.Weather(ctx, "01310100", nil)
+		assert.True(t, errors.Is(err, context.DeadlineExceeded), "unexpected error: %v", err)
+	})
+}
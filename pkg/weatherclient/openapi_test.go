@@ -0,0 +1,96 @@
+package weatherclient
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type openAPISchema struct {
+	Properties map[string]json.RawMessage `json:"properties"`
+	AllOf      []openAPISchema            `json:"allOf"`
+	Ref        string                     `json:"$ref"`
+}
+
+// Campos JSON de um tipo, incluindo os de structs embutidas
+func jsonFields(typ reflect.Type) []string {
+	var fields []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.Anonymous {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+		if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+			fields = append(fields, name)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Propriedades de um schema, seguindo allOf e $ref
+func schemaFields(schemas map[string]openAPISchema, schema openAPISchema) []string {
+	if schema.Ref != "" {
+		return schemaFields(schemas, schemas[strings.TrimPrefix(schema.Ref, "#/components/schemas/")])
+	}
+	var fields []string
+	for name := range schema.Properties {
+		fields = append(fields, name)
+	}
+	for _, part := range schema.AllOf {
+		fields = append(fields, schemaFields(schemas, part)...)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+// Os tipos do cliente acompanham os schemas de openapi.json: um campo novo,
+// removido ou renomeado na especificação precisa aparecer aqui também
+func TestTypesMatchOpenAPI(t *testing.T) {
+	data, err := os.ReadFile("../../api/openapi.json")
+	assert.NoError(t, err)
+	var spec struct {
+		Components struct {
+			Schemas map[string]openAPISchema `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(data, &spec))
+	schemas := spec.Components.Schemas
+
+	types := map[string]interface{}{
+		"WeatherResponse":   WeatherResponse{},
+		"Address":           Address{},
+		"AirQuality":        AirQuality{},
+		"Conditions":        Conditions{},
+		"ResponseMeta":      ResponseMeta{},
+		"DailyTemperatures": DailyTemperatures{},
+		"BatchRequest":      BatchRequest{},
+		"BatchResponse":     BatchResponse{},
+		"ReadingsResponse":  ReadingsResponse{},
+		"Reading":           Reading{},
+		"AstronomyResponse": AstronomyResponse{},
+		"AlertsResponse":    AlertsResponse{},
+		"Alert":             Alert{},
+		"ErrorResponse":     ErrorResponse{},
+	}
+	for name, value := range types {
+		schema, ok := schemas[name]
+		if !assert.True(t, ok, "schema %s is not in openapi.json", name) {
+			continue
+		}
+		assert.Equal(t, schemaFields(schemas, schema), jsonFields(reflect.TypeOf(value)), "fields of %s", name)
+	}
+
+	// Os itens de BatchResponse.results são um schema inline
+	var results struct {
+		Items openAPISchema `json:"items"`
+	}
+	assert.NoError(t, json.Unmarshal(schemas["BatchResponse"].Properties["results"], &results))
+	assert.Equal(t, schemaFields(schemas, results.Items), jsonFields(reflect.TypeOf(BatchResult{})))
+}
@@ -0,0 +1,130 @@
+package weatherclient
+
+import "time"
+
+// Tipos dos schemas de openapi.json, com os mesmos nomes de campo. O teste
+// TestTypesMatchOpenAPI falha quando a especificação e estes tipos divergem
+
+type WeatherResponse struct {
+	TempC *float64 `json:"temp_C,omitempty"`
+	TempF *float64 `json:"temp_F,omitempty"`
+	TempK *float64 `json:"temp_K,omitempty"`
+	TempR *float64 `json:"temp_R,omitempty"`
+
+	FeelsLikeC *float64 `json:"feels_like_C,omitempty"`
+	FeelsLikeF *float64 `json:"feels_like_F,omitempty"`
+	FeelsLikeK *float64 `json:"feels_like_K,omitempty"`
+	FeelsLikeR *float64 `json:"feels_like_R,omitempty"`
+
+	Location   *Address    `json:"location,omitempty"`
+	AirQuality *AirQuality `json:"air_quality,omitempty"`
+	Conditions *Conditions `json:"conditions,omitempty"`
+	// Código IBGE do município, com extended=true
+	IBGE string `json:"ibge,omitempty"`
+	// Origem e idade dos dados, com meta=true
+	Meta *ResponseMeta `json:"meta,omitempty"`
+}
+
+type ResponseMeta struct {
+	Provider string `json:"provider"`
+	// viacep, brasilapi, address_cache, embedded, ibge ou zippopotam
+	LocationProvider string `json:"location_provider,omitempty"`
+	// hit, miss ou stale no cache de respostas da WeatherAPI
+	Cache      string     `json:"cache"`
+	ObservedAt *time.Time `json:"observed_at,omitempty"`
+	DurationMs float64    `json:"duration_ms"`
+}
+
+type Address struct {
+	Cep        string `json:"cep"`
+	Logradouro string `json:"logradouro"`
+	Bairro     string `json:"bairro"`
+	Localidade string `json:"localidade"`
+	UF         string `json:"uf"`
+	// Código ISO do país, só nas consultas com country de fora do Brasil
+	Country string `json:"country,omitempty"`
+	// "approximate" quando a cidade veio da tabela de CEPs embutida
+	Source string `json:"source,omitempty"`
+}
+
+type AirQuality struct {
+	PM25       float64 `json:"pm2_5"`
+	PM10       float64 `json:"pm10"`
+	USEPAIndex int     `json:"us_epa_index"`
+}
+
+type Conditions struct {
+	Humidity      int     `json:"humidity"`
+	WindKph       float64 `json:"wind_kph"`
+	WindDir       string  `json:"wind_dir"`
+	WindDegree    int     `json:"wind_degree"`
+	PressureMb    float64 `json:"pressure_mb"`
+	Cloud         int     `json:"cloud"`
+	ConditionText string  `json:"condition_text"`
+	ConditionCode int     `json:"condition_code"`
+}
+
+type DailyTemperatures struct {
+	Date string          `json:"date"`
+	Avg  WeatherResponse `json:"avg"`
+	Min  WeatherResponse `json:"min"`
+	Max  WeatherResponse `json:"max"`
+}
+
+type BatchRequest struct {
+	CEPs []string `json:"ceps"`
+}
+
+type BatchResponse struct {
+	Results []BatchResult `json:"results"`
+}
+
+// Resultado de um CEP do lote: Weather ou Error
+type BatchResult struct {
+	CEP     string           `json:"cep"`
+	Weather *WeatherResponse `json:"weather,omitempty"`
+	Error   string           `json:"error,omitempty"`
+}
+
+type ReadingsResponse struct {
+	CEP      string    `json:"cep"`
+	Page     int       `json:"page"`
+	Limit    int       `json:"limit"`
+	Total    int       `json:"total"`
+	Readings []Reading `json:"readings"`
+}
+
+type Reading struct {
+	LookedUpAt time.Time `json:"looked_up_at"`
+	City       string    `json:"city"`
+	UF         string    `json:"uf"`
+	WeatherResponse
+}
+
+type AstronomyResponse struct {
+	Sunrise   string `json:"sunrise"`
+	Sunset    string `json:"sunset"`
+	Moonrise  string `json:"moonrise"`
+	Moonset   string `json:"moonset"`
+	MoonPhase string `json:"moon_phase"`
+}
+
+type AlertsResponse struct {
+	Alerts []Alert `json:"alerts"`
+}
+
+type Alert struct {
+	Headline    string `json:"headline"`
+	Event       string `json:"event"`
+	Severity    string `json:"severity"`
+	Urgency     string `json:"urgency"`
+	Areas       string `json:"areas"`
+	Effective   string `json:"effective"`
+	Expires     string `json:"expires"`
+	Description string `json:"description"`
+	Instruction string `json:"instruction"`
+}
+
+type ErrorResponse struct {
+	Message string `json:"message"`
+}
@@ -0,0 +1,28 @@
+#!/bin/bash
+
+# Gera clientes da API em outras linguagens a partir de api/openapi.json, com o
+# openapi-generator rodando em Docker. O cliente Go fica em pkg/weatherclient
+#
+# Uso: ./sdk/generate.sh [linguagem...]   (padrão: typescript-fetch python java)
+
+set -euo pipefail
+
+GENERATOR_IMAGE="${GENERATOR_IMAGE:-openapitools/openapi-generator-cli:v7.10.0}"
+ROOT="$(cd "$(dirname "$0")/.." && pwd)"
+
+languages=("$@")
+if [ ${#languages[@]} -eq 0 ]; then
+  languages=(typescript-fetch python java)
+fi
+
+for language in "${languages[@]}"; do
+  echo "🔨 Gerando cliente ${language} em sdk/${language}..."
+  rm -rf "${ROOT}/sdk/${language}"
+  docker run --rm -u "$(id -u):$(id -g)" -v "${ROOT}:/local" "${GENERATOR_IMAGE}" generate \
+    -i /local/api/openapi.json \
+    -g "${language}" \
+    -o "/local/sdk/${language}" \
+    --additional-properties=packageName=weather_service,projectName=weather-service-client
+done
+
+echo "✅ Clientes gerados em sdk/"